@@ -4,6 +4,7 @@ package async
 import (
 	"bytes"
 	"context"
+	"encoding/csv"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -15,8 +16,7 @@ import (
 	"strings"
 	"time"
 
-	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
-	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventgrid/publisher"
+	"github.com/RMI/pacta/async/normalize"
 	"github.com/RMI/pacta/async/parsed"
 	"github.com/RMI/pacta/blob"
 	"github.com/RMI/pacta/pacta"
@@ -28,7 +28,7 @@ import (
 
 type Config struct {
 	Blob   Blob
-	PubSub *publisher.Client
+	PubSub Publisher
 	Logger *zap.Logger
 
 	BenchmarkDir string
@@ -63,7 +63,7 @@ type Blob interface {
 
 type Handler struct {
 	blob   Blob
-	pubsub *publisher.Client
+	pubsub Publisher
 	logger *zap.Logger
 
 	// Mounted directories with data needed for report generation.
@@ -84,34 +84,114 @@ func New(cfg *Config) (*Handler, error) {
 	}, nil
 }
 
-// TODO: Send a notification when parsing fails.
-func (h *Handler) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.ParsePortfolioRequest, destPortfolioContainer string) error {
+// localInput is where a CSV given to the parser came from.
+type localInput struct {
+	source pacta.BlobURI
+	// entry is the sheet or archive entry within source, empty if source was
+	// already a CSV.
+	entry string
+}
+
+// checkRowLimit returns an error if the CSV at the given path has more than
+// maxRows rows, not counting the header. A limit of zero disables the check.
+// Malformed CSVs are left for the parser to report on.
+func checkRowLimit(path string, maxRows int) error {
+	if maxRows <= 0 {
+		return nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open CSV: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	r.ReuseRecord = true
+	// Start at -1 so that the header isn't counted.
+	rows := -1
+	for {
+		if _, err := r.Read(); err != nil {
+			break
+		}
+		rows++
+		if rows > maxRows {
+			return fmt.Errorf("file has more than the limit of %d rows", maxRows)
+		}
+	}
+	return nil
+}
+
+func (h *Handler) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.ParsePortfolioRequest, destPortfolioContainer string) (rErr error) {
 	// Make the directories we require first. We use these instead of
 	// /mnt/{input,output} because the base image (quite reasonably) uses a non-root
 	// user, so we can't be creating directories in the root filesystem all willy
 	// nilly.
+	rawDir := filepath.Join("/", "home", "portfolio-parser", "raw")
 	inputDir := filepath.Join("/", "home", "portfolio-parser", "input")
 	outputDir := filepath.Join("/", "home", "portfolio-parser", "output")
+	logsDir := filepath.Join("/", "home", "portfolio-parser", "logs")
 
+	if err := os.MkdirAll(rawDir, 0700); err != nil {
+		return fmt.Errorf("failed to create raw dir to store uploaded files: %w", err)
+	}
 	if err := os.MkdirAll(inputDir, 0700); err != nil {
 		return fmt.Errorf("failed to create input dir to store input CSVs: %w", err)
 	}
 	if err := os.MkdirAll(outputDir, 0700); err != nil {
 		return fmt.Errorf("failed to create output dir to store output CSVs: %w", err)
 	}
+	if err := os.MkdirAll(logsDir, 0700); err != nil {
+		return fmt.Errorf("failed to create logs dir to store parser output: %w", err)
+	}
 
-	// Load the portfolio from blob storage, place it in /mnt/inputs, where
-	// the `process_portfolios.R` script expects it to be.
-	localCSVToBlob := make(map[string]pacta.BlobURI)
+	// If we fail after the parser has run, we still want to report what it told
+	// us, so that users can see why their upload couldn't be parsed.
+	var (
+		sources  []*task.ParsePortfolioSource
+		logs     []*task.ParsePortfolioLog
+		rejected []*task.ParsePortfolioRejectedFile
+	)
+	defer func() {
+		if rErr != nil && (len(sources) > 0 || len(logs) > 0 || len(rejected) > 0) {
+			rErr = &parseOutputError{sources: sources, logs: logs, rejected: rejected, err: rErr}
+		}
+	}()
+
+	// Load the portfolio from blob storage and normalize it into one or more
+	// CSVs in the input directory, where the `process_portfolios.R` script
+	// expects them to be. Uploads can be CSVs, XLSX workbooks or ZIP archives of
+	// CSVs, and the parser only understands the former. Files we can't read are
+	// reported on their own, and don't stop the rest from being parsed.
+	localCSVToInput := make(map[string]localInput)
 	for _, srcURI := range req.BlobURIs {
 		id := uuid.New().String()
-		// TODO: Probably set the CSV extension in the signed upload URL instead.
-		fn := fmt.Sprintf("%s.csv", id)
-		destPath := filepath.Join(inputDir, fn)
-		if err := h.downloadBlob(ctx, string(srcURI), destPath); err != nil {
+		rawPath := filepath.Join(rawDir, id)
+		if err := h.downloadBlob(ctx, string(srcURI), rawPath); err != nil {
 			return fmt.Errorf("failed to download raw portfolio blob: %w", err)
 		}
-		localCSVToBlob[fn] = srcURI
+		outs, err := normalize.Normalize(rawPath, inputDir, id, normalize.DefaultLimits)
+		if errors.Is(err, normalize.ErrUnsupported) {
+			h.logger.Info("rejected unsupported upload", zap.String("task_id", string(taskID)), zapfield.Str("blob_uri", srcURI), zap.Error(err))
+			rejected = append(rejected, &task.ParsePortfolioRejectedFile{
+				Source:         srcURI,
+				FailureCode:    pacta.FailureCode_UnsupportedFile,
+				FailureMessage: err.Error(),
+			})
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to normalize raw portfolio %q: %w", srcURI, err)
+		}
+		for _, o := range outs {
+			if err := checkRowLimit(filepath.Join(inputDir, o.FileName), req.MaxRows); err != nil {
+				return withFailureCode(pacta.FailureCode_TooManyRows, fmt.Errorf("uploaded file %q: %w", srcURI, err))
+			}
+			localCSVToInput[o.FileName] = localInput{source: srcURI, entry: o.Entry}
+		}
+	}
+	if len(localCSVToInput) == 0 {
+		return withFailureCode(pacta.FailureCode_UnsupportedFile, errors.New("none of the uploaded files could be read"))
 	}
 
 	cmd := exec.CommandContext(ctx,
@@ -119,14 +199,17 @@ func (h *Handler) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.
 		"-e", "logger::log_threshold(Sys.getenv('LOG_LEVEL', 'INFO'));workflow.portfolio.parsing::process_directory('"+inputDir+"', '"+outputDir+"')",
 	)
 
-	// We don't expect log output to be particularly large, it's fine to write them to an in-memory buffer.
-	// TODO(#185): Find a good place to put these in storage, such that it can be correlated with the input file(s)
-	var stdout, stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run process_portfolios script: %w", err)
+	logPath := filepath.Join(logsDir, "parser_output.txt")
+	runErr := runScript(cmd, logPath)
+	// We upload the log even if the parser failed, it's most useful then.
+	var uploadErr error
+	logs, uploadErr = h.uploadParseLogs(context.WithoutCancel(ctx), req.IncompleteUploadIDs, logPath, destPortfolioContainer)
+	if uploadErr != nil {
+		// Losing the log shouldn't hide the outcome of the parse itself.
+		h.logger.Error("failed to upload parser log", zap.String("task_id", string(taskID)), zap.Error(uploadErr))
+	}
+	if runErr != nil {
+		return fmt.Errorf("failed to run process_portfolios script: %w", scriptFailure(ctx, runErr))
 	}
 
 	// After successful execution, the API contract is that there should be a 'processed_portfolios.json' file in the output directory.
@@ -150,13 +233,16 @@ func (h *Handler) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.
 	// NOTE: This code could benefit from some concurrency, but I'm opting not to prematurely optimize.
 	var out []*task.ParsePortfolioResponseItem
 	for _, sf := range sourceFiles {
-		sourceURI, ok := localCSVToBlob[sf.InputFilename]
+		input, ok := localCSVToInput[sf.InputFilename]
 		if !ok {
-			return fmt.Errorf("parse output mentioned input file %q, which wasn't found in our input -> blob URI map %+v", sf.InputFilename, localCSVToBlob)
+			return fmt.Errorf("parse output mentioned input file %q, which wasn't found in our input -> blob URI map %+v", sf.InputFilename, localCSVToInput)
 		}
-
-		// TODO(#187): There's lots of metadata associated with the input files (e.g.
-		// sf.Errors, sf.GroupCols, etc), we should likely store that info somewhere.
+		sourceURI := input.source
+		sources = append(sources, &task.ParsePortfolioSource{
+			Source: sourceURI,
+			Entry:  input.entry,
+			File:   sf,
+		})
 
 		for _, p := range sf.Portfolios {
 			outPath := filepath.Join(outputDir, p.OutputFilename)
@@ -206,22 +292,18 @@ func (h *Handler) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.
 		}
 	}
 
-	events := []publisher.Event{
-		{
-			Data: task.ParsePortfolioResponse{
-				TaskID:  taskID,
-				Request: req,
-				Outputs: out,
-			},
-			DataVersion: to.Ptr("1.0"),
-			EventType:   to.Ptr("parsed-portfolio"),
-			EventTime:   to.Ptr(time.Now()),
-			ID:          to.Ptr(string(taskID)),
-			Subject:     to.Ptr(string(taskID)),
-		},
+	if len(out) == 0 {
+		return withFailureCode(pacta.FailureCode_ParseInvalidCSV, errors.New("no portfolios could be parsed from the given input files"))
 	}
 
-	if _, err := h.pubsub.PublishEvents(ctx, events, nil); err != nil {
+	if err := h.publish(ctx, taskID, "parsed-portfolio", task.ParsePortfolioResponse{
+		TaskID:   taskID,
+		Request:  req,
+		Outputs:  out,
+		Sources:  sources,
+		Logs:     logs,
+		Rejected: rejected,
+	}); err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
 
@@ -230,12 +312,7 @@ func (h *Handler) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.
 	return nil
 }
 
-func (h *Handler) CreateAudit(ctx context.Context, taskID task.ID, req *task.CreateAuditRequest, auditContainer string) error {
-	if n := len(req.BlobURIs); n != 1 {
-		return fmt.Errorf("expected exactly one blob URI as input, got %d", n)
-	}
-	blobURI := req.BlobURIs[0]
-
+func (h *Handler) CreateAudit(ctx context.Context, taskID task.ID, req *task.CreateAuditRequest, auditContainer string) (rErr error) {
 	//  We use this instead of /mnt/... because the base image (quite
 	// reasonably) uses a non-root user, so we can't be creating directories in the
 	// root filesystem all willy nilly.
@@ -246,25 +323,31 @@ func (h *Handler) CreateAudit(ctx context.Context, taskID task.ID, req *task.Cre
 	if err != nil {
 		return fmt.Errorf("failed to init report env: %w", err)
 	}
+	logPath := filepath.Join(auditEnv.pathForDir(LogsDir), string(taskID)+".txt")
+	defer func() {
+		if rErr != nil {
+			rErr = h.withLogArtifact(context.WithoutCancel(ctx), rErr, logPath, auditContainer, req.AnalysisID, req.Portfolio)
+		}
+	}()
 
-	// Load the parsed portfolio from blob storage, place it in our PORFOLIO_DIR,
-	// where the `run_pacta.R` script expects it to be.
-	fileNameWithExt := filepath.Base(string(blobURI))
-	if !strings.HasSuffix(fileNameWithExt, ".csv") {
-		return fmt.Errorf("given blob wasn't a CSV-formatted portfolio, %q", fileNameWithExt)
-	}
-	destPath := filepath.Join(auditEnv.pathForDir(PortfoliosDir), fileNameWithExt)
-	if err := h.downloadBlob(ctx, string(blobURI), destPath); err != nil {
-		return fmt.Errorf("failed to download processed portfolio blob: %w", err)
+	// Load the parsed portfolios from blob storage, place them in our PORFOLIO_DIR,
+	// where the `run_pacta.R` script expects them to be.
+	fileNames, err := h.downloadPortfolios(ctx, req.BlobURIs, auditEnv.pathForDir(PortfoliosDir))
+	if err != nil {
+		return err
 	}
 
+	name, holdingsDate, err := portfolioInput(req.Portfolio, req.Inherit)
+	if err != nil {
+		return fmt.Errorf("invalid audit request: %w", err)
+	}
 	inp := AuditInput{
 		Portfolio: AuditInputPortfolio{
-			Files:        []string{fileNameWithExt},
-			HoldingsDate: "2023-12-31",   // TODO(#206)
-			Name:         "FooPortfolio", // TODO(#206)
+			Files:        fileNames,
+			HoldingsDate: holdingsDate,
+			Name:         name,
 		},
-		Inherit: "GENERAL_2023Q4", // TODO(#206): Should this be configurable
+		Inherit: req.Inherit,
 	}
 
 	var inpJSON bytes.Buffer
@@ -278,16 +361,14 @@ func (h *Handler) CreateAudit(ctx context.Context, taskID task.ID, req *task.Cre
 		"LOG_LEVEL=DEBUG",
 		"HOME=/root", /* Required by pandoc */
 	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run pacta test CLI: %w", err)
+	if err := runScript(cmd, logPath); err != nil {
+		return fmt.Errorf("failed to run pacta test CLI: %w", scriptFailure(ctx, err))
 	}
 
 	var artifacts []*task.AnalysisArtifact
 	uploadDir := func(dir string) error {
-		aas, err := h.uploadDirectory(ctx, dir, auditContainer, req.AnalysisID)
+		aas, err := h.uploadDirectory(ctx, dir, auditContainer, req.AnalysisID, req.Portfolio.Scope)
 		if err != nil {
 			return fmt.Errorf("failed to upload report directory: %w", err)
 		}
@@ -295,28 +376,17 @@ func (h *Handler) CreateAudit(ctx context.Context, taskID task.ID, req *task.Cre
 		return nil
 	}
 
-	for _, outDir := range auditEnv.outputDirs() {
+	for _, outDir := range append(auditEnv.outputDirs(), auditEnv.pathForDir(LogsDir)) {
 		if err := uploadDir(outDir); err != nil {
 			return fmt.Errorf("failed to upload artifacts %q: %w", outDir, err)
 		}
 	}
 
-	events := []publisher.Event{
-		{
-			Data: task.CreateAuditResponse{
-				TaskID:    taskID,
-				Request:   req,
-				Artifacts: artifacts,
-			},
-			DataVersion: to.Ptr("1.0"),
-			EventType:   to.Ptr("created-audit"),
-			EventTime:   to.Ptr(time.Now()),
-			ID:          to.Ptr(string(taskID)),
-			Subject:     to.Ptr(string(taskID)),
-		},
-	}
-
-	if _, err := h.pubsub.PublishEvents(ctx, events, nil); err != nil {
+	if err := h.publish(ctx, taskID, "created-audit", task.CreateAuditResponse{
+		TaskID:    taskID,
+		Request:   req,
+		Artifacts: artifacts,
+	}); err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
 
@@ -325,6 +395,30 @@ func (h *Handler) CreateAudit(ctx context.Context, taskID task.ID, req *task.Cre
 	return nil
 }
 
+// portfolioInput returns the name and holdings date of the portfolio being
+// analyzed, in the format the R workflows expect.
+func portfolioInput(p *task.AnalysisPortfolio, inherit string) (string, string, error) {
+	if p == nil {
+		return "", "", errors.New("no portfolio given")
+	}
+	switch p.Scope {
+	case task.AnalysisScopePortfolio, task.AnalysisScopePortfolioGroup, task.AnalysisScopeInitiative:
+	default:
+		return "", "", fmt.Errorf("unknown analysis scope %q", p.Scope)
+	}
+	if inherit == "" {
+		return "", "", errors.New("no inherit configuration given")
+	}
+	hd := p.HoldingsDate
+	if hd == nil || hd.Time.IsZero() {
+		var err error
+		if hd, err = task.DefaultHoldingsDate(inherit); err != nil {
+			return "", "", fmt.Errorf("no holdings date given: %w", err)
+		}
+	}
+	return p.Name, hd.Time.UTC().Format(time.DateOnly), nil
+}
+
 type ReportInput struct {
 	Portfolio ReportInputPortfolio `json:"portfolio"`
 	Inherit   string               `json:"inherit"`
@@ -404,6 +498,10 @@ const (
 	ReportOutputDir    = ReportDir("report-output")
 	DashboardOutputDir = ReportDir("dashboard-output")
 	SummaryOutputDir   = ReportDir("summary-output")
+
+	// LogsDir holds the output of the R scripts themselves, which we upload
+	// alongside the other outputs for debugging.
+	LogsDir = ReportDir("logs")
 )
 
 func (r *TaskEnv) outputDirs() []string {
@@ -464,6 +562,7 @@ func (r *TaskEnv) makeDirectories() error {
 	makeDir(filepath.Join(r.pathForDir(DashboardOutputDir), "data"))
 	makeReportDir(ReportOutputDir)
 	makeReportDir(SummaryOutputDir)
+	makeReportDir(LogsDir)
 
 	if rErr != nil {
 		return rErr
@@ -471,12 +570,7 @@ func (r *TaskEnv) makeDirectories() error {
 	return nil
 }
 
-func (h *Handler) CreateDashboard(ctx context.Context, taskID task.ID, req *task.CreateDashboardRequest, dashboardContainer string) error {
-	if n := len(req.BlobURIs); n != 1 {
-		return fmt.Errorf("expected exactly one blob URI as input, got %d", n)
-	}
-	blobURI := req.BlobURIs[0]
-
+func (h *Handler) CreateDashboard(ctx context.Context, taskID task.ID, req *task.CreateDashboardRequest, dashboardContainer string) (rErr error) {
 	//  We use this instead of /mnt/... because the base image (quite
 	// reasonably) uses a non-root user, so we can't be creating directories in the
 	// root filesystem all willy nilly.
@@ -486,25 +580,31 @@ func (h *Handler) CreateDashboard(ctx context.Context, taskID task.ID, req *task
 	if err != nil {
 		return fmt.Errorf("failed to init report env: %w", err)
 	}
+	logPath := filepath.Join(dashEnv.pathForDir(LogsDir), string(taskID)+".txt")
+	defer func() {
+		if rErr != nil {
+			rErr = h.withLogArtifact(context.WithoutCancel(ctx), rErr, logPath, dashboardContainer, req.AnalysisID, req.Portfolio)
+		}
+	}()
 
-	// Load the parsed portfolio from blob storage, place it in our PORFOLIO_DIR,
-	// where the `prepare_dashboard_data.R` script expects it to be.
-	fileNameWithExt := filepath.Base(string(blobURI))
-	if !strings.HasSuffix(fileNameWithExt, ".csv") {
-		return fmt.Errorf("given blob wasn't a CSV-formatted portfolio, %q", fileNameWithExt)
-	}
-	destPath := filepath.Join(dashEnv.pathForDir(PortfoliosDir), fileNameWithExt)
-	if err := h.downloadBlob(ctx, string(blobURI), destPath); err != nil {
-		return fmt.Errorf("failed to download processed portfolio blob: %w", err)
+	// Load the parsed portfolios from blob storage, place them in our PORFOLIO_DIR,
+	// where the `prepare_dashboard_data.R` script expects them to be.
+	fileNames, err := h.downloadPortfolios(ctx, req.BlobURIs, dashEnv.pathForDir(PortfoliosDir))
+	if err != nil {
+		return err
 	}
 
+	name, holdingsDate, err := portfolioInput(req.Portfolio, req.Inherit)
+	if err != nil {
+		return fmt.Errorf("invalid dashboard request: %w", err)
+	}
 	inp := DashboardInput{
 		Portfolio: DashboardInputPortfolio{
-			Files:        []string{fileNameWithExt},
-			HoldingsDate: "2023-12-31",   // TODO(#206)
-			Name:         "FooPortfolio", // TODO(#206)
+			Files:        fileNames,
+			HoldingsDate: holdingsDate,
+			Name:         name,
 		},
-		Inherit: "GENERAL_2023Q4", // TODO(#206): Should this be configurable
+		Inherit: req.Inherit,
 	}
 
 	var inpJSON bytes.Buffer
@@ -522,16 +622,14 @@ func (h *Handler) CreateDashboard(ctx context.Context, taskID task.ID, req *task
 		"LOG_LEVEL=DEBUG",
 		"HOME=/root", /* Required by pandoc */
 	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run pacta dashboard script: %w", err)
+	if err := runScript(cmd, logPath); err != nil {
+		return fmt.Errorf("failed to run pacta dashboard script: %w", scriptFailure(ctx, err))
 	}
 
 	var artifacts []*task.AnalysisArtifact
 	uploadDir := func(dir string) error {
-		aas, err := h.uploadDirectory(ctx, dir, dashboardContainer, req.AnalysisID)
+		aas, err := h.uploadDirectory(ctx, dir, dashboardContainer, req.AnalysisID, req.Portfolio.Scope)
 		if err != nil {
 			return fmt.Errorf("failed to upload report directory: %w", err)
 		}
@@ -539,28 +637,17 @@ func (h *Handler) CreateDashboard(ctx context.Context, taskID task.ID, req *task
 		return nil
 	}
 
-	for _, outDir := range dashEnv.outputDirs() {
+	for _, outDir := range append(dashEnv.outputDirs(), dashEnv.pathForDir(LogsDir)) {
 		if err := uploadDir(outDir); err != nil {
 			return fmt.Errorf("failed to upload artifacts %q: %w", outDir, err)
 		}
 	}
 
-	events := []publisher.Event{
-		{
-			Data: task.CreateDashboardResponse{
-				TaskID:    taskID,
-				Request:   req,
-				Artifacts: artifacts,
-			},
-			DataVersion: to.Ptr("1.0"),
-			EventType:   to.Ptr("created-dashboard"),
-			EventTime:   to.Ptr(time.Now()),
-			ID:          to.Ptr(string(taskID)),
-			Subject:     to.Ptr(string(taskID)),
-		},
-	}
-
-	if _, err := h.pubsub.PublishEvents(ctx, events, nil); err != nil {
+	if err := h.publish(ctx, taskID, "created-dashboard", task.CreateDashboardResponse{
+		TaskID:    taskID,
+		Request:   req,
+		Artifacts: artifacts,
+	}); err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
 
@@ -569,12 +656,7 @@ func (h *Handler) CreateDashboard(ctx context.Context, taskID task.ID, req *task
 	return nil
 }
 
-func (h *Handler) CreateReport(ctx context.Context, taskID task.ID, req *task.CreateReportRequest, reportContainer string) error {
-	if n := len(req.BlobURIs); n != 1 {
-		return fmt.Errorf("expected exactly one blob URI as input, got %d", n)
-	}
-	blobURI := req.BlobURIs[0]
-
+func (h *Handler) CreateReport(ctx context.Context, taskID task.ID, req *task.CreateReportRequest, reportContainer string) (rErr error) {
 	//  We use this instead of /mnt/... because the base image (quite
 	// reasonably) uses a non-root user, so we can't be creating directories in the
 	// root filesystem all willy nilly.
@@ -584,25 +666,31 @@ func (h *Handler) CreateReport(ctx context.Context, taskID task.ID, req *task.Cr
 	if err != nil {
 		return fmt.Errorf("failed to init report env: %w", err)
 	}
+	logPath := filepath.Join(reportEnv.pathForDir(LogsDir), string(taskID)+".txt")
+	defer func() {
+		if rErr != nil {
+			rErr = h.withLogArtifact(context.WithoutCancel(ctx), rErr, logPath, reportContainer, req.AnalysisID, req.Portfolio)
+		}
+	}()
 
-	// Load the parsed portfolio from blob storage, place it in our PORFOLIO_DIR,
-	// where the `run_pacta.R` script expects it to be.
-	fileNameWithExt := filepath.Base(string(blobURI))
-	if !strings.HasSuffix(fileNameWithExt, ".csv") {
-		return fmt.Errorf("given blob wasn't a CSV-formatted portfolio, %q", fileNameWithExt)
-	}
-	destPath := filepath.Join(reportEnv.pathForDir(PortfoliosDir), fileNameWithExt)
-	if err := h.downloadBlob(ctx, string(blobURI), destPath); err != nil {
-		return fmt.Errorf("failed to download processed portfolio blob: %w", err)
+	// Load the parsed portfolios from blob storage, place them in our PORFOLIO_DIR,
+	// where the `run_pacta.R` script expects them to be.
+	fileNames, err := h.downloadPortfolios(ctx, req.BlobURIs, reportEnv.pathForDir(PortfoliosDir))
+	if err != nil {
+		return err
 	}
 
+	name, holdingsDate, err := portfolioInput(req.Portfolio, req.Inherit)
+	if err != nil {
+		return fmt.Errorf("invalid report request: %w", err)
+	}
 	inp := ReportInput{
 		Portfolio: ReportInputPortfolio{
-			Files:        []string{fileNameWithExt},
-			HoldingsDate: "2023-12-31",   // TODO(#206)
-			Name:         "FooPortfolio", // TODO(#206)
+			Files:        fileNames,
+			HoldingsDate: holdingsDate,
+			Name:         name,
 		},
-		Inherit: "GENERAL_2023Q4", // TODO(#206): Should this be configurable
+		Inherit: req.Inherit,
 	}
 
 	var inpJSON bytes.Buffer
@@ -620,16 +708,14 @@ func (h *Handler) CreateReport(ctx context.Context, taskID task.ID, req *task.Cr
 		"LOG_LEVEL=DEBUG",
 		"HOME=/root", /* Required by pandoc */
 	)
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
 
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to run pacta test CLI: %w", err)
+	if err := runScript(cmd, logPath); err != nil {
+		return fmt.Errorf("failed to run pacta test CLI: %w", scriptFailure(ctx, err))
 	}
 
 	var artifacts []*task.AnalysisArtifact
 	uploadDir := func(dir string) error {
-		aas, err := h.uploadDirectory(ctx, dir, reportContainer, req.AnalysisID)
+		aas, err := h.uploadDirectory(ctx, dir, reportContainer, req.AnalysisID, req.Portfolio.Scope)
 		if err != nil {
 			return fmt.Errorf("failed to upload report directory: %w", err)
 		}
@@ -637,28 +723,17 @@ func (h *Handler) CreateReport(ctx context.Context, taskID task.ID, req *task.Cr
 		return nil
 	}
 
-	for _, outDir := range reportEnv.outputDirs() {
+	for _, outDir := range append(reportEnv.outputDirs(), reportEnv.pathForDir(LogsDir)) {
 		if err := uploadDir(outDir); err != nil {
 			return fmt.Errorf("failed to upload artifacts %q: %w", outDir, err)
 		}
 	}
 
-	events := []publisher.Event{
-		{
-			Data: task.CreateReportResponse{
-				TaskID:    taskID,
-				Request:   req,
-				Artifacts: artifacts,
-			},
-			DataVersion: to.Ptr("1.0"),
-			EventType:   to.Ptr("created-report"),
-			EventTime:   to.Ptr(time.Now()),
-			ID:          to.Ptr(string(taskID)),
-			Subject:     to.Ptr(string(taskID)),
-		},
-	}
-
-	if _, err := h.pubsub.PublishEvents(ctx, events, nil); err != nil {
+	if err := h.publish(ctx, taskID, "created-report", task.CreateReportResponse{
+		TaskID:    taskID,
+		Request:   req,
+		Artifacts: artifacts,
+	}); err != nil {
 		return fmt.Errorf("failed to publish event: %w", err)
 	}
 
@@ -667,6 +742,42 @@ func (h *Handler) CreateReport(ctx context.Context, taskID task.ID, req *task.Cr
 	return nil
 }
 
+func (h *Handler) publish(ctx context.Context, taskID task.ID, eventType string, data any) error {
+	return h.pubsub.Publish(ctx, &Event{
+		Type:    eventType,
+		ID:      string(taskID),
+		Subject: string(taskID),
+		Time:    time.Now(),
+		Data:    data,
+	})
+}
+
+// downloadPortfolios downloads each of the given parsed portfolios into destDir,
+// returning their file names in the same order.
+func (h *Handler) downloadPortfolios(ctx context.Context, blobURIs []pacta.BlobURI, destDir string) ([]string, error) {
+	if len(blobURIs) == 0 {
+		return nil, errors.New("no blob URIs given as input")
+	}
+	var fileNames []string
+	seen := make(map[string]bool)
+	for _, blobURI := range blobURIs {
+		fileNameWithExt := filepath.Base(string(blobURI))
+		if !strings.HasSuffix(fileNameWithExt, ".csv") {
+			return nil, fmt.Errorf("given blob wasn't a CSV-formatted portfolio, %q", fileNameWithExt)
+		}
+		if seen[fileNameWithExt] {
+			return nil, fmt.Errorf("multiple portfolios were named %q", fileNameWithExt)
+		}
+		seen[fileNameWithExt] = true
+		destPath := filepath.Join(destDir, fileNameWithExt)
+		if err := h.downloadBlob(ctx, string(blobURI), destPath); err != nil {
+			return nil, fmt.Errorf("failed to download processed portfolio blob %q: %w", blobURI, err)
+		}
+		fileNames = append(fileNames, fileNameWithExt)
+	}
+	return fileNames, nil
+}
+
 func (h *Handler) downloadBlob(ctx context.Context, srcURI, destPath string) error {
 	// Make sure the destination exists
 	if err := os.MkdirAll(filepath.Dir(destPath), 0700); err != nil {
@@ -681,12 +792,12 @@ func (h *Handler) downloadBlob(ctx context.Context, srcURI, destPath string) err
 
 	br, err := h.blob.ReadBlob(ctx, srcURI)
 	if err != nil {
-		return fmt.Errorf("failed to read raw portfolio: %w", err)
+		return withFailureCode(pacta.FailureCode_BlobIO, fmt.Errorf("failed to read raw portfolio: %w", err))
 	}
 	defer br.Close() // Best-effort in case something fails
 
 	if _, err := io.Copy(destF, br); err != nil {
-		return fmt.Errorf("failed to load raw portfolio: %w", err)
+		return withFailureCode(pacta.FailureCode_BlobIO, fmt.Errorf("failed to load raw portfolio: %w", err))
 	}
 
 	if err := br.Close(); err != nil {
@@ -700,7 +811,9 @@ func (h *Handler) downloadBlob(ctx context.Context, srcURI, destPath string) err
 	return nil
 }
 
-func (h *Handler) uploadDirectory(ctx context.Context, dirPath, container string, analysisID pacta.AnalysisID) ([]*task.AnalysisArtifact, error) {
+// uploadDirectory uploads every file in the given directory, under a path
+// labeled with the analysis and the scope of the snapshot it was run on.
+func (h *Handler) uploadDirectory(ctx context.Context, dirPath, container string, analysisID pacta.AnalysisID, scope task.AnalysisScope) ([]*task.AnalysisArtifact, error) {
 	base := filepath.Base(dirPath)
 
 	var artifacts []*task.AnalysisArtifact
@@ -710,22 +823,11 @@ func (h *Handler) uploadDirectory(ctx context.Context, dirPath, container string
 		}
 
 		// This is a file, let's upload it to the container
-		uri := blob.Join(h.blob.Scheme(), container, string(analysisID), base, strings.TrimPrefix(path, dirPath+"/"))
-		if err := h.uploadBlob(ctx, path, uri); err != nil {
-			return fmt.Errorf("failed to upload blob: %w", err)
-		}
-
-		fn := filepath.Base(path)
-		// Returns pacta.FileType_UNKNOWN for unrecognized extensions, which we'll serve as binary blobs.
-		ft := fileTypeFromFilename(fn)
-		if ft == pacta.FileType_UNKNOWN {
-			h.logger.Error("unhandled file extension", zap.String("dir", dirPath), zap.String("filename", fn), zap.String("file_ext", filepath.Ext(fn)))
+		artifact, err := h.uploadArtifact(ctx, path, container, analysisID, scope, base, strings.TrimPrefix(path, dirPath+"/"))
+		if err != nil {
+			return err
 		}
-		artifacts = append(artifacts, &task.AnalysisArtifact{
-			BlobURI:  pacta.BlobURI(uri),
-			FileName: fn,
-			FileType: ft,
-		})
+		artifacts = append(artifacts, artifact)
 		return nil
 	})
 	if err != nil {
@@ -734,6 +836,95 @@ func (h *Handler) uploadDirectory(ctx context.Context, dirPath, container string
 	return artifacts, nil
 }
 
+// uploadArtifact uploads a single output file of an analysis, under a path
+// labeled with the analysis and the scope of the snapshot it was run on.
+func (h *Handler) uploadArtifact(ctx context.Context, srcPath, container string, analysisID pacta.AnalysisID, scope task.AnalysisScope, relPath ...string) (*task.AnalysisArtifact, error) {
+	parts := append([]string{container, string(analysisID), string(scope)}, relPath...)
+	uri := blob.Join(h.blob.Scheme(), parts...)
+	if err := h.uploadBlob(ctx, srcPath, uri); err != nil {
+		return nil, fmt.Errorf("failed to upload blob: %w", err)
+	}
+
+	fn := filepath.Base(srcPath)
+	// Returns pacta.FileType_UNKNOWN for unrecognized extensions, which we'll serve as binary blobs.
+	ft := fileTypeFromFilename(fn)
+	if ft == pacta.FileType_UNKNOWN {
+		h.logger.Error("unhandled file extension", zap.String("path", srcPath), zap.String("filename", fn), zap.String("file_ext", filepath.Ext(fn)))
+	}
+	return &task.AnalysisArtifact{
+		BlobURI:  pacta.BlobURI(uri),
+		FileName: fn,
+		FileType: ft,
+		// Logs of the R scripts can include internal details of the run, so
+		// they're only for admins debugging it.
+		AdminOnly: len(relPath) > 0 && relPath[0] == string(LogsDir),
+	}, nil
+}
+
+// withLogArtifact uploads the log of an analysis task that failed, if its R
+// script got far enough to write one, and attaches it to the task's error so
+// that it's reported along with the failure.
+func (h *Handler) withLogArtifact(ctx context.Context, taskErr error, logPath, container string, analysisID pacta.AnalysisID, p *task.AnalysisPortfolio) error {
+	if p == nil {
+		return taskErr
+	}
+	if _, err := os.Stat(logPath); err != nil {
+		return taskErr
+	}
+	artifact, err := h.uploadArtifact(ctx, logPath, container, analysisID, p.Scope, string(LogsDir), filepath.Base(logPath))
+	if err != nil {
+		h.logger.Error("failed to upload log of failed analysis", zap.String("analysis_id", string(analysisID)), zap.Error(err))
+		return taskErr
+	}
+	return &logArtifactError{artifact: artifact, err: taskErr}
+}
+
+// uploadParseLogs copies the parser's log to a blob for each of the incomplete
+// uploads it parsed. The blob names only depend on the upload, so parsing an
+// upload again replaces its log.
+func (h *Handler) uploadParseLogs(ctx context.Context, iuIDs []pacta.IncompleteUploadID, logPath, container string) ([]*task.ParsePortfolioLog, error) {
+	if _, err := os.Stat(logPath); err != nil {
+		return nil, fmt.Errorf("failed to stat parser log: %w", err)
+	}
+	var logs []*task.ParsePortfolioLog
+	for _, iuID := range iuIDs {
+		uri := pacta.BlobURI(blob.Join(h.blob.Scheme(), container, "logs", string(iuID)+".txt"))
+		if err := h.uploadBlob(ctx, logPath, string(uri)); err != nil {
+			return nil, fmt.Errorf("failed to upload parser log for %q: %w", iuID, err)
+		}
+		logs = append(logs, &task.ParsePortfolioLog{
+			IncompleteUploadID: iuID,
+			Blob: pacta.Blob{
+				FileName: filepath.Base(logPath),
+				FileType: pacta.FileType_TEXT,
+				BlobURI:  uri,
+			},
+		})
+	}
+	return logs, nil
+}
+
+// runScript runs the given R script, streaming its combined stdout and stderr
+// both to our own stdout and to the file at logPath.
+func runScript(cmd *exec.Cmd, logPath string) error {
+	logF, err := os.Create(logPath)
+	if err != nil {
+		return fmt.Errorf("failed to create log file: %w", err)
+	}
+	defer logF.Close() // Best-effort in case something fails
+
+	// Using the same writer for both means exec won't write to it concurrently.
+	w := io.MultiWriter(os.Stdout, logF)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	runErr := cmd.Run()
+
+	if err := logF.Close(); err != nil && runErr == nil {
+		return fmt.Errorf("failed to close log file: %w", err)
+	}
+	return runErr
+}
+
 func fileTypeFromFilename(fn string) pacta.FileType {
 	ext := filepath.Ext(fn)
 
@@ -798,7 +989,7 @@ func (h *Handler) uploadBlob(ctx context.Context, srcPath, destURI string) error
 	defer srcF.Close() // Best-effort in case something fails
 
 	if err := h.blob.WriteBlob(ctx, destURI, srcF); err != nil {
-		return fmt.Errorf("failed to write file to blob storage: %w", err)
+		return withFailureCode(pacta.FailureCode_BlobIO, fmt.Errorf("failed to write file to blob storage: %w", err))
 	}
 
 	if err := srcF.Close(); err != nil {
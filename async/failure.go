@@ -0,0 +1,138 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"syscall"
+
+	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
+)
+
+// failureError annotates an error with the pacta.FailureCode that should be
+// reported back to the server when a task fails because of it.
+type failureError struct {
+	code pacta.FailureCode
+	err  error
+}
+
+func (e *failureError) Error() string {
+	return e.err.Error()
+}
+
+func (e *failureError) Unwrap() error {
+	return e.err
+}
+
+func withFailureCode(code pacta.FailureCode, err error) error {
+	return &failureError{code: code, err: err}
+}
+
+// parseOutputError annotates an error from a ParsePortfolio task with what the
+// parser reported about its input files and its log, so that users can see why
+// parsing failed.
+type parseOutputError struct {
+	sources  []*task.ParsePortfolioSource
+	logs     []*task.ParsePortfolioLog
+	rejected []*task.ParsePortfolioRejectedFile
+	err      error
+}
+
+func (e *parseOutputError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseOutputError) Unwrap() error {
+	return e.err
+}
+
+// logArtifactError annotates an error from an analysis task with the uploaded
+// log of its R script.
+type logArtifactError struct {
+	artifact *task.AnalysisArtifact
+	err      error
+}
+
+func (e *logArtifactError) Error() string {
+	return e.err.Error()
+}
+
+func (e *logArtifactError) Unwrap() error {
+	return e.err
+}
+
+// scriptFailure classifies an error returned from running one of the R
+// scripts. The kernel OOM killer sends a SIGKILL, which is the only reason
+// we'd expect our scripts to be killed that way.
+func scriptFailure(ctx context.Context, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return withFailureCode(pacta.FailureCode_Timeout, err)
+	}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		// 137 = 128 + SIGKILL, which is what we see when the script is run via a shell wrapper.
+		if exitErr.ExitCode() == 137 {
+			return withFailureCode(pacta.FailureCode_OutOfMemory, err)
+		}
+		if ws, ok := exitErr.Sys().(syscall.WaitStatus); ok && ws.Signaled() && ws.Signal() == syscall.SIGKILL {
+			return withFailureCode(pacta.FailureCode_OutOfMemory, err)
+		}
+	}
+	return withFailureCode(pacta.FailureCode_RScriptFailed, err)
+}
+
+// FailureCodeForError returns the most specific pacta.FailureCode for an error
+// returned by one of the Handler's task methods.
+func FailureCodeForError(err error) pacta.FailureCode {
+	var fe *failureError
+	if errors.As(err, &fe) {
+		return fe.code
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return pacta.FailureCode_Timeout
+	}
+	return pacta.FailureCode_Unknown
+}
+
+// PublishParsePortfolioFailure notifies the server that a ParsePortfolio task
+// failed with the given error, so that it can mark the associated incomplete
+// uploads as failed.
+func (h *Handler) PublishParsePortfolioFailure(ctx context.Context, taskID task.ID, req *task.ParsePortfolioRequest, taskErr error) error {
+	var (
+		sources  []*task.ParsePortfolioSource
+		logs     []*task.ParsePortfolioLog
+		rejected []*task.ParsePortfolioRejectedFile
+	)
+	var poe *parseOutputError
+	if errors.As(taskErr, &poe) {
+		sources, logs, rejected = poe.sources, poe.logs, poe.rejected
+	}
+	return h.publish(ctx, taskID, "parse-portfolio-failed", task.ParsePortfolioFailedResponse{
+		TaskID:         taskID,
+		Request:        req,
+		FailureCode:    FailureCodeForError(taskErr),
+		FailureMessage: taskErr.Error(),
+		Sources:        sources,
+		Logs:           logs,
+		Rejected:       rejected,
+	})
+}
+
+// PublishAnalysisFailure notifies the server that a CreateAudit, CreateReport,
+// or CreateDashboard task failed with the given error, so that it can mark the
+// analysis as failed.
+func (h *Handler) PublishAnalysisFailure(ctx context.Context, taskID task.ID, analysisID pacta.AnalysisID, taskErr error) error {
+	var artifacts []*task.AnalysisArtifact
+	var lae *logArtifactError
+	if errors.As(taskErr, &lae) {
+		artifacts = append(artifacts, lae.artifact)
+	}
+	return h.publish(ctx, taskID, "analysis-failed", task.AnalysisFailedResponse{
+		TaskID:         taskID,
+		AnalysisID:     analysisID,
+		FailureCode:    FailureCodeForError(taskErr),
+		FailureMessage: taskErr.Error(),
+		Artifacts:      artifacts,
+	})
+}
@@ -0,0 +1,673 @@
+// Package normalize turns uploaded portfolio files into the CSVs that the
+// portfolio parser understands. Uploads can be CSVs (passed through as-is),
+// XLSX workbooks (one CSV per non-empty sheet), or ZIP archives of CSVs (one
+// CSV per entry). CSVs in UTF-16, as Excel exports "Unicode text", are
+// converted to UTF-8. Uploads are untrusted, so everything read from them is
+// bounded by Limits.
+package normalize
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/csv"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// ErrUnsupported is wrapped by errors caused by the content of an upload, as
+// opposed to errors reading or writing files.
+var ErrUnsupported = errors.New("unsupported upload")
+
+// Limits bounds the work done normalizing a single upload.
+type Limits struct {
+	// MaxFiles is the most CSVs that a single upload can expand into.
+	MaxFiles int
+	// MaxFileBytes is the largest uncompressed file (CSV, archive entry, or
+	// workbook part) that will be read.
+	MaxFileBytes int64
+	// MaxTotalBytes is the most uncompressed data that will be read from a
+	// single upload.
+	MaxTotalBytes int64
+	// MaxColumns is the widest workbook sheet that will be converted. A single
+	// cell far to the right makes every row of the CSV that wide, and no
+	// portfolio has more than a few dozen columns.
+	MaxColumns int
+	// MaxCells is the most cells, including the padding that makes each row
+	// the same width, that a workbook sheet will be converted into.
+	MaxCells int
+}
+
+var DefaultLimits = Limits{
+	MaxFiles:      25,
+	MaxFileBytes:  100 << 20,
+	MaxTotalBytes: 250 << 20,
+	MaxColumns:    256,
+	MaxCells:      10_000_000,
+}
+
+// Output is a CSV produced from an upload.
+type Output struct {
+	// FileName is the name of the CSV in the destination directory.
+	FileName string
+	// Entry is the name of the sheet or archive entry the CSV came from, and is
+	// empty if the upload was a CSV to begin with.
+	Entry string
+}
+
+var (
+	zipMagic      = []byte("PK\x03\x04")
+	emptyZipMagic = []byte("PK\x05\x06")
+	// Legacy .xls workbooks (and other old Office formats) are OLE compound files.
+	oleMagic = []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1")
+
+	utf16LEBOM = []byte("\xFF\xFE")
+	utf16BEBOM = []byte("\xFE\xFF")
+)
+
+// Format is what an upload appears to be, judging by its leading bytes.
+type Format int
+
+const (
+	FormatUnknown Format = iota
+	// FormatText is plain text, which may be a CSV.
+	FormatText
+	// FormatZIP is a ZIP archive, which may be an XLSX workbook.
+	FormatZIP
+)
+
+// SniffLen is the number of leading bytes that Sniff looks at.
+const SniffLen = 512
+
+// Sniff guesses the format of an upload from its first SniffLen bytes, so that
+// uploads that obviously can't be normalized can be rejected without reading
+// them in full.
+func Sniff(head []byte) Format {
+	if len(head) > SniffLen {
+		head = head[:SniffLen]
+	}
+	switch {
+	case bytes.HasPrefix(head, zipMagic), bytes.HasPrefix(head, emptyZipMagic):
+		return FormatZIP
+	case bytes.HasPrefix(head, oleMagic):
+		return FormatUnknown
+	}
+	if order, ok := utf16ByteOrder(head); ok {
+		// A trailing odd byte is the start of a code unit that was cut off.
+		units := head[len(utf16LEBOM) : len(head)&^1]
+		head, _ = io.ReadAll(newUTF16Reader(bytes.NewReader(units), order))
+	}
+	// We don't insist on UTF-8, plenty of CSVs are exported in other
+	// single-byte encodings, but text doesn't contain control characters.
+	for _, c := range head {
+		if c < 0x20 && c != '\t' && c != '\n' && c != '\r' && c != '\f' {
+			return FormatUnknown
+		}
+	}
+	return FormatText
+}
+
+// Normalize converts the upload at srcPath into one or more CSVs in destDir,
+// with names starting with prefix. If it fails, any CSVs it already wrote are
+// removed, so the rest of destDir can still be used.
+func Normalize(srcPath, destDir, prefix string, limits Limits) ([]Output, error) {
+	n := &normalizer{destDir: destDir, prefix: prefix, limits: limits, remaining: limits.MaxTotalBytes}
+	outs, err := n.normalize(srcPath)
+	if err != nil {
+		for _, fn := range n.created {
+			os.Remove(filepath.Join(destDir, fn))
+		}
+		return nil, err
+	}
+	return outs, nil
+}
+
+func (n *normalizer) normalize(srcPath string) ([]Output, error) {
+	head, err := readHead(srcPath, len(oleMagic))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.HasPrefix(head, zipMagic), bytes.HasPrefix(head, emptyZipMagic):
+		zr, err := zip.OpenReader(srcPath)
+		if err != nil {
+			return nil, fmt.Errorf("%w: file looks like a ZIP archive or XLSX workbook, but couldn't be opened: %v", ErrUnsupported, err)
+		}
+		defer zr.Close()
+		if isWorkbook(&zr.Reader) {
+			return n.workbook(&zr.Reader)
+		}
+		return n.archive(&zr.Reader)
+	case bytes.HasPrefix(head, oleMagic):
+		return nil, fmt.Errorf("%w: legacy Excel (.xls) workbooks aren't supported, save the file as .xlsx or .csv instead", ErrUnsupported)
+	}
+	return n.csv(srcPath)
+}
+
+func readHead(srcPath string, n int) ([]byte, error) {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer f.Close()
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	return buf[:read], nil
+}
+
+// utf16ByteOrder returns the byte order of text starting with a UTF-16 byte
+// order mark.
+func utf16ByteOrder(head []byte) (binary.ByteOrder, bool) {
+	switch {
+	case bytes.HasPrefix(head, utf16LEBOM):
+		return binary.LittleEndian, true
+	case bytes.HasPrefix(head, utf16BEBOM):
+		return binary.BigEndian, true
+	}
+	return nil, false
+}
+
+// decodeText returns a reader of the given CSV as UTF-8. Text starting with a
+// UTF-16 byte order mark is decoded, anything else is passed through as-is.
+func decodeText(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	head, _ := br.Peek(len(utf16LEBOM))
+	order, ok := utf16ByteOrder(head)
+	if !ok {
+		return br
+	}
+	br.Discard(len(head))
+	return newUTF16Reader(br, order)
+}
+
+// utf16Reader decodes UTF-16 text in the given byte order into UTF-8. Unpaired
+// surrogates are replaced with U+FFFD, like utf16.Decode does.
+type utf16Reader struct {
+	r     *bufio.Reader
+	order binary.ByteOrder
+
+	// pending is a code unit that was read but not yet decoded, or -1.
+	pending rune
+	// buf is decoded text that hasn't been read yet.
+	buf []byte
+	err error
+}
+
+func newUTF16Reader(r io.Reader, order binary.ByteOrder) *utf16Reader {
+	return &utf16Reader{r: bufio.NewReader(r), order: order, pending: -1}
+}
+
+func (u *utf16Reader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) && u.err == nil {
+		if len(u.buf) == 0 {
+			var r rune
+			if r, u.err = u.readRune(); u.err != nil {
+				break
+			}
+			u.buf = utf8.AppendRune(u.buf[:0], r)
+		}
+		c := copy(p[n:], u.buf)
+		u.buf = u.buf[c:]
+		n += c
+	}
+	if n > 0 {
+		return n, nil
+	}
+	return 0, u.err
+}
+
+func (u *utf16Reader) readRune() (rune, error) {
+	r1 := u.pending
+	u.pending = -1
+	if r1 < 0 {
+		var err error
+		if r1, err = u.readUnit(); err != nil {
+			return 0, err
+		}
+	}
+	if !utf16.IsSurrogate(r1) {
+		return r1, nil
+	}
+	r2, err := u.readUnit()
+	if errors.Is(err, io.EOF) {
+		return utf8.RuneError, nil
+	} else if err != nil {
+		return 0, err
+	}
+	if r := utf16.DecodeRune(r1, r2); r != utf8.RuneError {
+		return r, nil
+	}
+	// r1 wasn't the start of a surrogate pair, so r2 starts the next rune.
+	u.pending = r2
+	return utf8.RuneError, nil
+}
+
+func (u *utf16Reader) readUnit() (rune, error) {
+	var b [2]byte
+	if _, err := io.ReadFull(u.r, b[:]); errors.Is(err, io.ErrUnexpectedEOF) {
+		return 0, fmt.Errorf("%w: UTF-16 text has an odd number of bytes", ErrUnsupported)
+	} else if err != nil {
+		return 0, err
+	}
+	return rune(u.order.Uint16(b[:])), nil
+}
+
+func isWorkbook(zr *zip.Reader) bool {
+	for _, f := range zr.File {
+		if f.Name == "xl/workbook.xml" {
+			return true
+		}
+	}
+	return false
+}
+
+type normalizer struct {
+	destDir string
+	prefix  string
+	limits  Limits
+
+	remaining int64
+	outputs   []Output
+	// created is every file written to destDir, including any that failed
+	// partway through.
+	created []string
+}
+
+func (n *normalizer) csv(srcPath string) ([]Output, error) {
+	f, err := os.Open(srcPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open upload: %w", err)
+	}
+	defer f.Close()
+	fn := n.prefix + ".csv"
+	if err := n.write(fn, func(w io.Writer) error { return n.copy(w, decodeText(f), "upload") }); err != nil {
+		return nil, err
+	}
+	return []Output{{FileName: fn}}, nil
+}
+
+func (n *normalizer) archive(zr *zip.Reader) ([]Output, error) {
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || ignoredEntry(f.Name) {
+			continue
+		}
+		if !safeEntryName(f.Name) {
+			return nil, fmt.Errorf("%w: archive entry %q has an unsafe path", ErrUnsupported, f.Name)
+		}
+		if !strings.EqualFold(path.Ext(f.Name), ".csv") {
+			return nil, fmt.Errorf("%w: archive entry %q isn't a CSV, only CSVs are supported inside ZIP archives", ErrUnsupported, f.Name)
+		}
+		if f.UncompressedSize64 > uint64(n.limits.MaxFileBytes) {
+			return nil, fmt.Errorf("%w: archive entry %q is larger than the limit of %d bytes", ErrUnsupported, f.Name, n.limits.MaxFileBytes)
+		}
+		if err := n.checkCount(); err != nil {
+			return nil, err
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%w: couldn't open archive entry %q: %v", ErrUnsupported, f.Name, err)
+		}
+		fn := n.nextFileName()
+		err = n.write(fn, func(w io.Writer) error { return n.copy(w, decodeText(rc), f.Name) })
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		n.outputs = append(n.outputs, Output{FileName: fn, Entry: f.Name})
+	}
+	if len(n.outputs) == 0 {
+		return nil, fmt.Errorf("%w: archive doesn't contain any CSVs", ErrUnsupported)
+	}
+	return n.outputs, nil
+}
+
+func (n *normalizer) workbook(zr *zip.Reader) ([]Output, error) {
+	var wb xlsxWorkbook
+	if err := n.readXML(zr, "xl/workbook.xml", &wb); err != nil {
+		return nil, err
+	}
+	var rels xlsxRelationships
+	if err := n.readXML(zr, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string)
+	for _, r := range rels.Relationships {
+		if strings.HasPrefix(r.Target, "/") {
+			targets[r.ID] = strings.TrimPrefix(r.Target, "/")
+		} else {
+			targets[r.ID] = path.Join("xl", r.Target)
+		}
+	}
+	var sst xlsxSharedStrings
+	if hasFile(zr, "xl/sharedStrings.xml") {
+		if err := n.readXML(zr, "xl/sharedStrings.xml", &sst); err != nil {
+			return nil, err
+		}
+	}
+	strs := make([]string, len(sst.Items))
+	for i, si := range sst.Items {
+		strs[i] = si.text()
+	}
+
+	for _, s := range wb.Sheets {
+		target, ok := targets[s.relationshipID()]
+		if !ok {
+			return nil, fmt.Errorf("%w: workbook is malformed, sheet %q has no data", ErrUnsupported, s.Name)
+		}
+		var sheet xlsxSheet
+		if err := n.readXML(zr, target, &sheet); err != nil {
+			return nil, err
+		}
+		records, err := sheet.records(strs, n.limits.MaxColumns, n.limits.MaxCells)
+		if err != nil {
+			return nil, fmt.Errorf("%w: sheet %q: %v", ErrUnsupported, s.Name, err)
+		}
+		if len(records) == 0 {
+			continue
+		}
+		if err := n.checkCount(); err != nil {
+			return nil, err
+		}
+		fn := n.nextFileName()
+		err = n.write(fn, func(w io.Writer) error {
+			cw := csv.NewWriter(w)
+			if err := cw.WriteAll(records); err != nil {
+				return fmt.Errorf("failed to write CSV for sheet %q: %w", s.Name, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		n.outputs = append(n.outputs, Output{FileName: fn, Entry: s.Name})
+	}
+	if len(n.outputs) == 0 {
+		return nil, fmt.Errorf("%w: workbook doesn't contain any non-empty sheets", ErrUnsupported)
+	}
+	return n.outputs, nil
+}
+
+func (n *normalizer) checkCount() error {
+	if len(n.outputs) >= n.limits.MaxFiles {
+		return fmt.Errorf("%w: upload contains more than the limit of %d files", ErrUnsupported, n.limits.MaxFiles)
+	}
+	return nil
+}
+
+func (n *normalizer) nextFileName() string {
+	return fmt.Sprintf("%s_%d.csv", n.prefix, len(n.outputs)+1)
+}
+
+func (n *normalizer) write(fn string, fill func(io.Writer) error) error {
+	f, err := os.Create(filepath.Join(n.destDir, fn))
+	if err != nil {
+		return fmt.Errorf("failed to create %q: %w", fn, err)
+	}
+	n.created = append(n.created, fn)
+	if err := fill(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close %q: %w", fn, err)
+	}
+	return nil
+}
+
+// copy copies at most the file and total limits from src to dst, failing if
+// there was more to copy.
+func (n *normalizer) copy(dst io.Writer, src io.Reader, name string) error {
+	max := min(n.limits.MaxFileBytes, n.remaining)
+	written, err := io.Copy(dst, io.LimitReader(src, max+1))
+	if err != nil {
+		return fmt.Errorf("failed to copy %q: %w", name, err)
+	}
+	if written > max {
+		return n.tooBig(name)
+	}
+	n.remaining -= written
+	return nil
+}
+
+func (n *normalizer) tooBig(name string) error {
+	if n.remaining < n.limits.MaxFileBytes {
+		return fmt.Errorf("%w: upload is larger than the limit of %d bytes", ErrUnsupported, n.limits.MaxTotalBytes)
+	}
+	return fmt.Errorf("%w: %q is larger than the limit of %d bytes", ErrUnsupported, name, n.limits.MaxFileBytes)
+}
+
+func (n *normalizer) readXML(zr *zip.Reader, name string, v any) error {
+	for _, f := range zr.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("%w: workbook is malformed, couldn't open %q: %v", ErrUnsupported, name, err)
+		}
+		defer rc.Close()
+		var buf bytes.Buffer
+		if err := n.copy(&buf, rc, name); err != nil {
+			return err
+		}
+		if err := xml.Unmarshal(buf.Bytes(), v); err != nil {
+			return fmt.Errorf("%w: workbook is malformed, couldn't parse %q: %v", ErrUnsupported, name, err)
+		}
+		return nil
+	}
+	return fmt.Errorf("%w: workbook is malformed, it has no %q", ErrUnsupported, name)
+}
+
+func hasFile(zr *zip.Reader, name string) bool {
+	for _, f := range zr.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ignoredEntry reports whether an archive entry is metadata added by the
+// operating system that created the archive.
+func ignoredEntry(name string) bool {
+	base := path.Base(name)
+	return strings.HasPrefix(name, "__MACOSX/") || strings.HasPrefix(base, "._") || base == ".DS_Store"
+}
+
+// safeEntryName reports whether an archive entry stays within the archive
+// when extracted. We never extract entries under their own names, but an
+// archive that tries this is not one we want to parse.
+func safeEntryName(name string) bool {
+	if name == "" || strings.HasPrefix(name, "/") || strings.Contains(name, `\`) || strings.Contains(name, ":") {
+		return false
+	}
+	clean := path.Clean(name)
+	return clean != ".." && !strings.HasPrefix(clean, "../")
+}
+
+type xlsxWorkbook struct {
+	Sheets []xlsxSheetRef `xml:"sheets>sheet"`
+}
+
+type xlsxSheetRef struct {
+	Name  string     `xml:"name,attr"`
+	Attrs []xml.Attr `xml:",any,attr"`
+}
+
+// relationshipID returns the sheet's r:id attribute. We match on any
+// namespace, as transitional and strict workbooks use different ones.
+func (s xlsxSheetRef) relationshipID() string {
+	for _, a := range s.Attrs {
+		if a.Name.Local == "id" && a.Name.Space != "" {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+type xlsxRelationships struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+type xlsxSharedStrings struct {
+	Items []xlsxString `xml:"si"`
+}
+
+// xlsxString is either plain text, or rich text made of several runs.
+type xlsxString struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (s *xlsxString) text() string {
+	if len(s.Runs) == 0 {
+		return s.T
+	}
+	var sb strings.Builder
+	sb.WriteString(s.T)
+	for _, r := range s.Runs {
+		sb.WriteString(r.T)
+	}
+	return sb.String()
+}
+
+type xlsxSheet struct {
+	Rows []struct {
+		Cells []xlsxCell `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type xlsxCell struct {
+	Ref    string      `xml:"r,attr"`
+	Type   string      `xml:"t,attr"`
+	Value  string      `xml:"v"`
+	Inline *xlsxString `xml:"is"`
+}
+
+// records converts the sheet to CSV records, dropping empty rows and padding
+// the rest to the same width. Numbers are written as stored, which means dates
+// come out as serial numbers. Sheets wider than maxColumns, or that would be
+// more than maxCells once padded, are rejected before they're expanded.
+func (s *xlsxSheet) records(strs []string, maxColumns, maxCells int) ([][]string, error) {
+	var records [][]string
+	width := 0
+	for _, row := range s.Rows {
+		var record []string
+		for i, c := range row.Cells {
+			col := i
+			if c.Ref != "" {
+				var err error
+				if col, err = columnIndex(c.Ref); err != nil {
+					return nil, err
+				}
+			}
+			v, err := c.value(strs)
+			if err != nil {
+				return nil, err
+			}
+			if v == "" {
+				continue
+			}
+			if col >= maxColumns {
+				return nil, fmt.Errorf("cell %s is beyond the limit of %d columns", cellName(c.Ref, col), maxColumns)
+			}
+			for len(record) <= col {
+				record = append(record, "")
+			}
+			record[col] = v
+		}
+		if len(record) == 0 {
+			continue
+		}
+		width = max(width, len(record))
+		if width*(len(records)+1) > maxCells {
+			return nil, fmt.Errorf("sheet has more than the limit of %d cells", maxCells)
+		}
+		records = append(records, record)
+	}
+	for i, r := range records {
+		for len(r) < width {
+			r = append(r, "")
+		}
+		records[i] = r
+	}
+	return records, nil
+}
+
+func (c *xlsxCell) value(strs []string) (string, error) {
+	switch c.Type {
+	case "s":
+		if c.Value == "" {
+			return "", nil
+		}
+		idx, err := strconv.Atoi(c.Value)
+		if err != nil || idx < 0 || idx >= len(strs) {
+			return "", fmt.Errorf("cell %s refers to unknown string %q", c.Ref, c.Value)
+		}
+		return strs[idx], nil
+	case "inlineStr":
+		if c.Inline == nil {
+			return "", nil
+		}
+		return c.Inline.text(), nil
+	case "b":
+		switch c.Value {
+		case "1":
+			return "TRUE", nil
+		case "0":
+			return "FALSE", nil
+		}
+		return c.Value, nil
+	}
+	return c.Value, nil
+}
+
+// cellName identifies a cell in errors, by its reference if it has one.
+func cellName(ref string, col int) string {
+	if ref != "" {
+		return ref
+	}
+	return fmt.Sprintf("in column %d", col+1)
+}
+
+// excelColumns is the number of columns in an Excel sheet, XFD.
+const excelColumns = 16384
+
+// columnIndex returns the zero-based column of a cell reference like "AB12".
+func columnIndex(ref string) (int, error) {
+	col := 0
+	i := 0
+	for ; i < len(ref); i++ {
+		ch := ref[i]
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		if col > excelColumns {
+			return 0, fmt.Errorf("cell reference %q is out of range", ref)
+		}
+	}
+	if i == 0 {
+		return 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+	return col - 1, nil
+}
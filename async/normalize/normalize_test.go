@@ -0,0 +1,364 @@
+package normalize
+
+import (
+	"archive/zip"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestNormalizeCSV(t *testing.T) {
+	src := writeFile(t, "upload", []byte("isin,market_value\nXS123,100\n"))
+	destDir := t.TempDir()
+
+	got, err := Normalize(src, destDir, "abc", DefaultLimits)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	if diff := cmp.Diff([]Output{{FileName: "abc.csv"}}, got); diff != "" {
+		t.Errorf("unexpected outputs (-want +got)\n%s", diff)
+	}
+	checkFile(t, filepath.Join(destDir, "abc.csv"), "isin,market_value\nXS123,100\n")
+}
+
+func TestNormalizeUTF16CSV(t *testing.T) {
+	tests := []struct {
+		name   string
+		upload string
+		want   string
+	}{
+		{
+			name:   "little endian",
+			upload: "\xff\xfei\x00s\x00i\x00n\x00\n\x00\xe9\x00\n\x00",
+			want:   "isin\n\u00e9\n",
+		},
+		{
+			name:   "big endian",
+			upload: "\xfe\xff\x00i\x00s\x00i\x00n\x00\n\x00\xe9\x00\n",
+			want:   "isin\n\u00e9\n",
+		},
+		{
+			name:   "surrogate pair",
+			upload: "\xff\xfea\x00\x3d\xd8\x00\xde\n\x00",
+			want:   "a\U0001F600\n",
+		},
+		{
+			name:   "unpaired surrogates",
+			upload: "\xff\xfe\x00\xdea\x00\x3d\xd8b\x00\x3d\xd8",
+			want:   "\uFFFDa\uFFFDb\uFFFD",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src := writeFile(t, "upload", []byte(test.upload))
+			destDir := t.TempDir()
+
+			if _, err := Normalize(src, destDir, "abc", DefaultLimits); err != nil {
+				t.Fatalf("Normalize: %v", err)
+			}
+			checkFile(t, filepath.Join(destDir, "abc.csv"), test.want)
+		})
+	}
+}
+
+func TestNormalizeWorkbook(t *testing.T) {
+	src := writeZip(t, map[string]string{
+		"[Content_Types].xml": `<Types/>`,
+		"xl/workbook.xml": `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+	<sheets>
+		<sheet name="Holdings" sheetId="1" r:id="rId1"/>
+		<sheet name="Empty" sheetId="2" r:id="rId2"/>
+		<sheet name="More, Holdings" sheetId="3" r:id="rId3"/>
+	</sheets>
+</workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+	<Relationship Id="rId1" Target="worksheets/sheet1.xml"/>
+	<Relationship Id="rId2" Target="worksheets/sheet2.xml"/>
+	<Relationship Id="rId3" Target="/xl/worksheets/sheet3.xml"/>
+	<Relationship Id="rId4" Target="sharedStrings.xml"/>
+</Relationships>`,
+		"xl/sharedStrings.xml": `<sst><si><t>isin</t></si><si><t>market_value</t></si><si><r><t>XS</t></r><r><t>123</t></r></si></sst>`,
+		"xl/worksheets/sheet1.xml": `<worksheet><sheetData>
+	<row r="1"><c r="A1" t="s"><v>0</v></c><c r="B1" t="s"><v>1</v></c><c r="D1" t="inlineStr"><is><t>esg</t></is></c></row>
+	<row r="2"><c r="A2" t="s"><v>2</v></c><c r="B2"><v>100.5</v></c><c r="D2" t="b"><v>1</v></c></row>
+	<row r="3"><c r="A3" s="1"/></row>
+	<row r="5"><c r="B5"><v>7</v></c></row>
+</sheetData></worksheet>`,
+		"xl/worksheets/sheet2.xml": `<worksheet><sheetData/></worksheet>`,
+		"xl/worksheets/sheet3.xml": `<worksheet><sheetData><row><c t="inlineStr"><is><t>a,b</t></is></c><c><v>1</v></c></row></sheetData></worksheet>`,
+	})
+	destDir := t.TempDir()
+
+	got, err := Normalize(src, destDir, "abc", DefaultLimits)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	want := []Output{
+		{FileName: "abc_1.csv", Entry: "Holdings"},
+		{FileName: "abc_2.csv", Entry: "More, Holdings"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected outputs (-want +got)\n%s", diff)
+	}
+	checkFile(t, filepath.Join(destDir, "abc_1.csv"), "isin,market_value,,esg\nXS123,100.5,,TRUE\n,7,,\n")
+	checkFile(t, filepath.Join(destDir, "abc_2.csv"), "\"a,b\",1\n")
+}
+
+func TestNormalizeArchive(t *testing.T) {
+	src := writeZip(t, map[string]string{
+		"a.csv":               "isin\nXS1\n",
+		"c.csv":               "\xff\xfei\x00s\x00i\x00n\x00\n\x00",
+		"nested/b.CSV":        "isin\nXS2\n",
+		"__MACOSX/._a.csv":    "junk",
+		"nested/.DS_Store":    "junk",
+		"nested/another/dir/": "",
+	})
+	destDir := t.TempDir()
+
+	got, err := Normalize(src, destDir, "abc", DefaultLimits)
+	if err != nil {
+		t.Fatalf("Normalize: %v", err)
+	}
+	want := []Output{
+		{FileName: "abc_1.csv", Entry: "a.csv"},
+		{FileName: "abc_2.csv", Entry: "c.csv"},
+		{FileName: "abc_3.csv", Entry: "nested/b.CSV"},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected outputs (-want +got)\n%s", diff)
+	}
+	checkFile(t, filepath.Join(destDir, "abc_1.csv"), "isin\nXS1\n")
+	checkFile(t, filepath.Join(destDir, "abc_2.csv"), "isin\n")
+	checkFile(t, filepath.Join(destDir, "abc_3.csv"), "isin\nXS2\n")
+}
+
+func TestNormalizeUnsupported(t *testing.T) {
+	small := Limits{MaxFiles: 2, MaxFileBytes: 10, MaxTotalBytes: 15}
+	smallSheets := DefaultLimits
+	smallSheets.MaxColumns, smallSheets.MaxCells = 3, 4
+	tests := []struct {
+		name   string
+		src    string
+		limits Limits
+	}{
+		{
+			name:   "path traversal",
+			src:    writeZip(t, map[string]string{"../evil.csv": "a"}),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "absolute path",
+			src:    writeZip(t, map[string]string{"/etc/evil.csv": "a"}),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "non-CSV entry",
+			src:    writeZip(t, map[string]string{"a.csv": "a", "b.xlsx": "b"}),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "empty archive",
+			src:    writeZip(t, map[string]string{"__MACOSX/._a.csv": "a"}),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "too many entries",
+			src:    writeZip(t, map[string]string{"a.csv": "a", "b.csv": "b", "c.csv": "c"}),
+			limits: small,
+		},
+		{
+			name:   "odd-length UTF-16",
+			src:    writeFile(t, "upload", []byte("\xff\xfei\x00s")),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "entry too big",
+			src:    writeZip(t, map[string]string{"a.csv": "0123456789a"}),
+			limits: small,
+		},
+		{
+			name:   "archive too big",
+			src:    writeZip(t, map[string]string{"a.csv": "0123456789", "b.csv": "0123456789"}),
+			limits: small,
+		},
+		{
+			name:   "CSV too big",
+			src:    writeFile(t, "upload", []byte("0123456789a")),
+			limits: small,
+		},
+		{
+			name:   "legacy workbook",
+			src:    writeFile(t, "upload", []byte("\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1rest")),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "corrupt archive",
+			src:    writeFile(t, "upload", []byte("PK\x03\x04garbage")),
+			limits: DefaultLimits,
+		},
+		{
+			name: "workbook without sheets",
+			src: writeZip(t, map[string]string{
+				"xl/workbook.xml":            `<workbook><sheets/></workbook>`,
+				"xl/_rels/workbook.xml.rels": `<Relationships/>`,
+			}),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "sheet too wide",
+			src:    writeWorkbook(t, `<row><c r="XFD1"><v>1</v></c></row>`),
+			limits: DefaultLimits,
+		},
+		{
+			name:   "sheet too wide without references",
+			src:    writeWorkbook(t, `<row><c/><c/><c/><c><v>1</v></c></row>`),
+			limits: smallSheets,
+		},
+		{
+			name:   "sheet has too many cells",
+			src:    writeWorkbook(t, `<row><c r="A1"><v>1</v></c></row><row><c r="A2"><v>1</v></c></row><row><c r="C3"><v>1</v></c></row>`),
+			limits: smallSheets,
+		},
+		{
+			name:   "bad entry after a good one",
+			src:    writeZip(t, map[string]string{"a.csv": "isin\nXS1\n", "b.xlsx": "b"}),
+			limits: DefaultLimits,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			destDir := t.TempDir()
+			_, err := Normalize(test.src, destDir, "abc", test.limits)
+			if !errors.Is(err, ErrUnsupported) {
+				t.Errorf("expected an ErrUnsupported, got %v", err)
+			}
+			entries, err := os.ReadDir(destDir)
+			if err != nil {
+				t.Fatalf("reading destination: %v", err)
+			}
+			if len(entries) != 0 {
+				t.Errorf("expected no files to be left behind, found %d", len(entries))
+			}
+		})
+	}
+}
+
+func TestSniff(t *testing.T) {
+	tests := []struct {
+		name string
+		head string
+		want Format
+	}{
+		{"csv", "isin,market_value\r\nXS123,100\r\n", FormatText},
+		{"latin-1 csv", "name\n\xe9t\xe9\n", FormatText},
+		{"empty", "", FormatText},
+		{"zip", "PK\x03\x04rest", FormatZIP},
+		{"empty zip", "PK\x05\x06rest", FormatZIP},
+		{"xls", "\xD0\xCF\x11\xE0\xA1\xB1\x1A\xE1rest", FormatUnknown},
+		{"utf-16le csv", "\xff\xfei\x00s\x00\r\x00\n\x00", FormatText},
+		{"utf-16be csv", "\xfe\xff\x00i\x00s\x00\r\x00\n", FormatText},
+		{"utf-16 csv cut off", "\xff\xfei\x00s\x00\x3d\xd8\x00", FormatText},
+		{"utf-16 binary", "\xff\xfe\x01\x00\x02\x00", FormatUnknown},
+		{"pdf", "%PDF-1.7\n\x00\x01", FormatUnknown},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := Sniff([]byte(test.head)); got != test.want {
+				t.Errorf("Sniff(%q) = %d, want %d", test.head, got, test.want)
+			}
+		})
+	}
+}
+
+func TestColumnIndex(t *testing.T) {
+	tests := map[string]int{
+		"A1":     0,
+		"Z9":     25,
+		"AA10":   26,
+		"AB1":    27,
+		"XFD999": 16383,
+	}
+	for ref, want := range tests {
+		got, err := columnIndex(ref)
+		if err != nil {
+			t.Errorf("columnIndex(%q): %v", ref, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("columnIndex(%q) = %d, want %d", ref, got, want)
+		}
+	}
+	for _, ref := range []string{"", "1", "XFE1", "a1"} {
+		if _, err := columnIndex(ref); err == nil {
+			t.Errorf("columnIndex(%q) should have failed", ref)
+		}
+	}
+}
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(p, data, 0600); err != nil {
+		t.Fatalf("writing %q: %v", p, err)
+	}
+	return p
+}
+
+// writeWorkbook writes a workbook with a single sheet containing the given rows.
+func writeWorkbook(t *testing.T, rows string) string {
+	t.Helper()
+	return writeZip(t, map[string]string{
+		"xl/workbook.xml":            `<workbook xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets><sheet name="Sheet1" r:id="rId1"/></sheets></workbook>`,
+		"xl/_rels/workbook.xml.rels": `<Relationships><Relationship Id="rId1" Target="worksheets/sheet1.xml"/></Relationships>`,
+		"xl/worksheets/sheet1.xml":   `<worksheet><sheetData>` + rows + `</sheetData></worksheet>`,
+	})
+}
+
+func writeZip(t *testing.T, files map[string]string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "upload")
+	f, err := os.Create(p)
+	if err != nil {
+		t.Fatalf("creating %q: %v", p, err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	// Sort for a deterministic entry order.
+	for _, name := range sortedKeys(files) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("creating zip entry %q: %v", name, err)
+		}
+		if _, err := w.Write([]byte(files[name])); err != nil {
+			t.Fatalf("writing zip entry %q: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("closing zip: %v", err)
+	}
+	return p
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func checkFile(t *testing.T, p, want string) {
+	t.Helper()
+	got, err := os.ReadFile(p)
+	if err != nil {
+		t.Fatalf("reading %q: %v", p, err)
+	}
+	if diff := cmp.Diff(want, string(got)); diff != "" {
+		t.Errorf("unexpected contents of %q (-want +got)\n%s", filepath.Base(p), diff)
+	}
+}
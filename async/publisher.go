@@ -0,0 +1,140 @@
+package async
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/messaging/azeventgrid/publisher"
+)
+
+// Event is a notification about an async task, delivered to the API server
+// via a Publisher.
+type Event struct {
+	// Type is the kind of event, like 'parsed-portfolio' or 'created-report',
+	// and determines how the API server interprets Data.
+	Type    string
+	ID      string
+	Subject string
+	Time    time.Time
+	Data    any
+}
+
+// Publisher sends events to the API server, which receives them in
+// azure/azevents.
+type Publisher interface {
+	Publish(ctx context.Context, ev *Event) error
+}
+
+// EventGridPublisher publishes events to an Azure Event Grid topic, which then
+// forwards them to the API server via a webhook subscription.
+type EventGridPublisher struct {
+	client *publisher.Client
+}
+
+func NewEventGridPublisher(client *publisher.Client) *EventGridPublisher {
+	return &EventGridPublisher{client: client}
+}
+
+func (p *EventGridPublisher) Publish(ctx context.Context, ev *Event) error {
+	events := []publisher.Event{
+		{
+			Data:        ev.Data,
+			DataVersion: to.Ptr("1.0"),
+			EventType:   to.Ptr(ev.Type),
+			EventTime:   to.Ptr(ev.Time),
+			ID:          to.Ptr(ev.ID),
+			Subject:     to.Ptr(ev.Subject),
+		},
+	}
+	if _, err := p.client.PublishEvents(ctx, events, nil); err != nil {
+		return fmt.Errorf("failed to publish events to Event Grid: %w", err)
+	}
+	return nil
+}
+
+// HTTPPublisher sends events directly to the API server's webhook endpoint,
+// using the same envelope and shared-secret authorization that Event Grid
+// uses. This allows running the full pipeline locally without Azure.
+type HTTPPublisher struct {
+	url        string
+	authSecret string
+	client     *http.Client
+}
+
+// NewHTTPPublisher returns a publisher that POSTs events to the given URL, like
+// 'http://localhost:8081/events'. The authSecret must be one of the secrets
+// the receiving server accepts for incoming webhooks.
+func NewHTTPPublisher(webhookURL, authSecret string) (*HTTPPublisher, error) {
+	if webhookURL == "" {
+		return nil, errors.New("no webhook URL was given")
+	}
+	if _, err := url.Parse(webhookURL); err != nil {
+		return nil, fmt.Errorf("invalid webhook URL %q: %w", webhookURL, err)
+	}
+	if authSecret == "" {
+		return nil, errors.New("no webhook auth secret was given")
+	}
+	return &HTTPPublisher{
+		url:        webhookURL,
+		authSecret: authSecret,
+		client:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// httpEvent mirrors the Event Grid event schema, which is what the API server
+// expects to receive, see
+// https://learn.microsoft.com/en-us/azure/event-grid/event-schema
+type httpEvent struct {
+	Data            any       `json:"data"`
+	EventType       string    `json:"eventType"`
+	ID              string    `json:"id"`
+	Subject         string    `json:"subject"`
+	DataVersion     string    `json:"dataVersion"`
+	MetadataVersion string    `json:"metadataVersion"`
+	EventTime       time.Time `json:"eventTime"`
+	Topic           string    `json:"topic"`
+}
+
+func (p *HTTPPublisher) Publish(ctx context.Context, ev *Event) error {
+	body, err := json.Marshal([]httpEvent{
+		{
+			Data:            ev.Data,
+			EventType:       ev.Type,
+			ID:              ev.ID,
+			Subject:         ev.Subject,
+			DataVersion:     "1.0",
+			MetadataVersion: "1",
+			EventTime:       ev.Time,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("authorization", p.authSecret)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook request failed with status %d: %s", resp.StatusCode, msg)
+	}
+	return nil
+}
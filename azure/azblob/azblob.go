@@ -12,6 +12,7 @@ import (
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
 	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
 	azservice "github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
 	"github.com/RMI/pacta/blob"
@@ -87,6 +88,31 @@ func (c *Client) ReadBlob(ctx context.Context, uri string) (io.ReadCloser, error
 	return resp.Body, nil
 }
 
+func (c *Client) Properties(ctx context.Context, uri string) (*blob.Properties, error) {
+	ctr, blb, ok := blob.SplitURI(Scheme, uri)
+	if !ok {
+		return nil, fmt.Errorf("malformed URI %q is not for Azure", uri)
+	}
+
+	resp, err := c.client.ServiceClient().NewContainerClient(ctr).NewBlobClient(blb).GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return nil, fmt.Errorf("failed to get blob properties: %w", blob.ErrNotExist)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get blob properties: %w", err)
+	}
+
+	props := &blob.Properties{
+		ContentMD5: resp.ContentMD5,
+	}
+	if resp.ContentLength != nil {
+		props.Size = *resp.ContentLength
+	}
+	if resp.ContentType != nil {
+		props.ContentType = *resp.ContentType
+	}
+	return props, nil
+}
+
 func (c *Client) DeleteBlob(ctx context.Context, uri string) error {
 	ctr, blb, ok := blob.SplitURI(Scheme, uri)
 	if !ok {
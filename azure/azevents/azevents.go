@@ -10,9 +10,11 @@ import (
 	"fmt"
 	"net/http"
 	"path"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/RMI/pacta/async/parsed"
 	"github.com/RMI/pacta/db"
 	"github.com/RMI/pacta/pacta"
 	"github.com/RMI/pacta/task"
@@ -23,6 +25,7 @@ import (
 type Config struct {
 	Logger *zap.Logger
 	DB     DB
+	Blob   Blob
 	Now    func() time.Time
 
 	Subscription  string
@@ -39,8 +42,12 @@ type Config struct {
 type DB interface {
 	Transactional(context.Context, func(tx db.Tx) error) error
 
+	Blobs(tx db.Tx, ids []pacta.BlobID) (map[pacta.BlobID]*pacta.Blob, error)
 	CreateBlob(tx db.Tx, b *pacta.Blob) (pacta.BlobID, error)
+	UpdateBlob(tx db.Tx, id pacta.BlobID, mutations ...db.UpdateBlobFn) error
 	CreatePortfolio(tx db.Tx, p *pacta.Portfolio) (pacta.PortfolioID, error)
+	PortfolioIDsByContentMD5(tx db.Tx, ownerID pacta.OwnerID, contentMD5 string) ([]pacta.PortfolioID, error)
+	ReplacePortfolioHoldings(tx db.Tx, id pacta.PortfolioID, replacement *pacta.Portfolio) (pacta.PortfolioVersionID, error)
 
 	CreatePortfolioGroup(tx db.Tx, pg *pacta.PortfolioGroup) (pacta.PortfolioGroupID, error)
 	CreatePortfolioGroupMembership(tx db.Tx, pgID pacta.PortfolioGroupID, pID pacta.PortfolioID) error
@@ -52,6 +59,16 @@ type DB interface {
 	UpdateAnalysis(tx db.Tx, id pacta.AnalysisID, mutations ...db.UpdateAnalysisFn) error
 
 	CreateAnalysisArtifact(tx db.Tx, a *pacta.AnalysisArtifact) (pacta.AnalysisArtifactID, error)
+
+	Task(tx db.Tx, id pacta.TaskID) (*pacta.Task, error)
+	LockTask(tx db.Tx, id pacta.TaskID) (*pacta.Task, error)
+	TasksForAnalysis(tx db.Tx, analysisID pacta.AnalysisID) ([]*pacta.Task, error)
+	TasksForIncompleteUpload(tx db.Tx, iuID pacta.IncompleteUploadID) ([]*pacta.Task, error)
+	UpdateTask(tx db.Tx, id pacta.TaskID, mutations ...db.UpdateTaskFn) error
+}
+
+type Blob interface {
+	DeleteBlob(ctx context.Context, uri string) error
 }
 
 const eventPath = "/events"
@@ -75,6 +92,9 @@ func (c *Config) validate() error {
 	if c.DB == nil {
 		return errors.New("no DB was given")
 	}
+	if c.Blob == nil {
+		return errors.New("no blob client was given")
+	}
 	if c.Now == nil {
 		return errors.New("no Now function was given")
 	}
@@ -91,6 +111,7 @@ type Server struct {
 	resourceGroup string
 	topic         string
 	db            DB
+	blob          Blob
 	now           func() time.Time
 }
 
@@ -105,6 +126,7 @@ func NewServer(cfg *Config) (*Server, error) {
 		subscription:       cfg.Subscription,
 		resourceGroup:      cfg.ResourceGroup,
 		db:                 cfg.DB,
+		blob:               cfg.Blob,
 		now:                cfg.Now,
 		topic:              cfg.TopicName,
 	}, nil
@@ -284,6 +306,22 @@ func (s *Server) handleEventGrid(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		s.handleCreatedDashboard(req.ID, &resp, w)
+	case "parse-portfolio-failed":
+		var resp task.ParsePortfolioFailedResponse
+		if err := json.Unmarshal(req.Data, &resp); err != nil {
+			s.logger.Error("failed to parse event data as ParsePortfolioFailedResponse", zap.String("event_grid_id", req.ID), zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		s.handleParsePortfolioFailed(req.ID, &resp, w)
+	case "analysis-failed":
+		var resp task.AnalysisFailedResponse
+		if err := json.Unmarshal(req.Data, &resp); err != nil {
+			s.logger.Error("failed to parse event data as AnalysisFailedResponse", zap.String("event_grid_id", req.ID), zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+			return
+		}
+		s.handleAnalysisFailed(req.ID, &resp, w)
 	default:
 		s.logger.Error("unexpected event type", zap.String("event_grid_id", req.ID), zap.String("event_type", req.EventType))
 		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
@@ -299,10 +337,39 @@ func (s *Server) handleParsedPortfolio(id string, resp *task.ParsePortfolioRespo
 	}
 
 	portfolioIDs := []pacta.PortfolioID{}
-	var ranAt time.Time
+	// Several portfolios in an upload can link to the same existing one, which
+	// only belongs in the upload's group once.
+	seenPortfolios := make(map[pacta.PortfolioID]bool)
+	addPortfolio := func(id pacta.PortfolioID) {
+		if !seenPortfolios[id] {
+			seenPortfolios[id] = true
+			portfolioIDs = append(portfolioIDs, id)
+		}
+	}
+	// Parsed outputs that don't end up in a portfolio are deleted once the
+	// results are saved.
+	unusedBlobs := []pacta.BlobURI{}
+	duplicates := make(map[pacta.IncompleteUploadID][]pacta.PortfolioID)
+	rejected := make(map[pacta.IncompleteUploadID]bool)
+	rejectedIDs := []pacta.IncompleteUploadID{}
+	// Uploads that replace the holdings of an existing portfolio must parse
+	// into exactly one portfolio, and don't create new ones.
+	outputCounts := make(map[pacta.IncompleteUploadID]int)
+	invalidReplacements := make(map[pacta.IncompleteUploadID]bool)
+	replacedIDs := []pacta.PortfolioID{}
+	var (
+		ranAt time.Time
+		stale bool
+	)
 	now := s.now()
 	// We use a background context here rather than the one from the request so that it cannot be cancelled upstream.
 	err := s.db.Transactional(context.Background(), func(tx db.Tx) error {
+		if err := s.lockTask(tx, resp.TaskID, "", resp.Request.IncompleteUploadIDs); errors.Is(err, errStaleTask) {
+			stale = true
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("locking task: %w", err)
+		}
 		incompleteUploads, err := s.db.IncompleteUploads(tx, resp.Request.IncompleteUploadIDs)
 		if err != nil {
 			return fmt.Errorf("reading incomplete uploads: %w", err)
@@ -310,7 +377,6 @@ func (s *Server) handleParsedPortfolio(id string, resp *task.ParsePortfolioRespo
 		if len(incompleteUploads) == 0 {
 			return fmt.Errorf("no incomplete uploads found for ids: %v", resp.Request.IncompleteUploadIDs)
 		}
-		var properties *pacta.PortfolioProperties
 		var ownerID pacta.OwnerID
 		for _, iu := range incompleteUploads {
 			if ownerID == "" {
@@ -318,37 +384,113 @@ func (s *Server) handleParsedPortfolio(id string, resp *task.ParsePortfolioRespo
 			} else if ownerID != iu.Owner.ID {
 				return fmt.Errorf("multiple owners found for incomplete uploads: %+v", incompleteUploads)
 			}
-
-			if properties == nil {
-				properties = &iu.Properties
-			} else if *properties != iu.Properties {
-				// TODO(#75) We currently don't support merging portfolios with different properties.
-				// but we could change that if we get better input output correlation information.
-				return fmt.Errorf("multiple properties found for incomplete uploads: %+v", incompleteUploads)
-			}
 			if iu.RanAt.After(ranAt) {
 				ranAt = iu.RanAt
 			}
 		}
+		uploads, err := s.uploadsBySource(tx, resp.Request, incompleteUploads)
+		if err != nil {
+			return fmt.Errorf("matching parsed files to incomplete uploads: %w", err)
+		}
+		if err := s.saveParseResults(tx, incompleteUploads, uploads, resp.Sources); err != nil {
+			return fmt.Errorf("saving parse results: %w", err)
+		}
+		if err := s.saveParseLogs(tx, incompleteUploads, resp.Logs); err != nil {
+			return fmt.Errorf("saving parse logs: %w", err)
+		}
+		unreadable, err := rejectedUploads(uploads, resp.Rejected)
+		if err != nil {
+			return err
+		}
+		// Duplicates are looked up before anything is created, so only
+		// portfolios from earlier uploads count.
+		outputUploads := make([]*task.ParsePortfolioUpload, len(resp.Outputs))
+		duplicateOf := make([]pacta.PortfolioID, len(resp.Outputs))
 		for i, output := range resp.Outputs {
-			blobID, err := s.db.CreateBlob(tx, &output.Blob)
+			u, ok := uploads[output.Source]
+			if !ok {
+				return fmt.Errorf("no incomplete upload found for source %q of portfolio %d", output.Source, i)
+			}
+			outputUploads[i] = u
+			outputCounts[u.IncompleteUploadID]++
+			if incompleteUploads[u.IncompleteUploadID].ReplacesPortfolio != nil {
+				// Replacing a portfolio's holdings with a copy of another's
+				// is intentional, so duplicates aren't checked for.
+				continue
+			}
+			if output.Portfolio.OutputMD5 == "" {
+				continue
+			}
+			existing, err := s.db.PortfolioIDsByContentMD5(tx, ownerID, output.Portfolio.OutputMD5)
+			if err != nil {
+				return fmt.Errorf("looking up duplicates of portfolio %d: %w", i, err)
+			}
+			if len(existing) == 0 {
+				continue
+			}
+			duplicateOf[i] = existing[0]
+			duplicates[u.IncompleteUploadID] = append(duplicates[u.IncompleteUploadID], existing[0])
+			if incompleteUploads[u.IncompleteUploadID].DuplicatePolicy == pacta.DuplicatePolicy_Reject {
+				rejected[u.IncompleteUploadID] = true
+			}
+		}
+		for iuID, iu := range incompleteUploads {
+			if iu.ReplacesPortfolio != nil && outputCounts[iuID] != 1 && unreadable[iuID] == nil {
+				invalidReplacements[iuID] = true
+			}
+		}
+		for i, output := range resp.Outputs {
+			u := outputUploads[i]
+			if rejected[u.IncompleteUploadID] || invalidReplacements[u.IncompleteUploadID] {
+				unusedBlobs = append(unusedBlobs, output.Blob.BlobURI)
+				continue
+			}
+			if replaces := incompleteUploads[u.IncompleteUploadID].ReplacesPortfolio; replaces != nil {
+				b := output.Blob
+				b.ContentMD5 = output.Portfolio.OutputMD5
+				blobID, err := s.db.CreateBlob(tx, &b)
+				if err != nil {
+					return fmt.Errorf("creating blob %d: %w", i, err)
+				}
+				_, err = s.db.ReplacePortfolioHoldings(tx, replaces.ID, &pacta.Portfolio{
+					NumberOfRows:  output.Portfolio.OutputRows,
+					InvestorName:  output.Portfolio.InvestorName,
+					PortfolioName: output.Portfolio.PortfolioName,
+					MD5:           output.Portfolio.OutputMD5,
+					Blob:          &pacta.Blob{ID: blobID},
+				})
+				if err != nil {
+					return fmt.Errorf("replacing holdings of portfolio %q: %w", replaces.ID, err)
+				}
+				replacedIDs = append(replacedIDs, replaces.ID)
+				continue
+			}
+			if duplicateOf[i] != "" && incompleteUploads[u.IncompleteUploadID].DuplicatePolicy == pacta.DuplicatePolicy_Link {
+				addPortfolio(duplicateOf[i])
+				unusedBlobs = append(unusedBlobs, output.Blob.BlobURI)
+				continue
+			}
+			b := output.Blob
+			b.ContentMD5 = output.Portfolio.OutputMD5
+			blobID, err := s.db.CreateBlob(tx, &b)
 			if err != nil {
 				return fmt.Errorf("creating blob %d: %w", i, err)
 			}
 
-			// TODO(#187): There's other metadata in output.Portfolio, like `InvestorName`, that
-			// we aren't currently storing.
 			portfolioID, err := s.db.CreatePortfolio(tx, &pacta.Portfolio{
-				Owner:        &pacta.Owner{ID: ownerID},
-				Name:         output.Blob.FileName,
-				NumberOfRows: output.Portfolio.OutputRows,
-				Blob:         &pacta.Blob{ID: blobID},
-				Properties:   *properties,
+				Owner:         &pacta.Owner{ID: ownerID},
+				Name:          output.Blob.FileName,
+				NumberOfRows:  output.Portfolio.OutputRows,
+				InvestorName:  output.Portfolio.InvestorName,
+				PortfolioName: output.Portfolio.PortfolioName,
+				MD5:           output.Portfolio.OutputMD5,
+				Blob:          &pacta.Blob{ID: blobID},
+				Properties:    u.Properties,
 			})
 			if err != nil {
 				return fmt.Errorf("creating portfolio %d: %w", i, err)
 			}
-			portfolioIDs = append(portfolioIDs, portfolioID)
+			addPortfolio(portfolioID)
 		}
 		if len(portfolioIDs) > 1 {
 			pgID, err := s.db.CreatePortfolioGroup(tx, &pacta.PortfolioGroup{
@@ -364,15 +506,39 @@ func (s *Server) handleParsedPortfolio(id string, resp *task.ParsePortfolioRespo
 				}
 			}
 		}
-		for iuid, iu := range incompleteUploads {
-			err := s.db.UpdateIncompleteUpload(
-				tx,
-				iu.ID,
-				db.SetIncompleteUploadCompletedAt(now))
-			if err != nil {
+		for iuid := range incompleteUploads {
+			mutations := []db.UpdateIncompleteUploadFn{
+				db.SetIncompleteUploadDuplicatePortfolios(duplicates[iuid]),
+				db.SetIncompleteUploadCompletedAt(now),
+			}
+			if rejected[iuid] {
+				rejectedIDs = append(rejectedIDs, iuid)
+				msg := fmt.Sprintf("upload duplicates existing portfolios: %s", strings.Join(asStrs(duplicates[iuid]), ", "))
+				mutations = append(mutations,
+					db.SetIncompleteUploadFailureCode(pacta.FailureCode_Duplicate),
+					db.SetIncompleteUploadFailureMessage(msg))
+			}
+			if rf := unreadable[iuid]; rf != nil {
+				rejectedIDs = append(rejectedIDs, iuid)
+				mutations = append(mutations,
+					db.SetIncompleteUploadFailureCode(s.parseFailureCode(rf.FailureCode)),
+					db.SetIncompleteUploadFailureMessage(rf.FailureMessage))
+			}
+			if invalidReplacements[iuid] {
+				rejectedIDs = append(rejectedIDs, iuid)
+				msg := fmt.Sprintf("upload contained %d portfolios, but replacing the holdings of a portfolio requires exactly one", outputCounts[iuid])
+				mutations = append(mutations,
+					db.SetIncompleteUploadFailureCode(pacta.FailureCode_InvalidReplacement),
+					db.SetIncompleteUploadFailureMessage(msg))
+			}
+			if err := s.db.UpdateIncompleteUpload(tx, iuid, mutations...); err != nil {
 				return fmt.Errorf("updating incomplete upload %s: %w", iuid, err)
 			}
 		}
+		// Even if every upload was rejected, the task itself did its job.
+		if err := s.markTaskSucceeded(tx, resp.TaskID, now); err != nil {
+			return fmt.Errorf("updating task: %w", err)
+		}
 		return nil
 	})
 	if err != nil {
@@ -380,6 +546,20 @@ func (s *Server) handleParsedPortfolio(id string, resp *task.ParsePortfolioRespo
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
+	if stale {
+		s.logger.Info("ignoring parse results of finished or superseded task",
+			zap.String("task_id", string(resp.TaskID)),
+			zap.Strings("incomplete_upload_ids", asStrs(resp.Request.IncompleteUploadIDs)))
+		return
+	}
+
+	// The results are saved either way, so failing to clean up only leaves
+	// some orphaned blobs behind.
+	for _, uri := range unusedBlobs {
+		if err := s.blob.DeleteBlob(context.Background(), string(uri)); err != nil {
+			s.logger.Error("failed to delete unused parsed portfolio", zap.String("blob_uri", string(uri)), zap.Error(err))
+		}
+	}
 
 	s.logger.Info("parsed portfolio",
 		zap.String("task_id", string(resp.TaskID)),
@@ -387,14 +567,16 @@ func (s *Server) handleParsedPortfolio(id string, resp *task.ParsePortfolioRespo
 		zap.Strings("incomplete_upload_ids", asStrs(resp.Request.IncompleteUploadIDs)),
 		zap.Int("incomplete_upload_count", len(resp.Request.IncompleteUploadIDs)),
 		zap.Strings("portfolio_ids", asStrs(portfolioIDs)),
-		zap.Int("portfolio_count", len(portfolioIDs)))
+		zap.Int("portfolio_count", len(portfolioIDs)),
+		zap.Strings("replaced_portfolio_ids", asStrs(replacedIDs)),
+		zap.Strings("rejected_incomplete_upload_ids", asStrs(rejectedIDs)))
 }
 
 func (s *Server) handleCreatedAudit(id string, resp *task.CreateAuditResponse, w http.ResponseWriter) {
 	s.handleCompletedAnalysis(
 		pacta.AnalysisType_Audit,
 		resp.Request.AnalysisID,
-		id,
+		resp.TaskID,
 		resp.Artifacts,
 		w)
 }
@@ -403,7 +585,7 @@ func (s *Server) handleCreatedReport(id string, resp *task.CreateReportResponse,
 	s.handleCompletedAnalysis(
 		pacta.AnalysisType_Report,
 		resp.Request.AnalysisID,
-		id,
+		resp.TaskID,
 		resp.Artifacts,
 		w)
 }
@@ -412,7 +594,7 @@ func (s *Server) handleCreatedDashboard(id string, resp *task.CreateDashboardRes
 	s.handleCompletedAnalysis(
 		pacta.AnalysisType_Dashboard,
 		resp.Request.AnalysisID,
-		id,
+		resp.TaskID,
 		resp.Artifacts,
 		w)
 }
@@ -420,13 +602,23 @@ func (s *Server) handleCreatedDashboard(id string, resp *task.CreateDashboardRes
 func (s *Server) handleCompletedAnalysis(
 	analysisType pacta.AnalysisType,
 	analysisID pacta.AnalysisID,
-	taskID string,
+	taskID task.ID,
 	artifacts []*task.AnalysisArtifact,
 	w http.ResponseWriter) {
-	var ranAt time.Time
+	var (
+		ranAt     time.Time
+		stale     bool
+		cancelled bool
+	)
 	now := s.now()
 	// We use a background context here rather than the one from the request so that it cannot be cancelled upstream.
 	err := s.db.Transactional(context.Background(), func(tx db.Tx) error {
+		if err := s.lockTask(tx, taskID, analysisID, nil); errors.Is(err, errStaleTask) {
+			stale = true
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("locking task: %w", err)
+		}
 		a, err := s.db.Analysis(tx, analysisID)
 		if err != nil {
 			return fmt.Errorf("reading analysis: %w", err)
@@ -434,28 +626,28 @@ func (s *Server) handleCompletedAnalysis(
 		if a.AnalysisType != analysisType {
 			return fmt.Errorf("analysis type mismatch: %q != %q", a.AnalysisType, analysisType)
 		}
+		if a.FailureCode == pacta.FailureCode_Cancelled {
+			// The task finished before it could be stopped, drop its results.
+			cancelled = true
+			return nil
+		}
 		ranAt = a.RanAt
-		for _, artifact := range artifacts {
-			blobID, err := s.db.CreateBlob(tx, &pacta.Blob{
-				FileName: artifact.FileName,
-				FileType: artifact.FileType,
-				BlobURI:  artifact.BlobURI,
-			})
-			if err != nil {
-				return fmt.Errorf("creating blob: %w", err)
-			}
-			_, err = s.db.CreateAnalysisArtifact(tx, &pacta.AnalysisArtifact{
-				Blob:       &pacta.Blob{ID: blobID},
-				AnalysisID: analysisID,
-			})
-			if err != nil {
-				return fmt.Errorf("creating analysis artifact: %w", err)
-			}
+		if err := s.createAnalysisArtifacts(tx, analysisID, artifacts); err != nil {
+			return err
+		}
+		imageTag, err := s.taskImageTag(tx, taskID)
+		if err != nil {
+			return fmt.Errorf("reading task image: %w", err)
 		}
-		err = s.db.UpdateAnalysis(tx, analysisID, db.SetAnalysisCompletedAt(now))
+		err = s.db.UpdateAnalysis(tx, analysisID,
+			db.SetAnalysisCompletedAt(now),
+			db.SetAnalysisImageTag(imageTag))
 		if err != nil {
 			return fmt.Errorf("updating analysis: %w", err)
 		}
+		if err := s.markTaskSucceeded(tx, taskID, now); err != nil {
+			return fmt.Errorf("updating task: %w", err)
+		}
 		return nil
 	})
 	if err != nil {
@@ -463,6 +655,18 @@ func (s *Server) handleCompletedAnalysis(
 		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 		return
 	}
+	if stale {
+		s.logger.Info("ignoring completion of finished or superseded task",
+			zap.String("task_id", string(taskID)),
+			zap.String("analysis_id", string(analysisID)))
+		return
+	}
+	if cancelled {
+		s.logger.Info("ignoring completion of cancelled analysis",
+			zap.String("task_id", string(taskID)),
+			zap.String("analysis_id", string(analysisID)))
+		return
+	}
 
 	s.logger.Info("analysis completed",
 		zap.String("analysis_type", string(analysisType)),
@@ -471,6 +675,430 @@ func (s *Server) handleCompletedAnalysis(
 		zap.String("analysis_id", string(analysisID)))
 }
 
+func (s *Server) handleParsePortfolioFailed(id string, resp *task.ParsePortfolioFailedResponse, w http.ResponseWriter) {
+	if resp.Request == nil || len(resp.Request.IncompleteUploadIDs) == 0 {
+		s.logger.Error("webhook response had no incomplete uploads", zap.String("event_grid_id", id))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	failureCode := s.parseFailureCode(resp.FailureCode)
+	now := s.now()
+	stale := false
+	// We use a background context here rather than the one from the request so that it cannot be cancelled upstream.
+	err := s.db.Transactional(context.Background(), func(tx db.Tx) error {
+		if err := s.lockTask(tx, resp.TaskID, "", resp.Request.IncompleteUploadIDs); errors.Is(err, errStaleTask) {
+			stale = true
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("locking task: %w", err)
+		}
+		var unreadable map[pacta.IncompleteUploadID]*task.ParsePortfolioRejectedFile
+		if len(resp.Sources) > 0 || len(resp.Logs) > 0 || len(resp.Rejected) > 0 {
+			incompleteUploads, err := s.db.IncompleteUploads(tx, resp.Request.IncompleteUploadIDs)
+			if err != nil {
+				return fmt.Errorf("reading incomplete uploads: %w", err)
+			}
+			uploads, err := s.uploadsBySource(tx, resp.Request, incompleteUploads)
+			if err != nil {
+				return fmt.Errorf("matching parsed files to incomplete uploads: %w", err)
+			}
+			if err := s.saveParseResults(tx, incompleteUploads, uploads, resp.Sources); err != nil {
+				return fmt.Errorf("saving parse results: %w", err)
+			}
+			if err := s.saveParseLogs(tx, incompleteUploads, resp.Logs); err != nil {
+				return fmt.Errorf("saving parse logs: %w", err)
+			}
+			if unreadable, err = rejectedUploads(uploads, resp.Rejected); err != nil {
+				return err
+			}
+		}
+		for _, iuID := range resp.Request.IncompleteUploadIDs {
+			code, msg := failureCode, resp.FailureMessage
+			if rf := unreadable[iuID]; rf != nil {
+				code, msg = s.parseFailureCode(rf.FailureCode), rf.FailureMessage
+			}
+			err := s.db.UpdateIncompleteUpload(
+				tx,
+				iuID,
+				db.SetIncompleteUploadFailureCode(code),
+				db.SetIncompleteUploadFailureMessage(msg),
+				db.SetIncompleteUploadCompletedAt(now))
+			if err != nil {
+				return fmt.Errorf("updating incomplete upload %s: %w", iuID, err)
+			}
+		}
+		if err := s.markTaskFailed(tx, resp.TaskID, now); err != nil {
+			return fmt.Errorf("updating task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to save parse failure to database", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if stale {
+		s.logger.Info("ignoring parse failure of finished or superseded task",
+			zap.String("task_id", string(resp.TaskID)),
+			zap.Strings("incomplete_upload_ids", asStrs(resp.Request.IncompleteUploadIDs)))
+		return
+	}
+
+	s.logger.Info("portfolio parsing failed",
+		zap.String("task_id", string(resp.TaskID)),
+		zap.String("failure_code", string(failureCode)),
+		zap.String("failure_message", resp.FailureMessage),
+		zap.Strings("incomplete_upload_ids", asStrs(resp.Request.IncompleteUploadIDs)))
+}
+
+// uploadsBySource maps each input blob of a ParsePortfolio request to the
+// incomplete upload it was uploaded as. Requests from before
+// ParsePortfolioRequest.Uploads existed are matched up using the database, and
+// use the upload's current properties.
+func (s *Server) uploadsBySource(tx db.Tx, req *task.ParsePortfolioRequest, incompleteUploads map[pacta.IncompleteUploadID]*pacta.IncompleteUpload) (map[pacta.BlobURI]*task.ParsePortfolioUpload, error) {
+	result := make(map[pacta.BlobURI]*task.ParsePortfolioUpload)
+	if len(req.Uploads) > 0 {
+		for _, u := range req.Uploads {
+			if _, ok := incompleteUploads[u.IncompleteUploadID]; !ok {
+				return nil, fmt.Errorf("request contained unknown incomplete upload %q", u.IncompleteUploadID)
+			}
+			result[u.BlobURI] = u
+		}
+		return result, nil
+	}
+	var blobIDs []pacta.BlobID
+	for _, iu := range incompleteUploads {
+		blobIDs = append(blobIDs, iu.Blob.ID)
+	}
+	blobs, err := s.db.Blobs(tx, blobIDs)
+	if err != nil {
+		return nil, fmt.Errorf("reading incomplete upload blobs: %w", err)
+	}
+	for _, iu := range incompleteUploads {
+		b, ok := blobs[iu.Blob.ID]
+		if !ok {
+			return nil, fmt.Errorf("no blob found for incomplete upload %q", iu.ID)
+		}
+		result[b.BlobURI] = &task.ParsePortfolioUpload{
+			IncompleteUploadID: iu.ID,
+			BlobURI:            b.BlobURI,
+			Properties:         iu.Properties,
+		}
+	}
+	return result, nil
+}
+
+// rejectedUploads maps the files that the task couldn't read to the incomplete
+// uploads they were uploaded as.
+func rejectedUploads(uploads map[pacta.BlobURI]*task.ParsePortfolioUpload, rejected []*task.ParsePortfolioRejectedFile) (map[pacta.IncompleteUploadID]*task.ParsePortfolioRejectedFile, error) {
+	result := make(map[pacta.IncompleteUploadID]*task.ParsePortfolioRejectedFile)
+	for _, rf := range rejected {
+		u, ok := uploads[rf.Source]
+		if !ok {
+			return nil, fmt.Errorf("no incomplete upload found for rejected file %q", rf.Source)
+		}
+		result[u.IncompleteUploadID] = rf
+	}
+	return result, nil
+}
+
+// saveParseResults records what the parser reported about each input file on
+// the incomplete upload that the file was uploaded as. Workbooks and archives
+// are given to the parser as several files, which are combined into a single
+// result for their upload.
+func (s *Server) saveParseResults(tx db.Tx, incompleteUploads map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, uploads map[pacta.BlobURI]*task.ParsePortfolioUpload, sources []*task.ParsePortfolioSource) error {
+	if len(sources) == 0 {
+		return nil
+	}
+	var ids []pacta.IncompleteUploadID
+	byUpload := make(map[pacta.IncompleteUploadID][]*task.ParsePortfolioSource)
+	for _, src := range sources {
+		u, ok := uploads[src.Source]
+		if !ok {
+			return fmt.Errorf("no incomplete upload found for parsed file %q", src.Source)
+		}
+		if _, ok := byUpload[u.IncompleteUploadID]; !ok {
+			ids = append(ids, u.IncompleteUploadID)
+		}
+		byUpload[u.IncompleteUploadID] = append(byUpload[u.IncompleteUploadID], src)
+	}
+	for _, id := range ids {
+		srcs := byUpload[id]
+		pr := parseResult(&srcs[0].File)
+		for _, src := range srcs[1:] {
+			mergeParseResult(pr, parseResult(&src.File))
+		}
+		// The parser hashes the CSV it was given, which is only the uploaded
+		// file if we didn't have to extract it from a workbook or archive.
+		contentMD5 := ""
+		if len(srcs) == 1 && srcs[0].Entry == "" {
+			contentMD5 = srcs[0].File.InputMD5
+		} else {
+			pr.InputMD5 = ""
+		}
+		if err := s.db.UpdateIncompleteUpload(tx, id, db.SetIncompleteUploadParseResult(pr)); err != nil {
+			return fmt.Errorf("updating incomplete upload %s: %w", id, err)
+		}
+		if contentMD5 != "" {
+			err := s.db.UpdateBlob(tx, incompleteUploads[id].Blob.ID, db.SetBlobContentMD5(contentMD5))
+			if err != nil {
+				return fmt.Errorf("updating blob for incomplete upload %s: %w", id, err)
+			}
+		}
+	}
+	return nil
+}
+
+// mergeParseResult adds the counts, grouping columns and errors of src to dst.
+// All of the files in a task are parsed by the same parser, so dst's parser is
+// kept as-is.
+func mergeParseResult(dst, src *pacta.ParseResult) {
+	dst.InputEntries += src.InputEntries
+	dst.SubportfoliosCount += src.SubportfoliosCount
+	for _, c := range src.GroupCols {
+		if !slices.Contains(dst.GroupCols, c) {
+			dst.GroupCols = append(dst.GroupCols, c)
+		}
+	}
+	dst.Errors = append(dst.Errors, src.Errors...)
+}
+
+// saveParseLogs attaches the parser's log to each incomplete upload. Log blobs
+// are named after their upload, so if an upload was parsed before, its existing
+// blob already points at the new log.
+func (s *Server) saveParseLogs(tx db.Tx, incompleteUploads map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, logs []*task.ParsePortfolioLog) error {
+	for _, l := range logs {
+		iu, ok := incompleteUploads[l.IncompleteUploadID]
+		if !ok {
+			return fmt.Errorf("log given for unknown incomplete upload %q", l.IncompleteUploadID)
+		}
+		if iu.LogBlob != nil {
+			continue
+		}
+		blobID, err := s.db.CreateBlob(tx, &l.Blob)
+		if err != nil {
+			return fmt.Errorf("creating log blob for incomplete upload %s: %w", iu.ID, err)
+		}
+		if err := s.db.UpdateIncompleteUpload(tx, iu.ID, db.SetIncompleteUploadLogBlob(blobID)); err != nil {
+			return fmt.Errorf("updating incomplete upload %s: %w", iu.ID, err)
+		}
+	}
+	return nil
+}
+
+func parseResult(sf *parsed.SourceFile) *pacta.ParseResult {
+	deps := make([]pacta.ParserDependency, 0, len(sf.SystemInfo.Dependencies))
+	for _, d := range sf.SystemInfo.Dependencies {
+		deps = append(deps, pacta.ParserDependency{
+			Package: d.Package,
+			Version: d.Version,
+		})
+	}
+	return &pacta.ParseResult{
+		InputMD5:           sf.InputMD5,
+		InputEntries:       sf.InputEntries,
+		GroupCols:          sf.GroupCols,
+		SubportfoliosCount: sf.SubportfoliosCount,
+		Errors:             sf.Errors,
+		Parser: pacta.ParserInfo{
+			Timestamp:      sf.SystemInfo.Timestamp,
+			Package:        sf.SystemInfo.Package,
+			PackageVersion: sf.SystemInfo.PackageVersion,
+			RVersion:       sf.SystemInfo.RVersion,
+			Dependencies:   deps,
+		},
+	}
+}
+
+func (s *Server) handleAnalysisFailed(id string, resp *task.AnalysisFailedResponse, w http.ResponseWriter) {
+	if resp.AnalysisID == "" {
+		s.logger.Error("webhook response had no analysis ID", zap.String("event_grid_id", id))
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	failureCode := s.parseFailureCode(resp.FailureCode)
+	now := s.now()
+	stale := false
+	// We use a background context here rather than the one from the request so that it cannot be cancelled upstream.
+	err := s.db.Transactional(context.Background(), func(tx db.Tx) error {
+		if err := s.lockTask(tx, resp.TaskID, resp.AnalysisID, nil); errors.Is(err, errStaleTask) {
+			stale = true
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("locking task: %w", err)
+		}
+		a, err := s.db.Analysis(tx, resp.AnalysisID)
+		if err != nil {
+			return fmt.Errorf("reading analysis: %w", err)
+		}
+		if a.FailureCode == pacta.FailureCode_Cancelled {
+			// Stopping a task can cause it to report a failure, we keep the
+			// cancellation as the reason the analysis didn't complete.
+			failureCode = pacta.FailureCode_Cancelled
+			return nil
+		}
+		// These are the logs of the failed task, if it got far enough to produce any.
+		if err := s.createAnalysisArtifacts(tx, resp.AnalysisID, resp.Artifacts); err != nil {
+			return err
+		}
+		imageTag, err := s.taskImageTag(tx, resp.TaskID)
+		if err != nil {
+			return fmt.Errorf("reading task image: %w", err)
+		}
+		err = s.db.UpdateAnalysis(tx, resp.AnalysisID,
+			db.SetAnalysisFailureCode(failureCode),
+			db.SetAnalysisFailureMessage(resp.FailureMessage),
+			db.SetAnalysisCompletedAt(now),
+			db.SetAnalysisImageTag(imageTag))
+		if err != nil {
+			return fmt.Errorf("updating analysis: %w", err)
+		}
+		if err := s.markTaskFailed(tx, resp.TaskID, now); err != nil {
+			return fmt.Errorf("updating task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		s.logger.Error("failed to save analysis failure to database", zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	if stale {
+		s.logger.Info("ignoring failure of finished or superseded task",
+			zap.String("task_id", string(resp.TaskID)),
+			zap.String("failure_code", string(failureCode)),
+			zap.String("failure_message", resp.FailureMessage),
+			zap.String("analysis_id", string(resp.AnalysisID)))
+		return
+	}
+
+	s.logger.Info("analysis failed",
+		zap.String("task_id", string(resp.TaskID)),
+		zap.String("failure_code", string(failureCode)),
+		zap.String("failure_message", resp.FailureMessage),
+		zap.String("analysis_id", string(resp.AnalysisID)))
+}
+
+func (s *Server) createAnalysisArtifacts(tx db.Tx, analysisID pacta.AnalysisID, artifacts []*task.AnalysisArtifact) error {
+	for _, artifact := range artifacts {
+		blobID, err := s.db.CreateBlob(tx, &pacta.Blob{
+			FileName: artifact.FileName,
+			FileType: artifact.FileType,
+			BlobURI:  artifact.BlobURI,
+		})
+		if err != nil {
+			return fmt.Errorf("creating blob: %w", err)
+		}
+		_, err = s.db.CreateAnalysisArtifact(tx, &pacta.AnalysisArtifact{
+			Blob:       &pacta.Blob{ID: blobID},
+			AnalysisID: analysisID,
+			AdminOnly:  artifact.AdminOnly,
+		})
+		if err != nil {
+			return fmt.Errorf("creating analysis artifact: %w", err)
+		}
+	}
+	return nil
+}
+
+// parseFailureCode validates a failure code reported by a task, falling back
+// to FailureCode_Unknown so that a runner built against a newer set of codes
+// doesn't prevent us from recording the failure at all.
+func (s *Server) parseFailureCode(fc pacta.FailureCode) pacta.FailureCode {
+	parsed, err := pacta.ParseFailureCode(string(fc))
+	if err != nil {
+		s.logger.Warn("unknown failure code reported by task", zap.String("failure_code", string(fc)))
+		return pacta.FailureCode_Unknown
+	}
+	return parsed
+}
+
+// taskImageTag returns the image the given task was run with, so it can be
+// recorded on the analysis it produced. Like markTaskSucceeded, tasks we have
+// no record of are skipped.
+func (s *Server) taskImageTag(tx db.Tx, taskID task.ID) (string, error) {
+	t, err := s.db.Task(tx, pacta.TaskID(taskID))
+	if db.IsNotFound(err) {
+		s.logger.Warn("no record of task, not recording its image", zap.String("task_id", string(taskID)))
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return t.ImageTag, nil
+}
+
+// errStaleTask is returned by lockTask for events whose results should be
+// dropped.
+var errStaleTask = errors.New("task already finished or was superseded")
+
+// lockTask locks the task an event came from, before anything it worked on is
+// touched, so that the reaper, cancellations and retries (which also lock the
+// task first) can't interleave with recording its results. If the task has
+// already finished, e.g. because it was reaped, or a retry has since created a
+// newer task for the same analysis or incomplete uploads, errStaleTask is
+// returned. Tasks dispatched before we tracked them in the database won't
+// exist, and only count as stale once a newer tracked task exists.
+func (s *Server) lockTask(tx db.Tx, taskID task.ID, analysisID pacta.AnalysisID, iuIDs []pacta.IncompleteUploadID) error {
+	t, err := s.db.LockTask(tx, pacta.TaskID(taskID))
+	if db.IsNotFound(err) {
+		s.logger.Warn("no record of task, checking only for newer tasks", zap.String("task_id", string(taskID)))
+	} else if err != nil {
+		return fmt.Errorf("reading task: %w", err)
+	} else if t.State != pacta.TaskState_Queued && t.State != pacta.TaskState_Running {
+		return errStaleTask
+	}
+
+	var related [][]*pacta.Task
+	if analysisID != "" {
+		ts, err := s.db.TasksForAnalysis(tx, analysisID)
+		if err != nil {
+			return fmt.Errorf("reading tasks for analysis: %w", err)
+		}
+		related = append(related, ts)
+	}
+	for _, iuID := range iuIDs {
+		ts, err := s.db.TasksForIncompleteUpload(tx, iuID)
+		if err != nil {
+			return fmt.Errorf("reading tasks for incomplete upload %q: %w", iuID, err)
+		}
+		related = append(related, ts)
+	}
+	for _, ts := range related {
+		if len(ts) > 0 && ts[len(ts)-1].ID != pacta.TaskID(taskID) {
+			return errStaleTask
+		}
+	}
+	return nil
+}
+
+// markTaskSucceeded records that the given task completed successfully. Tasks
+// dispatched before we tracked them in the database won't exist, so those are
+// logged and skipped rather than failing the whole event.
+func (s *Server) markTaskSucceeded(tx db.Tx, taskID task.ID, now time.Time) error {
+	err := s.db.UpdateTask(tx, pacta.TaskID(taskID),
+		db.SetTaskState(pacta.TaskState_Succeeded),
+		db.SetTaskCompletedAt(now))
+	if db.IsNotFound(err) {
+		s.logger.Warn("no record of completed task, skipping task update", zap.String("task_id", string(taskID)))
+		return nil
+	}
+	return err
+}
+
+// markTaskFailed records that the given task failed, see markTaskSucceeded for
+// how untracked tasks are handled.
+func (s *Server) markTaskFailed(tx db.Tx, taskID task.ID, now time.Time) error {
+	err := s.db.UpdateTask(tx, pacta.TaskID(taskID),
+		db.SetTaskState(pacta.TaskState_Failed),
+		db.SetTaskCompletedAt(now))
+	if db.IsNotFound(err) {
+		s.logger.Warn("no record of failed task, skipping task update", zap.String("task_id", string(taskID)))
+		return nil
+	}
+	return err
+}
+
 func asStrs[T ~string](ts []T) []string {
 	ss := make([]string, len(ts))
 	for i, t := range ts {
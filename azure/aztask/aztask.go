@@ -6,6 +6,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
 	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
@@ -15,7 +16,8 @@ import (
 )
 
 type Runner struct {
-	client *armappcontainers.JobsClient
+	client     *armappcontainers.JobsClient
+	execClient *armappcontainers.JobsExecutionsClient
 
 	cfg *Config
 	gen *idgen.Generator
@@ -57,8 +59,9 @@ func NewRunner(creds azcore.TokenCredential, cfg *Config) (*Runner, error) {
 	}
 
 	return &Runner{
-		client: clientFactory.NewJobsClient(),
-		cfg:    cfg,
+		client:     clientFactory.NewJobsClient(),
+		execClient: clientFactory.NewJobsExecutionsClient(),
+		cfg:        cfg,
 	}, nil
 }
 
@@ -106,6 +109,64 @@ func (r *Runner) Run(ctx context.Context, cfg *task.Config) (task.RunnerID, erro
 	return task.RunnerID(*res.ID), nil
 }
 
+func (r *Runner) Status(ctx context.Context, runnerID task.RunnerID) (task.Status, error) {
+	name := executionName(runnerID)
+	pager := r.execClient.NewListPager(r.cfg.ResourceGroup, r.cfg.JobName, nil)
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to list container app job executions: %w", err)
+		}
+		for _, exec := range page.Value {
+			if exec.Name == nil || *exec.Name != name {
+				continue
+			}
+			if exec.Status == nil {
+				return task.StatusUnknown, nil
+			}
+			switch *exec.Status {
+			case armappcontainers.JobExecutionRunningStateProcessing:
+				return task.StatusPending, nil
+			case armappcontainers.JobExecutionRunningStateRunning:
+				return task.StatusRunning, nil
+			case armappcontainers.JobExecutionRunningStateSucceeded:
+				return task.StatusSucceeded, nil
+			case armappcontainers.JobExecutionRunningStateFailed, armappcontainers.JobExecutionRunningStateDegraded:
+				return task.StatusFailed, nil
+			case armappcontainers.JobExecutionRunningStateStopped:
+				return task.StatusCancelled, nil
+			default:
+				return task.StatusUnknown, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no execution %q found for job %q", name, r.cfg.JobName)
+}
+
+// Logs isn't supported for Container Apps Jobs, their console logs are only
+// available via the Log Analytics workspace attached to the environment.
+func (r *Runner) Logs(ctx context.Context, runnerID task.RunnerID) ([]byte, error) {
+	return nil, fmt.Errorf("container app job logs are only available in Log Analytics: %w", errors.ErrUnsupported)
+}
+
+func (r *Runner) Cancel(ctx context.Context, runnerID task.RunnerID) error {
+	poller, err := r.client.BeginStopExecution(ctx, r.cfg.ResourceGroup, r.cfg.JobName, executionName(runnerID), nil)
+	if err != nil {
+		return fmt.Errorf("failed to stop container app job execution: %w", err)
+	}
+	if _, err := poller.PollUntilDone(ctx, nil); err != nil {
+		return fmt.Errorf("failed to poll for container app job execution stop: %w", err)
+	}
+	return nil
+}
+
+// executionName returns the name of the job execution from a runner ID, which
+// is the full resource ID of the execution, like
+// /subscriptions/.../jobs/<job name>/executions/<execution name>
+func executionName(runnerID task.RunnerID) string {
+	return path.Base(string(runnerID))
+}
+
 func toPtrs[T any](in []T) []*T {
 	if in == nil {
 		return nil
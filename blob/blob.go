@@ -2,7 +2,26 @@
 // storage systems.
 package blob
 
-import "strings"
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotExist is returned (wrapped) by blob implementations when asked about a
+// blob that doesn't exist, like one that was never uploaded to a signed URL.
+var ErrNotExist = errors.New("blob does not exist")
+
+// Properties is metadata about a stored blob, retrieved without downloading
+// its contents.
+type Properties struct {
+	// Size is the length of the blob's contents in bytes.
+	Size int64
+	// ContentType is the MIME type the blob was uploaded with, if any.
+	ContentType string
+	// ContentMD5 is the MD5 hash of the blob's contents, if the storage system
+	// recorded one.
+	ContentMD5 []byte
+}
 
 type Scheme string
 
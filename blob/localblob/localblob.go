@@ -0,0 +1,431 @@
+// Package localblob provides an implementation of our blob interfaces backed by
+// the local filesystem, which is useful for running the full stack locally
+// without access to a cloud storage account.
+//
+// URIs are of the form file://<container>/<path/to/blob>, and map to files at
+// <root>/<container>/<path/to/blob>. Signed URLs are HMAC-signed, expiring URLs
+// served by the handlers registered in RegisterHandlers.
+package localblob
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RMI/pacta/blob"
+	chi "github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	Scheme = blob.Scheme("file")
+
+	// HandlerPrefix is the path prefix that signed URLs are served under.
+	HandlerPrefix = "/localblob"
+
+	signedURLValidity = 15 * time.Minute
+)
+
+type operation string
+
+const (
+	opUpload   = operation("upload")
+	opDownload = operation("download")
+)
+
+type Config struct {
+	// Root is the directory that all blobs are stored under.
+	Root string
+	// BaseURL is the externally-reachable address of the server that the
+	// handlers are registered on, like 'http://localhost:8081'.
+	BaseURL string
+	// SigningKey is the secret used to sign and verify upload and download URLs.
+	//
+	// BaseURL and SigningKey are only needed to sign URLs and serve them, and
+	// are left empty by clients that just read and write blobs, like tasks.
+	SigningKey []byte
+	// MaxUploadBytes is optional, and returns the most bytes a signed upload URL
+	// will accept, like the configured limit on portfolio uploads. A limit of
+	// zero or less means uploads aren't limited.
+	MaxUploadBytes func(context.Context) (int64, error)
+
+	Logger *zap.Logger
+	// Now is optional, defaults to time.Now.
+	Now func() time.Time
+}
+
+func (c *Config) validate() error {
+	if c.Root == "" {
+		return errors.New("no root directory was given")
+	}
+	if (c.BaseURL == "") != (len(c.SigningKey) == 0) {
+		return errors.New("a base URL and signing key must be given together")
+	}
+	if _, err := url.Parse(c.BaseURL); err != nil {
+		return fmt.Errorf("invalid base URL %q: %w", c.BaseURL, err)
+	}
+	if c.Logger == nil {
+		return errors.New("no logger was given")
+	}
+	return nil
+}
+
+type Client struct {
+	root       string
+	baseURL    string
+	signingKey []byte
+	maxUpload  func(context.Context) (int64, error)
+	logger     *zap.Logger
+	now        func() time.Time
+}
+
+func NewClient(cfg *Config) (*Client, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
+	root, err := filepath.Abs(cfg.Root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve root directory %q: %w", cfg.Root, err)
+	}
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create root directory: %w", err)
+	}
+
+	now := cfg.Now
+	if now == nil {
+		now = time.Now
+	}
+
+	return &Client{
+		root:       root,
+		baseURL:    strings.TrimSuffix(cfg.BaseURL, "/"),
+		signingKey: cfg.SigningKey,
+		maxUpload:  cfg.MaxUploadBytes,
+		logger:     cfg.Logger,
+		now:        now,
+	}, nil
+}
+
+func (c *Client) Scheme() blob.Scheme {
+	return Scheme
+}
+
+func (c *Client) WriteBlob(ctx context.Context, uri string, r io.Reader) error {
+	fp, err := c.filePath(uri)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(fp), 0700); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+
+	// Write to a temporary file first, so that readers never see a partially
+	// written blob.
+	f, err := os.CreateTemp(filepath.Dir(fp), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp file: %w", err)
+	}
+	defer os.Remove(f.Name()) // No-op if the rename succeeds.
+
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write blob: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to close blob: %w", err)
+	}
+	if err := os.Rename(f.Name(), fp); err != nil {
+		return fmt.Errorf("failed to move blob into place: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ReadBlob(ctx context.Context, uri string) (io.ReadCloser, error) {
+	fp, err := c.filePath(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	return f, nil
+}
+
+// Properties returns the size and MD5 hash of the blob at the given URI. Local
+// blobs don't record a content type, so none is returned.
+func (c *Client) Properties(ctx context.Context, uri string) (*blob.Properties, error) {
+	fp, err := c.filePath(uri)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read blob %q: %w", uri, blob.ErrNotExist)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read blob: %w", err)
+	}
+	defer f.Close()
+
+	// Azure computes the MD5 on upload, we compute it on demand. Local blobs are
+	// small enough that this is fine.
+	h := md5.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash blob: %w", err)
+	}
+	return &blob.Properties{
+		Size:       n,
+		ContentMD5: h.Sum(nil),
+	}, nil
+}
+
+func (c *Client) DeleteBlob(ctx context.Context, uri string) error {
+	fp, err := c.filePath(uri)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(fp); err != nil {
+		return fmt.Errorf("failed to delete blob: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) ListBlobs(ctx context.Context, uriPrefix string) ([]string, error) {
+	ctr, blobPrefix, ok := blob.SplitURI(Scheme, uriPrefix)
+	if !ok {
+		return nil, fmt.Errorf("malformed URI prefix %q is not for the local filesystem", uriPrefix)
+	}
+
+	if blobPrefix == "" {
+		return nil, fmt.Errorf("uri prefix %q did not contain a blob component", uriPrefix)
+	}
+
+	ctrDir, err := c.resolve(ctr)
+	if err != nil {
+		return nil, err
+	}
+
+	var blobs []string
+	err = filepath.WalkDir(ctrDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".upload-") {
+			return nil
+		}
+		rel, err := filepath.Rel(ctrDir, p)
+		if err != nil {
+			return fmt.Errorf("failed to get relative path for %q: %w", p, err)
+		}
+		name := filepath.ToSlash(rel)
+		if strings.HasPrefix(name, blobPrefix) {
+			blobs = append(blobs, blob.Join(Scheme, ctr, name))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blobs: %w", err)
+	}
+
+	return blobs, nil
+}
+
+// SignedUploadURL returns a URL that is allowed to upload to the given URI via
+// an HTTP PUT request.
+func (c *Client) SignedUploadURL(ctx context.Context, uri string) (string, time.Time, error) {
+	return c.signBlob(uri, opUpload)
+}
+
+// SignedDownloadURL returns a URL that is allowed to download the file at the
+// given URI via an HTTP GET request.
+func (c *Client) SignedDownloadURL(ctx context.Context, uri string) (string, time.Time, error) {
+	return c.signBlob(uri, opDownload)
+}
+
+func (c *Client) signBlob(uri string, op operation) (string, time.Time, error) {
+	if len(c.signingKey) == 0 {
+		return "", time.Time{}, errors.New("client wasn't configured to sign URLs")
+	}
+	ctr, blb, ok := blob.SplitURI(Scheme, uri)
+	if !ok {
+		return "", time.Time{}, fmt.Errorf("malformed URI %q is not for the local filesystem", uri)
+	}
+
+	// Like with Azure, the blob component is important, otherwise the signed URL
+	// would be applicable to the whole container.
+	if blb == "" {
+		return "", time.Time{}, fmt.Errorf("uri %q did not contain a blob component", uri)
+	}
+	if _, err := c.resolve(ctr, blb); err != nil {
+		return "", time.Time{}, err
+	}
+
+	expiry := c.now().UTC().Add(signedURLValidity).Truncate(time.Second)
+	exp := strconv.FormatInt(expiry.Unix(), 10)
+
+	q := url.Values{}
+	q.Set("op", string(op))
+	q.Set("exp", exp)
+	q.Set("sig", c.signature(op, ctr, blb, exp))
+
+	return fmt.Sprintf("%s%s/%s/%s?%s", c.baseURL, HandlerPrefix, ctr, blb, q.Encode()), expiry, nil
+}
+
+func (c *Client) signature(op operation, ctr, blb, exp string) string {
+	mac := hmac.New(sha256.New, c.signingKey)
+	mac.Write([]byte(strings.Join([]string{string(op), ctr, blb, exp}, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RegisterHandlers registers the handlers for signed upload and download URLs.
+// These handlers do their own authorization based on the URL signature, and so
+// should not be put behind the usual authentication middleware.
+func (c *Client) RegisterHandlers(r chi.Router) {
+	r.Put(HandlerPrefix+"/{container}/*", c.handleUpload)
+	r.Get(HandlerPrefix+"/{container}/*", c.handleDownload)
+}
+
+func (c *Client) handleUpload(w http.ResponseWriter, r *http.Request) {
+	uri, ok := c.verifyRequest(w, r, opUpload)
+	if !ok {
+		return
+	}
+
+	if c.maxUpload != nil {
+		maxBytes, err := c.maxUpload(r.Context())
+		if err != nil {
+			c.logger.Error("failed to load upload limit", zap.String("uri", uri), zap.Error(err))
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		if maxBytes > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	if err := c.WriteBlob(r.Context(), uri, r.Body); errors.As(err, &maxBytesErr) {
+		// The partially written blob is discarded by WriteBlob, so nothing is left
+		// behind for the upload to be processed from.
+		http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+		return
+	} else if err != nil {
+		c.logger.Error("failed to write uploaded blob", zap.String("uri", uri), zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	// Matches the response from Azure's Put Blob API.
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (c *Client) handleDownload(w http.ResponseWriter, r *http.Request) {
+	uri, ok := c.verifyRequest(w, r, opDownload)
+	if !ok {
+		return
+	}
+
+	fp, err := c.filePath(uri)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return
+	}
+	f, err := os.Open(fp)
+	if errors.Is(err, fs.ErrNotExist) {
+		http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
+		return
+	} else if err != nil {
+		c.logger.Error("failed to read blob", zap.String("uri", uri), zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	fi, err := f.Stat()
+	if err != nil {
+		c.logger.Error("failed to stat blob", zap.String("uri", uri), zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, path.Base(fp), fi.ModTime(), f)
+}
+
+// verifyRequest checks the signature and expiry on the request, and returns
+// the blob URI the request is for. If the request isn't valid, an error is
+// written to the response and false is returned.
+func (c *Client) verifyRequest(w http.ResponseWriter, r *http.Request, op operation) (string, bool) {
+	ctr, blb := chi.URLParam(r, "container"), chi.URLParam(r, "*")
+	q := r.URL.Query()
+	exp, sig := q.Get("exp"), q.Get("sig")
+
+	if len(c.signingKey) == 0 || q.Get("op") != string(op) || exp == "" || sig == "" {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return "", false
+	}
+
+	want := c.signature(op, ctr, blb, exp)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return "", false
+	}
+
+	expUnix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil {
+		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		return "", false
+	}
+	if c.now().After(time.Unix(expUnix, 0)) {
+		http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+		return "", false
+	}
+
+	return blob.Join(Scheme, ctr, blb), true
+}
+
+func (c *Client) filePath(uri string) (string, error) {
+	ctr, blb, ok := blob.SplitURI(Scheme, uri)
+	if !ok {
+		return "", fmt.Errorf("malformed URI %q is not for the local filesystem", uri)
+	}
+	if blb == "" {
+		return "", fmt.Errorf("uri %q did not contain a blob component", uri)
+	}
+	return c.resolve(ctr, blb)
+}
+
+// resolve joins the given path components onto the root, and makes sure the
+// result doesn't escape the root directory.
+func (c *Client) resolve(parts ...string) (string, error) {
+	for _, p := range parts {
+		if p == "" {
+			return "", errors.New("path component was empty")
+		}
+	}
+	fp := filepath.Join(append([]string{c.root}, parts...)...)
+	if !strings.HasPrefix(fp, c.root+string(filepath.Separator)) {
+		return "", fmt.Errorf("path %q is outside of the blob root", path.Join(parts...))
+	}
+	return fp, nil
+}
@@ -0,0 +1,272 @@
+package localblob
+
+import (
+	"context"
+	"crypto/md5"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/blob"
+	chi "github.com/go-chi/chi/v5"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReadWriteListDelete(t *testing.T) {
+	c, _ := newClientForTest(t)
+	ctx := context.Background()
+
+	uris := []string{
+		"file://ctr/dir/a.csv",
+		"file://ctr/dir/b.csv",
+		"file://ctr/other/c.csv",
+	}
+	for _, uri := range uris {
+		if err := c.WriteBlob(ctx, uri, strings.NewReader("contents of "+uri)); err != nil {
+			t.Fatalf("WriteBlob(%q): %v", uri, err)
+		}
+	}
+
+	got := readBlob(t, c, "file://ctr/dir/b.csv")
+	if want := "contents of file://ctr/dir/b.csv"; got != want {
+		t.Errorf("ReadBlob = %q, want %q", got, want)
+	}
+
+	listed, err := c.ListBlobs(ctx, "file://ctr/dir/")
+	if err != nil {
+		t.Fatalf("ListBlobs: %v", err)
+	}
+	if diff := cmp.Diff(uris[:2], listed); diff != "" {
+		t.Errorf("unexpected blobs listed (-want +got)\n%s", diff)
+	}
+
+	if err := c.DeleteBlob(ctx, "file://ctr/dir/a.csv"); err != nil {
+		t.Fatalf("DeleteBlob: %v", err)
+	}
+	if _, err := c.ReadBlob(ctx, "file://ctr/dir/a.csv"); err == nil {
+		t.Error("ReadBlob succeeded after blob was deleted")
+	}
+}
+
+func TestProperties(t *testing.T) {
+	c, _ := newClientForTest(t)
+	ctx := context.Background()
+
+	if err := c.WriteBlob(ctx, "file://ctr/a.csv", strings.NewReader("isin,market_value\n")); err != nil {
+		t.Fatalf("WriteBlob: %v", err)
+	}
+
+	got, err := c.Properties(ctx, "file://ctr/a.csv")
+	if err != nil {
+		t.Fatalf("Properties: %v", err)
+	}
+	sum := md5.Sum([]byte("isin,market_value\n"))
+	want := &blob.Properties{
+		Size:       18,
+		ContentMD5: sum[:],
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected properties (-want +got)\n%s", diff)
+	}
+
+	if _, err := c.Properties(ctx, "file://ctr/missing.csv"); !errors.Is(err, blob.ErrNotExist) {
+		t.Errorf("Properties of a missing blob = %v, want an ErrNotExist", err)
+	}
+}
+
+func TestInvalidURIs(t *testing.T) {
+	c, _ := newClientForTest(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		desc string
+		uri  string
+	}{
+		{
+			desc: "wrong scheme",
+			uri:  "az://ctr/blob",
+		},
+		{
+			desc: "no blob component",
+			uri:  "file://ctr",
+		},
+		{
+			desc: "escapes root",
+			uri:  "file://ctr/../../etc/passwd",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			if _, err := c.ReadBlob(ctx, test.uri); err == nil {
+				t.Error("ReadBlob succeeded, wanted an error")
+			}
+			if _, _, err := c.SignedDownloadURL(ctx, test.uri); err == nil {
+				t.Error("SignedDownloadURL succeeded, wanted an error")
+			}
+		})
+	}
+}
+
+func TestSignedURLs(t *testing.T) {
+	c, now := newClientForTest(t)
+	ctx := context.Background()
+
+	r := chi.NewRouter()
+	c.RegisterHandlers(r)
+
+	uri := "file://ctr/path/to/portfolio.csv"
+
+	uploadURL, _, err := c.SignedUploadURL(ctx, uri)
+	if err != nil {
+		t.Fatalf("SignedUploadURL: %v", err)
+	}
+	downloadURL, _, err := c.SignedDownloadURL(ctx, uri)
+	if err != nil {
+		t.Fatalf("SignedDownloadURL: %v", err)
+	}
+
+	// Using a download URL to upload shouldn't work.
+	if code := serve(r, http.MethodPut, downloadURL, "bad"); code != http.StatusForbidden {
+		t.Errorf("upload with download URL returned %d, want %d", code, http.StatusForbidden)
+	}
+	// Neither should tampering with the path.
+	tampered := strings.Replace(uploadURL, "portfolio.csv", "other.csv", 1)
+	if code := serve(r, http.MethodPut, tampered, "bad"); code != http.StatusForbidden {
+		t.Errorf("upload with tampered URL returned %d, want %d", code, http.StatusForbidden)
+	}
+
+	if code := serve(r, http.MethodPut, uploadURL, "a,b,c"); code != http.StatusCreated {
+		t.Fatalf("upload returned %d, want %d", code, http.StatusCreated)
+	}
+	if got := readBlob(t, c, uri); got != "a,b,c" {
+		t.Errorf("uploaded blob = %q, want %q", got, "a,b,c")
+	}
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, downloadURL, nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("download returned %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Body.String(); got != "a,b,c" {
+		t.Errorf("downloaded blob = %q, want %q", got, "a,b,c")
+	}
+
+	// Once the URL expires, it should no longer work.
+	*now = now.Add(signedURLValidity + time.Second)
+	if code := serve(r, http.MethodGet, downloadURL, ""); code != http.StatusForbidden {
+		t.Errorf("download with expired URL returned %d, want %d", code, http.StatusForbidden)
+	}
+}
+
+func TestUploadLimit(t *testing.T) {
+	maxBytes := int64(5)
+	c, err := NewClient(&Config{
+		Root:       t.TempDir(),
+		BaseURL:    "http://localhost:8081",
+		SigningKey: []byte("test-signing-key"),
+		MaxUploadBytes: func(context.Context) (int64, error) {
+			return maxBytes, nil
+		},
+		Logger: zaptest.NewLogger(t),
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+
+	r := chi.NewRouter()
+	c.RegisterHandlers(r)
+
+	uri := "file://ctr/portfolio.csv"
+	uploadURL, _, err := c.SignedUploadURL(ctx, uri)
+	if err != nil {
+		t.Fatalf("SignedUploadURL: %v", err)
+	}
+
+	if code := serve(r, http.MethodPut, uploadURL, "a,b,c,d"); code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized upload returned %d, want %d", code, http.StatusRequestEntityTooLarge)
+	}
+	if _, err := c.Properties(ctx, uri); !errors.Is(err, blob.ErrNotExist) {
+		t.Errorf("Properties after oversized upload returned %v, want %v", err, blob.ErrNotExist)
+	}
+
+	if code := serve(r, http.MethodPut, uploadURL, "a,b,c"); code != http.StatusCreated {
+		t.Fatalf("upload at the limit returned %d, want %d", code, http.StatusCreated)
+	}
+	if got := readBlob(t, c, uri); got != "a,b,c" {
+		t.Errorf("uploaded blob = %q, want %q", got, "a,b,c")
+	}
+
+	// A limit of zero means uploads aren't limited.
+	maxBytes = 0
+	if code := serve(r, http.MethodPut, uploadURL, "a,b,c,d"); code != http.StatusCreated {
+		t.Errorf("unlimited upload returned %d, want %d", code, http.StatusCreated)
+	}
+}
+
+func TestUnsignedClient(t *testing.T) {
+	c, err := NewClient(&Config{
+		Root:   t.TempDir(),
+		Logger: zaptest.NewLogger(t),
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	ctx := context.Background()
+	uri := "file://container/some/blob.txt"
+	if err := c.WriteBlob(ctx, uri, strings.NewReader("contents")); err != nil {
+		t.Fatalf("WriteBlob: %v", err)
+	}
+	if got := readBlob(t, c, uri); got != "contents" {
+		t.Errorf("ReadBlob = %q, want %q", got, "contents")
+	}
+	if _, _, err := c.SignedDownloadURL(ctx, uri); err == nil {
+		t.Error("SignedDownloadURL succeeded without a signing key")
+	}
+
+	// A signing key without a base URL to sign for is a misconfiguration.
+	if _, err := NewClient(&Config{Root: t.TempDir(), SigningKey: []byte("key"), Logger: zaptest.NewLogger(t)}); err == nil {
+		t.Error("NewClient succeeded with a signing key but no base URL")
+	}
+}
+
+func newClientForTest(t *testing.T) (*Client, *time.Time) {
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+	c, err := NewClient(&Config{
+		Root:       t.TempDir(),
+		BaseURL:    "http://localhost:8081",
+		SigningKey: []byte("test-signing-key"),
+		Logger:     zaptest.NewLogger(t),
+		Now:        func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	return c, &now
+}
+
+func readBlob(t *testing.T, c *Client, uri string) string {
+	t.Helper()
+	rc, err := c.ReadBlob(context.Background(), uri)
+	if err != nil {
+		t.Fatalf("ReadBlob(%q): %v", uri, err)
+	}
+	defer rc.Close()
+	dat, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read blob %q: %v", uri, err)
+	}
+	return string(dat)
+}
+
+func serve(h http.Handler, method, target, body string) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return w.Code
+}
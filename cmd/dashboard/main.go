@@ -15,6 +15,7 @@ import (
 	"github.com/RMI/pacta/azure/azblob"
 	"github.com/RMI/pacta/azure/azcreds"
 	"github.com/RMI/pacta/azure/azlog"
+	"github.com/RMI/pacta/blob/localblob"
 	"github.com/RMI/pacta/task"
 	"github.com/namsral/flag"
 	"go.uber.org/zap"
@@ -43,9 +44,15 @@ func run(args []string) error {
 		azEventTopic    = fs.String("azure_event_topic", "", "The EventGrid topic to send notifications when tasks have finished")
 		azTopicLocation = fs.String("azure_topic_location", "", "The location (like 'centralus-1') where our EventGrid topics are hosted")
 
+		eventWebhookURL    = fs.String("event_webhook_url", "", "If set, send notifications directly to this URL (like 'http://host.docker.internal:8081/events') instead of via EventGrid, useful for running locally without Azure")
+		eventWebhookSecret = fs.String("event_webhook_secret", "", "The shared secret to authorize requests to --event_webhook_url with, must be one that the server accepts")
+
 		azStorageAccount         = fs.String("azure_storage_account", "", "The storage account to authenticate against for blob operations")
 		azDestPortfolioContainer = fs.String("azure_dest_portfolio_container", "", "The container in the storage account where we write parsed portfolios")
 
+		useLocalBlob = fs.Bool("use_local_blob", false, "If true, read and write blobs on the local filesystem instead of in Azure Blob Storage. Can only be used when running locally.")
+		localBlobDir = fs.String("local_blob_dir", "", "Directory blobs are stored in when --use_local_blob is set")
+
 		// TODO(brandon): Pretty sure these aren't needed, but it's a larger refactoring to split parsing logic from report generation logic.
 		benchmarkDir = fs.String("benchmark_dir", "", "The path to the benchmark data for report generation")
 		pactaDataDir = fs.String("pacta_data_dir", "", "The path to the PACTA data for report generation")
@@ -93,19 +100,46 @@ func run(args []string) error {
 		}
 	}
 
-	pubsubClient, err := publisher.NewClient(fmt.Sprintf("https://%s.%s.eventgrid.azure.net/api/events", *azEventTopic, *azTopicLocation), creds, nil)
-	if err != nil {
-		return fmt.Errorf("failed to init pub/sub client: %w", err)
+	var pubsub async.Publisher
+	if *eventWebhookURL != "" {
+		logger.Info("sending notifications directly to webhook", zap.String("event_webhook_url", *eventWebhookURL))
+		if pubsub, err = async.NewHTTPPublisher(*eventWebhookURL, *eventWebhookSecret); err != nil {
+			return fmt.Errorf("failed to init HTTP pub/sub client: %w", err)
+		}
+	} else {
+		pubsubClient, err := publisher.NewClient(fmt.Sprintf("https://%s.%s.eventgrid.azure.net/api/events", *azEventTopic, *azTopicLocation), creds, nil)
+		if err != nil {
+			return fmt.Errorf("failed to init pub/sub client: %w", err)
+		}
+		pubsub = async.NewEventGridPublisher(pubsubClient)
 	}
 
-	blobClient, err := azblob.NewClient(creds, *azStorageAccount)
-	if err != nil {
-		return fmt.Errorf("failed to init blob client: %w", err)
+	if *useLocalBlob && *env != "local" {
+		return errors.New("--use_local_blob set outside of local environment")
+	}
+
+	var blobClient async.Blob
+	if *useLocalBlob {
+		logger.Info("initializing local filesystem blob client", zap.String("local_blob_dir", *localBlobDir))
+		tmp, err := localblob.NewClient(&localblob.Config{
+			Root:   *localBlobDir,
+			Logger: logger,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to init local blob client: %w", err)
+		}
+		blobClient = tmp
+	} else {
+		tmp, err := azblob.NewClient(creds, *azStorageAccount)
+		if err != nil {
+			return fmt.Errorf("failed to init blob client: %w", err)
+		}
+		blobClient = tmp
 	}
 
 	h, err := async.New(&async.Config{
 		Blob:   blobClient,
-		PubSub: pubsubClient,
+		PubSub: pubsub,
 		Logger: logger,
 
 		// TODO(brandon): Pretty sure these aren't needed, but it's a larger refactoring to split parsing logic from report generation logic.
@@ -129,6 +163,10 @@ func run(args []string) error {
 	logger.Info("running PACTA parsing task", zap.String("task_id", string(taskID)))
 
 	if err := h.CreateDashboard(ctx, taskID, req, *azDestPortfolioContainer); err != nil {
+		// The task may have failed because ctx expired, we still want to report it.
+		if pubErr := h.PublishAnalysisFailure(context.WithoutCancel(ctx), taskID, req.AnalysisID, err); pubErr != nil {
+			logger.Error("failed to publish failure event", zap.String("task_id", string(taskID)), zap.Error(pubErr))
+		}
 		return fmt.Errorf("error running task: %w", err)
 	}
 
@@ -12,6 +12,8 @@ import (
 	"github.com/RMI/pacta/azure/azblob"
 	"github.com/RMI/pacta/azure/azcreds"
 	"github.com/RMI/pacta/azure/azlog"
+	"github.com/RMI/pacta/blob/localblob"
+	"github.com/RMI/pacta/pacta"
 	"github.com/RMI/pacta/task"
 	"github.com/namsral/flag"
 	"go.uber.org/zap"
@@ -43,10 +45,16 @@ func run(args []string) error {
 		azEventTopic    = fs.String("azure_event_topic", "", "The EventGrid topic to send notifications when tasks have finished")
 		azTopicLocation = fs.String("azure_topic_location", "", "The location (like 'centralus-1') where our EventGrid topics are hosted")
 
+		eventWebhookURL    = fs.String("event_webhook_url", "", "If set, send notifications directly to this URL (like 'http://host.docker.internal:8081/events') instead of via EventGrid, useful for running locally without Azure")
+		eventWebhookSecret = fs.String("event_webhook_secret", "", "The shared secret to authorize requests to --event_webhook_url with, must be one that the server accepts")
+
 		azStorageAccount  = fs.String("azure_storage_account", "", "The storage account to authenticate against for blob operations")
 		azReportContainer = fs.String("azure_report_container", "", "The container in the storage account where we write generated portfolio reports to")
 		azAuditContainer  = fs.String("azure_audit_container", "", "The container in the storage account where we write generated portfolio audits to")
 
+		useLocalBlob = fs.Bool("use_local_blob", false, "If true, read and write blobs on the local filesystem instead of in Azure Blob Storage. Can only be used when running locally.")
+		localBlobDir = fs.String("local_blob_dir", "", "Directory blobs are stored in when --use_local_blob is set")
+
 		minLogLevel zapcore.Level = zapcore.DebugLevel
 	)
 	fs.Var(&minLogLevel, "min_log_level", "If set, retains logs at the given level and above. Options: 'debug', 'info', 'warn', 'error', 'dpanic', 'panic', 'fatal' - default warn.")
@@ -73,19 +81,46 @@ func run(args []string) error {
 	}
 	logger.Info("authenticated with Azure", zapfield.Str("credential_type", credType))
 
-	pubsubClient, err := publisher.NewClient(fmt.Sprintf("https://%s.%s.eventgrid.azure.net/api/events", *azEventTopic, *azTopicLocation), creds, nil)
-	if err != nil {
-		return fmt.Errorf("failed to init pub/sub client: %w", err)
+	var pubsub async.Publisher
+	if *eventWebhookURL != "" {
+		logger.Info("sending notifications directly to webhook", zap.String("event_webhook_url", *eventWebhookURL))
+		if pubsub, err = async.NewHTTPPublisher(*eventWebhookURL, *eventWebhookSecret); err != nil {
+			return fmt.Errorf("failed to init HTTP pub/sub client: %w", err)
+		}
+	} else {
+		pubsubClient, err := publisher.NewClient(fmt.Sprintf("https://%s.%s.eventgrid.azure.net/api/events", *azEventTopic, *azTopicLocation), creds, nil)
+		if err != nil {
+			return fmt.Errorf("failed to init pub/sub client: %w", err)
+		}
+		pubsub = async.NewEventGridPublisher(pubsubClient)
 	}
 
-	blobClient, err := azblob.NewClient(creds, *azStorageAccount)
-	if err != nil {
-		return fmt.Errorf("failed to init blob client: %w", err)
+	if *useLocalBlob && *env != "local" {
+		return errors.New("--use_local_blob set outside of local environment")
+	}
+
+	var blobClient async.Blob
+	if *useLocalBlob {
+		logger.Info("initializing local filesystem blob client", zap.String("local_blob_dir", *localBlobDir))
+		tmp, err := localblob.NewClient(&localblob.Config{
+			Root:   *localBlobDir,
+			Logger: logger,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to init local blob client: %w", err)
+		}
+		blobClient = tmp
+	} else {
+		tmp, err := azblob.NewClient(creds, *azStorageAccount)
+		if err != nil {
+			return fmt.Errorf("failed to init blob client: %w", err)
+		}
+		blobClient = tmp
 	}
 
 	h, err := async.New(&async.Config{
 		Blob:         blobClient,
-		PubSub:       pubsubClient,
+		PubSub:       pubsub,
 		Logger:       logger,
 		BenchmarkDir: *benchmarkDir,
 		PACTADataDir: *pactaDataDir,
@@ -96,10 +131,16 @@ func run(args []string) error {
 
 	validTasks := map[task.Type]func(context.Context, task.ID) error{
 		task.CreateReport: toRunFn(async.LoadCreateReportRequestFromEnv, func(ctx context.Context, id task.ID, req *task.CreateReportRequest) error {
-			return h.CreateReport(ctx, id, req, *azReportContainer)
+			if err := h.CreateReport(ctx, id, req, *azReportContainer); err != nil {
+				return publishAnalysisFailure(ctx, h, logger, id, req.AnalysisID, err)
+			}
+			return nil
 		}),
 		task.CreateAudit: toRunFn(async.LoadCreateAuditRequestFromEnv, func(ctx context.Context, id task.ID, req *task.CreateAuditRequest) error {
-			return h.CreateAudit(ctx, id, req, *azAuditContainer)
+			if err := h.CreateAudit(ctx, id, req, *azAuditContainer); err != nil {
+				return publishAnalysisFailure(ctx, h, logger, id, req.AnalysisID, err)
+			}
+			return nil
 		}),
 	}
 
@@ -138,3 +179,13 @@ func toRunFn[T any](reqFn func() (T, error), runFn func(context.Context, task.ID
 		return runFn(ctx, taskID, req)
 	}
 }
+
+// publishAnalysisFailure lets the server know that the analysis failed, and
+// returns the original error.
+func publishAnalysisFailure(ctx context.Context, h *async.Handler, logger *zap.Logger, taskID task.ID, analysisID pacta.AnalysisID, taskErr error) error {
+	// The task may have failed because ctx expired, we still want to report it.
+	if err := h.PublishAnalysisFailure(context.WithoutCancel(ctx), taskID, analysisID, taskErr); err != nil {
+		logger.Error("failed to publish failure event", zap.String("task_id", string(taskID)), zap.Error(err))
+	}
+	return taskErr
+}
@@ -3,6 +3,8 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"log"
@@ -18,11 +20,15 @@ import (
 	"github.com/RMI/pacta/azure/azcreds"
 	"github.com/RMI/pacta/azure/azevents"
 	"github.com/RMI/pacta/azure/aztask"
+	"github.com/RMI/pacta/blob/localblob"
 	"github.com/RMI/pacta/cmd/server/pactasrv"
 	"github.com/RMI/pacta/db/sqldb"
 	"github.com/RMI/pacta/dockertask"
+	"github.com/RMI/pacta/keyutil"
 	"github.com/RMI/pacta/oapierr"
 	oapipacta "github.com/RMI/pacta/openapi/pacta"
+	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/reaper"
 	"github.com/RMI/pacta/reportsrv"
 	"github.com/RMI/pacta/secrets"
 	"github.com/RMI/pacta/session"
@@ -75,9 +81,27 @@ func run(args []string) error {
 		localDockerClientSecret = fs.String("local_docker_client_secret", "", "The client secret for accessing the localdocker service principal")
 		dockerRepoRoot          = fs.String("docker_repo_root", "", "Absolute path to the repo, used for finding the ./workflow-data directory for the runner")
 
+		// Blob storage
+		useLocalBlob        = fs.Bool("use_local_blob", false, "If true, store blobs on the local filesystem instead of in Azure Blob Storage. Can only be used when running locally.")
+		localBlobDir        = fs.String("local_blob_dir", "", "Directory to store blobs in when --use_local_blob is set")
+		localBlobBaseURL    = fs.String("local_blob_base_url", "", "Externally-reachable base URL for signed local blob URLs, defaults to http://localhost:<port>")
+		localBlobSigningKey = fs.String("local_blob_signing_key", "", "Key to sign local blob URLs with. If empty, a random key is generated at startup.")
+
 		// PACTA Execution
 		useAZRunner = fs.Bool("use_azure_runner", false, "If true, execute PACTA on Azure Container Apps Jobs instead of a local instance.")
 
+		// Stuck task reaping
+		reaperInterval                = fs.Duration("reaper_interval", 5*time.Minute, "How often to check for tasks that have run past their deadline. If zero, stuck tasks are never reaped.")
+		reaperParsePortfolioDeadline  = fs.Duration("reaper_parse_portfolio_deadline", 30*time.Minute, "How long a portfolio parsing task can run before it is marked as timed out.")
+		reaperCreateReportDeadline    = fs.Duration("reaper_create_report_deadline", 2*time.Hour, "How long a report task can run before it is marked as timed out.")
+		reaperCreateAuditDeadline     = fs.Duration("reaper_create_audit_deadline", time.Hour, "How long an audit task can run before it is marked as timed out.")
+		reaperCreateDashboardDeadline = fs.Duration("reaper_create_dashboard_deadline", time.Hour, "How long a dashboard task can run before it is marked as timed out.")
+
+		authJWKSReloadInterval = fs.Duration("auth_jwks_reload_interval", 5*time.Minute, "How often to reload the JWKS documents from secret_auth_jwks_file or secret_auth_jwks_url, and those of the issuers in oidc_issuers_file.")
+
+		// Additional identity providers
+		oidcIssuersFile = fs.String("oidc_issuers_file", "", "Path to a JSON file listing OIDC providers whose tokens we accept in addition to the credential service's, see oidcIssuerConfig for the format.")
+
 		// Secrets
 		pgHost     = fs.String("secret_postgres_host", "", "Host of the Postgres server, like db.example.com")
 		pgPort     = fs.Int("secret_postgres_port", 5432, "Port to connect to the Postgres server on")
@@ -85,8 +109,10 @@ func run(args []string) error {
 		pgUser     = fs.String("secret_postgres_user", "", "Name of the Postgres user to connect as")
 		pgPassword = fs.String("secret_postgres_password", "", "Password of the Postgres user to connect as")
 
-		authKeyID   = fs.String("secret_auth_public_key_id", "", "Key ID (kid) of the JWT tokens to allow")
-		authKeyData = fs.String("secret_auth_public_key_data", "", "PEM-encoded Ed25519 public key to verify JWT tokens with, contains literal \\n characters that will need to be replaced before parsing")
+		authKeyID    = fs.String("secret_auth_public_key_id", "", "Comma-separated key IDs (kid) of the JWT tokens to allow, one for each key in secret_auth_public_key_data")
+		authKeyData  = fs.String("secret_auth_public_key_data", "", "Comma-separated PEM-encoded Ed25519 public keys to verify JWT tokens with, contain literal \\n characters that will need to be replaced before parsing")
+		authJWKSFile = fs.String("secret_auth_jwks_file", "", "Path to a JWKS document with additional Ed25519 keys to verify JWT tokens with")
+		authJWKSURL  = fs.String("secret_auth_jwks_url", "", "URL of a JWKS document with additional Ed25519 keys to verify JWT tokens with")
 
 		azStorageAccount           = fs.String("secret_azure_storage_account", "", "The storage account to authenticate against for blob operations")
 		azSourcePortfolioContainer = fs.String("secret_azure_source_portfolio_container", "", "The container in the storage account where we write raw portfolios to")
@@ -138,9 +164,10 @@ func run(args []string) error {
 			User:     *pgUser,
 			Password: *pgPassword,
 		},
-		AuthVerificationKey: &secrets.RawAuthVerificationKey{
-			ID:   *authKeyID,
-			Data: *authKeyData,
+		AuthVerificationKeys: &secrets.RawAuthVerificationKeys{
+			Keys:     rawAuthVerificationKeys(*authKeyID, *authKeyData),
+			JWKSFile: *authJWKSFile,
+			JWKSURL:  *authJWKSURL,
 		},
 		RunnerConfig: &secrets.RawRunnerConfig{
 			ConfigPath:              *runnerConfigConfigPath,
@@ -219,11 +246,16 @@ func run(args []string) error {
 		runner = tmp
 	} else {
 		logger.Info("initializing local task runner client")
+		// Tasks need to see the same blobs as we do.
+		var taskBlobDir string
+		if *useLocalBlob {
+			taskBlobDir = *localBlobDir
+		}
 		tmp, err := dockertask.NewRunner(logger, &dockertask.ServicePrincipal{
 			TenantID:     *localDockerTenantID,
 			ClientID:     *localDockerClientID,
 			ClientSecret: *localDockerClientSecret,
-		}, *dockerRepoRoot)
+		}, *dockerRepoRoot, taskBlobDir)
 		if err != nil {
 			return fmt.Errorf("failed to init docker runner: %w", err)
 		}
@@ -246,14 +278,79 @@ func run(args []string) error {
 		},
 		Logger: logger,
 		Runner: runner,
+		DB:     db,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to init task runner: %w", err)
 	}
 
-	blobClient, err := azblob.NewClient(creds, *azStorageAccount)
-	if err != nil {
-		return fmt.Errorf("failed to init blob client: %w", err)
+	if *reaperInterval > 0 {
+		rpr, err := reaper.New(&reaper.Config{
+			DB:     db,
+			Logger: logger,
+			Deadlines: map[task.Type]time.Duration{
+				task.ParsePortfolio:  *reaperParsePortfolioDeadline,
+				task.CreateReport:    *reaperCreateReportDeadline,
+				task.CreateAudit:     *reaperCreateAuditDeadline,
+				task.CreateDashboard: *reaperCreateDashboardDeadline,
+			},
+			Interval: *reaperInterval,
+			Runner:   tr,
+			Now:      time.Now,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to init task reaper: %w", err)
+		}
+		go rpr.Run(ctx)
+	}
+
+	if *useLocalBlob && *env != "local" {
+		return errors.New("--use_local_blob set outside of local environment")
+	}
+
+	var (
+		blobClient interface {
+			pactasrv.Blob
+			reportsrv.Blob
+			azevents.Blob
+		}
+		localBlob *localblob.Client
+	)
+	if *useLocalBlob {
+		logger.Info("initializing local filesystem blob client", zap.String("local_blob_dir", *localBlobDir))
+		baseURL := *localBlobBaseURL
+		if baseURL == "" {
+			baseURL = fmt.Sprintf("http://localhost:%d", *port)
+		}
+		signingKey := []byte(*localBlobSigningKey)
+		if len(signingKey) == 0 {
+			signingKey = make([]byte, 32)
+			if _, err := rand.Read(signingKey); err != nil {
+				return fmt.Errorf("failed to generate local blob signing key: %w", err)
+			}
+		}
+		if localBlob, err = localblob.NewClient(&localblob.Config{
+			Root:       *localBlobDir,
+			BaseURL:    baseURL,
+			SigningKey: signingKey,
+			MaxUploadBytes: func(ctx context.Context) (int64, error) {
+				ul, err := db.UploadLimits(db.NoTxn(ctx))
+				if err != nil {
+					return 0, err
+				}
+				return ul.MaxBytes, nil
+			},
+			Logger: logger,
+		}); err != nil {
+			return fmt.Errorf("failed to init local blob client: %w", err)
+		}
+		blobClient = localBlob
+	} else {
+		tmp, err := azblob.NewClient(creds, *azStorageAccount)
+		if err != nil {
+			return fmt.Errorf("failed to init blob client: %w", err)
+		}
+		blobClient = tmp
 	}
 
 	// Create an instance of our handler which satisfies each generated interface
@@ -271,10 +368,14 @@ func run(args []string) error {
 		ResponseErrorHandlerFunc: oapierr.ErrorHandlerFunc(logger, func(err *oapierr.Error) *oapipacta.Error {
 			// We don't care if it's the default message or not.
 			cm, _ := err.ClientMessage()
-			return &oapipacta.Error{
+			resp := &oapipacta.Error{
 				ErrorId: string(err.ErrorID()),
 				Message: cm,
 			}
+			if ues, ok := err.Details().([]oapipacta.UploadError); ok {
+				resp.UploadErrors = &ues
+			}
+			return resp
 		}),
 	})
 
@@ -285,6 +386,7 @@ func run(args []string) error {
 		ResourceGroup:      *azEventResourceGroup,
 		TopicName:          *azEventTopic,
 		DB:                 db,
+		Blob:               blobClient,
 		Now:                time.Now,
 	})
 	if err != nil {
@@ -300,11 +402,20 @@ func run(args []string) error {
 		return fmt.Errorf("failed to init report server: %w", err)
 	}
 
-	jwKey, err := jwk.FromRaw(sec.AuthVerificationKey.PublicKey)
+	authKeys, err := loadAuthKeySet(ctx, logger, sec.AuthVerificationKeys)
 	if err != nil {
-		return fmt.Errorf("failed to make JWK key: %w", err)
+		return fmt.Errorf("failed to load JWT verification keys: %w", err)
+	}
+	if sec.AuthVerificationKeys.JWKSFile != "" || sec.AuthVerificationKeys.JWKSURL != "" {
+		go authKeys.Run(ctx, *authJWKSReloadInterval)
+	}
+
+	var oidcIssuers []*session.Issuer
+	if *oidcIssuersFile != "" {
+		if oidcIssuers, err = loadOIDCIssuers(ctx, logger, *oidcIssuersFile, *authJWKSReloadInterval); err != nil {
+			return fmt.Errorf("failed to load OIDC issuers: %w", err)
+		}
 	}
-	jwKey.Set(jwk.KeyIDKey, sec.AuthVerificationKey.ID)
 
 	type middlewareFunc = func(http.Handler) http.Handler
 	middleware := func(addl ...middlewareFunc) []middlewareFunc {
@@ -322,15 +433,29 @@ func run(args []string) error {
 			// zaphttplog.NewMiddleware(logger, zaphttplog.WithConcise(false)),
 
 			chimiddleware.Recoverer,
-			jwtauth.Verifier(jwtauth.New("EdDSA", nil, jwKey)),
-			siteverify.CheckSite(allowlist.SitePACTA, *logger),
-			requireJWTIfNotPublicEndpoint,
-			session.WithAuthn(logger, db),
+
+			// Requests bearing one of our API tokens are authenticated here and skip
+			// the JWT checks below, everything else goes through them. Tokens from
+			// the additional OIDC providers are verified against that provider's
+			// keys, and skip the credential service's checks.
+			session.WithAPITokenAuthn(logger, db, time.Now, allowForAPIToken,
+				session.WithIssuerAuthn(logger, db, oidcIssuers,
+					session.Verifier(authKeys),
+					siteverify.CheckSite(allowlist.SitePACTA, *logger),
+					requireJWTIfNotPublicEndpoint,
+					session.WithAuthn(logger, db),
+				),
+			),
 		}, addl...)
 	}
 
 	r := chi.NewRouter()
 	r.With(chimiddleware.Recoverer).Group(eventSrv.RegisterHandlers)
+	if localBlob != nil {
+		// Signed URLs carry their own authorization, so these don't go through the
+		// usual authn middleware.
+		r.With(chimiddleware.Recoverer).Group(localBlob.RegisterHandlers)
+	}
 	r.With(middleware()...).Group(reportSrv.RegisterHandlers)
 
 	// We now register our PACTA above as the handler for the interface
@@ -357,10 +482,15 @@ func run(args []string) error {
 	// If CORS was specified, wrap our handler in that.
 	var handler http.Handler
 	if *allowedCORSOrigin != "" {
+		allowedHeaders := []string{"Authorization", "Content-Type"}
+		if localBlob != nil {
+			// The frontend sends Azure-specific headers on uploads, which now come to us.
+			allowedHeaders = append(allowedHeaders, "X-Ms-Blob-Type")
+		}
 		handler = cors.New(cors.Options{
 			AllowedOrigins:   []string{*allowedCORSOrigin},
 			AllowCredentials: true,
-			AllowedHeaders:   []string{"Authorization", "Content-Type"},
+			AllowedHeaders:   allowedHeaders,
 			Debug:            false,
 			AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE"},
 		}).Handler(r)
@@ -404,6 +534,58 @@ func allowPublicAnalysisDownloads(r *http.Request) bool {
 	return strings.HasPrefix(r.URL.Path, "/report/")
 }
 
+// apiTokenScopeEndpoints lists what each API token scope grants access to, in
+// addition to the read endpoints that any token can use. Tokens without any
+// scopes have full access.
+var apiTokenScopeEndpoints = map[pacta.APITokenScope][]allowFn{
+	pacta.APITokenScope_ReadOnly: {},
+	pacta.APITokenScope_Upload: {
+		allowPost(`^/portfolio-upload$`),
+		allowPost(`^/portfolio-upload:complete$`),
+		allowPost(`^/incomplete-upload/[^/]*:retry$`),
+		allowPost(`^/portfolio/[^/]*:replace-holdings$`),
+	},
+	pacta.APITokenScope_RunAnalysis: {
+		allowPost(`^/run-analysis$`),
+		allowPost(`^/analysis/[^/]*:retry$`),
+		allowPost(`^/analysis/[^/]*:cancel$`),
+	},
+}
+
+var apiTokenReadEndpoints = []allowFn{
+	func(r *http.Request) bool { return r.Method == http.MethodGet },
+	// These are queries, they just take their arguments in the body.
+	allowPost(`^/access-blob-content$`),
+	allowPost(`^/audit-logs$`),
+	allowPost(`^/users$`),
+}
+
+func allowForAPIToken(t *pacta.APIToken, r *http.Request) bool {
+	if len(t.Scopes) == 0 {
+		return true
+	}
+	for _, fn := range apiTokenReadEndpoints {
+		if fn(r) {
+			return true
+		}
+	}
+	for _, scope := range t.Scopes {
+		for _, fn := range apiTokenScopeEndpoints[scope] {
+			if fn(r) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func allowPost(pathRegexp string) allowFn {
+	re := regexp.MustCompile(pathRegexp)
+	return func(r *http.Request) bool {
+		return r.Method == http.MethodPost && re.MatchString(r.URL.Path)
+	}
+}
+
 func requireJWTIfNotPublicEndpoint(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		for _, fn := range publicEndpoints {
@@ -430,6 +612,10 @@ func rateLimitMiddleware(maxReq int, windowLength time.Duration) func(http.Handl
 				// Rate limit by IP address if the user is anonymous.
 				return r.RemoteAddr, nil
 			}
+			if t := session.APITokenFromContext(r.Context()); t != nil {
+				// API token requests don't have claims, but they're all on behalf of a user.
+				return string(t.User.ID), nil
+			}
 			_, claims, err := jwtauth.FromContext(r.Context())
 			if err != nil {
 				return "", fmt.Errorf("failed to get claims from context: %w", err)
@@ -458,6 +644,134 @@ func findFirstInClaims(claims map[string]any, keys ...string) (string, error) {
 	return "", errors.New("no valid claim was found")
 }
 
+// rawAuthVerificationKeys pairs up the comma-separated key IDs and keys from
+// the secret_auth_public_key_* flags. Mismatched lists are left for
+// secrets.LoadPACTA to reject.
+func rawAuthVerificationKeys(ids, data string) []*secrets.RawAuthVerificationKey {
+	if ids == "" && data == "" {
+		return nil
+	}
+	idList, dataList := strings.Split(ids, ","), strings.Split(data, ",")
+	var keys []*secrets.RawAuthVerificationKey
+	for i := 0; i < len(idList) || i < len(dataList); i++ {
+		k := &secrets.RawAuthVerificationKey{}
+		if i < len(idList) {
+			k.ID = strings.TrimSpace(idList[i])
+		}
+		if i < len(dataList) {
+			k.Data = strings.TrimSpace(dataList[i])
+		}
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func loadAuthKeySet(ctx context.Context, logger *zap.Logger, avks secrets.AuthVerificationKeys) (*session.KeySet, error) {
+	static := jwk.NewSet()
+	for _, avk := range avks.Keys {
+		k, err := keyutil.ED25519JWK(avk.ID, avk.PublicKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to make JWK for key %q: %w", avk.ID, err)
+		}
+		if err := static.AddKey(k); err != nil {
+			return nil, fmt.Errorf("failed to add key %q: %w", avk.ID, err)
+		}
+	}
+	var load func(context.Context) (jwk.Set, error)
+	switch {
+	case avks.JWKSFile != "":
+		load = func(context.Context) (jwk.Set, error) { return jwk.ReadFile(avks.JWKSFile) }
+	case avks.JWKSURL != "":
+		load = func(ctx context.Context) (jwk.Set, error) { return jwk.Fetch(ctx, avks.JWKSURL) }
+	}
+	ks := session.NewKeySet(logger, static, load)
+	// We don't start serving until we have all the keys, otherwise we'd reject
+	// valid tokens until the first reload.
+	if err := ks.Reload(ctx); err != nil {
+		return nil, err
+	}
+	return ks, nil
+}
+
+// oidcIssuerConfig is an entry in the --oidc_issuers_file, which is a JSON list
+// of them. Each provider's keys are read from exactly one of JWKSFile or
+// JWKSURL, the former is mostly useful for testing against a local provider.
+// Providers can only sign in users with emails in EmailDomains. Mechanism is
+// the pacta.AuthnMechanism that the provider's users are recorded with, each
+// provider needs its own.
+type oidcIssuerConfig struct {
+	Issuer       string   `json:"issuer"`
+	Audience     string   `json:"audience"`
+	Mechanism    string   `json:"mechanism"`
+	JWKSFile     string   `json:"jwks_file"`
+	JWKSURL      string   `json:"jwks_url"`
+	EmailDomains []string `json:"email_domains"`
+}
+
+// loadOIDCIssuers reads the issuers from the given file and loads their keys.
+// The keys are reloaded periodically until the context is cancelled.
+func loadOIDCIssuers(ctx context.Context, logger *zap.Logger, path string, reloadInterval time.Duration) ([]*session.Issuer, error) {
+	dat, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	var cfgs []oidcIssuerConfig
+	if err := json.Unmarshal(dat, &cfgs); err != nil {
+		return nil, fmt.Errorf("failed to parse file: %w", err)
+	}
+	var issuers []*session.Issuer
+	seenMechs := make(map[pacta.AuthnMechanism]string)
+	for i, cfg := range cfgs {
+		if cfg.Issuer == "" || cfg.Audience == "" {
+			return nil, fmt.Errorf("issuer %d is missing an issuer or audience", i)
+		}
+		if len(cfg.EmailDomains) == 0 {
+			return nil, fmt.Errorf("issuer %q needs at least one email_domains entry", cfg.Issuer)
+		}
+		mech, err := pacta.ParseAuthnMechanism(cfg.Mechanism)
+		if err != nil {
+			return nil, fmt.Errorf("issuer %q has an invalid mechanism: %w", cfg.Issuer, err)
+		}
+		if !mech.IsOIDC() {
+			return nil, fmt.Errorf("issuer %q has mechanism %q, which isn't an OIDC mechanism", cfg.Issuer, mech)
+		}
+		if other, ok := seenMechs[mech]; ok {
+			return nil, fmt.Errorf("issuers %q and %q both have mechanism %q", other, cfg.Issuer, mech)
+		}
+		seenMechs[mech] = cfg.Issuer
+		var load func(context.Context) (jwk.Set, error)
+		switch {
+		case cfg.JWKSFile != "" && cfg.JWKSURL != "":
+			return nil, fmt.Errorf("issuer %q has both a jwks_file and a jwks_url", cfg.Issuer)
+		case cfg.JWKSFile != "":
+			jwksFile := cfg.JWKSFile
+			load = func(context.Context) (jwk.Set, error) { return jwk.ReadFile(jwksFile) }
+		case cfg.JWKSURL != "":
+			jwksURL := cfg.JWKSURL
+			load = func(ctx context.Context) (jwk.Set, error) { return jwk.Fetch(ctx, jwksURL) }
+		default:
+			return nil, fmt.Errorf("issuer %q needs a jwks_file or a jwks_url", cfg.Issuer)
+		}
+		ks := session.NewProviderKeySet(logger.With(zap.String("issuer", cfg.Issuer)), load)
+		if err := ks.Reload(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load keys for issuer %q: %w", cfg.Issuer, err)
+		}
+		issuers = append(issuers, &session.Issuer{
+			URL:          cfg.Issuer,
+			Audience:     cfg.Audience,
+			Mechanism:    mech,
+			KeySet:       ks,
+			EmailDomains: cfg.EmailDomains,
+		})
+	}
+	// Only start reloading once every issuer loaded, so we don't leak goroutines
+	// on a bad config.
+	for _, iss := range issuers {
+		go iss.KeySet.Run(ctx, reloadInterval)
+	}
+	return issuers, nil
+}
+
 func requestErrorHandlerFuncForService(logger *zap.Logger, svc string) func(w http.ResponseWriter, r *http.Request, err error) {
 	return func(w http.ResponseWriter, r *http.Request, err error) {
 		// We log these at WARN because in aggregate, they might indicate an issue with our request handling.
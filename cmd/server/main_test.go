@@ -0,0 +1,106 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RMI/pacta/pacta"
+)
+
+func TestAllowForAPIToken(t *testing.T) {
+	tests := []struct {
+		desc   string
+		scopes []pacta.APITokenScope
+		method string
+		path   string
+		want   bool
+	}{
+		{
+			desc:   "unscoped token can do anything",
+			method: http.MethodDelete,
+			path:   "/portfolio/portfolio.123",
+			want:   true,
+		},
+		{
+			desc:   "read-only token can read",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_ReadOnly},
+			method: http.MethodGet,
+			path:   "/portfolios",
+			want:   true,
+		},
+		{
+			desc:   "read-only token can query",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_ReadOnly},
+			method: http.MethodPost,
+			path:   "/access-blob-content",
+			want:   true,
+		},
+		{
+			desc:   "read-only token can't upload",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_ReadOnly},
+			method: http.MethodPost,
+			path:   "/portfolio-upload",
+			want:   false,
+		},
+		{
+			desc:   "read-only token can't delete",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_ReadOnly},
+			method: http.MethodDelete,
+			path:   "/portfolio/portfolio.123",
+			want:   false,
+		},
+		{
+			desc:   "upload token can upload",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_Upload},
+			method: http.MethodPost,
+			path:   "/portfolio-upload:complete",
+			want:   true,
+		},
+		{
+			desc:   "upload token can't run analyses",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_Upload},
+			method: http.MethodPost,
+			path:   "/run-analysis",
+			want:   false,
+		},
+		{
+			desc:   "run-analysis token can run analyses",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_RunAnalysis},
+			method: http.MethodPost,
+			path:   "/analysis/analysis.123:retry",
+			want:   true,
+		},
+		{
+			desc:   "multiple scopes combine",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_Upload, pacta.APITokenScope_RunAnalysis},
+			method: http.MethodPost,
+			path:   "/run-analysis",
+			want:   true,
+		},
+		{
+			desc:   "scoped token can't create tokens",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_Upload, pacta.APITokenScope_RunAnalysis},
+			method: http.MethodPost,
+			path:   "/user/me/tokens",
+			want:   false,
+		},
+		{
+			desc:   "path must match exactly",
+			scopes: []pacta.APITokenScope{pacta.APITokenScope_RunAnalysis},
+			method: http.MethodPost,
+			path:   "/run-analysis/extra",
+			want:   false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tok := &pacta.APIToken{ID: "apitoken.123", Scopes: test.scopes}
+			r := httptest.NewRequest(test.method, test.path, nil)
+			if got := allowForAPIToken(tok, r); got != test.want {
+				t.Errorf("allowForAPIToken(%q %q) = %t, want %t", test.method, test.path, got, test.want)
+			}
+		})
+	}
+}
@@ -35,8 +35,8 @@ func (s *Server) MergeUsers(ctx context.Context, request api.MergeUsersRequestOb
 	}
 
 	var (
-		numIncompleteUploads, numAnalyses, numPortfolios, numPortfolioGroups, numAuditLogsCreated int
-		buris                                                                                     []pacta.BlobURI
+		numIncompleteUploads, numAnalyses, numPortfolios, numPortfolioGroups, numTasks, numAuditLogsCreated int
+		buris                                                                                               []pacta.BlobURI
 	)
 
 	err = s.DB.Transactional(ctx, func(tx db.Tx) error {
@@ -63,6 +63,7 @@ func (s *Server) MergeUsers(ctx context.Context, request api.MergeUsersRequestOb
 				ActorType:            pacta.AuditLogActorType_Admin,
 				ActorID:              string(actorUserInfo.UserID),
 				ActorOwner:           &pacta.Owner{ID: actorUserInfo.OwnerID},
+				ActorAPITokenID:      actorUserInfo.APITokenID,
 				PrimaryTargetType:    t,
 				PrimaryTargetID:      id,
 				PrimaryTargetOwner:   &pacta.Owner{ID: destOwner},
@@ -124,11 +125,30 @@ func (s *Server) MergeUsers(ctx context.Context, request api.MergeUsersRequestOb
 		}
 		numPortfolioGroups = len(portfolioGroups)
 
+		// Tasks are a record of work done on the entities above, so they follow
+		// them to the new owner, but aren't audit logged themselves.
+		tasks, err := s.DB.TasksByOwner(tx, sourceOwner)
+		if err != nil {
+			return fmt.Errorf("failed to get tasks for source owner: %w", err)
+		}
+		for i, t := range tasks {
+			err := s.DB.UpdateTask(tx, t.ID, db.SetTaskOwner(destOwner))
+			if err != nil {
+				return fmt.Errorf("failed to update task owner %d/%d: %w", i+1, len(tasks), err)
+			}
+		}
+		numTasks = len(tasks)
+
 		if err := s.DB.CreateAuditLogs(tx, auditLogsToCreate); err != nil {
 			return fmt.Errorf("failed to create audit logs: %w", err)
 		}
 		numAuditLogsCreated = len(auditLogsToCreate)
 
+		// Keep the source user's logins working, they'll now log in as the destination user.
+		if err := s.DB.TransferUserAuthns(tx, sourceUID, destUID); err != nil {
+			return fmt.Errorf("failed to transfer user authns: %w", err)
+		}
+
 		// Now that we've transferred all the entities, we can delete the user.
 		deletedUserBuris, err := s.DB.DeleteUser(tx, sourceUID)
 		if err != nil {
@@ -158,6 +178,7 @@ func (s *Server) MergeUsers(ctx context.Context, request api.MergeUsersRequestOb
 		zap.Int("num_analyses", numAnalyses),
 		zap.Int("num_portfolios", numPortfolios),
 		zap.Int("num_portfolio_groups", numPortfolioGroups),
+		zap.Int("num_tasks", numTasks),
 		zap.Int("num_audit_logs_created", numAuditLogsCreated),
 	)
 	return api.MergeUsers200JSONResponse{
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
 	"github.com/RMI/pacta/db"
@@ -25,6 +26,16 @@ func (s *Server) ListAnalyses(ctx context.Context, request api.ListAnalysesReque
 	if err != nil {
 		return nil, oapierr.Internal("failed to query analyses", zap.Error(err))
 	}
+	items, err := s.analysesToOAPI(ctx, as)
+	if err != nil {
+		return nil, err
+	}
+	return api.ListAnalyses200JSONResponse{Items: items}, nil
+}
+
+// analysesToOAPI populates the artifacts and snapshots of the analyses, and
+// converts them for a list response.
+func (s *Server) analysesToOAPI(ctx context.Context, as []*pacta.Analysis) ([]api.Analysis, error) {
 	if err := s.populateArtifactsInAnalyses(ctx, as...); err != nil {
 		return nil, err
 	}
@@ -38,11 +49,7 @@ func (s *Server) ListAnalyses(ctx context.Context, request api.ListAnalysesReque
 	if err := s.populateSnapshotsInAnalyses(ctx, as...); err != nil {
 		return nil, err
 	}
-	items, err := dereference(conv.AnalysesToOAPI(as))
-	if err != nil {
-		return nil, err
-	}
-	return api.ListAnalyses200JSONResponse{Items: items}, nil
+	return dereference(conv.AnalysesToOAPI(as))
 }
 
 // Deletes an analysis (and its artifacts) by ID
@@ -171,6 +178,10 @@ func (s *Server) RunAnalysis(ctx context.Context, request api.RunAnalysisRequest
 	if err != nil {
 		return nil, err
 	}
+	taskType, err := taskTypeForAnalysisType(analysisType)
+	if err != nil {
+		return nil, err
+	}
 
 	var ais []entityForAnalysis
 	if request.Body.InitiativeId != nil {
@@ -191,40 +202,39 @@ func (s *Server) RunAnalysis(ctx context.Context, request api.RunAnalysisRequest
 	ai := ais[0]
 
 	var analysisID pacta.AnalysisID
-	var blobURIs []pacta.BlobURI
+	var taskID pacta.TaskID
+	var input *analysisInput
+	var as *authzStatus
 	err = s.DB.Transactional(ctx, func(tx db.Tx) error {
+		authz, err := ai.checkAuth(ctx, tx)
+		if err != nil {
+			return err
+		}
+		as = authz
+
 		var pvID pacta.PACTAVersionID
-		if request.Body.PactaVersionId == nil {
-			pv, err := s.DB.DefaultPACTAVersion(tx)
-			if err != nil {
-				return fmt.Errorf("looking up default pacta version: %w", err)
-			}
-			pvID = pv.ID
-		} else {
+		if request.Body.PactaVersionId != nil {
 			pvID = pacta.PACTAVersionID(*request.Body.PactaVersionId)
 			_, err := s.DB.PACTAVersion(tx, pvID)
 			if err != nil {
 				return oapierr.BadRequest("pacta_version_id is invalid", zap.Error(err), zap.String("pacta_version_id", string(pvID)))
 			}
+		} else {
+			pv, err := s.DB.DefaultPACTAVersion(tx)
+			if err != nil {
+				return fmt.Errorf("looking up default pacta version: %w", err)
+			}
+			pvID = pv.ID
 		}
 
-		var snapshotID pacta.PortfolioSnapshotID
-		var blobIDs []pacta.BlobID
-		if err := ai.checkAuth(ctx, tx); err != nil {
-			return err
-		}
-
-		snapshotID, blobIDs, err := ai.createSnapshot(tx)
+		snapshotID, err := ai.createSnapshot(tx)
 		if err != nil {
 			return err
 		}
 
-		blobs, err := s.DB.Blobs(tx, blobIDs)
+		input, err = s.loadAnalysisInput(tx, analysisType, snapshotID, pvID)
 		if err != nil {
-			return fmt.Errorf("looking up blobs: %w", err)
-		}
-		for _, blob := range blobs {
-			blobURIs = append(blobURIs, blob.BlobURI)
+			return err
 		}
 
 		aID, err := s.DB.CreateAnalysis(tx, &pacta.Analysis{
@@ -238,18 +248,17 @@ func (s *Server) RunAnalysis(ctx context.Context, request api.RunAnalysisRequest
 		if err != nil {
 			return fmt.Errorf("creating analysis: %w", err)
 		}
-		if _, err := s.DB.CreateAuditLog(tx, &pacta.AuditLog{
-			ActorType:          pacta.AuditLogActorType_Owner,
-			ActorID:            string(actorInfo.UserID),
-			ActorOwner:         &pacta.Owner{ID: actorInfo.OwnerID},
-			Action:             pacta.AuditLogAction_Create,
-			PrimaryTargetType:  pacta.AuditLogTargetType_Analysis,
-			PrimaryTargetID:    string(aID),
-			PrimaryTargetOwner: &pacta.Owner{ID: actorInfo.OwnerID},
-		}); err != nil {
-			return fmt.Errorf("creating audit log: %w", err)
+		tID, err := s.DB.CreateTask(tx, &pacta.Task{
+			Type:     taskType,
+			Owner:    &pacta.Owner{ID: actorInfo.OwnerID},
+			Analysis: &pacta.Analysis{ID: aID},
+			State:    pacta.TaskState_Queued,
+		})
+		if err != nil {
+			return fmt.Errorf("creating task: %w", err)
 		}
 		analysisID = aID
+		taskID = tID
 		return nil
 	})
 	if err != nil {
@@ -260,52 +269,393 @@ func (s *Server) RunAnalysis(ctx context.Context, request api.RunAnalysisRequest
 		return nil, oapierr.Internal("failed to create analysis", zap.Error(err))
 	}
 
+	as.primaryTargetID = string(analysisID)
+	as.primaryTargetType = pacta.AuditLogTargetType_Analysis
+	as.primaryTargetOwnerID = actorInfo.OwnerID
+	if err := s.auditLogIfAuthorizedOrFail(ctx, as); err != nil {
+		return nil, err
+	}
+
+	if err := s.dispatchAnalysis(ctx, analysisType, analysisID, task.ID(taskID), input); err != nil {
+		return nil, err
+	}
+
+	now := s.Now()
+	if err := s.DB.UpdateAnalysis(s.DB.NoTxn(ctx), analysisID, db.SetAnalysisRanAt(now)); err != nil {
+		// Just log the error, it's non-critical
+		s.Logger.Error("failed to set ranAt time on analysis", zap.String("analysis_id", string(analysisID)), zap.Time("ran_at", now))
+	}
+
+	return api.RunAnalysis200JSONResponse{AnalysisId: string(analysisID)}, nil
+}
+
+// Retries a failed analysis
+// (POST /analysis/{id}:retry)
+func (s *Server) RetryAnalysis(ctx context.Context, request api.RetryAnalysisRequestObject) (api.RetryAnalysisResponseObject, error) {
+	id := pacta.AnalysisID(request.Id)
+	if err := s.analysisDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Retry); err != nil {
+		return nil, err
+	}
+
+	var (
+		analysisType pacta.AnalysisType
+		taskID       pacta.TaskID
+		input        *analysisInput
+		failed       *pacta.Analysis
+	)
+	now := s.Now()
+	err := s.DB.Transactional(ctx, func(tx db.Tx) error {
+		// Concurrent retries wait here, so only one of them sees the previous
+		// task as finished.
+		if err := s.DB.LockAnalysis(tx, id); err != nil {
+			return oapierr.Internal("failed to lock analysis", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		a, err := s.DB.Analysis(tx, id)
+		if err != nil {
+			return oapierr.Internal("failed to query analysis", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		if a.FailureCode == "" {
+			return oapierr.Conflict("only failed analyses can be retried", zap.String("analysis_id", string(id)))
+		}
+		analysisType = a.AnalysisType
+		taskType, err := taskTypeForAnalysisType(analysisType)
+		if err != nil {
+			return err
+		}
+
+		input, err = s.loadAnalysisInput(tx, analysisType, a.PortfolioSnapshot.ID, a.PACTAVersion.ID)
+		if err != nil {
+			return err
+		}
+
+		prev, err := s.DB.TasksForAnalysis(tx, id)
+		if err != nil {
+			return oapierr.Internal("failed to query tasks for analysis", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		taskID, err = s.createRetryTask(tx, prev, &pacta.Task{
+			Type:     taskType,
+			Owner:    &pacta.Owner{ID: a.Owner.ID},
+			Analysis: &pacta.Analysis{ID: id},
+			State:    pacta.TaskState_Queued,
+		})
+		if err != nil {
+			return err
+		}
+
+		// The failure is cleared alongside creating the task, so that it can't
+		// wipe out the result of a task that finishes quickly.
+		failed = a
+		err = s.DB.UpdateAnalysis(tx, id,
+			db.SetAnalysisFailureCode(""),
+			db.SetAnalysisFailureMessage(""),
+			db.SetAnalysisCompletedAt(time.Time{}),
+			db.SetAnalysisRanAt(now))
+		if err != nil {
+			return oapierr.Internal("failed to clear analysis failure", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The task runner marks the task as failed if it can't be dispatched, and
+	// the analysis goes back to showing its earlier failure, so it can be
+	// retried again.
+	if err := s.dispatchAnalysis(ctx, analysisType, id, task.ID(taskID), input); err != nil {
+		s.restoreAnalysisFailure(ctx, failed)
+		return nil, err
+	}
+
+	return api.RetryAnalysis204Response{}, nil
+}
+
+// restoreAnalysisFailure puts back the failure that a retry cleared, for when
+// the retry couldn't be dispatched. If something else has finished the
+// analysis since (e.g. it was cancelled), that's left alone. Errors are logged
+// rather than returned, as callers are already handling a more relevant error.
+func (s *Server) restoreAnalysisFailure(ctx context.Context, failed *pacta.Analysis) {
+	err := s.DB.Transactional(context.WithoutCancel(ctx), func(tx db.Tx) error {
+		if err := s.DB.LockAnalysis(tx, failed.ID); err != nil {
+			return fmt.Errorf("locking analysis: %w", err)
+		}
+		a, err := s.DB.Analysis(tx, failed.ID)
+		if err != nil {
+			return fmt.Errorf("reading analysis: %w", err)
+		}
+		if !a.CompletedAt.IsZero() {
+			return nil
+		}
+		return s.DB.UpdateAnalysis(tx, failed.ID,
+			db.SetAnalysisFailureCode(failed.FailureCode),
+			db.SetAnalysisFailureMessage(failed.FailureMessage),
+			db.SetAnalysisCompletedAt(failed.CompletedAt))
+	})
+	if err != nil {
+		s.Logger.Error("failed to restore failure of analysis whose retry wasn't dispatched", zap.String("analysis_id", string(failed.ID)), zap.Error(err))
+	}
+}
+
+// Cancels a running analysis
+// (POST /analysis/{id}:cancel)
+func (s *Server) CancelAnalysis(ctx context.Context, request api.CancelAnalysisRequestObject) (api.CancelAnalysisResponseObject, error) {
+	id := pacta.AnalysisID(request.Id)
+	if err := s.analysisDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Cancel); err != nil {
+		return nil, err
+	}
+
+	// We mark the analysis as cancelled before stopping the task, so that any
+	// completion event that races with the cancellation gets ignored.
+	var runnerID task.RunnerID
+	now := s.Now()
+	err := s.DB.Transactional(ctx, func(tx db.Tx) error {
+		a, err := s.DB.Analysis(tx, id)
+		if err != nil {
+			return oapierr.Internal("failed to query analysis", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		if !a.CompletedAt.IsZero() {
+			return oapierr.Conflict("only running analyses can be cancelled", zap.String("analysis_id", string(id)))
+		}
+		ts, err := s.DB.TasksForAnalysis(tx, id)
+		if err != nil {
+			return oapierr.Internal("failed to query tasks for analysis", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		if len(ts) > 0 {
+			latest := ts[len(ts)-1]
+			if latest.State == pacta.TaskState_Queued || latest.State == pacta.TaskState_Running {
+				runnerID = task.RunnerID(latest.RunnerID)
+				err := s.DB.UpdateTask(tx, latest.ID,
+					db.SetTaskState(pacta.TaskState_Failed),
+					db.SetTaskCompletedAt(now))
+				if err != nil {
+					return oapierr.Internal("failed to update task", zap.String("task_id", string(latest.ID)), zap.Error(err))
+				}
+			}
+		}
+		err = s.DB.UpdateAnalysis(tx, id,
+			db.SetAnalysisFailureCode(pacta.FailureCode_Cancelled),
+			db.SetAnalysisFailureMessage("the analysis was cancelled"),
+			db.SetAnalysisCompletedAt(now))
+		if err != nil {
+			return oapierr.Internal("failed to mark analysis as cancelled", zap.String("analysis_id", string(id)), zap.Error(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// A task that was never dispatched has nothing to stop.
+	if runnerID == "" {
+		return api.CancelAnalysis204Response{}, nil
+	}
+	if err := s.TaskRunner.Cancel(ctx, runnerID); err != nil {
+		return nil, oapierr.Internal("analysis was cancelled, but failed to stop its task", zap.String("analysis_id", string(id)), zap.String("runner_id", string(runnerID)), zap.Error(err))
+	}
+	s.Logger.Info("cancelled analysis", zap.String("analysis_id", string(id)), zap.String("runner_id", string(runnerID)))
+
+	return api.CancelAnalysis204Response{}, nil
+}
+
+// dispatchAnalysis hands an already-created task for the given analysis off to
+// the task runner.
+func (s *Server) dispatchAnalysis(ctx context.Context, analysisType pacta.AnalysisType, analysisID pacta.AnalysisID, taskID task.ID, input *analysisInput) error {
 	switch analysisType {
 	case pacta.AnalysisType_Audit:
-		taskID, runnerID, err := s.TaskRunner.CreateAudit(ctx, &task.CreateAuditRequest{
-			AnalysisID: analysisID,
-			BlobURIs:   blobURIs,
+		runnerID, err := s.TaskRunner.CreateAudit(ctx, taskID, &task.CreateAuditRequest{
+			AnalysisID:       analysisID,
+			BlobURIs:         input.blobURIs,
+			Portfolio:        input.portfolio,
+			Inherit:          input.inherit,
+			ImageVersion:     input.imageVersion,
+			BenchmarkDataset: input.benchmarkDataset,
+			PACTADataset:     input.pactaDataset,
 		})
 		if err != nil {
-			return nil, oapierr.Internal("failed to create audit task", zap.Error(err))
+			return oapierr.Internal("failed to create audit task", zap.Error(err))
 		}
 		s.Logger.Info("created audit task", zap.String("task_id", string(taskID)), zap.String("runner_id", string(runnerID)), zap.String("analysis_id", string(analysisID)))
 	case pacta.AnalysisType_Report:
-		taskID, runnerID, err := s.TaskRunner.CreateReport(ctx, &task.CreateReportRequest{
-			AnalysisID: analysisID,
-			BlobURIs:   blobURIs,
+		runnerID, err := s.TaskRunner.CreateReport(ctx, taskID, &task.CreateReportRequest{
+			AnalysisID:       analysisID,
+			BlobURIs:         input.blobURIs,
+			Portfolio:        input.portfolio,
+			Inherit:          input.inherit,
+			ImageVersion:     input.imageVersion,
+			BenchmarkDataset: input.benchmarkDataset,
+			PACTADataset:     input.pactaDataset,
 		})
 		if err != nil {
-			return nil, oapierr.Internal("failed to create report task", zap.Error(err))
+			return oapierr.Internal("failed to create report task", zap.Error(err))
 		}
 		s.Logger.Info("created report task", zap.String("task_id", string(taskID)), zap.String("runner_id", string(runnerID)), zap.String("analysis_id", string(analysisID)))
 	case pacta.AnalysisType_Dashboard:
-		taskID, runnerID, err := s.TaskRunner.CreateDashboard(ctx, &task.CreateDashboardRequest{
-			AnalysisID: analysisID,
-			BlobURIs:   blobURIs,
+		runnerID, err := s.TaskRunner.CreateDashboard(ctx, taskID, &task.CreateDashboardRequest{
+			AnalysisID:       analysisID,
+			BlobURIs:         input.blobURIs,
+			Portfolio:        input.portfolio,
+			Inherit:          input.inherit,
+			ImageVersion:     input.imageVersion,
+			BenchmarkDataset: input.benchmarkDataset,
+			PACTADataset:     input.pactaDataset,
 		})
 		if err != nil {
-			return nil, oapierr.Internal("failed to create dashboard task", zap.Error(err))
+			return oapierr.Internal("failed to create dashboard task", zap.Error(err))
 		}
 		s.Logger.Info("created dashboard task", zap.String("task_id", string(taskID)), zap.String("runner_id", string(runnerID)), zap.String("analysis_id", string(analysisID)))
 	default:
-		return nil, oapierr.Internal("unknown analysis type", zap.String("analysis_type", string(analysisType)))
+		return oapierr.Internal("unknown analysis type", zap.String("analysis_type", string(analysisType)))
 	}
+	return nil
+}
 
-	now := s.Now()
-	if err := s.DB.UpdateAnalysis(s.DB.NoTxn(ctx), analysisID, db.SetAnalysisRanAt(now)); err != nil {
-		// Just log the error, it's non-critical
-		s.Logger.Error("failed to set ranAt time on analysis", zap.String("analysis_id", string(analysisID)), zap.Time("ran_at", now))
+// analysisInput is what the task runner needs to run an analysis on a
+// portfolio snapshot.
+type analysisInput struct {
+	blobURIs         []pacta.BlobURI
+	portfolio        *task.AnalysisPortfolio
+	inherit          string
+	imageVersion     string
+	benchmarkDataset string
+	pactaDataset     string
+}
+
+// loadAnalysisInput looks up the portfolios in the given snapshot, and derives
+// the metadata the R workflows need from them and the PACTA version.
+func (s *Server) loadAnalysisInput(tx db.Tx, analysisType pacta.AnalysisType, snapshotID pacta.PortfolioSnapshotID, pvID pacta.PACTAVersionID) (*analysisInput, error) {
+	snapshots, err := s.DB.PortfolioSnapshots(tx, []pacta.PortfolioSnapshotID{snapshotID})
+	if err != nil {
+		return nil, oapierr.Internal("failed to query portfolio snapshot", zap.String("portfolio_snapshot_id", string(snapshotID)), zap.Error(err))
+	}
+	snapshot, ok := snapshots[snapshotID]
+	if !ok {
+		return nil, oapierr.Internal("portfolio snapshot not found", zap.String("portfolio_snapshot_id", string(snapshotID)))
+	}
+	portfolios, err := s.DB.Portfolios(tx, snapshot.PortfolioIDs)
+	if err != nil {
+		return nil, oapierr.Internal("failed to query snapshot portfolios", zap.String("portfolio_snapshot_id", string(snapshotID)), zap.Error(err))
 	}
 
-	return api.RunAnalysis200JSONResponse{AnalysisId: string(analysisID)}, nil
+	var (
+		blobIDs      []pacta.BlobID
+		holdingsDate *pacta.HoldingsDate
+	)
+	if len(snapshot.BlobIDs) > 0 && len(snapshot.BlobIDs) != len(snapshot.PortfolioIDs) {
+		return nil, oapierr.Internal("portfolio snapshot has mismatched blobs", zap.String("portfolio_snapshot_id", string(snapshotID)))
+	}
+	for i, pID := range snapshot.PortfolioIDs {
+		p, ok := portfolios[pID]
+		if !ok {
+			return nil, oapierr.Internal("portfolio in snapshot not found", zap.String("portfolio_snapshot_id", string(snapshotID)), zap.String("portfolio_id", string(pID)))
+		}
+		// Analyze the holdings the portfolio had when the snapshot was taken,
+		// which may since have been replaced. Snapshots from before holdings
+		// could be replaced don't record them.
+		if len(snapshot.BlobIDs) > 0 {
+			blobIDs = append(blobIDs, snapshot.BlobIDs[i])
+		} else {
+			blobIDs = append(blobIDs, p.Blob.ID)
+		}
+		// Portfolios without a holdings date take that of the others.
+		hd := p.Properties.HoldingsDate
+		if hd == nil || hd.Time.IsZero() {
+			continue
+		}
+		if holdingsDate != nil && !holdingsDate.Time.Equal(hd.Time) {
+			return nil, oapierr.BadRequest("portfolios analyzed together must have the same holdings date", zap.String("portfolio_snapshot_id", string(snapshotID)))
+		}
+		holdingsDate = hd
+	}
+	if len(blobIDs) == 0 {
+		return nil, oapierr.BadRequest("there are no portfolios to analyze", zap.String("portfolio_snapshot_id", string(snapshotID)))
+	}
+
+	blobs, err := s.DB.Blobs(tx, blobIDs)
+	if err != nil {
+		return nil, oapierr.Internal("failed to query blobs", zap.String("portfolio_snapshot_id", string(snapshotID)), zap.Error(err))
+	}
+	var blobURIs []pacta.BlobURI
+	for _, blob := range blobs {
+		blobURIs = append(blobURIs, blob.BlobURI)
+	}
+
+	var (
+		name  string
+		scope task.AnalysisScope
+	)
+	switch {
+	case snapshot.Portfolio != nil:
+		name = portfolios[snapshot.Portfolio.ID].Name
+		scope = task.AnalysisScopePortfolio
+	case snapshot.PortfolioGroup != nil:
+		pg, err := s.DB.PortfolioGroup(tx, snapshot.PortfolioGroup.ID)
+		if err != nil {
+			return nil, oapierr.Internal("failed to query portfolio group", zap.String("portfolio_group_id", string(snapshot.PortfolioGroup.ID)), zap.Error(err))
+		}
+		name = pg.Name
+		scope = task.AnalysisScopePortfolioGroup
+	case snapshot.Initiatiative != nil:
+		i, err := s.DB.Initiative(tx, snapshot.Initiatiative.ID)
+		if err != nil {
+			return nil, oapierr.Internal("failed to query initiative", zap.String("initiative_id", string(snapshot.Initiatiative.ID)), zap.Error(err))
+		}
+		name = i.Name
+		scope = task.AnalysisScopeInitiative
+	default:
+		return nil, oapierr.Internal("portfolio snapshot had no source", zap.String("portfolio_snapshot_id", string(snapshotID)))
+	}
+
+	pv, err := s.DB.PACTAVersion(tx, pvID)
+	if err != nil {
+		return nil, oapierr.Internal("failed to query pacta version", zap.String("pacta_version_id", string(pvID)), zap.Error(err))
+	}
+	if pv.Inherit == "" {
+		return nil, oapierr.Internal("pacta version has no inherit configuration", zap.String("pacta_version_id", string(pvID)))
+	}
+	// Audits and reports run the runner image, dashboards have their own.
+	digest := pv.Digest
+	if analysisType == pacta.AnalysisType_Dashboard {
+		if pv.DashboardDigest == "" {
+			return nil, oapierr.BadRequest("pacta version has no dashboard image, an administrator needs to set one", zap.String("pacta_version_id", string(pvID)))
+		}
+		digest = pv.DashboardDigest
+	}
+	imageVersion, err := task.NormalizeImageVersion(digest)
+	if err != nil {
+		return nil, oapierr.Internal("pacta version has an invalid digest", zap.String("pacta_version_id", string(pvID)), zap.Error(err))
+	}
+	if err := anyError(task.ValidateDataset(pv.BenchmarkDataset), task.ValidateDataset(pv.PACTADataset)); err != nil {
+		return nil, oapierr.Internal("pacta version has an invalid dataset", zap.String("pacta_version_id", string(pvID)), zap.Error(err))
+	}
+	if holdingsDate == nil {
+		// None of the portfolios have a holdings date, so assume they're as of
+		// the quarter the analysis is run against.
+		if holdingsDate, err = task.DefaultHoldingsDate(pv.Inherit); err != nil {
+			return nil, oapierr.BadRequest("portfolios must have a holdings date to be analyzed", zap.String("portfolio_snapshot_id", string(snapshotID)), zap.Error(err))
+		}
+	}
+
+	return &analysisInput{
+		blobURIs: blobURIs,
+		portfolio: &task.AnalysisPortfolio{
+			Name:         name,
+			HoldingsDate: holdingsDate,
+			Scope:        scope,
+		},
+		inherit:          pv.Inherit,
+		imageVersion:     imageVersion,
+		benchmarkDataset: pv.BenchmarkDataset,
+		pactaDataset:     pv.PACTADataset,
+	}, nil
 }
 
 // This name is awkward, but it just encapsulates things we can run an analysis
-// on that represent one or more underlying portfolios.
+// on that represent one or more underlying portfolios. checkAuth returns the
+// authorization for creating the analysis, with the entity as the secondary
+// target. The primary target is filled in once the analysis exists.
 type entityForAnalysis interface {
-	checkAuth(context.Context, db.Tx) error
-	createSnapshot(db.Tx) (pacta.PortfolioSnapshotID, []pacta.BlobID, error)
+	checkAuth(context.Context, db.Tx) (*authzStatus, error)
+	createSnapshot(db.Tx) (pacta.PortfolioSnapshotID, error)
 }
 
 type portfolioAnalysis struct {
@@ -321,34 +671,42 @@ func notFoundErr[T ~string](typeName string, id T, fields ...zapcore.Field) erro
 	return oapierr.NotFound(fmt.Sprintf("%s not found", typeName), fs...)
 }
 
-func (pa *portfolioAnalysis) checkAuth(ctx context.Context, tx db.Tx) error {
+func (pa *portfolioAnalysis) checkAuth(ctx context.Context, tx db.Tx) (*authzStatus, error) {
 	actorInfo, err := pa.s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	p, err := pa.s.DB.Portfolio(tx, pa.pID)
 	if err != nil {
 		if db.IsNotFound(err) {
-			return notFoundErr("portfolio", pa.pID, zap.Error(err))
+			return nil, notFoundErr("portfolio", pa.pID, zap.Error(err))
 		}
-		return fmt.Errorf("looking up portfolio: %w", err)
+		return nil, fmt.Errorf("looking up portfolio: %w", err)
 	}
 	if p.Owner.ID != actorInfo.OwnerID {
-		return notFoundErr("portfolio", pa.pID,
+		return nil, notFoundErr("portfolio", pa.pID,
 			zap.Error(fmt.Errorf("portfolio does not belong to user")),
 			zap.String("portfolio_owner_id", string(p.Owner.ID)),
 			zap.String("actor_owner_id", string(actorInfo.OwnerID)))
 	}
 	pa.p = p
-	return nil
+	return &authzStatus{
+		secondaryTargetID:      string(pa.pID),
+		secondaryTargetType:    pacta.AuditLogTargetType_Portfolio,
+		secondaryTargetOwnerID: p.Owner.ID,
+		actorInfo:              actorInfo,
+		action:                 pacta.AuditLogAction_Create,
+		isAuthorized:           true,
+		authorizedAsActorType:  ptr(pacta.AuditLogActorType_Owner),
+	}, nil
 }
 
-func (pa *portfolioAnalysis) createSnapshot(tx db.Tx) (pacta.PortfolioSnapshotID, []pacta.BlobID, error) {
+func (pa *portfolioAnalysis) createSnapshot(tx db.Tx) (pacta.PortfolioSnapshotID, error) {
 	sID, err := pa.s.DB.CreateSnapshotOfPortfolio(tx, pa.pID)
 	if err != nil {
-		return "", nil, fmt.Errorf("creating snapshot of portfolio: %w", err)
+		return "", fmt.Errorf("creating snapshot of portfolio: %w", err)
 	}
-	return sID, []pacta.BlobID{pa.p.Blob.ID}, nil
+	return sID, nil
 }
 
 type portfolioGroupAnalysis struct {
@@ -358,46 +716,42 @@ type portfolioGroupAnalysis struct {
 	pg *pacta.PortfolioGroup
 }
 
-func (pga *portfolioGroupAnalysis) checkAuth(ctx context.Context, tx db.Tx) error {
+func (pga *portfolioGroupAnalysis) checkAuth(ctx context.Context, tx db.Tx) (*authzStatus, error) {
 	actorInfo, err := pga.s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	pg, err := pga.s.DB.PortfolioGroup(tx, pga.pgID)
 	if err != nil {
 		if db.IsNotFound(err) {
-			return notFoundErr("portfolio_group", pga.pgID, zap.Error(err))
+			return nil, notFoundErr("portfolio_group", pga.pgID, zap.Error(err))
 		}
-		return fmt.Errorf("looking up portfolio_group: %w", err)
+		return nil, fmt.Errorf("looking up portfolio_group: %w", err)
 	}
 	if pg.Owner.ID != actorInfo.OwnerID {
-		return notFoundErr("portfolio_group", pga.pgID,
+		return nil, notFoundErr("portfolio_group", pga.pgID,
 			zap.Error(fmt.Errorf("portfolio group does not belong to user")),
 			zap.String("pg_owner_id", string(pg.Owner.ID)),
 			zap.String("actor_owner_id", string(actorInfo.OwnerID)))
 	}
 	pga.pg = pg
-	return nil
+	return &authzStatus{
+		secondaryTargetID:      string(pga.pgID),
+		secondaryTargetType:    pacta.AuditLogTargetType_PortfolioGroup,
+		secondaryTargetOwnerID: pg.Owner.ID,
+		actorInfo:              actorInfo,
+		action:                 pacta.AuditLogAction_Create,
+		isAuthorized:           true,
+		authorizedAsActorType:  ptr(pacta.AuditLogActorType_Owner),
+	}, nil
 }
 
-func (pga *portfolioGroupAnalysis) createSnapshot(tx db.Tx) (pacta.PortfolioSnapshotID, []pacta.BlobID, error) {
+func (pga *portfolioGroupAnalysis) createSnapshot(tx db.Tx) (pacta.PortfolioSnapshotID, error) {
 	sID, err := pga.s.DB.CreateSnapshotOfPortfolioGroup(tx, pga.pgID)
 	if err != nil {
-		return "", nil, fmt.Errorf("creating snapshot of portfolio group: %w", err)
-	}
-	pids := []pacta.PortfolioID{}
-	for _, pm := range pga.pg.PortfolioGroupMemberships {
-		pids = append(pids, pm.Portfolio.ID)
+		return "", fmt.Errorf("creating snapshot of portfolio group: %w", err)
 	}
-	portfolios, err := pga.s.DB.Portfolios(tx, pids)
-	if err != nil {
-		return "", nil, fmt.Errorf("looking up portfolios: %w", err)
-	}
-	var blobIDs []pacta.BlobID
-	for _, p := range portfolios {
-		blobIDs = append(blobIDs, p.Blob.ID)
-	}
-	return sID, blobIDs, nil
+	return sID, nil
 }
 
 type initiativeAnalysis struct {
@@ -407,40 +761,47 @@ type initiativeAnalysis struct {
 	i *pacta.Initiative
 }
 
-func (ia *initiativeAnalysis) checkAuth(ctx context.Context, tx db.Tx) error {
-	// This crudely tests whether or not a user is a manager of the initiative.
-	if _, err := ia.s.initiativeDoAuthzAndAuditLog(ctx, ia.iID, pacta.AuditLogAction_Update); err != nil {
-		return err
+func (ia *initiativeAnalysis) checkAuth(ctx context.Context, tx db.Tx) (*authzStatus, error) {
+	actorInfo, err := ia.s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return nil, err
 	}
 	i, err := ia.s.DB.Initiative(tx, ia.iID)
 	if err != nil {
 		if db.IsNotFound(err) {
-			return notFoundErr("initiative", ia.iID, zap.Error(err))
+			return nil, notFoundErr("initiative", ia.iID, zap.Error(err))
 		}
-		return fmt.Errorf("looking up initiative: %w", err)
+		return nil, fmt.Errorf("looking up initiative: %w", err)
+	}
+	role, err := ia.s.initiativeRole(ctx, ia.iID, actorInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	as := &authzStatus{
+		secondaryTargetID:      string(ia.iID),
+		secondaryTargetType:    pacta.AuditLogTargetType_Initiative,
+		secondaryTargetOwnerID: systemOwnedEntityOwner,
+		actorInfo:              actorInfo,
+		action:                 pacta.AuditLogAction_Create,
+	}
+	// The analysis is recorded as being created by the actor's initiative role,
+	// or as an admin, rather than as the owner of the initiative's portfolios.
+	as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, role, pacta.InitiativeUserRole.CanAnalyze)
+	if !as.isAuthorized {
+		return nil, notFoundErr("initiative", ia.iID,
+			zap.Error(fmt.Errorf("user can't run analyses on initiative")),
+			zap.String("initiative_role", string(role)))
 	}
 	ia.i = i
-	return nil
+	return as, nil
 }
 
-func (ia *initiativeAnalysis) createSnapshot(tx db.Tx) (pacta.PortfolioSnapshotID, []pacta.BlobID, error) {
+func (ia *initiativeAnalysis) createSnapshot(tx db.Tx) (pacta.PortfolioSnapshotID, error) {
 	sID, err := ia.s.DB.CreateSnapshotOfInitiative(tx, ia.iID)
 	if err != nil {
-		return "", nil, fmt.Errorf("creating snapshot of initiative: %w", err)
+		return "", fmt.Errorf("creating snapshot of initiative: %w", err)
 	}
-	pids := []pacta.PortfolioID{}
-	for _, pm := range ia.i.PortfolioInitiativeMemberships {
-		pids = append(pids, pm.Portfolio.ID)
-	}
-	portfolios, err := ia.s.DB.Portfolios(tx, pids)
-	if err != nil {
-		return "", nil, fmt.Errorf("looking up portfolios: %w", err)
-	}
-	var blobIDs []pacta.BlobID
-	for _, p := range portfolios {
-		blobIDs = append(blobIDs, p.Blob.ID)
-	}
-	return sID, blobIDs, nil
+	return sID, nil
 }
 
 func (s *Server) analysisDoAuthzAndAuditLog(ctx context.Context, analysisID pacta.AnalysisID, action pacta.AuditLogAction) error {
@@ -463,7 +824,16 @@ func (s *Server) analysisDoAuthzAndAuditLog(ctx context.Context, analysisID pact
 		action:               action,
 	}
 	switch action {
-	case pacta.AuditLogAction_Update, pacta.AuditLogAction_Delete, pacta.AuditLogAction_ReadMetadata:
+	case pacta.AuditLogAction_ReadMetadata:
+		as.isAuthorized, as.authorizedAsActorType = allowIfAdminOrOwner(actorInfo, analysis.Owner.ID)
+		if !as.isAuthorized {
+			role, err := s.analysisInitiativeRole(ctx, analysis, actorInfo.UserID)
+			if err != nil {
+				return err
+			}
+			as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRole(role, pacta.InitiativeUserRole.CanViewResults)
+		}
+	case pacta.AuditLogAction_Update, pacta.AuditLogAction_Delete, pacta.AuditLogAction_Retry, pacta.AuditLogAction_Cancel:
 		as.isAuthorized, as.authorizedAsActorType = allowIfAdminOrOwner(actorInfo, analysis.Owner.ID)
 	default:
 		return fmt.Errorf("unknown action %q for analysis authz", action)
@@ -505,14 +875,25 @@ func (s *Server) analysisArtifactDoAuthzAndAuditLog(ctx context.Context, aaID pa
 	}
 	switch action {
 	case pacta.AuditLogAction_Download:
-		if actorInfo.OwnerID == analysis.Owner.ID {
+		if artifact.AdminOnly {
+			// Task logs and other debugging output are only for admins, and
+			// only once the owner has enabled admin debugging.
+			if artifact.AdminDebugEnabled {
+				as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
+			}
+		} else if actorInfo.OwnerID == analysis.Owner.ID {
 			as.isAuthorized, as.authorizedAsActorType = true, ptr(pacta.AuditLogActorType_Owner)
 		} else if artifact.SharedToPublic {
 			as.isAuthorized, as.authorizedAsActorType = true, ptr(pacta.AuditLogActorType_Public)
-		} else if artifact.AdminDebugEnabled {
-			as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
 		} else {
-			as.isAuthorized, as.authorizedAsActorType = false, nil
+			role, err := s.analysisInitiativeRole(ctx, analysis, actorInfo.UserID)
+			if err != nil {
+				return err
+			}
+			as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRole(role, pacta.InitiativeUserRole.CanViewResults)
+			if !as.isAuthorized && artifact.AdminDebugEnabled {
+				as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
+			}
 		}
 	case pacta.AuditLogAction_EnableAdminDebug,
 		pacta.AuditLogAction_DisableAdminDebug,
@@ -527,3 +908,21 @@ func (s *Server) analysisArtifactDoAuthzAndAuditLog(ctx context.Context, aaID pa
 	}
 	return s.auditLogIfAuthorizedOrFail(ctx, as)
 }
+
+// analysisInitiativeRole returns the user's role in the initiative that the
+// analysis was run on, or the zero value if it wasn't run on an initiative or
+// the user isn't part of it.
+func (s *Server) analysisInitiativeRole(ctx context.Context, a *pacta.Analysis, uID pacta.UserID) (pacta.InitiativeUserRole, error) {
+	if a.PortfolioSnapshot == nil {
+		return "", nil
+	}
+	snapshots, err := s.DB.PortfolioSnapshots(s.DB.NoTxn(ctx), []pacta.PortfolioSnapshotID{a.PortfolioSnapshot.ID})
+	if err != nil {
+		return "", oapierr.Internal("failed to look up portfolio snapshot for analysis", zap.String("analysis_id", string(a.ID)), zap.Error(err))
+	}
+	snapshot, ok := snapshots[a.PortfolioSnapshot.ID]
+	if !ok || snapshot.Initiatiative == nil {
+		return "", nil
+	}
+	return s.initiativeRole(ctx, snapshot.Initiatiative.ID, uID)
+}
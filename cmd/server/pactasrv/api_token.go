@@ -0,0 +1,123 @@
+package pactasrv
+
+import (
+	"context"
+
+	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/oapierr"
+	api "github.com/RMI/pacta/openapi/pacta"
+	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/session"
+	"go.uber.org/zap"
+)
+
+// Returns the logged in user's API tokens
+// (GET /user/me/tokens)
+func (s *Server) ListAPITokens(ctx context.Context, request api.ListAPITokensRequestObject) (api.ListAPITokensResponseObject, error) {
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ts, err := s.DB.APITokensByUser(s.DB.NoTxn(ctx), actorInfo.UserID)
+	if err != nil {
+		return nil, oapierr.Internal("failed to list api tokens", zap.String("user_id", string(actorInfo.UserID)), zap.Error(err))
+	}
+	items, err := conv.APITokensToOAPI(ts)
+	if err != nil {
+		return nil, err
+	}
+	return api.ListAPITokens200JSONResponse{Items: items}, nil
+}
+
+// Creates an API token for the logged in user
+// (POST /user/me/tokens)
+func (s *Server) CreateAPIToken(ctx context.Context, request api.CreateAPITokenRequestObject) (api.CreateAPITokenResponseObject, error) {
+	if err := anyError(
+		checkStringLimitSmall("name", request.Body.Name),
+	); err != nil {
+		return nil, err
+	}
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := forbidIfAPIToken(actorInfo, "manage api tokens"); err != nil {
+		return nil, err
+	}
+	t, err := conv.APITokenCreateFromOAPI(request.Body, actorInfo.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if !t.ExpiresAt.IsZero() && !t.ExpiresAt.After(s.Now()) {
+		return nil, oapierr.BadRequest("expiresAt must be in the future").
+			WithMessage("The expiration time must be in the future.")
+	}
+	secret, tokenHash, err := session.NewAPITokenSecret()
+	if err != nil {
+		return nil, oapierr.Internal("failed to generate api token secret", zap.Error(err))
+	}
+	id, err := s.DB.CreateAPIToken(s.DB.NoTxn(ctx), t, tokenHash)
+	if err != nil {
+		return nil, oapierr.Internal("failed to create api token", zap.Error(err))
+	}
+	t.ID = id
+	t.CreatedAt = s.Now()
+	if err := s.auditLogForCreateEvent(ctx, actorInfo, pacta.AuditLogActorType_Owner, pacta.AuditLogTargetType_APIToken, string(id)); err != nil {
+		return nil, err
+	}
+	resp, err := conv.APITokenToOAPI(t)
+	if err != nil {
+		return nil, err
+	}
+	return api.CreateAPIToken200JSONResponse{
+		Token:  *resp,
+		Secret: secret,
+	}, nil
+}
+
+// Revokes an API token
+// (DELETE /user/me/tokens/{id})
+func (s *Server) RevokeAPIToken(ctx context.Context, request api.RevokeAPITokenRequestObject) (api.RevokeAPITokenResponseObject, error) {
+	id := pacta.APITokenID(request.Id)
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := forbidIfAPIToken(actorInfo, "manage api tokens"); err != nil {
+		return nil, err
+	}
+	// Revoking is recorded as a deletion, as far as the user is concerned the
+	// token is gone.
+	action := pacta.AuditLogAction_Delete
+	t, err := s.DB.APIToken(s.DB.NoTxn(ctx), id)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, notFoundOrUnauthorized(actorInfo, action, pacta.AuditLogTargetType_APIToken, id)
+		}
+		return nil, oapierr.Internal("failed to look up api token", zap.String("api_token_id", string(id)), zap.Error(err))
+	}
+	// Users can only see and revoke their own tokens, an admin who needs to cut off
+	// a user's access can delete the user.
+	if t.User.ID != actorInfo.UserID {
+		return nil, notFoundOrUnauthorized(actorInfo, action, pacta.AuditLogTargetType_APIToken, id)
+	}
+	if !t.RevokedAt.IsZero() {
+		return api.RevokeAPIToken204Response{}, nil
+	}
+	if err := s.auditLogIfAuthorizedOrFail(ctx, &authzStatus{
+		primaryTargetID:       string(id),
+		primaryTargetType:     pacta.AuditLogTargetType_APIToken,
+		primaryTargetOwnerID:  actorInfo.OwnerID,
+		actorInfo:             actorInfo,
+		action:                action,
+		isAuthorized:          true,
+		authorizedAsActorType: ptr(pacta.AuditLogActorType_Owner),
+	}); err != nil {
+		return nil, err
+	}
+	if err := s.DB.UpdateAPIToken(s.DB.NoTxn(ctx), id, db.SetAPITokenRevokedAt(s.Now())); err != nil {
+		return nil, oapierr.Internal("failed to revoke api token", zap.String("api_token_id", string(id)), zap.Error(err))
+	}
+	return api.RevokeAPIToken204Response{}, nil
+}
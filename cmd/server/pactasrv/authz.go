@@ -2,6 +2,7 @@ package pactasrv
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/RMI/pacta/oapierr"
 	"github.com/RMI/pacta/pacta"
@@ -55,6 +56,8 @@ func (as *authzStatus) ToAuditLog() (*pacta.AuditLog, error) {
 		ActorID:    as.actorUserID(),
 		ActorOwner: as.actorOwner(),
 
+		ActorAPITokenID: as.actorInfo.APITokenID,
+
 		Action: as.action,
 
 		PrimaryTargetType:  as.primaryTargetType,
@@ -117,6 +120,19 @@ func (s *Server) auditLogForCreateEvent(ctx context.Context, actorInfo actorInfo
 	return s.auditLogIfAuthorizedOrFail(ctx, as)
 }
 
+// forbidIfAPIToken rejects actors authenticated with an API token rather than a
+// login session, for things a leaked token shouldn't be enough to do, like
+// minting new tokens or logging its user out. what describes the operation,
+// e.g. "revoke sessions".
+func forbidIfAPIToken(actorInfo actorInfo, what string) error {
+	if actorInfo.APITokenID == "" {
+		return nil
+	}
+	return oapierr.Forbidden("api tokens can't be used to "+what,
+		zap.String("api_token_id", string(actorInfo.APITokenID))).
+		WithMessage(fmt.Sprintf("API tokens can't be used to %s, please log in instead.", what))
+}
+
 func allowIfAdmin(actorInfo actorInfo) (bool, *pacta.AuditLogActorType) {
 	if actorInfo.IsAdmin {
 		return true, ptr(pacta.AuditLogActorType_Admin)
@@ -141,4 +157,29 @@ func allowIfAdminOrOwner(actorInfo actorInfo, targetOwnerID pacta.OwnerID) (bool
 	return allowIfAdmin(actorInfo)
 }
 
+// initiativeRoleActorType returns how an actor authorized by their role in an
+// initiative is recorded in the audit log, or nil if they have no role.
+func initiativeRoleActorType(role pacta.InitiativeUserRole) *pacta.AuditLogActorType {
+	if at := role.ActorType(); at != "" {
+		return &at
+	}
+	return nil
+}
+
+// allowIfInitiativeRole authorizes actors whose role in an initiative allows
+// it, as determined by can, e.g. pacta.InitiativeUserRole.CanManage.
+func allowIfInitiativeRole(role pacta.InitiativeUserRole, can func(pacta.InitiativeUserRole) bool) (bool, *pacta.AuditLogActorType) {
+	if can(role) {
+		return true, initiativeRoleActorType(role)
+	}
+	return false, nil
+}
+
+func allowIfInitiativeRoleOrAdmin(actorInfo actorInfo, role pacta.InitiativeUserRole, can func(pacta.InitiativeUserRole) bool) (bool, *pacta.AuditLogActorType) {
+	if ok, actorType := allowIfInitiativeRole(role, can); ok {
+		return ok, actorType
+	}
+	return allowIfAdmin(actorInfo)
+}
+
 const systemOwnedEntityOwner = "SYSTEM-OWNED"
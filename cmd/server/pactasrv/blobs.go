@@ -35,7 +35,9 @@ func (s *Server) AccessBlobContent(ctx context.Context, request api.AccessBlobCo
 	auditLogs := []*pacta.AuditLog{}
 	for _, blobID := range blobIDs {
 		bc := asMap[blobID]
-		accessAsOwner := bc.PrimaryTargetOwnerID == actorInfo.OwnerID
+		// Admin-only blobs, like task logs, are for debugging, so even their
+		// owner can't download them.
+		accessAsOwner := !bc.AdminOnly && bc.PrimaryTargetOwnerID == actorInfo.OwnerID
 		accessAsAdmin := bc.AdminDebugEnabled && actorInfo.IsAdmin
 		accessAsSuperAdmin := bc.AdminDebugEnabled && actorInfo.IsSuperAdmin
 		var actorType pacta.AuditLogActorType
@@ -53,6 +55,7 @@ func (s *Server) AccessBlobContent(ctx context.Context, request api.AccessBlobCo
 			Action:             pacta.AuditLogAction_Download,
 			ActorID:            string(actorInfo.UserID),
 			ActorOwner:         &pacta.Owner{ID: actorInfo.OwnerID},
+			ActorAPITokenID:    actorInfo.APITokenID,
 			ActorType:          actorType,
 			PrimaryTargetType:  bc.PrimaryTargetType,
 			PrimaryTargetID:    bc.PrimaryTargetID,
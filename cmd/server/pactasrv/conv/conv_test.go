@@ -22,6 +22,14 @@ func TestAuditLogTargetTypeRoundTrip(t *testing.T) {
 	testEnumConvertability(t, pacta.AuditLogTargetTypeValues, auditLogTargetTypeToOAPI, auditLogTargetTypeFromOAPI)
 }
 
+func TestAPITokenScopeRoundTrip(t *testing.T) {
+	testEnumConvertability(t, pacta.APITokenScopeValues, apiTokenScopeToOAPI, apiTokenScopeFromOAPI)
+}
+
+func TestInitiativeUserRoleRoundTrip(t *testing.T) {
+	testEnumConvertability(t, pacta.InitiativeUserRoleValues, InitiativeUserRoleToOAPI, InitiativeUserRoleFromOAPI)
+}
+
 func TestAnalysisTypeRoundTrip(t *testing.T) {
 	testEnumConvertability(t, pacta.AnalysisTypeValues, AnalysisTypeToOAPI, AnalysisTypeFromOAPI)
 }
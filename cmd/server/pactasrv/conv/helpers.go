@@ -24,6 +24,14 @@ func fromStrs[T ~string](ss []string) []T {
 	return result
 }
 
+func toStrs[T ~string](ts []T) []string {
+	result := make([]string, len(ts))
+	for i, t := range ts {
+		result[i] = string(t)
+	}
+	return result
+}
+
 func ifNil[T any](t *T, fallback T) T {
 	if t == nil {
 		return fallback
@@ -3,6 +3,7 @@ package conv
 import (
 	"fmt"
 	"regexp"
+	"time"
 
 	"github.com/RMI/pacta/db"
 	"github.com/RMI/pacta/oapierr"
@@ -71,9 +72,13 @@ func PactaVersionCreateFromOAPI(p *api.PactaVersionCreate) (*pacta.PACTAVersion,
 		return nil, oapierr.BadRequest("PactaVersionCreate cannot be nil")
 	}
 	return &pacta.PACTAVersion{
-		Name:        p.Name,
-		Digest:      p.Digest,
-		Description: p.Description,
+		Name:             p.Name,
+		Digest:           p.Digest,
+		DashboardDigest:  p.DashboardDigest,
+		Inherit:          p.Inherit,
+		BenchmarkDataset: p.BenchmarkDataset,
+		PACTADataset:     p.PactaDataset,
+		Description:      p.Description,
 	}, nil
 }
 
@@ -87,9 +92,71 @@ func InitiativeInvitationFromOAPI(i *api.InitiativeInvitationCreate) (*pacta.Ini
 	if i.InitiativeId == "" {
 		return nil, oapierr.BadRequest("initiative_id must not be empty")
 	}
+	role := pacta.InitiativeUserRole_PortfolioContributor
+	if i.Role != nil {
+		r, err := InitiativeUserRoleFromOAPI(*i.Role)
+		if err != nil {
+			return nil, err
+		}
+		role = r
+	}
 	return &pacta.InitiativeInvitation{
 		ID:         pacta.InitiativeInvitationID(i.Id),
 		Initiative: &pacta.Initiative{ID: pacta.InitiativeID(i.InitiativeId)},
+		Role:       role,
+	}, nil
+}
+
+func InitiativeUserRoleFromOAPI(r api.InitiativeUserRole) (pacta.InitiativeUserRole, error) {
+	switch r {
+	case api.InitiativeUserRoleViewer:
+		return pacta.InitiativeUserRole_Viewer, nil
+	case api.InitiativeUserRolePortfolioContributor:
+		return pacta.InitiativeUserRole_PortfolioContributor, nil
+	case api.InitiativeUserRoleAnalyst:
+		return pacta.InitiativeUserRole_Analyst, nil
+	case api.InitiativeUserRoleManager:
+		return pacta.InitiativeUserRole_Manager, nil
+	}
+	return "", oapierr.BadRequest("unknown initiative user role", zap.String("initiative_user_role", string(r)))
+}
+
+func apiTokenScopeFromOAPI(s api.APITokenScope) (pacta.APITokenScope, error) {
+	switch s {
+	case api.APITokenScopeReadOnly:
+		return pacta.APITokenScope_ReadOnly, nil
+	case api.APITokenScopeUpload:
+		return pacta.APITokenScope_Upload, nil
+	case api.APITokenScopeRunAnalysis:
+		return pacta.APITokenScope_RunAnalysis, nil
+	}
+	return "", oapierr.BadRequest("apiTokenScopeFromOAPI: unknown scope", zap.String("api_token_scope", string(s)))
+}
+
+func APITokenCreateFromOAPI(t *api.CreateAPITokenReq, userID pacta.UserID) (*pacta.APIToken, error) {
+	if t == nil {
+		return nil, oapierr.Internal("apiTokenCreateFromOAPI: can't convert nil pointer")
+	}
+	if t.Name == "" {
+		return nil, oapierr.BadRequest("name must not be empty")
+	}
+	var scopes []pacta.APITokenScope
+	if t.Scopes != nil {
+		s, err := convAll(*t.Scopes, apiTokenScopeFromOAPI)
+		if err != nil {
+			return nil, err
+		}
+		scopes = s
+	}
+	var expiresAt time.Time
+	if t.ExpiresAt != nil {
+		expiresAt = *t.ExpiresAt
+	}
+	return &pacta.APIToken{
+		User:      &pacta.User{ID: userID},
+		Name:      t.Name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
 	}, nil
 }
 
@@ -105,6 +172,21 @@ func AnalysisTypeFromOAPI(at api.AnalysisType) (pacta.AnalysisType, error) {
 	return "", oapierr.BadRequest("analysisTypeFromOAPI: unknown analysis type", zap.String("analysis_type", string(at)))
 }
 
+func DuplicatePolicyFromOAPI(dp *api.DuplicatePolicy) (pacta.DuplicatePolicy, error) {
+	if dp == nil {
+		return pacta.DuplicatePolicy_Warn, nil
+	}
+	switch *dp {
+	case api.DuplicatePolicyLINK:
+		return pacta.DuplicatePolicy_Link, nil
+	case api.DuplicatePolicyWARN:
+		return pacta.DuplicatePolicy_Warn, nil
+	case api.DuplicatePolicyREJECT:
+		return pacta.DuplicatePolicy_Reject, nil
+	}
+	return "", oapierr.BadRequest("duplicatePolicyFromOAPI: unknown duplicate policy", zap.String("duplicate_policy", string(*dp)))
+}
+
 func HoldingsDateFromOAPI(hd api.HoldingsDate) (*pacta.HoldingsDate, error) {
 	if hd.Time == nil {
 		return nil, nil
@@ -157,6 +239,12 @@ func auditLogActionFromOAPI(i api.AuditLogAction) (pacta.AuditLogAction, error)
 		return pacta.AuditLogAction_ReadMetadata, nil
 	case api.AuditLogActionTransferOwnership:
 		return pacta.AuditLogAction_TransferOwnership, nil
+	case api.AuditLogActionRetry:
+		return pacta.AuditLogAction_Retry, nil
+	case api.AuditLogActionCancel:
+		return pacta.AuditLogAction_Cancel, nil
+	case api.AuditLogActionRevokeSessions:
+		return pacta.AuditLogAction_RevokeSessions, nil
 	}
 	return "", oapierr.BadRequest("unknown audit log action", zap.String("audit_log_action", string(i)))
 }
@@ -173,6 +261,14 @@ func auditLogActorTypeFromOAPI(i api.AuditLogActorType) (pacta.AuditLogActorType
 		return pacta.AuditLogActorType_SuperAdmin, nil
 	case api.AuditLogActorTypeSystem:
 		return pacta.AuditLogActorType_System, nil
+	case api.AuditLogActorTypeInitiativeViewer:
+		return pacta.AuditLogActorType_InitiativeViewer, nil
+	case api.AuditLogActorTypeInitiativePortfolioContributor:
+		return pacta.AuditLogActorType_InitiativePortfolioContributor, nil
+	case api.AuditLogActorTypeInitiativeAnalyst:
+		return pacta.AuditLogActorType_InitiativeAnalyst, nil
+	case api.AuditLogActorTypeInitiativeManager:
+		return pacta.AuditLogActorType_InitiativeManager, nil
 	}
 	return "", oapierr.BadRequest("unknown audit log actor type", zap.String("audit_log_actor_type", string(i)))
 }
@@ -197,6 +293,12 @@ func auditLogTargetTypeFromOAPI(i api.AuditLogTargetType) (pacta.AuditLogTargetT
 		return pacta.AuditLogTargetType_Analysis, nil
 	case api.AuditLogTargetTypeAnalysisArtifact:
 		return pacta.AuditLogTargetType_AnalysisArtifact, nil
+	case api.AuditLogTargetTypeTask:
+		return pacta.AuditLogTargetType_Task, nil
+	case api.AuditLogTargetTypeAPIToken:
+		return pacta.AuditLogTargetType_APIToken, nil
+	case api.AuditLogTargetTypeUploadLimits:
+		return pacta.AuditLogTargetType_UploadLimits, nil
 	}
 	return "", oapierr.BadRequest("unknown audit log target type", zap.String("audit_log_target_type", string(i)))
 }
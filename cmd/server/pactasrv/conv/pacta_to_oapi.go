@@ -6,6 +6,7 @@ import (
 	"github.com/RMI/pacta/oapierr"
 	api "github.com/RMI/pacta/openapi/pacta"
 	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
 	"go.uber.org/zap"
 )
 
@@ -128,17 +129,67 @@ func UserToOAPI(user *pacta.User) (*api.User, error) {
 	}, nil
 }
 
+func apiTokenScopeToOAPI(s pacta.APITokenScope) (api.APITokenScope, error) {
+	switch s {
+	case pacta.APITokenScope_ReadOnly:
+		return api.APITokenScopeReadOnly, nil
+	case pacta.APITokenScope_Upload:
+		return api.APITokenScopeUpload, nil
+	case pacta.APITokenScope_RunAnalysis:
+		return api.APITokenScopeRunAnalysis, nil
+	}
+	return "", oapierr.Internal(fmt.Sprintf("apiTokenScopeToOAPI: unknown scope: %q", s))
+}
+
+func APITokenToOAPI(t *pacta.APIToken) (*api.APIToken, error) {
+	if t == nil {
+		return nil, oapierr.Internal("apiTokenToOAPI: can't convert nil pointer")
+	}
+	scopes, err := convAll(t.Scopes, apiTokenScopeToOAPI)
+	if err != nil {
+		return nil, oapierr.Internal("apiTokenToOAPI: apiTokenScopeToOAPI failed", zap.Error(err))
+	}
+	return &api.APIToken{
+		Id:         string(t.ID),
+		Name:       t.Name,
+		Scopes:     scopes,
+		CreatedAt:  t.CreatedAt,
+		ExpiresAt:  timeToNilable(t.ExpiresAt),
+		LastUsedAt: timeToNilable(t.LastUsedAt),
+		RevokedAt:  timeToNilable(t.RevokedAt),
+	}, nil
+}
+
+func APITokensToOAPI(ts []*pacta.APIToken) ([]api.APIToken, error) {
+	return dereference(convAll(ts, APITokenToOAPI))
+}
+
 func PactaVersionToOAPI(pv *pacta.PACTAVersion) (*api.PactaVersion, error) {
 	if pv == nil {
 		return nil, oapierr.Internal("pactaVersionToOAPI: can't convert nil pointer")
 	}
 	return &api.PactaVersion{
-		CreatedAt:   pv.CreatedAt,
-		Description: pv.Description,
-		Digest:      pv.Digest,
-		Id:          string(pv.ID),
-		IsDefault:   pv.IsDefault,
-		Name:        pv.Name,
+		CreatedAt:        pv.CreatedAt,
+		Description:      pv.Description,
+		Digest:           pv.Digest,
+		DashboardDigest:  pv.DashboardDigest,
+		Id:               string(pv.ID),
+		Inherit:          pv.Inherit,
+		BenchmarkDataset: pv.BenchmarkDataset,
+		PactaDataset:     pv.PACTADataset,
+		IsDefault:        pv.IsDefault,
+		Name:             pv.Name,
+	}, nil
+}
+
+func UploadLimitsToOAPI(ul *pacta.UploadLimits) (*api.UploadLimits, error) {
+	if ul == nil {
+		return nil, oapierr.Internal("uploadLimitsToOAPI: can't convert nil pointer")
+	}
+	return &api.UploadLimits{
+		MaxBytes:  ul.MaxBytes,
+		MaxRows:   ul.MaxRows,
+		UpdatedAt: ul.UpdatedAt,
 	}, nil
 }
 
@@ -154,10 +205,15 @@ func InitiativeInvitationToOAPI(i *pacta.InitiativeInvitation) (*api.InitiativeI
 	if i.UsedBy != nil {
 		usedBy = strPtr(i.UsedBy.ID)
 	}
+	role, err := InitiativeUserRoleToOAPI(i.Role)
+	if err != nil {
+		return nil, err
+	}
 	return &api.InitiativeInvitation{
 		CreatedAt:    i.CreatedAt,
 		Id:           string(i.ID),
 		InitiativeId: string(i.Initiative.ID),
+		Role:         role,
 		UsedAt:       usedAt,
 		UsedByUserId: usedBy,
 	}, nil
@@ -173,15 +229,32 @@ func InitiativeUserRelationshipToOAPI(i *pacta.InitiativeUserRelationship) (*api
 	if i.Initiative == nil {
 		return nil, oapierr.Internal("initiativeUserRelationshipToOAPI: can't convert nil initiative")
 	}
+	role, err := InitiativeUserRoleToOAPI(i.Role)
+	if err != nil {
+		return nil, err
+	}
 	return &api.InitiativeUserRelationship{
 		UpdatedAt:    i.UpdatedAt,
 		InitiativeId: string(i.Initiative.ID),
 		UserId:       string(i.User.ID),
-		Manager:      i.Manager,
-		Member:       i.Member,
+		Role:         role,
 	}, nil
 }
 
+func InitiativeUserRoleToOAPI(r pacta.InitiativeUserRole) (api.InitiativeUserRole, error) {
+	switch r {
+	case pacta.InitiativeUserRole_Viewer:
+		return api.InitiativeUserRoleViewer, nil
+	case pacta.InitiativeUserRole_PortfolioContributor:
+		return api.InitiativeUserRolePortfolioContributor, nil
+	case pacta.InitiativeUserRole_Analyst:
+		return api.InitiativeUserRoleAnalyst, nil
+	case pacta.InitiativeUserRole_Manager:
+		return api.InitiativeUserRoleManager, nil
+	}
+	return "", oapierr.Internal(fmt.Sprintf("initiativeUserRoleToOAPI: unknown role: %q", r))
+}
+
 func HoldingsDateToOAPI(hd *pacta.HoldingsDate) (api.HoldingsDate, error) {
 	if hd == nil {
 		return api.HoldingsDate{}, nil
@@ -201,10 +274,42 @@ func FailureCodeToOAPI(f pacta.FailureCode) (*api.FailureCode, error) {
 		return nil, nil
 	case pacta.FailureCode_Unknown:
 		return ptr(api.FailureCodeUNKNOWN), nil
+	case pacta.FailureCode_ParseInvalidCSV:
+		return ptr(api.FailureCodeParseInvalidCSV), nil
+	case pacta.FailureCode_RScriptFailed:
+		return ptr(api.FailureCodeRScriptFailed), nil
+	case pacta.FailureCode_BlobIO:
+		return ptr(api.FailureCodeBlobIO), nil
+	case pacta.FailureCode_Timeout:
+		return ptr(api.FailureCodeTimeout), nil
+	case pacta.FailureCode_OutOfMemory:
+		return ptr(api.FailureCodeOutOfMemory), nil
+	case pacta.FailureCode_Cancelled:
+		return ptr(api.FailureCodeCancelled), nil
+	case pacta.FailureCode_Duplicate:
+		return ptr(api.FailureCodeDuplicate), nil
+	case pacta.FailureCode_UnsupportedFile:
+		return ptr(api.FailureCodeUnsupportedFile), nil
+	case pacta.FailureCode_TooManyRows:
+		return ptr(api.FailureCodeTooManyRows), nil
+	case pacta.FailureCode_InvalidReplacement:
+		return ptr(api.FailureCodeInvalidReplacement), nil
 	}
 	return nil, fmt.Errorf("unknown failure code: %q", f)
 }
 
+func DuplicatePolicyToOAPI(dp pacta.DuplicatePolicy) (api.DuplicatePolicy, error) {
+	switch dp {
+	case pacta.DuplicatePolicy_Link:
+		return api.DuplicatePolicyLINK, nil
+	case pacta.DuplicatePolicy_Warn:
+		return api.DuplicatePolicyWARN, nil
+	case pacta.DuplicatePolicy_Reject:
+		return api.DuplicatePolicyREJECT, nil
+	}
+	return "", fmt.Errorf("unknown duplicate policy: %q", dp)
+}
+
 func IncompleteUploadToOAPI(iu *pacta.IncompleteUpload) (*api.IncompleteUpload, error) {
 	if iu == nil {
 		return nil, oapierr.Internal("incompleteUploadToOAPI: can't convert nil pointer")
@@ -217,6 +322,10 @@ func IncompleteUploadToOAPI(iu *pacta.IncompleteUpload) (*api.IncompleteUpload,
 	if err != nil {
 		return nil, oapierr.Internal("incompleteUploadToOAPI: holdingsDateToOAPI failed", zap.Error(err))
 	}
+	dp, err := DuplicatePolicyToOAPI(iu.DuplicatePolicy)
+	if err != nil {
+		return nil, oapierr.Internal("incompleteUploadToOAPI: duplicatePolicyToOAPI failed", zap.Error(err))
+	}
 	return &api.IncompleteUpload{
 		Id:                         string(iu.ID),
 		Name:                       iu.Name,
@@ -231,9 +340,51 @@ func IncompleteUploadToOAPI(iu *pacta.IncompleteUpload) (*api.IncompleteUpload,
 		PropertyESG:                optionalBoolToOAPI(iu.Properties.ESG),
 		PropertyExternal:           optionalBoolToOAPI(iu.Properties.External),
 		PropertyEngagementStrategy: optionalBoolToOAPI(iu.Properties.EngagementStrategy),
+		ParseResult:                parseResultToOAPI(iu.ParseResult),
+		DuplicatePolicy:            dp,
+		DuplicatePortfolioIds:      toStrs(iu.DuplicatePortfolios),
+		LogBlobId:                  logBlobIDToOAPI(iu.LogBlob),
 	}, nil
 }
 
+func logBlobIDToOAPI(b *pacta.Blob) *string {
+	if b == nil {
+		return nil
+	}
+	return ptr(string(b.ID))
+}
+
+func parseResultToOAPI(pr *pacta.ParseResult) *api.ParseResult {
+	if pr == nil {
+		return nil
+	}
+	errs := make([][]string, 0, len(pr.Errors))
+	for _, e := range pr.Errors {
+		errs = append(errs, append([]string{}, e...))
+	}
+	deps := make([]api.ParserDependency, 0, len(pr.Parser.Dependencies))
+	for _, d := range pr.Parser.Dependencies {
+		deps = append(deps, api.ParserDependency{
+			Package: d.Package,
+			Version: d.Version,
+		})
+	}
+	return &api.ParseResult{
+		InputMd5:           pr.InputMD5,
+		InputEntries:       pr.InputEntries,
+		GroupCols:          append([]string{}, pr.GroupCols...),
+		SubportfoliosCount: pr.SubportfoliosCount,
+		Errors:             errs,
+		Parser: api.ParserInfo{
+			Timestamp:      pr.Parser.Timestamp,
+			Package:        pr.Parser.Package,
+			PackageVersion: pr.Parser.PackageVersion,
+			RVersion:       pr.Parser.RVersion,
+			Dependencies:   deps,
+		},
+	}
+}
+
 func PortfoliosToOAPI(ius []*pacta.Portfolio) ([]*api.Portfolio, error) {
 	return convAll(ius, PortfolioToOAPI)
 }
@@ -276,6 +427,9 @@ func PortfolioToOAPI(p *pacta.Portfolio) (*api.Portfolio, error) {
 		Description:                p.Description,
 		CreatedAt:                  p.CreatedAt,
 		NumberOfRows:               p.NumberOfRows,
+		InvestorName:               stringToNilable(p.InvestorName),
+		PortfolioName:              stringToNilable(p.PortfolioName),
+		Md5:                        stringToNilable(p.MD5),
 		AdminDebugEnabled:          p.AdminDebugEnabled,
 		Groups:                     &portfolioGroupMemberships,
 		Initiatives:                &pims,
@@ -404,6 +558,7 @@ func AnalysisArtifactToOAPI(aa *pacta.AnalysisArtifact) (*api.AnalysisArtifact,
 		Id:                string(aa.ID),
 		AdminDebugEnabled: aa.AdminDebugEnabled,
 		SharedToPublic:    aa.SharedToPublic,
+		AdminOnly:         aa.AdminOnly,
 		Blob:              *blob,
 	}, nil
 }
@@ -432,9 +587,9 @@ func AnalysisToOAPI(a *pacta.Analysis) (*api.Analysis, error) {
 	if err != nil {
 		return nil, oapierr.Internal("analysisToOAPI: portfolioSnapshotToOAPI failed", zap.Error(err))
 	}
-	var fc *api.FailureCode
-	if a.FailureCode != "" {
-		fc = ptr(api.FailureCode(a.FailureCode))
+	fc, err := FailureCodeToOAPI(a.FailureCode)
+	if err != nil {
+		return nil, oapierr.Internal("analysisToOAPI: failureCodeToOAPI failed", zap.Error(err))
 	}
 	var fm *string
 	if a.FailureMessage != "" {
@@ -456,6 +611,7 @@ func AnalysisToOAPI(a *pacta.Analysis) (*api.Analysis, error) {
 		CompletedAt:       timeToNilable(a.CompletedAt),
 		FailureCode:       fc,
 		FailureMessage:    fm,
+		ImageTag:          stringToNilable(a.ImageTag),
 		Artifacts:         aas,
 		OwnerId:           string(a.Owner.ID),
 	}, nil
@@ -465,6 +621,87 @@ func AnalysesToOAPI(as []*pacta.Analysis) ([]*api.Analysis, error) {
 	return convAll(as, AnalysisToOAPI)
 }
 
+func taskTypeToOAPI(tt pacta.TaskType) (api.TaskType, error) {
+	switch tt {
+	case pacta.TaskType_ParsePortfolio:
+		return api.TaskTypeParsePortfolio, nil
+	case pacta.TaskType_CreateReport:
+		return api.TaskTypeCreateReport, nil
+	case pacta.TaskType_CreateAudit:
+		return api.TaskTypeCreateAudit, nil
+	case pacta.TaskType_CreateDashboard:
+		return api.TaskTypeCreateDashboard, nil
+	}
+	return "", fmt.Errorf("unknown task type: %q", tt)
+}
+
+func taskStateToOAPI(ts pacta.TaskState) (api.TaskState, error) {
+	switch ts {
+	case pacta.TaskState_Queued:
+		return api.TaskStateQueued, nil
+	case pacta.TaskState_Running:
+		return api.TaskStateRunning, nil
+	case pacta.TaskState_Succeeded:
+		return api.TaskStateSucceeded, nil
+	case pacta.TaskState_Failed:
+		return api.TaskStateFailed, nil
+	}
+	return "", fmt.Errorf("unknown task state: %q", ts)
+}
+
+func RunnerStatusToOAPI(s task.Status) (api.RunnerStatus, error) {
+	switch s {
+	case task.StatusPending:
+		return api.RunnerStatusPending, nil
+	case task.StatusRunning:
+		return api.RunnerStatusRunning, nil
+	case task.StatusSucceeded:
+		return api.RunnerStatusSucceeded, nil
+	case task.StatusFailed:
+		return api.RunnerStatusFailed, nil
+	case task.StatusCancelled:
+		return api.RunnerStatusCancelled, nil
+	case task.StatusUnknown:
+		return api.RunnerStatusUnknown, nil
+	}
+	return "", fmt.Errorf("unknown runner status: %q", s)
+}
+
+func TaskToOAPI(t *pacta.Task) (*api.Task, error) {
+	if t == nil {
+		return nil, oapierr.Internal("taskToOAPI: can't convert nil pointer")
+	}
+	tt, err := taskTypeToOAPI(t.Type)
+	if err != nil {
+		return nil, oapierr.Internal("taskToOAPI: taskTypeToOAPI failed", zap.Error(err))
+	}
+	ts, err := taskStateToOAPI(t.State)
+	if err != nil {
+		return nil, oapierr.Internal("taskToOAPI: taskStateToOAPI failed", zap.Error(err))
+	}
+	var aID *string
+	if t.Analysis != nil {
+		aID = stringToNilable(t.Analysis.ID)
+	}
+	return &api.Task{
+		Id:           string(t.ID),
+		TaskType:     tt,
+		State:        ts,
+		AnalysisId:   aID,
+		RunnerId:     stringToNilable(t.RunnerID),
+		ImageTag:     stringToNilable(t.ImageTag),
+		AttemptCount: t.AttemptCount,
+		CreatedAt:    t.CreatedAt,
+		StartedAt:    timeToNilable(t.StartedAt),
+		CompletedAt:  timeToNilable(t.CompletedAt),
+		OwnerId:      string(t.Owner.ID),
+	}, nil
+}
+
+func TasksToOAPI(ts []*pacta.Task) ([]*api.Task, error) {
+	return convAll(ts, TaskToOAPI)
+}
+
 func auditLogActorTypeToOAPI(i pacta.AuditLogActorType) (api.AuditLogActorType, error) {
 	switch i {
 	case pacta.AuditLogActorType_Public:
@@ -477,6 +714,14 @@ func auditLogActorTypeToOAPI(i pacta.AuditLogActorType) (api.AuditLogActorType,
 		return api.AuditLogActorTypeSuperAdmin, nil
 	case pacta.AuditLogActorType_System:
 		return api.AuditLogActorTypeSystem, nil
+	case pacta.AuditLogActorType_InitiativeViewer:
+		return api.AuditLogActorTypeInitiativeViewer, nil
+	case pacta.AuditLogActorType_InitiativePortfolioContributor:
+		return api.AuditLogActorTypeInitiativePortfolioContributor, nil
+	case pacta.AuditLogActorType_InitiativeAnalyst:
+		return api.AuditLogActorTypeInitiativeAnalyst, nil
+	case pacta.AuditLogActorType_InitiativeManager:
+		return api.AuditLogActorTypeInitiativeManager, nil
 	}
 	return "", oapierr.Internal(fmt.Sprintf("auditLogActorTypeToOAPI: unknown actor type: %q", i))
 }
@@ -507,6 +752,12 @@ func auditLogActionToOAPI(i pacta.AuditLogAction) (api.AuditLogAction, error) {
 		return api.AuditLogActionReadMetadata, nil
 	case pacta.AuditLogAction_TransferOwnership:
 		return api.AuditLogActionTransferOwnership, nil
+	case pacta.AuditLogAction_Retry:
+		return api.AuditLogActionRetry, nil
+	case pacta.AuditLogAction_Cancel:
+		return api.AuditLogActionCancel, nil
+	case pacta.AuditLogAction_RevokeSessions:
+		return api.AuditLogActionRevokeSessions, nil
 	}
 	return "", oapierr.Internal(fmt.Sprintf("auditLogActionToOAPI: unknown action: %q", i))
 }
@@ -531,6 +782,12 @@ func auditLogTargetTypeToOAPI(i pacta.AuditLogTargetType) (api.AuditLogTargetTyp
 		return api.AuditLogTargetTypeAnalysis, nil
 	case pacta.AuditLogTargetType_AnalysisArtifact:
 		return api.AuditLogTargetTypeAnalysisArtifact, nil
+	case pacta.AuditLogTargetType_Task:
+		return api.AuditLogTargetTypeTask, nil
+	case pacta.AuditLogTargetType_APIToken:
+		return api.AuditLogTargetTypeAPIToken, nil
+	case pacta.AuditLogTargetType_UploadLimits:
+		return api.AuditLogTargetTypeUploadLimits, nil
 	}
 	return "", oapierr.Internal(fmt.Sprintf("auditLogTargetTypeToOAPI: unknown target type: %q", i))
 }
@@ -579,6 +836,7 @@ func AuditLogToOAPI(al *pacta.AuditLog) (*api.AuditLog, error) {
 		ActorType:            at,
 		ActorId:              stringToNilable(al.ActorID),
 		ActorOwnerId:         aoi,
+		ActorApiTokenId:      stringToNilable(al.ActorAPITokenID),
 		Action:               act,
 		PrimaryTargetType:    ptt,
 		PrimaryTargetId:      al.PrimaryTargetID,
@@ -0,0 +1,178 @@
+package pactasrv
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	api "github.com/RMI/pacta/openapi/pacta"
+)
+
+// The columns of a parsed portfolio CSV that we know how to type. The parser
+// normalizes column names, but we're lenient about case and whitespace anyway.
+const (
+	holdingsColISIN          = "isin"
+	holdingsColMarketValue   = "market_value"
+	holdingsColCurrency      = "currency"
+	holdingsColInvestorName  = "investor_name"
+	holdingsColPortfolioName = "portfolio_name"
+)
+
+type holding struct {
+	// RowNumber is the 1-based index of the row in the portfolio, not counting
+	// the header.
+	RowNumber     int
+	ISIN          string
+	MarketValue   *float64
+	Currency      string
+	InvestorName  string
+	PortfolioName string
+}
+
+type holdingsCurrencyTotal struct {
+	Currency   string
+	TotalValue float64
+	RowCount   int
+}
+
+type holdingsSummary struct {
+	RowCount                  int
+	TotalValue                float64
+	MissingIdentifierRowCount int
+	MissingValueRowCount      int
+	// Currencies is ordered by first appearance in the portfolio.
+	Currencies []*holdingsCurrencyTotal
+}
+
+type holdingsPage struct {
+	Holdings    []*holding
+	Summary     *holdingsSummary
+	HasNextPage bool
+}
+
+// readHoldings reads the parsed portfolio CSV from r, returning up to limit
+// rows starting after offset rows, along with summary statistics over all of
+// the rows. Rows are read one at a time, so only the requested page is held in
+// memory.
+func readHoldings(r io.Reader, offset, limit int) (*holdingsPage, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return &holdingsPage{Summary: &holdingsSummary{}}, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read header: %w", err)
+	}
+	cols := make(map[string]int)
+	for i, h := range header {
+		h = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		if _, ok := cols[h]; !ok {
+			cols[h] = i
+		}
+	}
+	field := func(rec []string, col string) string {
+		i, ok := cols[col]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+
+	page := &holdingsPage{Summary: &holdingsSummary{}}
+	sum := page.Summary
+	currencies := make(map[string]*holdingsCurrencyTotal)
+	for {
+		rec, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read row %d: %w", sum.RowCount+1, err)
+		}
+		sum.RowCount++
+		h := &holding{
+			RowNumber:     sum.RowCount,
+			ISIN:          field(rec, holdingsColISIN),
+			Currency:      strings.ToUpper(field(rec, holdingsColCurrency)),
+			InvestorName:  field(rec, holdingsColInvestorName),
+			PortfolioName: field(rec, holdingsColPortfolioName),
+		}
+		if mv, err := strconv.ParseFloat(field(rec, holdingsColMarketValue), 64); err == nil {
+			h.MarketValue = &mv
+		}
+
+		if h.ISIN == "" {
+			sum.MissingIdentifierRowCount++
+		}
+		if h.MarketValue == nil {
+			sum.MissingValueRowCount++
+		} else {
+			sum.TotalValue += *h.MarketValue
+		}
+		ct, ok := currencies[h.Currency]
+		if !ok {
+			ct = &holdingsCurrencyTotal{Currency: h.Currency}
+			currencies[h.Currency] = ct
+			sum.Currencies = append(sum.Currencies, ct)
+		}
+		ct.RowCount++
+		if h.MarketValue != nil {
+			ct.TotalValue += *h.MarketValue
+		}
+
+		switch {
+		case sum.RowCount <= offset:
+			// Before the requested page.
+		case len(page.Holdings) < limit:
+			page.Holdings = append(page.Holdings, h)
+		default:
+			page.HasNextPage = true
+		}
+	}
+	return page, nil
+}
+
+func holdingsPageToOAPI(page *holdingsPage, offset int) api.ListPortfolioHoldingsResp {
+	holdings := make([]api.PortfolioHolding, 0, len(page.Holdings))
+	for _, h := range page.Holdings {
+		holdings = append(holdings, api.PortfolioHolding{
+			RowNumber:     h.RowNumber,
+			Isin:          h.ISIN,
+			MarketValue:   h.MarketValue,
+			Currency:      h.Currency,
+			InvestorName:  nonEmptyPtr(h.InvestorName),
+			PortfolioName: nonEmptyPtr(h.PortfolioName),
+		})
+	}
+	currencies := make([]api.PortfolioHoldingsCurrencyTotal, 0, len(page.Summary.Currencies))
+	for _, c := range page.Summary.Currencies {
+		currencies = append(currencies, api.PortfolioHoldingsCurrencyTotal{
+			Currency:   c.Currency,
+			TotalValue: c.TotalValue,
+			RowCount:   c.RowCount,
+		})
+	}
+	return api.ListPortfolioHoldingsResp{
+		Holdings: holdings,
+		Summary: api.PortfolioHoldingsSummary{
+			RowCount:                  page.Summary.RowCount,
+			TotalValue:                page.Summary.TotalValue,
+			MissingIdentifierRowCount: page.Summary.MissingIdentifierRowCount,
+			MissingValueRowCount:      page.Summary.MissingValueRowCount,
+			Currencies:                currencies,
+		},
+		HasNextPage: page.HasNextPage,
+		Cursor:      strconv.Itoa(offset + len(page.Holdings)),
+	}
+}
+
+func nonEmptyPtr(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
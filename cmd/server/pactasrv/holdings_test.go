@@ -0,0 +1,85 @@
+package pactasrv
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+const testHoldingsCSV = "\ufeffInvestor_Name,Portfolio_Name,ISIN,Market_Value,Currency\n" +
+	"Inv,Port,XS1,100.5,usd\n" +
+	"Inv,Port,,20,EUR\n" +
+	"Inv,Port,XS3,,USD\n" +
+	"Inv,Port,XS4,not a number,EUR\n" +
+	"Inv,Port,XS5,4\n"
+
+func TestReadHoldings(t *testing.T) {
+	got, err := readHoldings(strings.NewReader(testHoldingsCSV), 0, 2)
+	if err != nil {
+		t.Fatalf("readHoldings: %v", err)
+	}
+	want := &holdingsPage{
+		Holdings: []*holding{
+			{RowNumber: 1, ISIN: "XS1", MarketValue: ptr(100.5), Currency: "USD", InvestorName: "Inv", PortfolioName: "Port"},
+			{RowNumber: 2, MarketValue: ptr(20.0), Currency: "EUR", InvestorName: "Inv", PortfolioName: "Port"},
+		},
+		Summary: &holdingsSummary{
+			RowCount:                  5,
+			TotalValue:                124.5,
+			MissingIdentifierRowCount: 1,
+			MissingValueRowCount:      2,
+			Currencies: []*holdingsCurrencyTotal{
+				{Currency: "USD", TotalValue: 100.5, RowCount: 2},
+				{Currency: "EUR", TotalValue: 20, RowCount: 2},
+				{Currency: "", TotalValue: 4, RowCount: 1},
+			},
+		},
+		HasNextPage: true,
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("unexpected page (-want +got)\n%s", diff)
+	}
+}
+
+func TestReadHoldingsPages(t *testing.T) {
+	tests := []struct {
+		offset, limit int
+		wantRows      []int
+		wantNext      bool
+	}{
+		{offset: 0, limit: 5, wantRows: []int{1, 2, 3, 4, 5}, wantNext: false},
+		{offset: 2, limit: 2, wantRows: []int{3, 4}, wantNext: true},
+		{offset: 4, limit: 2, wantRows: []int{5}, wantNext: false},
+		{offset: 10, limit: 2, wantRows: nil, wantNext: false},
+	}
+	for _, test := range tests {
+		page, err := readHoldings(strings.NewReader(testHoldingsCSV), test.offset, test.limit)
+		if err != nil {
+			t.Fatalf("readHoldings(%d, %d): %v", test.offset, test.limit, err)
+		}
+		var rows []int
+		for _, h := range page.Holdings {
+			rows = append(rows, h.RowNumber)
+		}
+		if diff := cmp.Diff(test.wantRows, rows); diff != "" {
+			t.Errorf("readHoldings(%d, %d) unexpected rows (-want +got)\n%s", test.offset, test.limit, diff)
+		}
+		if page.HasNextPage != test.wantNext {
+			t.Errorf("readHoldings(%d, %d).HasNextPage = %t, want %t", test.offset, test.limit, page.HasNextPage, test.wantNext)
+		}
+		if page.Summary.RowCount != 5 {
+			t.Errorf("readHoldings(%d, %d).Summary.RowCount = %d, want 5", test.offset, test.limit, page.Summary.RowCount)
+		}
+	}
+}
+
+func TestReadHoldingsEmpty(t *testing.T) {
+	got, err := readHoldings(strings.NewReader(""), 0, 10)
+	if err != nil {
+		t.Fatalf("readHoldings: %v", err)
+	}
+	if diff := cmp.Diff(&holdingsPage{Summary: &holdingsSummary{}}, got); diff != "" {
+		t.Errorf("unexpected page (-want +got)\n%s", diff)
+	}
+}
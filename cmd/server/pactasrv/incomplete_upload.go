@@ -3,12 +3,14 @@ package pactasrv
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
 	"github.com/RMI/pacta/db"
 	"github.com/RMI/pacta/oapierr"
 	api "github.com/RMI/pacta/openapi/pacta"
 	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
 	"go.uber.org/zap"
 )
 
@@ -36,11 +38,11 @@ func (s *Server) DeleteIncompleteUpload(ctx context.Context, request api.DeleteI
 	if err := s.incompleteUploadDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Delete); err != nil {
 		return nil, err
 	}
-	blobURI, err := s.DB.DeleteIncompleteUpload(s.DB.NoTxn(ctx), id)
+	blobURIs, err := s.DB.DeleteIncompleteUpload(s.DB.NoTxn(ctx), id)
 	if err != nil {
 		return nil, oapierr.Internal("failed to delete incomplete upload", zap.Error(err))
 	}
-	if err := s.deleteBlobs(ctx, blobURI); err != nil {
+	if err := s.deleteBlobs(ctx, blobURIs...); err != nil {
 		return nil, err
 	}
 	return api.DeleteIncompleteUpload204Response{}, nil
@@ -105,6 +107,117 @@ func (s *Server) UpdateIncompleteUpload(ctx context.Context, request api.UpdateI
 	return api.UpdateIncompleteUpload204Response{}, nil
 }
 
+// Retries parsing a failed incomplete upload
+// (POST /incomplete-upload/{id}:retry)
+func (s *Server) RetryIncompleteUpload(ctx context.Context, request api.RetryIncompleteUploadRequestObject) (api.RetryIncompleteUploadResponseObject, error) {
+	id := pacta.IncompleteUploadID(request.Id)
+	if err := s.incompleteUploadDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Retry); err != nil {
+		return nil, err
+	}
+
+	var (
+		taskID pacta.TaskID
+		req    *task.ParsePortfolioRequest
+		failed *pacta.IncompleteUpload
+	)
+	now := s.Now()
+	err := s.DB.Transactional(ctx, func(tx db.Tx) error {
+		// Like with analyses, this serializes concurrent retries.
+		if err := s.DB.LockIncompleteUpload(tx, id); err != nil {
+			return oapierr.Internal("failed to lock incomplete upload", zap.String("incomplete_upload_id", string(id)), zap.Error(err))
+		}
+		iu, err := s.DB.IncompleteUpload(tx, id)
+		if err != nil {
+			return oapierr.Internal("failed to look up incomplete upload", zap.String("incomplete_upload_id", string(id)), zap.Error(err))
+		}
+		if iu.FailureCode == "" {
+			return oapierr.Conflict("only failed incomplete uploads can be retried", zap.String("incomplete_upload_id", string(id)))
+		}
+		blobs, err := s.DB.Blobs(tx, []pacta.BlobID{iu.Blob.ID})
+		if err != nil {
+			return oapierr.Internal("failed to query blob", zap.String("incomplete_upload_id", string(id)), zap.Error(err))
+		}
+		limits, err := s.uploadLimits(tx)
+		if err != nil {
+			return err
+		}
+		if req, err = s.parsePortfolioRequest([]*pacta.IncompleteUpload{iu}, blobs, limits); err != nil {
+			return err
+		}
+		encodedReq, err := encodeParsePortfolioRequest(req)
+		if err != nil {
+			return err
+		}
+
+		// Uploads may have been parsed alongside others, so the previous tasks can
+		// be shared with siblings. The retry task is for just this upload.
+		prev, err := s.DB.TasksForIncompleteUpload(tx, id)
+		if err != nil {
+			return oapierr.Internal("failed to query tasks for incomplete upload", zap.String("incomplete_upload_id", string(id)), zap.Error(err))
+		}
+		taskID, err = s.createRetryTask(tx, prev, &pacta.Task{
+			Type:    pacta.TaskType_ParsePortfolio,
+			Owner:   &pacta.Owner{ID: iu.Owner.ID},
+			Request: encodedReq,
+			State:   pacta.TaskState_Queued,
+		})
+		if err != nil {
+			return err
+		}
+
+		// Like with analyses, the failure is cleared alongside creating the
+		// task, and put back if the task can't be dispatched.
+		failed = iu
+		err = s.DB.UpdateIncompleteUpload(tx, id,
+			db.SetIncompleteUploadFailureCode(""),
+			db.SetIncompleteUploadFailureMessage(""),
+			db.SetIncompleteUploadCompletedAt(time.Time{}),
+			db.SetIncompleteUploadRanAt(now))
+		if err != nil {
+			return oapierr.Internal("failed to clear incomplete upload failure", zap.String("incomplete_upload_id", string(id)), zap.Error(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	runnerID, err := s.TaskRunner.ParsePortfolio(ctx, task.ID(taskID), req)
+	if err != nil {
+		s.restoreIncompleteUploadFailure(ctx, failed)
+		return nil, oapierr.Internal("failed to start task", zap.Error(err))
+	}
+	s.Logger.Info("retried parse portfolio task",
+		zap.String("task_id", string(taskID)),
+		zap.String("task_runner_id", string(runnerID)),
+		zap.String("incomplete_upload_id", string(id)))
+	return api.RetryIncompleteUpload204Response{}, nil
+}
+
+// restoreIncompleteUploadFailure puts back the failure that a retry cleared,
+// see restoreAnalysisFailure.
+func (s *Server) restoreIncompleteUploadFailure(ctx context.Context, failed *pacta.IncompleteUpload) {
+	err := s.DB.Transactional(context.WithoutCancel(ctx), func(tx db.Tx) error {
+		if err := s.DB.LockIncompleteUpload(tx, failed.ID); err != nil {
+			return fmt.Errorf("locking incomplete upload: %w", err)
+		}
+		iu, err := s.DB.IncompleteUpload(tx, failed.ID)
+		if err != nil {
+			return fmt.Errorf("reading incomplete upload: %w", err)
+		}
+		if !iu.CompletedAt.IsZero() {
+			return nil
+		}
+		return s.DB.UpdateIncompleteUpload(tx, failed.ID,
+			db.SetIncompleteUploadFailureCode(failed.FailureCode),
+			db.SetIncompleteUploadFailureMessage(failed.FailureMessage),
+			db.SetIncompleteUploadCompletedAt(failed.CompletedAt))
+	})
+	if err != nil {
+		s.Logger.Error("failed to restore failure of incomplete upload whose retry wasn't dispatched", zap.String("incomplete_upload_id", string(failed.ID)), zap.Error(err))
+	}
+}
+
 func (s *Server) incompleteUploadDoAuthzAndAuditLog(ctx context.Context, iuID pacta.IncompleteUploadID, action pacta.AuditLogAction) error {
 	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
@@ -127,7 +240,7 @@ func (s *Server) incompleteUploadDoAuthzAndAuditLog(ctx context.Context, iuID pa
 	switch action {
 	case pacta.AuditLogAction_EnableAdminDebug, pacta.AuditLogAction_DisableAdminDebug:
 		as.isAuthorized, as.authorizedAsActorType = allowIfOwner(actorInfo, iu.Owner.ID)
-	case pacta.AuditLogAction_Update, pacta.AuditLogAction_Delete, pacta.AuditLogAction_ReadMetadata:
+	case pacta.AuditLogAction_Update, pacta.AuditLogAction_Delete, pacta.AuditLogAction_ReadMetadata, pacta.AuditLogAction_Retry:
 		as.isAuthorized, as.authorizedAsActorType = allowIfAdminOrOwner(actorInfo, iu.Owner.ID)
 	default:
 		return fmt.Errorf("unknown action %q for incomplete_upload authz", action)
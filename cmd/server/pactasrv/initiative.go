@@ -3,6 +3,7 @@ package pactasrv
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
 	"github.com/RMI/pacta/db"
@@ -162,10 +163,11 @@ func (s *Server) FindInitiativeById(ctx context.Context, request api.FindInitiat
 		i.InitiativeUserRelationships = relationships
 	} else if actorInfo.UserID != "" {
 		iur, err := s.DB.InitiativeUserRelationship(s.DB.NoTxn(ctx), i.ID, actorInfo.UserID)
-		if err != nil {
+		if err == nil {
+			i.InitiativeUserRelationships = []*pacta.InitiativeUserRelationship{iur}
+		} else if !db.IsNotFound(err) {
 			return nil, oapierr.Internal("failed to load singular initiative user relationship for initiative", zap.String("initiative_id", string(i.ID)), zap.Error(err))
 		}
-		i.InitiativeUserRelationships = []*pacta.InitiativeUserRelationship{iur}
 	}
 	if !info.CanSeeInternalInfo {
 		i.InternalDescription = ""
@@ -194,6 +196,39 @@ func (s *Server) ListInitiatives(ctx context.Context, request api.ListInitiative
 	return api.ListInitiatives200JSONResponse(result), nil
 }
 
+// Returns the analyses that have been run on the initiative
+// (GET /initiative/{id}/analyses)
+func (s *Server) ListInitiativeAnalyses(ctx context.Context, request api.ListInitiativeAnalysesRequestObject) (api.ListInitiativeAnalysesResponseObject, error) {
+	if _, err := s.getActorInfoOrErrIfAnon(ctx); err != nil {
+		return nil, err
+	}
+	id := pacta.InitiativeID(request.Id)
+	info, err := s.initiativeDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_ReadMetadata)
+	if err != nil {
+		return nil, err
+	}
+	if !info.CanViewResults {
+		return nil, oapierr.Forbidden("only members of the initiative can view its analyses", zap.String("initiative_id", string(id)))
+	}
+	aIDs, err := s.DB.AnalysesRunOnInitiative(s.DB.NoTxn(ctx), id)
+	if err != nil {
+		return nil, oapierr.Internal("failed to look up analyses run on initiative", zap.String("initiative_id", string(id)), zap.Error(err))
+	}
+	as, err := s.DB.Analyses(s.DB.NoTxn(ctx), aIDs)
+	if err != nil {
+		return nil, oapierr.Internal("failed to load analyses run on initiative", zap.String("initiative_id", string(id)), zap.Error(err))
+	}
+	sorted := values(as)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].CreatedAt.After(sorted[j].CreatedAt)
+	})
+	items, err := s.analysesToOAPI(ctx, sorted)
+	if err != nil {
+		return nil, err
+	}
+	return api.ListInitiativeAnalyses200JSONResponse{Items: items}, nil
+}
+
 // Returns all of the portfolios that are participating in the initiative
 // (GET /initiative/{id}/all-data)
 func (s *Server) AllInitiativeData(ctx context.Context, request api.AllInitiativeDataRequestObject) (api.AllInitiativeDataResponseObject, error) {
@@ -202,7 +237,7 @@ func (s *Server) AllInitiativeData(ctx context.Context, request api.AllInitiativ
 		return nil, err
 	}
 	id := pacta.InitiativeID(request.Id)
-	_, err = s.initiativeDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Download)
+	info, err := s.initiativeDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Download)
 	if err != nil {
 		return nil, err
 	}
@@ -225,15 +260,16 @@ func (s *Server) AllInitiativeData(ctx context.Context, request api.AllInitiativ
 	for _, p := range portfolios {
 		auditLogs = append(auditLogs, &pacta.AuditLog{
 			Action:               pacta.AuditLogAction_Download,
-			ActorType:            pacta.AuditLogActorType_Admin, // TODO(#12) When merging with #121, use the actor type from authorization
+			ActorType:            info.AuthorizedAsActorType,
 			ActorID:              string(actorInfo.UserID),
 			ActorOwner:           &pacta.Owner{ID: actorInfo.OwnerID},
+			ActorAPITokenID:      actorInfo.APITokenID,
 			PrimaryTargetType:    pacta.AuditLogTargetType_Portfolio,
 			PrimaryTargetID:      string(p.ID),
 			PrimaryTargetOwner:   p.Owner,
 			SecondaryTargetType:  pacta.AuditLogTargetType_Initiative,
 			SecondaryTargetID:    string(id),
-			SecondaryTargetOwner: &pacta.Owner{ID: "SYSTEM"}, // TODO(#12) When merging with #121, use the const type.
+			SecondaryTargetOwner: &pacta.Owner{ID: systemOwnedEntityOwner},
 		})
 	}
 	if err := s.DB.CreateAuditLogs(s.DB.NoTxn(ctx), auditLogs); err != nil {
@@ -241,7 +277,7 @@ func (s *Server) AllInitiativeData(ctx context.Context, request api.AllInitiativ
 	}
 
 	// Note, it is likely this code will need to be parallelized in the future - initiatives may eventually become large.
-	// However, since this action is unlikely to be taken frequently, and will only be taken by admins, getting the experience
+	// However, since this action is unlikely to be taken frequently, and will only be taken by analysts and admins, getting the experience
 	// perfect here is not a priority.
 	response := api.InitiativeAllData{}
 	for _, portfolio := range portfolios {
@@ -262,6 +298,8 @@ func (s *Server) AllInitiativeData(ctx context.Context, request api.AllInitiativ
 type initiativeAuthzVisibilityInfo struct {
 	CanManageUsersAndPortfolios bool
 	CanSeeInternalInfo          bool
+	CanViewResults              bool
+	AuthorizedAsActorType       pacta.AuditLogActorType
 }
 
 func (s *Server) initiativeDoAuthzAndAuditLog(ctx context.Context, iID pacta.InitiativeID, action pacta.AuditLogAction) (*initiativeAuthzVisibilityInfo, error) {
@@ -269,19 +307,11 @@ func (s *Server) initiativeDoAuthzAndAuditLog(ctx context.Context, iID pacta.Ini
 	if err != nil {
 		return nil, err
 	}
-	userIsInitiativeManager := false
-	userIsInitiativeMember := false
-	if actorInfo.UserID != "" {
-		iur, err := s.DB.InitiativeUserRelationship(s.DB.NoTxn(ctx), iID, actorInfo.UserID)
-		if err != nil {
-			if !db.IsNotFound(err) {
-				return nil, oapierr.Internal("failed to find initiative user relationships", zap.Error(err))
-			}
-		} else {
-			userIsInitiativeMember = iur.Member
-			userIsInitiativeManager = iur.Manager
-		}
+	role, err := s.initiativeRole(ctx, iID, actorInfo.UserID)
+	if err != nil {
+		return nil, err
 	}
+	isAdmin := actorInfo.IsAdmin || actorInfo.IsSuperAdmin
 	as := &authzStatus{
 		primaryTargetID:      string(iID),
 		primaryTargetType:    pacta.AuditLogTargetType_Initiative,
@@ -292,20 +322,16 @@ func (s *Server) initiativeDoAuthzAndAuditLog(ctx context.Context, iID pacta.Ini
 	switch action {
 	case pacta.AuditLogAction_ReadMetadata:
 		as.isAuthorized = true
-		if userIsInitiativeManager {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-		} else if actorInfo.IsAdmin || actorInfo.IsSuperAdmin {
+		as.authorizedAsActorType = initiativeRoleActorType(role)
+		if as.authorizedAsActorType == nil && isAdmin {
 			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Admin)
-		} else {
+		} else if as.authorizedAsActorType == nil {
 			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Public)
 		}
-	case pacta.AuditLogAction_Delete, pacta.AuditLogAction_Create, pacta.AuditLogAction_Update, pacta.AuditLogAction_Download:
-		if userIsInitiativeManager {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-			as.isAuthorized = true
-		} else {
-			as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
-		}
+	case pacta.AuditLogAction_Delete, pacta.AuditLogAction_Create, pacta.AuditLogAction_Update:
+		as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, role, pacta.InitiativeUserRole.CanManage)
+	case pacta.AuditLogAction_Download:
+		as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, role, pacta.InitiativeUserRole.CanAnalyze)
 	default:
 		return nil, fmt.Errorf("unknown action %q for initiative authz", action)
 	}
@@ -313,7 +339,9 @@ func (s *Server) initiativeDoAuthzAndAuditLog(ctx context.Context, iID pacta.Ini
 		return nil, err
 	}
 	return &initiativeAuthzVisibilityInfo{
-		CanSeeInternalInfo:          userIsInitiativeMember || userIsInitiativeManager || actorInfo.IsAdmin || actorInfo.IsSuperAdmin,
-		CanManageUsersAndPortfolios: userIsInitiativeManager || actorInfo.IsAdmin || actorInfo.IsSuperAdmin,
+		CanSeeInternalInfo:          role.CanViewResults() || isAdmin,
+		CanManageUsersAndPortfolios: role.CanManage() || isAdmin,
+		CanViewResults:              role.CanViewResults() || isAdmin,
+		AuthorizedAsActorType:       *as.authorizedAsActorType,
 	}, nil
 }
@@ -103,8 +103,16 @@ func (s *Server) ClaimInitiativeInvitation(ctx context.Context, request api.Clai
 		if err != nil {
 			return fmt.Errorf("updating initiative invite: %w", err)
 		}
+		// Users that are already part of the initiative keep their existing role,
+		// so following an invitation link can't demote a manager.
+		_, err = s.DB.InitiativeUserRelationship(tx, ii.Initiative.ID, userID)
+		if err == nil {
+			return nil
+		} else if !db.IsNotFound(err) {
+			return fmt.Errorf("looking up initiative membership: %w", err)
+		}
 		err = s.DB.UpdateInitiativeUserRelationship(tx, ii.Initiative.ID, userID,
-			db.SetInitiativeUserRelationshipMember(true))
+			db.SetInitiativeUserRelationshipRole(ii.Role))
 		if err != nil {
 			return fmt.Errorf("creating initiative membership: %w", err)
 		}
@@ -145,16 +153,11 @@ func (s *Server) userIsInitiativeManagerOrAdmin(ctx context.Context, iID pacta.I
 	if actorInfo.IsAdmin || actorInfo.IsSuperAdmin {
 		return true, nil
 	}
-	iurs, err := s.DB.InitiativeUserRelationshipsByInitiative(s.DB.NoTxn(ctx), iID)
+	role, err := s.initiativeRole(ctx, iID, actorInfo.UserID)
 	if err != nil {
-		return false, oapierr.Internal("failed to list initiative user relationships", zap.Error(err))
-	}
-	for _, iur := range iurs {
-		if iur.User.ID == actorInfo.UserID && iur.Manager {
-			return true, nil
-		}
+		return false, err
 	}
-	return false, nil
+	return role.CanManage(), nil
 }
 
 func (s *Server) initiativeInvitationDoAuthzAndAuditLog(ctx context.Context, iID pacta.InitiativeID, iiID pacta.InitiativeInvitationID, action pacta.AuditLogAction) error {
@@ -162,16 +165,9 @@ func (s *Server) initiativeInvitationDoAuthzAndAuditLog(ctx context.Context, iID
 	if err != nil {
 		return err
 	}
-	iurs, err := s.DB.InitiativeUserRelationshipsByInitiative(s.DB.NoTxn(ctx), iID)
+	role, err := s.initiativeRole(ctx, iID, actorInfo.UserID)
 	if err != nil {
-		return oapierr.Internal("failed to list initiative user relationships", zap.Error(err))
-	}
-	actorIsInitiativeManager := false
-	for _, iur := range iurs {
-		if iur.User.ID == actorInfo.UserID {
-			actorIsInitiativeManager = iur.Manager
-			break
-		}
+		return err
 	}
 	as := &authzStatus{
 		primaryTargetID:      string(iID),
@@ -187,12 +183,7 @@ func (s *Server) initiativeInvitationDoAuthzAndAuditLog(ctx context.Context, iID
 	}
 	switch action {
 	case pacta.AuditLogAction_Delete, pacta.AuditLogAction_ReadMetadata, pacta.AuditLogAction_Create:
-		if actorIsInitiativeManager {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-			as.isAuthorized = true
-		} else {
-			as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
-		}
+		as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, role, pacta.InitiativeUserRole.CanManage)
 	default:
 		return fmt.Errorf("unknown action %q for initiative_invitation authz", action)
 	}
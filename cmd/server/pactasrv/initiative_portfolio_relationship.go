@@ -64,22 +64,9 @@ func (s *Server) initiativePortfolioRelationshipDoAuthzAndAuditLog(ctx context.C
 		}
 		return oapierr.Internal("failed to look up initiative", zap.String("initiative_id", string(iID)), zap.Error(err))
 	}
-	iurs, err := s.DB.InitiativeUserRelationshipsByInitiative(s.DB.NoTxn(ctx), iID)
+	role, err := s.initiativeRole(ctx, iID, actorInfo.UserID)
 	if err != nil {
-		return oapierr.Internal("failed to list initiative user relationships", zap.Error(err))
-	}
-	actorIsInitiativeManager := false
-	actorIsInitiativeMember := false
-	for _, iur := range iurs {
-		if iur.User.ID == actorInfo.UserID {
-			if iur.Manager {
-				actorIsInitiativeManager = true
-			}
-			if iur.Member {
-				actorIsInitiativeMember = true
-			}
-			break
-		}
+		return err
 	}
 	p, err := s.DB.Portfolio(s.DB.NoTxn(ctx), pID)
 	if err != nil {
@@ -99,29 +86,18 @@ func (s *Server) initiativePortfolioRelationshipDoAuthzAndAuditLog(ctx context.C
 		actorInfo:              actorInfo,
 		action:                 action,
 	}
+	// Managers can add and remove any portfolio, contributors can only add and
+	// remove their own.
+	canChangePortfolio := func(r pacta.InitiativeUserRole) bool {
+		return r.CanManage() || (r.CanContributePortfolios() && targetIsOwnedByActor)
+	}
 	switch action {
 	case pacta.AuditLogAction_AddTo:
 		if i.IsAcceptingNewPortfolios {
-			if actorIsInitiativeManager {
-				as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-				as.isAuthorized = true
-			} else if actorIsInitiativeMember && targetIsOwnedByActor {
-				as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Public)
-				as.isAuthorized = true
-			} else {
-				as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
-			}
+			as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, role, canChangePortfolio)
 		}
 	case pacta.AuditLogAction_RemoveFrom:
-		if actorIsInitiativeManager {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-			as.isAuthorized = true
-		} else if actorIsInitiativeMember && targetIsOwnedByActor {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Public)
-			as.isAuthorized = true
-		} else {
-			as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
-		}
+		as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, role, canChangePortfolio)
 	default:
 		return fmt.Errorf("unknown action %q for initiative_portfolio_relationship authz", action)
 	}
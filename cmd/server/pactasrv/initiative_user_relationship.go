@@ -35,33 +35,113 @@ func (s *Server) ListInitiativeUserRelationshipsByUser(ctx context.Context, requ
 func (s *Server) UpdateInitiativeUserRelationship(ctx context.Context, request api.UpdateInitiativeUserRelationshipRequestObject) (api.UpdateInitiativeUserRelationshipResponseObject, error) {
 	iID := pacta.InitiativeID(request.InitiativeId)
 	uID := pacta.UserID(request.UserId)
-	mutations := []db.UpdateInitiativeUserRelationshipFn{}
-	if request.Body.Manager != nil {
-		if err := s.initiativeUserRelationshipDoAuthzAndAuditLog(ctx, iID, uID, pacta.AuditLogAction_Update); err != nil {
-			return nil, err
-		}
-		mutations = append(mutations, db.SetInitiativeUserRelationshipManager(*request.Body.Manager))
+	role, err := conv.InitiativeUserRoleFromOAPI(request.Body.Role)
+	if err != nil {
+		return nil, err
+	}
+	existing, err := s.initiativeRole(ctx, iID, uID)
+	if err != nil {
+		return nil, err
+	}
+	action := pacta.AuditLogAction_Update
+	if existing == "" {
+		action = pacta.AuditLogAction_AddTo
 	}
-	if request.Body.Member != nil {
-		if *request.Body.Member {
-			if err := s.initiativeUserRelationshipDoAuthzAndAuditLog(ctx, iID, uID, pacta.AuditLogAction_AddTo); err != nil {
-				return nil, err
+	if err := s.initiativeUserRelationshipDoAuthzAndAuditLog(ctx, iID, uID, role, action); err != nil {
+		return nil, err
+	}
+	err = s.DB.Transactional(ctx, func(tx db.Tx) error {
+		if !role.CanManage() {
+			if err := s.checkNotLastManager(tx, iID, uID); err != nil {
+				return err
 			}
+		}
+		if err := s.DB.UpdateInitiativeUserRelationship(tx, iID, uID, db.SetInitiativeUserRelationshipRole(role)); err != nil {
+			return oapierr.Internal("failed to update initiative user relationship", zap.Error(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.UpdateInitiativeUserRelationship204Response{}, nil
+}
+
+// Removes a user from an initiative
+// (DELETE /initiative/{initiativeId}/user-relationship/{userId})
+func (s *Server) DeleteInitiativeUserRelationship(ctx context.Context, request api.DeleteInitiativeUserRelationshipRequestObject) (api.DeleteInitiativeUserRelationshipResponseObject, error) {
+	iID := pacta.InitiativeID(request.InitiativeId)
+	uID := pacta.UserID(request.UserId)
+	if err := s.initiativeUserRelationshipDoAuthzAndAuditLog(ctx, iID, uID, "", pacta.AuditLogAction_RemoveFrom); err != nil {
+		return nil, err
+	}
+	err := s.DB.Transactional(ctx, func(tx db.Tx) error {
+		if err := s.checkNotLastManager(tx, iID, uID); err != nil {
+			return err
+		}
+		if err := s.DB.DeleteInitiativeUserRelationship(tx, iID, uID); err != nil {
+			return oapierr.Internal("failed to delete initiative user relationship", zap.Error(err))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return api.DeleteInitiativeUserRelationship204Response{}, nil
+}
+
+// checkNotLastManager returns an error if the user is the initiative's only
+// manager, since removing or demoting them would leave nobody to manage it.
+func (s *Server) checkNotLastManager(tx db.Tx, iID pacta.InitiativeID, uID pacta.UserID) error {
+	iurs, err := s.DB.InitiativeUserRelationshipsByInitiative(tx, iID)
+	if err != nil {
+		return oapierr.Internal("failed to list initiative user relationships", zap.String("initiative_id", string(iID)), zap.Error(err))
+	}
+	if isLastManager(iurs, uID) {
+		return oapierr.Conflict("an initiative needs at least one manager",
+			zap.String("initiative_id", string(iID)),
+			zap.String("user_id", string(uID)))
+	}
+	return nil
+}
+
+func isLastManager(iurs []*pacta.InitiativeUserRelationship, uID pacta.UserID) bool {
+	isManager, otherManagers := false, 0
+	for _, iur := range iurs {
+		if !iur.Role.CanManage() {
+			continue
+		}
+		if iur.User.ID == uID {
+			isManager = true
 		} else {
-			if err := s.initiativeUserRelationshipDoAuthzAndAuditLog(ctx, iID, uID, pacta.AuditLogAction_RemoveFrom); err != nil {
-				return nil, err
-			}
+			otherManagers++
 		}
-		mutations = append(mutations, db.SetInitiativeUserRelationshipMember(*request.Body.Member))
 	}
-	err := s.DB.UpdateInitiativeUserRelationship(s.DB.NoTxn(ctx), pacta.InitiativeID(request.InitiativeId), pacta.UserID(request.UserId), mutations...)
+	return isManager && otherManagers == 0
+}
+
+// initiativeRole returns the role that the user has in the initiative, or the
+// zero value if they aren't part of it.
+func (s *Server) initiativeRole(ctx context.Context, iID pacta.InitiativeID, uID pacta.UserID) (pacta.InitiativeUserRole, error) {
+	if uID == "" {
+		return "", nil
+	}
+	iur, err := s.DB.InitiativeUserRelationship(s.DB.NoTxn(ctx), iID, uID)
 	if err != nil {
-		return nil, oapierr.Internal("failed to update initiative user relationship", zap.Error(err))
+		if db.IsNotFound(err) {
+			return "", nil
+		}
+		return "", oapierr.Internal("failed to look up initiative user relationship",
+			zap.String("initiative_id", string(iID)),
+			zap.String("user_id", string(uID)),
+			zap.Error(err))
 	}
-	return api.UpdateInitiativeUserRelationship204Response{}, nil
+	return iur.Role, nil
 }
 
-func (s *Server) initiativeUserRelationshipDoAuthzAndAuditLog(ctx context.Context, iID pacta.InitiativeID, targetUserID pacta.UserID, action pacta.AuditLogAction) error {
+// newRole is the role being given to the target user, and is only used when
+// adding them to the initiative.
+func (s *Server) initiativeUserRelationshipDoAuthzAndAuditLog(ctx context.Context, iID pacta.InitiativeID, targetUserID pacta.UserID, newRole pacta.InitiativeUserRole, action pacta.AuditLogAction) error {
 	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
 		return err
@@ -73,16 +153,9 @@ func (s *Server) initiativeUserRelationshipDoAuthzAndAuditLog(ctx context.Contex
 		}
 		return oapierr.Internal("failed to retrieve initiative", zap.Error(err))
 	}
-	iurs, err := s.DB.InitiativeUserRelationshipsByInitiative(s.DB.NoTxn(ctx), iID)
+	actorRole, err := s.initiativeRole(ctx, iID, actorInfo.UserID)
 	if err != nil {
-		return oapierr.Internal("failed to list initiative user relationships", zap.Error(err))
-	}
-	actorIsInitiativeManager := false
-	for _, iur := range iurs {
-		if iur.User.ID == actorInfo.UserID && iur.Manager {
-			actorIsInitiativeManager = true
-			break
-		}
+		return err
 	}
 	targetOwnerID, err := s.DB.GetOwnerForUser(s.DB.NoTxn(ctx), targetUserID)
 	if err != nil {
@@ -102,10 +175,10 @@ func (s *Server) initiativeUserRelationshipDoAuthzAndAuditLog(ctx context.Contex
 	switch action {
 	case pacta.AuditLogAction_AddTo:
 		if i.IsAcceptingNewMembers {
-			if actorIsInitiativeManager {
-				as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-				as.isAuthorized = true
-			} else if targetIsActor && !i.RequiresInvitationToJoin {
+			if actorRole.CanManage() {
+				as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRole(actorRole, pacta.InitiativeUserRole.CanManage)
+			} else if targetIsActor && !i.RequiresInvitationToJoin && newRole == pacta.InitiativeUserRole_PortfolioContributor {
+				// Anyone can join an open initiative, but only to contribute portfolios.
 				as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Public)
 				as.isAuthorized = true
 			} else {
@@ -113,19 +186,16 @@ func (s *Server) initiativeUserRelationshipDoAuthzAndAuditLog(ctx context.Contex
 			}
 		}
 	case pacta.AuditLogAction_Update:
-		if actorIsInitiativeManager {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-			as.isAuthorized = true
-		} else {
-			as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
-		}
+		as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRoleOrAdmin(actorInfo, actorRole, pacta.InitiativeUserRole.CanManage)
 	case pacta.AuditLogAction_RemoveFrom:
-		if actorIsInitiativeManager {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
-			as.isAuthorized = true
+		if actorRole.CanManage() {
+			as.isAuthorized, as.authorizedAsActorType = allowIfInitiativeRole(actorRole, pacta.InitiativeUserRole.CanManage)
 		} else if targetIsActor {
-			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Public)
+			// Anyone can leave an initiative.
 			as.isAuthorized = true
+			if as.authorizedAsActorType = initiativeRoleActorType(actorRole); as.authorizedAsActorType == nil {
+				as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Public)
+			}
 		} else {
 			as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
 		}
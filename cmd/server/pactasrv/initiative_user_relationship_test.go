@@ -0,0 +1,67 @@
+package pactasrv
+
+import (
+	"testing"
+
+	"github.com/RMI/pacta/pacta"
+)
+
+func TestIsLastManager(t *testing.T) {
+	iur := func(uID pacta.UserID, role pacta.InitiativeUserRole) *pacta.InitiativeUserRelationship {
+		return &pacta.InitiativeUserRelationship{
+			Initiative: &pacta.Initiative{ID: "initiative.id1"},
+			User:       &pacta.User{ID: uID},
+			Role:       role,
+		}
+	}
+	tests := []struct {
+		name string
+		iurs []*pacta.InitiativeUserRelationship
+		uID  pacta.UserID
+		want bool
+	}{
+		{
+			name: "only manager",
+			iurs: []*pacta.InitiativeUserRelationship{
+				iur("user.manager", pacta.InitiativeUserRole_Manager),
+				iur("user.viewer", pacta.InitiativeUserRole_Viewer),
+			},
+			uID:  "user.manager",
+			want: true,
+		},
+		{
+			name: "one of several managers",
+			iurs: []*pacta.InitiativeUserRelationship{
+				iur("user.manager1", pacta.InitiativeUserRole_Manager),
+				iur("user.manager2", pacta.InitiativeUserRole_Manager),
+			},
+			uID:  "user.manager1",
+			want: false,
+		},
+		{
+			name: "not a manager",
+			iurs: []*pacta.InitiativeUserRelationship{
+				iur("user.manager", pacta.InitiativeUserRole_Manager),
+				iur("user.analyst", pacta.InitiativeUserRole_Analyst),
+			},
+			uID:  "user.analyst",
+			want: false,
+		},
+		{
+			name: "not in initiative",
+			iurs: []*pacta.InitiativeUserRelationship{
+				iur("user.manager", pacta.InitiativeUserRole_Manager),
+			},
+			uID:  "user.other",
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLastManager(tt.iurs, tt.uID); got != tt.want {
+				t.Errorf("isLastManager(%q) = %t, want %t", tt.uID, got, tt.want)
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/RMI/pacta/oapierr"
 	api "github.com/RMI/pacta/openapi/pacta"
 	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
 	"go.uber.org/zap"
 )
 
@@ -54,10 +55,25 @@ func (s *Server) CreatePactaVersion(ctx context.Context, request api.CreatePacta
 	if err := anyError(
 		checkStringLimitSmall("name", request.Body.Name),
 		checkStringLimitSmall("digest", request.Body.Digest),
+		checkStringLimitSmall("dashboardDigest", request.Body.DashboardDigest),
+		checkStringLimitSmall("inherit", request.Body.Inherit),
+		checkStringLimitSmall("benchmarkDataset", request.Body.BenchmarkDataset),
+		checkStringLimitSmall("pactaDataset", request.Body.PactaDataset),
 		checkStringLimitMedium("description", request.Body.Description),
 	); err != nil {
 		return nil, err
 	}
+	digest, err := task.NormalizeImageVersion(request.Body.Digest)
+	if err != nil {
+		return nil, oapierr.BadRequest("digest must be a sha256 image digest or tag", zap.Error(err))
+	}
+	dashboardDigest, err := task.NormalizeImageVersion(request.Body.DashboardDigest)
+	if err != nil {
+		return nil, oapierr.BadRequest("dashboardDigest must be a sha256 image digest or tag", zap.Error(err))
+	}
+	if err := anyError(task.ValidateDataset(request.Body.BenchmarkDataset), task.ValidateDataset(request.Body.PactaDataset)); err != nil {
+		return nil, oapierr.BadRequest("datasets must be single directory names", zap.Error(err))
+	}
 	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
 		return nil, err
@@ -74,6 +90,8 @@ func (s *Server) CreatePactaVersion(ctx context.Context, request api.CreatePacta
 	if err != nil {
 		return nil, err
 	}
+	pv.Digest = digest
+	pv.DashboardDigest = dashboardDigest
 	pvID, err := s.DB.CreatePACTAVersion(s.DB.NoTxn(ctx), pv)
 	if err != nil {
 		return nil, oapierr.Internal("failed to create pacta version", zap.Error(err))
@@ -90,10 +108,35 @@ func (s *Server) UpdatePactaVersion(ctx context.Context, request api.UpdatePacta
 	if err := anyError(
 		checkStringLimitSmallPtr("name", request.Body.Name),
 		checkStringLimitSmallPtr("digest", request.Body.Digest),
+		checkStringLimitSmallPtr("dashboardDigest", request.Body.DashboardDigest),
+		checkStringLimitSmallPtr("inherit", request.Body.Inherit),
+		checkStringLimitSmallPtr("benchmarkDataset", request.Body.BenchmarkDataset),
+		checkStringLimitSmallPtr("pactaDataset", request.Body.PactaDataset),
 		checkStringLimitMediumPtr("description", request.Body.Description),
 	); err != nil {
 		return nil, err
 	}
+	var digest, dashboardDigest string
+	if request.Body.Digest != nil {
+		var err error
+		if digest, err = task.NormalizeImageVersion(*request.Body.Digest); err != nil {
+			return nil, oapierr.BadRequest("digest must be a sha256 image digest or tag", zap.Error(err))
+		}
+	}
+	if request.Body.DashboardDigest != nil {
+		var err error
+		if dashboardDigest, err = task.NormalizeImageVersion(*request.Body.DashboardDigest); err != nil {
+			return nil, oapierr.BadRequest("dashboardDigest must be a sha256 image digest or tag", zap.Error(err))
+		}
+	}
+	for _, dataset := range []*string{request.Body.BenchmarkDataset, request.Body.PactaDataset} {
+		if dataset == nil {
+			continue
+		}
+		if err := task.ValidateDataset(*dataset); err != nil {
+			return nil, oapierr.BadRequest("datasets must be single directory names", zap.Error(err))
+		}
+	}
 	id := pacta.PACTAVersionID(request.Id)
 	if err := s.pactaVersionAuthz(ctx, id, pacta.AuditLogAction_Update); err != nil {
 		return nil, err
@@ -104,7 +147,19 @@ func (s *Server) UpdatePactaVersion(ctx context.Context, request api.UpdatePacta
 		mutations = append(mutations, db.SetPACTAVersionDescription(*b.Description))
 	}
 	if b.Digest != nil {
-		mutations = append(mutations, db.SetPACTAVersionDigest(*b.Digest))
+		mutations = append(mutations, db.SetPACTAVersionDigest(digest))
+	}
+	if b.DashboardDigest != nil {
+		mutations = append(mutations, db.SetPACTAVersionDashboardDigest(dashboardDigest))
+	}
+	if b.Inherit != nil {
+		mutations = append(mutations, db.SetPACTAVersionInherit(*b.Inherit))
+	}
+	if b.BenchmarkDataset != nil {
+		mutations = append(mutations, db.SetPACTAVersionBenchmarkDataset(*b.BenchmarkDataset))
+	}
+	if b.PactaDataset != nil {
+		mutations = append(mutations, db.SetPACTAVersionPACTADataset(*b.PactaDataset))
 	}
 	if b.Name != nil {
 		mutations = append(mutations, db.SetPACTAVersionName(*b.Name))
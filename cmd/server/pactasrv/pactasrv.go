@@ -3,6 +3,7 @@ package pactasrv
 import (
 	"context"
 	"fmt"
+	"io"
 	"time"
 
 	"github.com/RMI/pacta/blob"
@@ -20,10 +21,14 @@ var (
 )
 
 type TaskRunner interface {
-	ParsePortfolio(ctx context.Context, req *task.ParsePortfolioRequest) (task.ID, task.RunnerID, error)
-	CreateAudit(ctx context.Context, req *task.CreateAuditRequest) (task.ID, task.RunnerID, error)
-	CreateReport(ctx context.Context, req *task.CreateReportRequest) (task.ID, task.RunnerID, error)
-	CreateDashboard(ctx context.Context, req *task.CreateDashboardRequest) (task.ID, task.RunnerID, error)
+	ParsePortfolio(ctx context.Context, taskID task.ID, req *task.ParsePortfolioRequest) (task.RunnerID, error)
+	CreateAudit(ctx context.Context, taskID task.ID, req *task.CreateAuditRequest) (task.RunnerID, error)
+	CreateReport(ctx context.Context, taskID task.ID, req *task.CreateReportRequest) (task.RunnerID, error)
+	CreateDashboard(ctx context.Context, taskID task.ID, req *task.CreateDashboardRequest) (task.RunnerID, error)
+
+	Status(ctx context.Context, runnerID task.RunnerID) (task.Status, error)
+	Logs(ctx context.Context, runnerID task.RunnerID) ([]byte, error)
+	Cancel(ctx context.Context, runnerID task.RunnerID) error
 }
 
 type DB interface {
@@ -50,6 +55,7 @@ type DB interface {
 	InitiativeUserRelationshipsByInitiative(tx db.Tx, iid pacta.InitiativeID) ([]*pacta.InitiativeUserRelationship, error)
 	PutInitiativeUserRelationship(tx db.Tx, iur *pacta.InitiativeUserRelationship) error
 	UpdateInitiativeUserRelationship(tx db.Tx, iid pacta.InitiativeID, uid pacta.UserID, mutations ...db.UpdateInitiativeUserRelationshipFn) error
+	DeleteInitiativeUserRelationship(tx db.Tx, iid pacta.InitiativeID, uid pacta.UserID) error
 
 	Initiative(tx db.Tx, id pacta.InitiativeID) (*pacta.Initiative, error)
 	Initiatives(tx db.Tx, ids []pacta.InitiativeID) (map[pacta.InitiativeID]*pacta.Initiative, error)
@@ -66,6 +72,9 @@ type DB interface {
 	UpdatePACTAVersion(tx db.Tx, id pacta.PACTAVersionID, mutations ...db.UpdatePACTAVersionFn) error
 	DeletePACTAVersion(tx db.Tx, id pacta.PACTAVersionID) error
 
+	UploadLimits(tx db.Tx) (*pacta.UploadLimits, error)
+	UpdateUploadLimits(tx db.Tx, mutations ...db.UpdateUploadLimitsFn) error
+
 	PortfolioInitiativeMembershipsByPortfolio(tx db.Tx, pid pacta.PortfolioID) ([]*pacta.PortfolioInitiativeMembership, error)
 	PortfolioInitiativeMembershipsByInitiative(tx db.Tx, iid pacta.InitiativeID) ([]*pacta.PortfolioInitiativeMembership, error)
 	CreatePortfolioInitiativeMembership(tx db.Tx, pim *pacta.PortfolioInitiativeMembership) error
@@ -73,30 +82,42 @@ type DB interface {
 
 	Portfolio(tx db.Tx, id pacta.PortfolioID) (*pacta.Portfolio, error)
 	PortfoliosByOwner(tx db.Tx, owner pacta.OwnerID) ([]*pacta.Portfolio, error)
+	DuplicatePortfolioIDsByOwner(tx db.Tx, owner pacta.OwnerID) ([][]pacta.PortfolioID, error)
 	Portfolios(tx db.Tx, ids []pacta.PortfolioID) (map[pacta.PortfolioID]*pacta.Portfolio, error)
 	CreatePortfolio(tx db.Tx, i *pacta.Portfolio) (pacta.PortfolioID, error)
 	UpdatePortfolio(tx db.Tx, id pacta.PortfolioID, mutations ...db.UpdatePortfolioFn) error
 	DeletePortfolio(tx db.Tx, id pacta.PortfolioID) ([]pacta.BlobURI, error)
+	PortfolioVersions(tx db.Tx, id pacta.PortfolioID) ([]*pacta.PortfolioVersion, error)
 
 	IncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) (*pacta.IncompleteUpload, error)
+	LockIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) error
 	IncompleteUploads(tx db.Tx, ids []pacta.IncompleteUploadID) (map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, error)
 	IncompleteUploadsByOwner(tx db.Tx, owner pacta.OwnerID) ([]*pacta.IncompleteUpload, error)
 	CreateIncompleteUpload(tx db.Tx, i *pacta.IncompleteUpload) (pacta.IncompleteUploadID, error)
 	UpdateIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID, mutations ...db.UpdateIncompleteUploadFn) error
-	DeleteIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) (pacta.BlobURI, error)
+	DeleteIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) ([]pacta.BlobURI, error)
 
 	CreateAnalysis(tx db.Tx, a *pacta.Analysis) (pacta.AnalysisID, error)
 	UpdateAnalysis(tx db.Tx, id pacta.AnalysisID, mutations ...db.UpdateAnalysisFn) error
 	DeleteAnalysis(tx db.Tx, id pacta.AnalysisID) ([]pacta.BlobURI, error)
 	Analysis(tx db.Tx, id pacta.AnalysisID) (*pacta.Analysis, error)
+	LockAnalysis(tx db.Tx, id pacta.AnalysisID) error
 	Analyses(tx db.Tx, ids []pacta.AnalysisID) (map[pacta.AnalysisID]*pacta.Analysis, error)
 	AnalysesByOwner(tx db.Tx, ownerID pacta.OwnerID) ([]*pacta.Analysis, error)
+	AnalysesRunOnInitiative(tx db.Tx, iID pacta.InitiativeID) ([]pacta.AnalysisID, error)
 
 	AnalysisArtifacts(tx db.Tx, ids []pacta.AnalysisArtifactID) (map[pacta.AnalysisArtifactID]*pacta.AnalysisArtifact, error)
 	AnalysisArtifact(tx db.Tx, id pacta.AnalysisArtifactID) (*pacta.AnalysisArtifact, error)
 	UpdateAnalysisArtifact(tx db.Tx, id pacta.AnalysisArtifactID, mutations ...db.UpdateAnalysisArtifactFn) error
 	DeleteAnalysisArtifact(tx db.Tx, id pacta.AnalysisArtifactID) (pacta.BlobURI, error)
 
+	Task(tx db.Tx, id pacta.TaskID) (*pacta.Task, error)
+	TasksByOwner(tx db.Tx, ownerID pacta.OwnerID) ([]*pacta.Task, error)
+	TasksForAnalysis(tx db.Tx, analysisID pacta.AnalysisID) ([]*pacta.Task, error)
+	TasksForIncompleteUpload(tx db.Tx, iuID pacta.IncompleteUploadID) ([]*pacta.Task, error)
+	CreateTask(tx db.Tx, t *pacta.Task) (pacta.TaskID, error)
+	UpdateTask(tx db.Tx, id pacta.TaskID, mutations ...db.UpdateTaskFn) error
+
 	CreateSnapshotOfPortfolio(tx db.Tx, pID pacta.PortfolioID) (pacta.PortfolioSnapshotID, error)
 	CreateSnapshotOfPortfolioGroup(tx db.Tx, pgID pacta.PortfolioGroupID) (pacta.PortfolioSnapshotID, error)
 	CreateSnapshotOfInitiative(tx db.Tx, iID pacta.InitiativeID) (pacta.PortfolioSnapshotID, error)
@@ -119,8 +140,16 @@ type DB interface {
 	Users(tx db.Tx, ids []pacta.UserID) (map[pacta.UserID]*pacta.User, error)
 	UpdateUser(tx db.Tx, id pacta.UserID, mutations ...db.UpdateUserFn) error
 	DeleteUser(tx db.Tx, id pacta.UserID) ([]pacta.BlobURI, error)
+	TransferUserAuthns(tx db.Tx, fromUserID, toUserID pacta.UserID) error
+	RevokeSession(tx db.Tx, uid pacta.UserID, jti string, expiresAt time.Time) error
+	RevokeUserSessionsBefore(tx db.Tx, uid pacta.UserID, before time.Time) error
 	QueryUsers(tx db.Tx, q *db.UserQuery) ([]*pacta.User, *db.PageInfo, error)
 
+	APIToken(tx db.Tx, id pacta.APITokenID) (*pacta.APIToken, error)
+	APITokensByUser(tx db.Tx, uid pacta.UserID) ([]*pacta.APIToken, error)
+	CreateAPIToken(tx db.Tx, t *pacta.APIToken, tokenHash string) (pacta.APITokenID, error)
+	UpdateAPIToken(tx db.Tx, id pacta.APITokenID, mutations ...db.UpdateAPITokenFn) error
+
 	CreateAuditLog(tx db.Tx, a *pacta.AuditLog) (pacta.AuditLogID, error)
 	CreateAuditLogs(tx db.Tx, as []*pacta.AuditLog) error
 	AuditLogs(tx db.Tx, q *db.AuditLogQuery) ([]*pacta.AuditLog, *db.PageInfo, error)
@@ -135,6 +164,8 @@ type Blob interface {
 	SignedUploadURL(ctx context.Context, uri string) (string, time.Time, error)
 	SignedDownloadURL(ctx context.Context, uri string) (string, time.Time, error)
 	DeleteBlob(ctx context.Context, uri string) error
+	ReadBlob(ctx context.Context, uri string) (io.ReadCloser, error)
+	Properties(ctx context.Context, uri string) (*blob.Properties, error)
 }
 
 type Server struct {
@@ -232,6 +263,8 @@ type actorInfo struct {
 	OwnerID      pacta.OwnerID
 	IsAdmin      bool
 	IsSuperAdmin bool
+	// APITokenID is set when the actor authenticated with an API token.
+	APITokenID pacta.APITokenID
 }
 
 var anonymousActorInfo = actorInfo{}
@@ -249,11 +282,16 @@ func (s *Server) getActorInfoOrErrIfAnon(ctx context.Context) (actorInfo, error)
 	if err != nil {
 		return anonymousActorInfo, err
 	}
+	var apiTokenID pacta.APITokenID
+	if t := session.APITokenFromContext(ctx); t != nil {
+		apiTokenID = t.ID
+	}
 	return actorInfo{
 		UserID:       actorUserID,
 		OwnerID:      actorOwnerID,
 		IsAdmin:      actorIsAdmin,
 		IsSuperAdmin: actorIsSuperAdmin,
+		APITokenID:   apiTokenID,
 	}, nil
 }
 
@@ -3,6 +3,8 @@ package pactasrv
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"time"
 
 	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
 	"github.com/RMI/pacta/db"
@@ -18,6 +20,9 @@ func (s *Server) ListPortfolios(ctx context.Context, request api.ListPortfoliosR
 	if err != nil {
 		return nil, err
 	}
+	if request.Params.Duplicates != nil && *request.Params.Duplicates {
+		return s.listDuplicatePortfolios(ctx, ownerID)
+	}
 	ps, err := s.DB.PortfoliosByOwner(s.DB.NoTxn(ctx), ownerID)
 	if err != nil {
 		return nil, oapierr.Internal("failed to query portfolios", zap.Error(err))
@@ -35,6 +40,46 @@ func (s *Server) ListPortfolios(ctx context.Context, request api.ListPortfoliosR
 	return api.ListPortfolios200JSONResponse{Items: items}, nil
 }
 
+func (s *Server) listDuplicatePortfolios(ctx context.Context, ownerID pacta.OwnerID) (api.ListPortfoliosResponseObject, error) {
+	groups, err := s.DB.DuplicatePortfolioIDsByOwner(s.DB.NoTxn(ctx), ownerID)
+	if err != nil {
+		return nil, oapierr.Internal("failed to query duplicate portfolios", zap.Error(err))
+	}
+	ids := []pacta.PortfolioID{}
+	for _, group := range groups {
+		ids = append(ids, group...)
+	}
+	portfolios, err := s.DB.Portfolios(s.DB.NoTxn(ctx), ids)
+	if err != nil {
+		return nil, oapierr.Internal("failed to query portfolios", zap.Error(err))
+	}
+	ps := []*pacta.Portfolio{}
+	duplicateGroups := [][]string{}
+	for _, group := range groups {
+		strs := []string{}
+		for _, id := range group {
+			p, ok := portfolios[id]
+			if !ok {
+				return nil, oapierr.Internal("duplicate portfolio not found", zap.String("portfolio_id", string(id)))
+			}
+			ps = append(ps, p)
+			strs = append(strs, string(id))
+		}
+		duplicateGroups = append(duplicateGroups, strs)
+	}
+	if err := s.populatePortfolioGroupsInPortfolios(ctx, ps); err != nil {
+		return nil, err
+	}
+	if err := s.populateInitiativesInPortfolios(ctx, ps); err != nil {
+		return nil, err
+	}
+	items, err := dereference(conv.PortfoliosToOAPI(ps))
+	if err != nil {
+		return nil, err
+	}
+	return api.ListPortfolios200JSONResponse{Items: items, DuplicateGroups: &duplicateGroups}, nil
+}
+
 // Deletes an portfolio by ID
 // (DELETE /portfolio/{id})
 func (s *Server) DeletePortfolio(ctx context.Context, request api.DeletePortfolioRequestObject) (api.DeletePortfolioResponseObject, error) {
@@ -76,6 +121,115 @@ func (s *Server) FindPortfolioById(ctx context.Context, request api.FindPortfoli
 	return api.FindPortfolioById200JSONResponse(*converted), nil
 }
 
+// Returns the holdings in a portfolio
+// (GET /portfolio/{id}/holdings)
+func (s *Server) ListPortfolioHoldings(ctx context.Context, request api.ListPortfolioHoldingsRequestObject) (api.ListPortfolioHoldingsResponseObject, error) {
+	id := pacta.PortfolioID(request.Id)
+	offset := 0
+	if request.Params.Cursor != nil && *request.Params.Cursor != "" {
+		o, err := strconv.Atoi(*request.Params.Cursor)
+		if err != nil || o < 0 {
+			return nil, oapierr.BadRequest("invalid cursor", zap.String("cursor", *request.Params.Cursor))
+		}
+		offset = o
+	}
+	limit := 100
+	if request.Params.Limit != nil {
+		limit = *request.Params.Limit
+		if limit < 1 {
+			return nil, oapierr.BadRequest("limit must be positive", zap.Int("limit", limit))
+		}
+		if err := checkIntLimit("limit", limit, 1000); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.portfolioDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_ReadMetadata); err != nil {
+		return nil, err
+	}
+	p, err := s.DB.Portfolio(s.DB.NoTxn(ctx), id)
+	if err != nil {
+		return nil, oapierr.Internal("failed to look up portfolio", zap.String("portfolio_id", string(id)), zap.Error(err))
+	}
+	b, err := s.DB.Blob(s.DB.NoTxn(ctx), p.Blob.ID)
+	if err != nil {
+		return nil, oapierr.Internal("failed to look up portfolio blob", zap.String("portfolio_id", string(id)), zap.Error(err))
+	}
+	r, err := s.Blob.ReadBlob(ctx, string(b.BlobURI))
+	if err != nil {
+		return nil, oapierr.Internal("failed to read portfolio blob", zap.String("portfolio_id", string(id)), zap.String("blob_uri", string(b.BlobURI)), zap.Error(err))
+	}
+	defer r.Close()
+	page, err := readHoldings(r, offset, limit)
+	if err != nil {
+		return nil, oapierr.Internal("failed to parse portfolio holdings", zap.String("portfolio_id", string(id)), zap.Error(err))
+	}
+	return api.ListPortfolioHoldings200JSONResponse(holdingsPageToOAPI(page, offset)), nil
+}
+
+// Returns the history of a portfolio's holdings
+// (GET /portfolio/{id}/versions)
+func (s *Server) ListPortfolioVersions(ctx context.Context, request api.ListPortfolioVersionsRequestObject) (api.ListPortfolioVersionsResponseObject, error) {
+	id := pacta.PortfolioID(request.Id)
+	if err := s.portfolioDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_ReadMetadata); err != nil {
+		return nil, err
+	}
+	p, err := s.DB.Portfolio(s.DB.NoTxn(ctx), id)
+	if err != nil {
+		return nil, oapierr.Internal("failed to look up portfolio", zap.String("portfolio_id", string(id)), zap.Error(err))
+	}
+	prior, err := s.DB.PortfolioVersions(s.DB.NoTxn(ctx), id)
+	if err != nil {
+		return nil, oapierr.Internal("failed to look up portfolio versions", zap.String("portfolio_id", string(id)), zap.Error(err))
+	}
+	// Versions don't record when they were created, but each set of holdings
+	// became current when the one before it was replaced.
+	createdAt := func(i int) time.Time {
+		if i < len(prior) {
+			return prior[i].ReplacedAt
+		}
+		return p.CreatedAt
+	}
+	current := api.PortfolioVersion{
+		Version:      1,
+		NumberOfRows: p.NumberOfRows,
+		CreatedAt:    createdAt(0),
+	}
+	if len(prior) > 0 {
+		current.Version = prior[0].Version + 1
+	}
+	items := []api.PortfolioVersion{current}
+	for i, v := range prior {
+		items = append(items, api.PortfolioVersion{
+			Version:      v.Version,
+			NumberOfRows: v.NumberOfRows,
+			CreatedAt:    createdAt(i + 1),
+			ReplacedAt:   ptr(v.ReplacedAt),
+		})
+	}
+	return api.ListPortfolioVersions200JSONResponse{Items: items}, nil
+}
+
+// Replaces the holdings of a portfolio with a new upload
+// (POST /portfolio/{id}:replace-holdings)
+func (s *Server) ReplacePortfolioHoldings(ctx context.Context, request api.ReplacePortfolioHoldingsRequestObject) (api.ReplacePortfolioHoldingsResponseObject, error) {
+	id := pacta.PortfolioID(request.Id)
+	if err := s.portfolioDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Update); err != nil {
+		return nil, err
+	}
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return nil, err
+	}
+	iuID := pacta.IncompleteUploadID(request.Body.IncompleteUploadId)
+	if iuID == "" {
+		return nil, oapierr.BadRequest("no incomplete upload ID provided")
+	}
+	if err := s.parseUploads(ctx, actorInfo.OwnerID, []pacta.IncompleteUploadID{iuID}, id); err != nil {
+		return nil, err
+	}
+	return api.ReplacePortfolioHoldings204Response{}, nil
+}
+
 // Updates portfolio properties
 // (PATCH /portfolio/{id})
 func (s *Server) UpdatePortfolio(ctx context.Context, request api.UpdatePortfolioRequestObject) (api.UpdatePortfolioResponseObject, error) {
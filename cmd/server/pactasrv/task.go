@@ -0,0 +1,160 @@
+package pactasrv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/oapierr"
+	api "github.com/RMI/pacta/openapi/pacta"
+	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
+	"go.uber.org/zap"
+)
+
+// Returns a task by ID
+// (GET /tasks/{id})
+func (s *Server) FindTaskById(ctx context.Context, request api.FindTaskByIdRequestObject) (api.FindTaskByIdResponseObject, error) {
+	id := pacta.TaskID(request.Id)
+	t, err := s.taskDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_ReadMetadata)
+	if err != nil {
+		return nil, err
+	}
+	converted, err := conv.TaskToOAPI(t)
+	if err != nil {
+		return nil, err
+	}
+	if t.State == pacta.TaskState_Running && t.RunnerID != "" {
+		// The live status is best-effort, we don't want to fail the whole
+		// request just because the runner is unavailable.
+		if status, err := s.TaskRunner.Status(ctx, task.RunnerID(t.RunnerID)); err != nil {
+			s.Logger.Warn("failed to load task status from runner", zap.String("task_id", string(id)), zap.Error(err))
+		} else if rs, err := conv.RunnerStatusToOAPI(status); err != nil {
+			s.Logger.Warn("failed to convert runner status", zap.String("task_id", string(id)), zap.Error(err))
+		} else {
+			converted.RunnerStatus = &rs
+		}
+	}
+	return api.FindTaskById200JSONResponse(*converted), nil
+}
+
+// Returns the logs of a task
+// (GET /tasks/{id}/logs)
+func (s *Server) FindTaskLogs(ctx context.Context, request api.FindTaskLogsRequestObject) (api.FindTaskLogsResponseObject, error) {
+	id := pacta.TaskID(request.Id)
+	t, err := s.taskDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_Download)
+	if err != nil {
+		return nil, err
+	}
+	if t.RunnerID == "" {
+		return nil, oapierr.BadRequest("task has not been started", zap.String("task_id", string(id)))
+	}
+	logs, err := s.TaskRunner.Logs(ctx, task.RunnerID(t.RunnerID))
+	if err != nil {
+		if errors.Is(err, errors.ErrUnsupported) {
+			return nil, oapierr.NotImplemented("task runner does not support retrieving logs", zap.String("task_id", string(id)), zap.Error(err))
+		}
+		return nil, oapierr.Internal("failed to load task logs", zap.String("task_id", string(id)), zap.Error(err))
+	}
+	return api.FindTaskLogs200JSONResponse{Logs: string(logs)}, nil
+}
+
+// Returns the tasks that have been run for an analysis
+// (GET /analysis/{id}/tasks)
+func (s *Server) ListTasksForAnalysis(ctx context.Context, request api.ListTasksForAnalysisRequestObject) (api.ListTasksForAnalysisResponseObject, error) {
+	id := pacta.AnalysisID(request.Id)
+	if err := s.analysisDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_ReadMetadata); err != nil {
+		return nil, err
+	}
+	ts, err := s.DB.TasksForAnalysis(s.DB.NoTxn(ctx), id)
+	if err != nil {
+		return nil, oapierr.Internal("failed to query tasks for analysis", zap.String("analysis_id", string(id)), zap.Error(err))
+	}
+	items, err := dereference(conv.TasksToOAPI(ts))
+	if err != nil {
+		return nil, err
+	}
+	return api.ListTasksForAnalysis200JSONResponse{Items: items}, nil
+}
+
+func (s *Server) taskDoAuthzAndAuditLog(ctx context.Context, taskID pacta.TaskID, action pacta.AuditLogAction) (*pacta.Task, error) {
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return nil, err
+	}
+	t, err := s.DB.Task(s.DB.NoTxn(ctx), taskID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return nil, notFoundOrUnauthorized(actorInfo, action, pacta.AuditLogTargetType_Task, taskID)
+		}
+		return nil, oapierr.Internal("querying task for authz failed", zap.Error(err))
+	}
+	as := &authzStatus{
+		primaryTargetID:      string(taskID),
+		primaryTargetType:    pacta.AuditLogTargetType_Task,
+		primaryTargetOwnerID: t.Owner.ID,
+		actorInfo:            actorInfo,
+		action:               action,
+	}
+	switch action {
+	case pacta.AuditLogAction_ReadMetadata:
+		as.isAuthorized, as.authorizedAsActorType = allowIfAdminOrOwner(actorInfo, t.Owner.ID)
+	case pacta.AuditLogAction_Download:
+		// Task logs can include R stack traces and file paths, so they're
+		// only available to administrators.
+		as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
+	default:
+		return nil, fmt.Errorf("unknown action %q for task authz", action)
+	}
+	if err := s.auditLogIfAuthorizedOrFail(ctx, as); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// createRetryTask creates t as a new task for another attempt at some
+// previously failed work. Every attempt gets its own task, so that the logs and
+// runner IDs of earlier attempts are kept. The attempt count carries on from
+// the most recent of the previous tasks, the task runner increments it on
+// dispatch.
+func (s *Server) createRetryTask(tx db.Tx, prev []*pacta.Task, t *pacta.Task) (pacta.TaskID, error) {
+	if len(prev) > 0 {
+		latest := prev[len(prev)-1]
+		switch latest.State {
+		case pacta.TaskState_Queued, pacta.TaskState_Running:
+			return "", oapierr.Conflict("task is still in progress", zap.String("task_id", string(latest.ID)))
+		}
+		t.AttemptCount = latest.AttemptCount
+	}
+	id, err := s.DB.CreateTask(tx, t)
+	if err != nil {
+		return "", oapierr.Internal("failed to create task", zap.Error(err))
+	}
+	return id, nil
+}
+
+// encodeParsePortfolioRequest encodes the request of a new parse task, so that
+// it can be recorded when the task is created. That's what links the task to
+// its incomplete uploads, so it has to be there before the task is dispatched.
+func encodeParsePortfolioRequest(req *task.ParsePortfolioRequest) (string, error) {
+	b, err := json.Marshal(req)
+	if err != nil {
+		return "", oapierr.Internal("failed to encode parse portfolio request", zap.Error(err))
+	}
+	return string(b), nil
+}
+
+func taskTypeForAnalysisType(at pacta.AnalysisType) (pacta.TaskType, error) {
+	switch at {
+	case pacta.AnalysisType_Audit:
+		return pacta.TaskType_CreateAudit, nil
+	case pacta.AnalysisType_Report:
+		return pacta.TaskType_CreateReport, nil
+	case pacta.AnalysisType_Dashboard:
+		return pacta.TaskType_CreateDashboard, nil
+	}
+	return "", oapierr.Internal("unknown analysis type", zap.String("analysis_type", string(at)))
+}
@@ -2,9 +2,13 @@ package pactasrv
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"path/filepath"
+	"slices"
 
+	"github.com/RMI/pacta/async/normalize"
 	"github.com/RMI/pacta/blob"
 	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
 	"github.com/RMI/pacta/db"
@@ -16,6 +20,18 @@ import (
 	"go.uber.org/zap"
 )
 
+// portfolioFileTypes are the upload formats the parser can normalize into
+// CSV, see async/normalize.
+var portfolioFileTypes = []pacta.FileType{
+	pacta.FileType_CSV,
+	pacta.FileType_XLSX,
+	pacta.FileType_ZIP,
+}
+
+func isPortfolioFileType(ft pacta.FileType) bool {
+	return slices.Contains(portfolioFileTypes, ft)
+}
+
 // Starts the process of uploading one or more portfolio files
 // (POST /portfolio-upload)
 func (s *Server) StartPortfolioUpload(ctx context.Context, request api.StartPortfolioUploadRequestObject) (api.StartPortfolioUploadResponseObject, error) {
@@ -35,6 +51,10 @@ func (s *Server) StartPortfolioUpload(ctx context.Context, request api.StartPort
 	properties.ESG = conv.OptionalBoolFromOAPI(request.Body.PropertyESG)
 	properties.External = conv.OptionalBoolFromOAPI(request.Body.PropertyExternal)
 	properties.EngagementStrategy = conv.OptionalBoolFromOAPI(request.Body.PropertyEngagementStrategy)
+	duplicatePolicy, err := conv.DuplicatePolicyFromOAPI(request.Body.DuplicatePolicy)
+	if err != nil {
+		return nil, err
+	}
 
 	n := len(request.Body.Items)
 	blobs := make([]*pacta.Blob, n)
@@ -46,6 +66,9 @@ func (s *Server) StartPortfolioUpload(ctx context.Context, request api.StartPort
 		}
 		extStr := filepath.Ext(fn)
 		ft, err := pacta.ParseFileType(extStr)
+		if err == nil && !isPortfolioFileType(ft) {
+			err = fmt.Errorf("portfolios can't be uploaded as %q files", ft)
+		}
 		if err != nil {
 			return nil, oapierr.BadRequest(
 				fmt.Sprintf("invalid file type: %q", extStr),
@@ -82,10 +105,11 @@ func (s *Server) StartPortfolioUpload(ctx context.Context, request api.StartPort
 			}
 			blob.ID = blobID
 			iuid, err := s.DB.CreateIncompleteUpload(tx, &pacta.IncompleteUpload{
-				Blob:       blob,
-				Name:       blob.FileName,
-				Properties: properties,
-				Owner:      owner,
+				Blob:            blob,
+				Name:            blob.FileName,
+				Properties:      properties,
+				Owner:           owner,
+				DuplicatePolicy: duplicatePolicy,
 			})
 			if err != nil {
 				return fmt.Errorf("creating incomplete upload %d: %w", i, err)
@@ -95,6 +119,7 @@ func (s *Server) StartPortfolioUpload(ctx context.Context, request api.StartPort
 				Action:             pacta.AuditLogAction_Create,
 				ActorID:            string(actorInfo.UserID),
 				ActorOwner:         owner,
+				ActorAPITokenID:    actorInfo.APITokenID,
 				ActorType:          pacta.AuditLogActorType_Owner,
 				PrimaryTargetType:  pacta.AuditLogTargetType_IncompleteUpload,
 				PrimaryTargetID:    string(iuid),
@@ -127,51 +152,109 @@ func (s *Server) CompletePortfolioUpload(ctx context.Context, request api.Comple
 	if len(ids) == 0 {
 		return nil, oapierr.BadRequest("no incomplete upload IDs provided")
 	}
-	// TODO(#71) Implement basic limits + validation
-	blobURIs := []pacta.BlobURI{}
-	err = s.DB.Transactional(ctx, func(tx db.Tx) error {
-		ius, err := s.DB.IncompleteUploads(tx, ids)
+	if err := s.parseUploads(ctx, actorInfo.OwnerID, ids, ""); err != nil {
+		return nil, err
+	}
+	return api.CompletePortfolioUpload200JSONResponse{}, nil
+}
+
+// parseUploads validates the given incomplete uploads, which must belong to
+// the owner, and starts a task to parse them. If replaces is set, the single
+// upload holds new holdings for that portfolio rather than new portfolios.
+func (s *Server) parseUploads(ctx context.Context, ownerID pacta.OwnerID, ids []pacta.IncompleteUploadID, replaces pacta.PortfolioID) error {
+	// The uploaded files are checked before we open a transaction, as doing so
+	// means reading them from blob storage.
+	ius, err := s.DB.IncompleteUploads(s.DB.NoTxn(ctx), ids)
+	if err != nil {
+		return oapierr.Internal("failed to query incomplete uploads", zap.Error(err))
+	}
+	iuList := make([]*pacta.IncompleteUpload, len(ids))
+	blobIDs := make([]pacta.BlobID, len(ids))
+	for i, id := range ids {
+		iu := ius[id]
+		if iu == nil || iu.Owner == nil || iu.Owner.ID != ownerID {
+			return oapierr.NotFound(
+				fmt.Sprintf("incomplete upload %s does not belong to user", id),
+				zap.String("incomplete_upload_id", string(id)),
+				zap.String("owner_id", string(ownerID)),
+			)
+		}
+		if replaces != "" && !iu.RanAt.IsZero() {
+			return oapierr.Conflict("incomplete upload has already been parsed",
+				zap.String("incomplete_upload_id", string(id)),
+				zap.String("portfolio_id", string(replaces)))
+		}
+		iuList[i] = iu
+		blobIDs[i] = iu.Blob.ID
+	}
+	blobs, err := s.DB.Blobs(s.DB.NoTxn(ctx), blobIDs)
+	if err != nil {
+		return oapierr.Internal("failed to query blobs", zap.Error(err))
+	}
+	limits, err := s.uploadLimits(s.DB.NoTxn(ctx))
+	if err != nil {
+		return err
+	}
+	var uploadErrs []api.UploadError
+	for _, iu := range iuList {
+		ue, err := s.validateUploadedBlob(ctx, iu, blobs[iu.Blob.ID], limits)
 		if err != nil {
-			return oapierr.Internal("failed to query incomplete uploads", zap.Error(err))
+			return err
 		}
-		blobIDs := []pacta.BlobID{}
-		for _, id := range ids {
-			iu := ius[id]
-			if iu == nil || iu.Owner == nil || iu.Owner.ID != actorInfo.OwnerID {
-				return oapierr.NotFound(
-					fmt.Sprintf("incomplete upload %s does not belong to user", id),
-					zap.String("incomplete_upload_id", string(id)),
-					zap.String("owner_id", string(actorInfo.OwnerID)),
-				)
+		if ue != nil {
+			uploadErrs = append(uploadErrs, *ue)
+		}
+	}
+	if len(uploadErrs) > 0 {
+		return oapierr.BadRequest("uploaded files are invalid", zap.Any("upload_errors", uploadErrs)).
+			WithErrorID("INVALID_UPLOADS").
+			WithMessage(fmt.Sprintf("%d of the uploaded files were rejected", len(uploadErrs))).
+			WithDetails(uploadErrs)
+	}
+	req, err := s.parsePortfolioRequest(iuList, blobs, limits)
+	if err != nil {
+		return err
+	}
+	encodedReq, err := encodeParsePortfolioRequest(req)
+	if err != nil {
+		return err
+	}
+
+	var taskID pacta.TaskID
+	err = s.DB.Transactional(ctx, func(tx db.Tx) error {
+		if replaces != "" {
+			err := s.DB.UpdateIncompleteUpload(tx, ids[0], db.SetIncompleteUploadReplacesPortfolio(replaces))
+			if err != nil {
+				return oapierr.Internal("failed to mark incomplete upload as a replacement", zap.String("incomplete_upload_id", string(ids[0])), zap.Error(err))
 			}
-			blobIDs = append(blobIDs, iu.Blob.ID)
 		}
-		blobs, err := s.DB.Blobs(s.DB.NoTxn(ctx), blobIDs)
+		tID, err := s.DB.CreateTask(tx, &pacta.Task{
+			Type:    pacta.TaskType_ParsePortfolio,
+			Owner:   &pacta.Owner{ID: ownerID},
+			Request: encodedReq,
+			State:   pacta.TaskState_Queued,
+		})
 		if err != nil {
-			return oapierr.Internal("failed to query blobs", zap.Error(err))
-		}
-		for _, blob := range blobs {
-			blobURIs = append(blobURIs, blob.BlobURI)
+			return oapierr.Internal("failed to create task", zap.Error(err))
 		}
+		taskID = tID
 		return nil
 	})
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	taskID, runnerID, err := s.TaskRunner.ParsePortfolio(ctx, &task.ParsePortfolioRequest{
-		BlobURIs:            blobURIs,
-		IncompleteUploadIDs: ids,
-	})
+	runnerID, err := s.TaskRunner.ParsePortfolio(ctx, task.ID(taskID), req)
 	if err != nil {
-		return nil, oapierr.Internal("failed to start task", zap.Error(err))
+		return oapierr.Internal("failed to start task", zap.Error(err))
 	}
 	s.Logger.Info("triggered parse portfolio task",
 		zap.String("task_id", string(taskID)),
-		zap.String("task_runner_id", string(runnerID)))
+		zap.String("task_runner_id", string(runnerID)),
+		zap.String("replaces_portfolio_id", string(replaces)))
 
 	now := s.Now()
-	err = s.DB.Transactional(ctx, func(tx db.Tx) error {
+	return s.DB.Transactional(ctx, func(tx db.Tx) error {
 		for _, id := range ids {
 			err := s.DB.UpdateIncompleteUpload(tx, id, db.SetIncompleteUploadRanAt(now))
 			if err != nil {
@@ -180,8 +263,80 @@ func (s *Server) CompletePortfolioUpload(ctx context.Context, request api.Comple
 		}
 		return nil
 	})
+}
+
+// validateUploadedBlob checks that something that could plausibly be parsed
+// was uploaded to the signed URL for the given incomplete upload, so that we
+// don't start a parse task for missing, empty or oversized files. Problems with
+// the upload itself are returned as an UploadError, so that they can be
+// reported alongside those of the other uploads.
+func (s *Server) validateUploadedBlob(ctx context.Context, iu *pacta.IncompleteUpload, b *pacta.Blob, limits *pacta.UploadLimits) (*api.UploadError, error) {
+	if b == nil {
+		return nil, oapierr.Internal("blob for incomplete upload not found", zap.String("incomplete_upload_id", string(iu.ID)), zap.String("blob_id", string(iu.Blob.ID)))
+	}
+	fields := []zap.Field{
+		zap.String("incomplete_upload_id", string(iu.ID)),
+		zap.String("blob_uri", string(b.BlobURI)),
+	}
+	uploadErr := func(errID string) *api.UploadError {
+		return &api.UploadError{
+			IncompleteUploadId: string(iu.ID),
+			FileName:           b.FileName,
+			ErrorId:            errID,
+		}
+	}
+
+	props, err := s.Blob.Properties(ctx, string(b.BlobURI))
+	if errors.Is(err, blob.ErrNotExist) {
+		return uploadErr("UPLOAD_NOT_FOUND"), nil
+	} else if err != nil {
+		return nil, oapierr.Internal("failed to get uploaded blob properties", append(fields, zap.Error(err))...)
+	}
+	if props.Size == 0 {
+		return uploadErr("UPLOAD_EMPTY"), nil
+	}
+	if limits.MaxBytes > 0 && props.Size > limits.MaxBytes {
+		ue := uploadErr("UPLOAD_TOO_LARGE")
+		ue.Size = ptr(props.Size)
+		ue.Limit = ptr(limits.MaxBytes)
+		return ue, nil
+	}
+
+	r, err := s.Blob.ReadBlob(ctx, string(b.BlobURI))
 	if err != nil {
-		return nil, err
+		return nil, oapierr.Internal("failed to read uploaded blob", append(fields, zap.Error(err))...)
 	}
-	return api.CompletePortfolioUpload200JSONResponse{}, nil
+	defer r.Close()
+	head, err := io.ReadAll(io.LimitReader(r, normalize.SniffLen))
+	if err != nil {
+		return nil, oapierr.Internal("failed to read uploaded blob", append(fields, zap.Error(err))...)
+	}
+	want := normalize.FormatText
+	if b.FileType == pacta.FileType_XLSX || b.FileType == pacta.FileType_ZIP {
+		want = normalize.FormatZIP
+	}
+	if normalize.Sniff(head) != want {
+		return uploadErr("UPLOAD_INVALID_CONTENT"), nil
+	}
+	return nil, nil
+}
+
+// parsePortfolioRequest builds the request to parse the given incomplete
+// uploads, whose blobs must all be present in blobs.
+func (s *Server) parsePortfolioRequest(ius []*pacta.IncompleteUpload, blobs map[pacta.BlobID]*pacta.Blob, limits *pacta.UploadLimits) (*task.ParsePortfolioRequest, error) {
+	req := &task.ParsePortfolioRequest{MaxRows: limits.MaxRows}
+	for _, iu := range ius {
+		b, ok := blobs[iu.Blob.ID]
+		if !ok {
+			return nil, oapierr.Internal("blob for incomplete upload not found", zap.String("incomplete_upload_id", string(iu.ID)), zap.String("blob_id", string(iu.Blob.ID)))
+		}
+		req.IncompleteUploadIDs = append(req.IncompleteUploadIDs, iu.ID)
+		req.BlobURIs = append(req.BlobURIs, b.BlobURI)
+		req.Uploads = append(req.Uploads, &task.ParsePortfolioUpload{
+			IncompleteUploadID: iu.ID,
+			BlobURI:            b.BlobURI,
+			Properties:         iu.Properties,
+		})
+	}
+	return req, nil
 }
@@ -0,0 +1,96 @@
+package pactasrv
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/RMI/pacta/cmd/server/pactasrv/conv"
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/oapierr"
+	api "github.com/RMI/pacta/openapi/pacta"
+	"github.com/RMI/pacta/pacta"
+	"go.uber.org/zap"
+)
+
+// Returns the limits on uploaded portfolio files
+// (GET /upload-limits)
+func (s *Server) GetUploadLimits(ctx context.Context, request api.GetUploadLimitsRequestObject) (api.GetUploadLimitsResponseObject, error) {
+	// Any signed in user can see the limits, so that they can be checked before
+	// uploading.
+	if _, err := s.getActorInfoOrErrIfAnon(ctx); err != nil {
+		return nil, err
+	}
+	ul, err := s.uploadLimits(s.DB.NoTxn(ctx))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := conv.UploadLimitsToOAPI(ul)
+	if err != nil {
+		return nil, err
+	}
+	return api.GetUploadLimits200JSONResponse(*resp), nil
+}
+
+// Updates the limits on uploaded portfolio files
+// (PATCH /upload-limits)
+func (s *Server) UpdateUploadLimits(ctx context.Context, request api.UpdateUploadLimitsRequestObject) (api.UpdateUploadLimitsResponseObject, error) {
+	mutations := []db.UpdateUploadLimitsFn{}
+	b := request.Body
+	if b.MaxBytes != nil {
+		if *b.MaxBytes < 0 {
+			return nil, oapierr.BadRequest("maxBytes can't be negative", zap.Int64("max_bytes", *b.MaxBytes))
+		}
+		mutations = append(mutations, db.SetUploadLimitsMaxBytes(*b.MaxBytes))
+	}
+	if b.MaxRows != nil {
+		if *b.MaxRows < 0 {
+			return nil, oapierr.BadRequest("maxRows can't be negative", zap.Int("max_rows", *b.MaxRows))
+		}
+		mutations = append(mutations, db.SetUploadLimitsMaxRows(*b.MaxRows))
+	}
+	actorInfo, err := s.uploadLimitsAuthz(ctx, pacta.AuditLogAction_Update)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.DB.UpdateUploadLimits(s.DB.NoTxn(ctx), mutations...); err != nil {
+		return nil, oapierr.Internal("failed to update upload limits", zap.Error(err))
+	}
+	s.Logger.Info("updated upload limits",
+		zap.String("actor_user_id", string(actorInfo.UserID)),
+		zap.Int64p("max_bytes", b.MaxBytes),
+		zap.Intp("max_rows", b.MaxRows))
+	return api.UpdateUploadLimits204Response{}, nil
+}
+
+// uploadLimitsTargetID identifies the upload limits in the audit log, there's
+// only ever one set of them.
+const uploadLimitsTargetID = "upload-limits"
+
+func (s *Server) uploadLimitsAuthz(ctx context.Context, action pacta.AuditLogAction) (actorInfo, error) {
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
+	if err != nil {
+		return actorInfo, err
+	}
+	as := &authzStatus{
+		primaryTargetID:      uploadLimitsTargetID,
+		primaryTargetType:    pacta.AuditLogTargetType_UploadLimits,
+		primaryTargetOwnerID: systemOwnedEntityOwner,
+		actorInfo:            actorInfo,
+		action:               action,
+	}
+	switch action {
+	case pacta.AuditLogAction_Update:
+		as.isAuthorized, as.authorizedAsActorType = allowIfAdmin(actorInfo)
+	default:
+		return actorInfo, fmt.Errorf("unknown action %q for upload_limits authz", action)
+	}
+	return actorInfo, s.auditLogIfAuthorizedOrFail(ctx, as)
+}
+
+func (s *Server) uploadLimits(tx db.Tx) (*pacta.UploadLimits, error) {
+	ul, err := s.DB.UploadLimits(tx)
+	if err != nil {
+		return nil, oapierr.Internal("failed to load upload limits", zap.Error(err))
+	}
+	return ul, nil
+}
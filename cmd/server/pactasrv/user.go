@@ -9,7 +9,9 @@ import (
 	"github.com/RMI/pacta/oapierr"
 	api "github.com/RMI/pacta/openapi/pacta"
 	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/session"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"go.uber.org/zap"
 )
 
@@ -122,39 +124,125 @@ func (s *Server) UserAuthenticationFollowup(ctx context.Context, _request api.Us
 	if token == nil {
 		return nil, oapierr.BadRequest("nil authorization token")
 	}
-	emailsClaim, ok := token.PrivateClaims()["emails"]
+	mech, authnID, err := session.AuthnIdentityFromContext(ctx)
+	if err != nil {
+		return nil, oapierr.BadRequest("couldn't find authn identity for token", zap.Error(err))
+	}
+	var email string
+	switch {
+	case mech == pacta.AuthnMechanism_EmailAndPass:
+		email, err = emailFromCredentialServiceClaims(token.PrivateClaims())
+	case mech.IsOIDC():
+		email, err = emailFromOIDCClaims(token.PrivateClaims())
+	default:
+		err = oapierr.BadRequest("unknown authn mechanism", zap.String("authn_mechanism", string(mech)))
+	}
+	if err != nil {
+		return nil, err
+	}
+	canonical, err := pacta.CanonicalizeEmail(email)
+	if err != nil {
+		return nil, oapierr.BadRequest(fmt.Sprintf("invalid email: %q", email), zap.String("email", email), zap.Error(err))
+	}
+	user, err := s.DB.GetOrCreateUserByAuthn(s.DB.NoTxn(ctx), mech, authnID, email, canonical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to GetOrCreateUser by authn: %w", err)
+	}
+	result, err := conv.UserToOAPI(user)
+	if err != nil {
+		return nil, err
+	}
+	return api.UserAuthenticationFollowup200JSONResponse(*result), nil
+}
+
+func emailFromCredentialServiceClaims(claims map[string]any) (string, error) {
+	emailsClaim, ok := claims["emails"]
 	if !ok {
-		return nil, oapierr.BadRequest("no email claim in token")
+		return "", oapierr.BadRequest("no email claim in token")
 	}
 	emails, ok := emailsClaim.([]interface{})
 	if !ok || len(emails) == 0 {
-		return nil, oapierr.BadRequest("couldn't find email claim in token", zap.String("emails_claim_type", fmt.Sprintf("%T", emailsClaim)))
+		return "", oapierr.BadRequest("couldn't find email claim in token", zap.String("emails_claim_type", fmt.Sprintf("%T", emailsClaim)))
 	}
 	// TODO(#18) Handle Multiple Emails in the Token Claims gracefully
 	if len(emails) > 1 {
-		return nil, oapierr.BadRequest(fmt.Sprintf("multiple emails in token: %+v", emails))
+		return "", oapierr.BadRequest(fmt.Sprintf("multiple emails in token: %+v", emails))
 	}
 	email, ok := emails[0].(string)
 	if !ok {
-		return nil, oapierr.BadRequest("wrong type for email claim", zap.String("email_claim_type", fmt.Sprintf("%T", emails[0])))
+		return "", oapierr.BadRequest("wrong type for email claim", zap.String("email_claim_type", fmt.Sprintf("%T", emails[0])))
 	}
-	canonical, err := pacta.CanonicalizeEmail(email)
+	return email, nil
+}
+
+// Accounts are linked by email, so we only accept emails that the provider
+// says it has verified, otherwise anyone could claim someone else's account.
+func emailFromOIDCClaims(claims map[string]any) (string, error) {
+	email, ok := claims["email"].(string)
+	if !ok || email == "" {
+		return "", oapierr.BadRequest("no email claim in token")
+	}
+	if verified, ok := claims["email_verified"].(bool); !ok || !verified {
+		return "", oapierr.BadRequest("email in token isn't verified", zap.String("email", email)).
+			WithMessage("Your identity provider hasn't verified your email address.")
+	}
+	return email, nil
+}
+
+// Logs the logged in user out everywhere
+// (POST /user/me:revoke-sessions)
+func (s *Server) RevokeMySessions(ctx context.Context, request api.RevokeMySessionsRequestObject) (api.RevokeMySessionsResponseObject, error) {
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
-		return nil, oapierr.BadRequest(fmt.Sprintf("invalid email: %q", email), zap.String("email", email), zap.Error(err))
+		return nil, err
 	}
-	authnID := token.Subject()
-	if authnID == "" {
-		return nil, oapierr.BadRequest("couldn't find authn id in jwt")
+	if err := forbidIfAPIToken(actorInfo, "revoke sessions"); err != nil {
+		return nil, err
 	}
-	user, err := s.DB.GetOrCreateUserByAuthn(s.DB.NoTxn(ctx), pacta.AuthnMechanism_EmailAndPass, authnID, email, canonical)
-	if err != nil {
-		return nil, fmt.Errorf("failed to GetOrCreateUser by authn: %w", err)
+	currentSessionOnly := request.Body.CurrentSessionOnly != nil && *request.Body.CurrentSessionOnly
+	var token jwt.Token
+	if currentSessionOnly {
+		if token, _, err = jwtauth.FromContext(ctx); err != nil || token == nil {
+			return nil, oapierr.BadRequest("couldn't find authorization token", zap.Error(err))
+		}
+		if token.JwtID() == "" {
+			return nil, oapierr.BadRequest("current session has no jti, so can't be revoked on its own").
+				WithMessage("This session can't be revoked on its own, try revoking all of your sessions instead.")
+		}
 	}
-	result, err := conv.UserToOAPI(user)
+	if err := s.userDoAuthzAndAuditLog(ctx, actorInfo.UserID, pacta.AuditLogAction_RevokeSessions); err != nil {
+		return nil, err
+	}
+	if currentSessionOnly {
+		if err := s.DB.RevokeSession(s.DB.NoTxn(ctx), actorInfo.UserID, token.JwtID(), token.Expiration()); err != nil {
+			return nil, oapierr.Internal("failed to revoke session", zap.String("user_id", string(actorInfo.UserID)), zap.Error(err))
+		}
+		return api.RevokeMySessions204Response{}, nil
+	}
+	if err := s.DB.RevokeUserSessionsBefore(s.DB.NoTxn(ctx), actorInfo.UserID, s.Now()); err != nil {
+		return nil, oapierr.Internal("failed to revoke sessions", zap.String("user_id", string(actorInfo.UserID)), zap.Error(err))
+	}
+	return api.RevokeMySessions204Response{}, nil
+}
+
+// Logs a user out everywhere
+// (POST /user/{id}:revoke-sessions)
+func (s *Server) RevokeUserSessions(ctx context.Context, request api.RevokeUserSessionsRequestObject) (api.RevokeUserSessionsResponseObject, error) {
+	actorInfo, err := s.getActorInfoOrErrIfAnon(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return api.UserAuthenticationFollowup200JSONResponse(*result), nil
+	if err := forbidIfAPIToken(actorInfo, "revoke sessions"); err != nil {
+		return nil, err
+	}
+	id := pacta.UserID(request.Id)
+	if err := s.userDoAuthzAndAuditLog(ctx, id, pacta.AuditLogAction_RevokeSessions); err != nil {
+		return nil, err
+	}
+	if err := s.DB.RevokeUserSessionsBefore(s.DB.NoTxn(ctx), id, s.Now()); err != nil {
+		return nil, oapierr.Internal("failed to revoke sessions", zap.String("user_id", string(id)), zap.Error(err))
+	}
+	return api.RevokeUserSessions204Response{}, nil
 }
 
 // (GET /users)
@@ -205,7 +293,7 @@ func (s *Server) userDoAuthzAndAuditLog(ctx context.Context, targetUserID pacta.
 		action:               action,
 	}
 	switch action {
-	case pacta.AuditLogAction_Update, pacta.AuditLogAction_Delete, pacta.AuditLogAction_ReadMetadata:
+	case pacta.AuditLogAction_Update, pacta.AuditLogAction_Delete, pacta.AuditLogAction_ReadMetadata, pacta.AuditLogAction_RevokeSessions:
 		if actorInfo.UserID == targetUserID {
 			as.isAuthorized = true
 			as.authorizedAsActorType = ptr(pacta.AuditLogActorType_Owner)
@@ -1,8 +1,15 @@
 // Command genjwt creates and signs a JWT token that can be used with the PACTA
 // API.
+//
+// To test key rotation locally, generate a new key with a new key ID, add it to
+// the JWKS document the server loads with --secret_auth_jwks_file, and sign
+// with it:
+//
+//	genjwt --generate_key --key_id=2024-01-01 --private_key_file=new.key --jwks_file=jwks.json
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
@@ -10,6 +17,8 @@ import (
 
 	"github.com/RMI/pacta/keyutil"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
 func main() {
@@ -23,6 +32,10 @@ func run() error {
 		keyType = flag.String("key_type", "ed25519", "The type of the key pair to load, currently only 'ed25519' is supported")
 
 		privKeyFile = flag.String("private_key_file", "test_server.key", "The path to read the private key to use for signing from.")
+		keyID       = flag.String("key_id", "", "If set, the key ID (kid) to put in the token header, which the server uses to pick the key to verify with.")
+		generateKey = flag.Bool("generate_key", false, "If true, generate a new key pair, writing the private key to --private_key_file and the public key to --public_key_file, and sign with it.")
+		pubKeyFile  = flag.String("public_key_file", "test_server.pub", "The path to write the public key to when --generate_key is set.")
+		jwksFile    = flag.String("jwks_file", "", "If set, add the public key to this JWKS document under --key_id, creating it if needed.")
 		userID      = flag.String("user_id", "test123", "The ID of the user to put in the 'sub' claim of the token.")
 		expiresIn   = flag.String("expires_in", "24h", "When the token should expire, relative to now. Should be formatted in a way that time.ParseDuration can handle.")
 	)
@@ -40,17 +53,43 @@ func run() error {
 		return fmt.Errorf("failed to parse duration: %w", err)
 	}
 
+	if *jwksFile != "" && *keyID == "" {
+		return errors.New("--jwks_file requires a --key_id")
+	}
+
+	if *generateKey {
+		if err := keyutil.GenerateED25519ToFiles(*pubKeyFile, *privKeyFile); err != nil {
+			return fmt.Errorf("failed to generate key pair: %w", err)
+		}
+	}
+
 	priv, err := keyutil.DecodeED25519PrivateKeyFromFile(*privKeyFile)
 	if err != nil {
 		return fmt.Errorf("failed to load private key file: %w", err)
 	}
 
-	jwtAuth := jwtauth.New("EdDSA", priv, nil /* verify, unused */)
+	var signKey any = priv
+	if *keyID != "" {
+		// The kid header is taken from the JWK.
+		if signKey, err = keyutil.ED25519JWK(*keyID, priv); err != nil {
+			return fmt.Errorf("failed to make JWK: %w", err)
+		}
+	}
+	if *jwksFile != "" {
+		if err := keyutil.AddToJWKSFile(*jwksFile, signKey.(jwk.Key)); err != nil {
+			return fmt.Errorf("failed to add key to JWKS file: %w", err)
+		}
+	}
+
+	jwtAuth := jwtauth.New("EdDSA", signKey, nil /* verify, unused */)
 	now := time.Now()
 	claims := map[string]any{
 		"sub": *userID,
 		"exp": now.Add(expiresInDur),
 		"nbf": now.Add(-5 * time.Second),
+		"iat": now,
+		// The ID lets the session be revoked individually.
+		"jti": uuid.NewString(),
 	}
 
 	_, tkn, err := jwtAuth.Encode(claims)
@@ -70,6 +70,22 @@ func SetUserPreferredLanguage(value pacta.Language) UpdateUserFn {
 	}
 }
 
+type UpdateAPITokenFn func(*pacta.APIToken) error
+
+func SetAPITokenLastUsedAt(value time.Time) UpdateAPITokenFn {
+	return func(v *pacta.APIToken) error {
+		v.LastUsedAt = value
+		return nil
+	}
+}
+
+func SetAPITokenRevokedAt(value time.Time) UpdateAPITokenFn {
+	return func(v *pacta.APIToken) error {
+		v.RevokedAt = value
+		return nil
+	}
+}
+
 type UpdatePACTAVersionFn func(*pacta.PACTAVersion) error
 
 func SetPACTAVersionName(value string) UpdatePACTAVersionFn {
@@ -93,6 +109,50 @@ func SetPACTAVersionDigest(value string) UpdatePACTAVersionFn {
 	}
 }
 
+func SetPACTAVersionDashboardDigest(value string) UpdatePACTAVersionFn {
+	return func(v *pacta.PACTAVersion) error {
+		v.DashboardDigest = value
+		return nil
+	}
+}
+
+func SetPACTAVersionInherit(value string) UpdatePACTAVersionFn {
+	return func(v *pacta.PACTAVersion) error {
+		v.Inherit = value
+		return nil
+	}
+}
+
+func SetPACTAVersionBenchmarkDataset(value string) UpdatePACTAVersionFn {
+	return func(v *pacta.PACTAVersion) error {
+		v.BenchmarkDataset = value
+		return nil
+	}
+}
+
+func SetPACTAVersionPACTADataset(value string) UpdatePACTAVersionFn {
+	return func(v *pacta.PACTAVersion) error {
+		v.PACTADataset = value
+		return nil
+	}
+}
+
+type UpdateUploadLimitsFn func(*pacta.UploadLimits) error
+
+func SetUploadLimitsMaxBytes(value int64) UpdateUploadLimitsFn {
+	return func(v *pacta.UploadLimits) error {
+		v.MaxBytes = value
+		return nil
+	}
+}
+
+func SetUploadLimitsMaxRows(value int) UpdateUploadLimitsFn {
+	return func(v *pacta.UploadLimits) error {
+		v.MaxRows = value
+		return nil
+	}
+}
+
 type UpdateInitiativeFn func(*pacta.Initiative) error
 
 func SetInitiativeName(value string) UpdateInitiativeFn {
@@ -193,6 +253,13 @@ func SetBlobFileName(v string) UpdateBlobFn {
 	}
 }
 
+func SetBlobContentMD5(v string) UpdateBlobFn {
+	return func(b *pacta.Blob) error {
+		b.ContentMD5 = v
+		return nil
+	}
+}
+
 type UpdatePortfolioFn func(*pacta.Portfolio) error
 
 func SetPortfolioName(value string) UpdatePortfolioFn {
@@ -332,6 +399,13 @@ func SetAnalysisFailureMessage(value string) UpdateAnalysisFn {
 	}
 }
 
+func SetAnalysisImageTag(value string) UpdateAnalysisFn {
+	return func(v *pacta.Analysis) error {
+		v.ImageTag = value
+		return nil
+	}
+}
+
 type UpdateAnalysisArtifactFn func(*pacta.AnalysisArtifact) error
 
 func SetAnalysisArtifactAdminDebugEnabled(value bool) UpdateAnalysisArtifactFn {
@@ -348,6 +422,78 @@ func SetAnalysisArtifactSharedToPublic(value bool) UpdateAnalysisArtifactFn {
 	}
 }
 
+type UpdateTaskFn func(*pacta.Task) error
+
+func SetTaskOwner(value pacta.OwnerID) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.Owner = &pacta.Owner{ID: value}
+		return nil
+	}
+}
+
+func SetTaskRequest(value string) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.Request = value
+		return nil
+	}
+}
+
+func SetTaskRunnerID(value string) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.RunnerID = value
+		return nil
+	}
+}
+
+func SetTaskImageTag(value string) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.ImageTag = value
+		return nil
+	}
+}
+
+func SetTaskState(value pacta.TaskState) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.State = value
+		return nil
+	}
+}
+
+func IncrementTaskAttemptCount() UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.AttemptCount++
+		return nil
+	}
+}
+
+func SetTaskStartedAt(value time.Time) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.StartedAt = value
+		return nil
+	}
+}
+
+// SetTaskStartedIfQueued marks a task as RUNNING, unless it has already moved
+// on, e.g. because it finished before its start was recorded.
+func SetTaskStartedIfQueued(value time.Time) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		if v.StartedAt.IsZero() {
+			v.StartedAt = value
+		}
+		if v.State == pacta.TaskState_Queued {
+			v.State = pacta.TaskState_Running
+		}
+		return nil
+	}
+}
+
+func SetTaskCompletedAt(value time.Time) UpdateTaskFn {
+	return func(v *pacta.Task) error {
+		v.CompletedAt = value
+		return nil
+	}
+}
+
 type UpdateIncompleteUploadFn func(*pacta.IncompleteUpload) error
 
 func SetIncompleteUploadOwner(value pacta.OwnerID) UpdateIncompleteUploadFn {
@@ -406,6 +552,34 @@ func SetIncompleteUploadFailureMessage(value string) UpdateIncompleteUploadFn {
 	}
 }
 
+func SetIncompleteUploadDuplicatePortfolios(value []pacta.PortfolioID) UpdateIncompleteUploadFn {
+	return func(v *pacta.IncompleteUpload) error {
+		v.DuplicatePortfolios = value
+		return nil
+	}
+}
+
+func SetIncompleteUploadReplacesPortfolio(value pacta.PortfolioID) UpdateIncompleteUploadFn {
+	return func(v *pacta.IncompleteUpload) error {
+		v.ReplacesPortfolio = &pacta.Portfolio{ID: value}
+		return nil
+	}
+}
+
+func SetIncompleteUploadLogBlob(value pacta.BlobID) UpdateIncompleteUploadFn {
+	return func(v *pacta.IncompleteUpload) error {
+		v.LogBlob = &pacta.Blob{ID: value}
+		return nil
+	}
+}
+
+func SetIncompleteUploadParseResult(value *pacta.ParseResult) UpdateIncompleteUploadFn {
+	return func(v *pacta.IncompleteUpload) error {
+		v.ParseResult = value
+		return nil
+	}
+}
+
 func SetIncompleteUploadPropertyHoldingsDate(value *pacta.HoldingsDate) UpdateIncompleteUploadFn {
 	return func(v *pacta.IncompleteUpload) error {
 		v.Properties.HoldingsDate = value
@@ -436,16 +610,9 @@ func SetIncompleteUploadPropertyEngagementStrategy(value *bool) UpdateIncomplete
 
 type UpdateInitiativeUserRelationshipFn func(*pacta.InitiativeUserRelationship) error
 
-func SetInitiativeUserRelationshipMember(value bool) UpdateInitiativeUserRelationshipFn {
-	return func(v *pacta.InitiativeUserRelationship) error {
-		v.Member = value
-		return nil
-	}
-}
-
-func SetInitiativeUserRelationshipManager(value bool) UpdateInitiativeUserRelationshipFn {
+func SetInitiativeUserRelationshipRole(value pacta.InitiativeUserRole) UpdateInitiativeUserRelationshipFn {
 	return func(v *pacta.InitiativeUserRelationship) error {
-		v.Manager = value
+		v.Role = value
 		return nil
 	}
 }
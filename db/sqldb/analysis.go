@@ -1,6 +1,7 @@
 package sqldb
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/RMI/pacta/db"
@@ -27,6 +28,7 @@ func analysisQuery(where string) string {
 			analysis.completed_at,
 			analysis.failure_code,
 			analysis.failure_message,
+			analysis.image_tag,
 			aas.analysis_artifact_ids
 		FROM
 			analysis
@@ -54,6 +56,20 @@ func (d *DB) Analysis(tx db.Tx, id pacta.AnalysisID) (*pacta.Analysis, error) {
 	return exactlyOne("analysis", id, pvs)
 }
 
+// LockAnalysis locks the given analysis until the end of the transaction, so
+// that checks made before changing it (or its tasks) can't race.
+func (d *DB) LockAnalysis(tx db.Tx, id pacta.AnalysisID) error {
+	var lockedID pacta.AnalysisID
+	row := d.queryRow(tx, `SELECT id FROM analysis WHERE id = $1 FOR UPDATE;`, id)
+	if err := row.Scan(&lockedID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.NotFound(id, "analysis")
+		}
+		return fmt.Errorf("locking analysis: %w", err)
+	}
+	return nil
+}
+
 func (d *DB) Analyses(tx db.Tx, ids []pacta.AnalysisID) (map[pacta.AnalysisID]*pacta.Analysis, error) {
 	ids = dedupeIDs(ids)
 	rows, err := d.query(tx, analysisQuery(`WHERE id IN `+createWhereInFmt(len(ids))), idsToInterface(ids)...)
@@ -148,6 +164,10 @@ func (d *DB) DeleteAnalysis(tx db.Tx, id pacta.AnalysisID) ([]pacta.BlobURI, err
 		if err != nil {
 			return fmt.Errorf("retrieving analysis_artifacts blob_uris: %w", err)
 		}
+		err = d.exec(tx, `DELETE FROM task WHERE analysis_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("deleting analysis tasks: %w", err)
+		}
 		err = d.exec(tx, `DELETE FROM analysis WHERE id = $1;`, id)
 		if err != nil {
 			return fmt.Errorf("deleting analysis: %w", err)
@@ -173,6 +193,7 @@ func rowToAnalysis(row rowScanner) (*pacta.Analysis, error) {
 	var (
 		aType                       string
 		failureCode, failureMessage pgtype.Text
+		imageTag                    pgtype.Text
 		ranAt, completedAt          pgtype.Timestamptz
 		artifactIDs                 []pacta.AnalysisArtifactID
 	)
@@ -189,6 +210,7 @@ func rowToAnalysis(row rowScanner) (*pacta.Analysis, error) {
 		&completedAt,
 		&failureCode,
 		&failureMessage,
+		&imageTag,
 		&artifactIDs,
 	)
 	if err != nil {
@@ -213,6 +235,9 @@ func rowToAnalysis(row rowScanner) (*pacta.Analysis, error) {
 	if failureMessage.Valid {
 		a.FailureMessage = failureMessage.String
 	}
+	if imageTag.Valid {
+		a.ImageTag = imageTag.String
+	}
 	for _, id := range artifactIDs {
 		a.Artifacts = append(a.Artifacts, &pacta.AnalysisArtifact{ID: id})
 	}
@@ -232,7 +257,8 @@ func (db *DB) putAnalysis(tx db.Tx, a *pacta.Analysis) error {
 			ran_at = $5,
 			completed_at = $6,
 			failure_code = $7,
-			failure_message = $8
+			failure_message = $8,
+			image_tag = $9
 		WHERE id = $1;
 		`,
 		a.ID,
@@ -243,6 +269,7 @@ func (db *DB) putAnalysis(tx db.Tx, a *pacta.Analysis) error {
 		timeToNilable(a.CompletedAt),
 		strToNilable(a.FailureCode),
 		strToNilable(a.FailureMessage),
+		strToNilable(a.ImageTag),
 	)
 	if err != nil {
 		return fmt.Errorf("updating analysis writable fields: %w", err)
@@ -14,7 +14,8 @@ const analysisArtifactSelectColumns = `
 	analysis_artifact.analysis_id,
 	analysis_artifact.blob_id,
 	analysis_artifact.admin_debug_enabled,
-	analysis_artifact.shared_to_public
+	analysis_artifact.shared_to_public,
+	analysis_artifact.admin_only
 `
 
 func (d *DB) AnalysisArtifact(tx db.Tx, id pacta.AnalysisArtifactID) (*pacta.AnalysisArtifact, error) {
@@ -70,10 +71,10 @@ func (d *DB) CreateAnalysisArtifact(tx db.Tx, a *pacta.AnalysisArtifact) (pacta.
 	id := pacta.AnalysisArtifactID(d.randomID(analysisArtifactIDNamespace))
 	err := d.exec(tx, `
 		INSERT INTO analysis_artifact 
-			(id, analysis_id, blob_id, admin_debug_enabled, shared_to_public)
+			(id, analysis_id, blob_id, admin_debug_enabled, shared_to_public, admin_only)
 			VALUES
-			($1, $2, $3, $4, $5);
-	`, id, a.AnalysisID, a.Blob.ID, a.AdminDebugEnabled, a.SharedToPublic)
+			($1, $2, $3, $4, $5, $6);
+	`, id, a.AnalysisID, a.Blob.ID, a.AdminDebugEnabled, a.SharedToPublic, a.AdminOnly)
 	if err != nil {
 		return "", fmt.Errorf("creating analysis_artifact row: %w", err)
 	}
@@ -150,6 +151,7 @@ func rowToAnalysisArtifact(row rowScanner) (*pacta.AnalysisArtifact, error) {
 		&a.Blob.ID,
 		&a.AdminDebugEnabled,
 		&a.SharedToPublic,
+		&a.AdminOnly,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scanning into analysis_artifact: %w", err)
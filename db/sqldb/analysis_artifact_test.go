@@ -43,6 +43,7 @@ func TestAnalysisArtifacts(t *testing.T) {
 	aa1 := &pacta.AnalysisArtifact{
 		AnalysisID: aid,
 		Blob:       &pacta.Blob{ID: b1.ID},
+		AdminOnly:  true,
 	}
 	aa1.ID, err = tdb.CreateAnalysisArtifact(tx, aa1)
 	if err != nil {
@@ -109,6 +110,7 @@ func TestAnalysisArtifacts(t *testing.T) {
 		PrimaryTargetType:    "ANALYSIS",
 		PrimaryTargetID:      string(aid),
 		AdminDebugEnabled:    false,
+		AdminOnly:            true,
 	}, {
 		BlobID:               b2.ID,
 		PrimaryTargetOwnerID: o.ID,
@@ -59,12 +59,20 @@ func TestAnalysisCRUD(t *testing.T) {
 		t.Fatalf("analysis mismatch (-want +got):\n%s", diff)
 	}
 
+	if err := tdb.LockAnalysis(tx, iu.ID); err != nil {
+		t.Fatalf("locking analysis: %v", err)
+	}
+	if err := tdb.LockAnalysis(tx, "nonsense"); !db.IsNotFound(err) {
+		t.Fatalf("locking missing analysis: got err %v, want not found", err)
+	}
+
 	nName := "new-name"
 	nDesc := "new-description"
 	ranAt := time.UnixMilli(111111111)
 	completedAt := time.UnixMilli(222222222)
 	failureCode := pacta.FailureCode_Unknown
 	failureMessage := "failureMessage"
+	imageTag := "example.azurecr.io/runner@sha256:0123"
 	err = tdb.UpdateAnalysis(tx, iu.ID,
 		db.SetAnalysisName(nName),
 		db.SetAnalysisDescription(nDesc),
@@ -73,6 +81,7 @@ func TestAnalysisCRUD(t *testing.T) {
 		db.SetAnalysisFailureCode(failureCode),
 		db.SetAnalysisCompletedAt(completedAt),
 		db.SetAnalysisFailureMessage(failureMessage),
+		db.SetAnalysisImageTag(imageTag),
 	)
 	if err != nil {
 		t.Fatalf("updating analysis: %v", err)
@@ -84,6 +93,7 @@ func TestAnalysisCRUD(t *testing.T) {
 	iu.FailureCode = failureCode
 	iu.CompletedAt = completedAt
 	iu.FailureMessage = failureMessage
+	iu.ImageTag = imageTag
 
 	actual, err = tdb.Analysis(tx, iu.ID)
 	if err != nil {
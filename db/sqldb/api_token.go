@@ -0,0 +1,192 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const apiTokenIDNamespace = "apit"
+const apiTokenSelectColumns = `
+	api_token.id,
+	api_token.user_id,
+	api_token.name,
+	api_token.scopes::TEXT[],
+	api_token.created_at,
+	api_token.expires_at,
+	api_token.last_used_at,
+	api_token.revoked_at`
+
+func (d *DB) APIToken(tx db.Tx, id pacta.APITokenID) (*pacta.APIToken, error) {
+	rows, err := d.query(tx, `
+		SELECT `+apiTokenSelectColumns+`
+		FROM api_token
+		WHERE id = $1;`, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying api_token: %w", err)
+	}
+	ts, err := rowsToAPITokens(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to api_tokens: %w", err)
+	}
+	return exactlyOne("api_token", id, ts)
+}
+
+// APITokenByHash looks up a token by the hash of its secret. It doesn't check
+// whether the token has been revoked or has expired, that's up to the caller.
+func (d *DB) APITokenByHash(tx db.Tx, tokenHash string) (*pacta.APIToken, error) {
+	rows, err := d.query(tx, `
+		SELECT `+apiTokenSelectColumns+`
+		FROM api_token
+		WHERE token_hash = $1;`, tokenHash)
+	if err != nil {
+		return nil, fmt.Errorf("querying api_token by hash: %w", err)
+	}
+	ts, err := rowsToAPITokens(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to api_tokens: %w", err)
+	}
+	return exactlyOne("api_token", "<redacted hash>", ts)
+}
+
+func (d *DB) APITokensByUser(tx db.Tx, uid pacta.UserID) ([]*pacta.APIToken, error) {
+	rows, err := d.query(tx, `
+		SELECT `+apiTokenSelectColumns+`
+		FROM api_token
+		WHERE user_id = $1
+		ORDER BY created_at DESC;`, uid)
+	if err != nil {
+		return nil, fmt.Errorf("querying api_tokens by user: %w", err)
+	}
+	ts, err := rowsToAPITokens(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to api_tokens: %w", err)
+	}
+	return ts, nil
+}
+
+func (d *DB) CreateAPIToken(tx db.Tx, t *pacta.APIToken, tokenHash string) (pacta.APITokenID, error) {
+	if err := validateAPITokenForCreation(t, tokenHash); err != nil {
+		return "", fmt.Errorf("validating api_token for creation: %w", err)
+	}
+	scopes := make([]string, len(t.Scopes))
+	for i, s := range t.Scopes {
+		scopes[i] = string(s)
+	}
+	id := pacta.APITokenID(d.randomID(apiTokenIDNamespace))
+	err := d.exec(tx, `
+		INSERT INTO api_token
+			(id, user_id, name, token_hash, scopes, expires_at)
+			VALUES
+			($1, $2, $3, $4, $5::api_token_scope[], $6);`,
+		id, t.User.ID, t.Name, tokenHash, scopes, timeToNilable(t.ExpiresAt))
+	if err != nil {
+		return "", fmt.Errorf("creating api_token: %w", err)
+	}
+	return id, nil
+}
+
+func (d *DB) UpdateAPIToken(tx db.Tx, id pacta.APITokenID, mutations ...db.UpdateAPITokenFn) error {
+	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
+		t, err := d.APIToken(tx, id)
+		if err != nil {
+			return fmt.Errorf("reading api_token: %w", err)
+		}
+		for i, m := range mutations {
+			err := m(t)
+			if err != nil {
+				return fmt.Errorf("running %d-th mutation: %w", i, err)
+			}
+		}
+		err = d.putAPIToken(tx, t)
+		if err != nil {
+			return fmt.Errorf("putting api_token: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("updating api_token: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) putAPIToken(tx db.Tx, t *pacta.APIToken) error {
+	err := d.exec(tx, `
+		UPDATE api_token SET
+			last_used_at = $2,
+			revoked_at = $3
+		WHERE id = $1;
+		`, t.ID, timeToNilable(t.LastUsedAt), timeToNilable(t.RevokedAt))
+	if err != nil {
+		return fmt.Errorf("updating api_token writable fields: %w", err)
+	}
+	return nil
+}
+
+func rowToAPIToken(row rowScanner) (*pacta.APIToken, error) {
+	t := &pacta.APIToken{User: &pacta.User{}}
+	var scopes []string
+	var expiresAt, lastUsedAt, revokedAt pgtype.Timestamptz
+	err := row.Scan(
+		&t.ID,
+		&t.User.ID,
+		&t.Name,
+		&scopes,
+		&t.CreatedAt,
+		&expiresAt,
+		&lastUsedAt,
+		&revokedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scanning into api_token: %w", err)
+	}
+	for _, s := range scopes {
+		scope, err := pacta.ParseAPITokenScope(s)
+		if err != nil {
+			return nil, fmt.Errorf("parsing api_token scope: %w", err)
+		}
+		t.Scopes = append(t.Scopes, scope)
+	}
+	if expiresAt.Valid {
+		t.ExpiresAt = expiresAt.Time
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = revokedAt.Time
+	}
+	return t, nil
+}
+
+func rowsToAPITokens(rows pgx.Rows) ([]*pacta.APIToken, error) {
+	return mapRows("api_token", rows, rowToAPIToken)
+}
+
+func validateAPITokenForCreation(t *pacta.APIToken, tokenHash string) error {
+	if t.ID != "" {
+		return fmt.Errorf("api_token already has an ID")
+	}
+	if t.User == nil || t.User.ID == "" {
+		return fmt.Errorf("api_token missing required user")
+	}
+	if t.Name == "" {
+		return fmt.Errorf("api_token missing required name")
+	}
+	if tokenHash == "" {
+		return fmt.Errorf("api_token missing required token hash")
+	}
+	if !t.CreatedAt.IsZero() {
+		return fmt.Errorf("api_token already has a CreatedAt")
+	}
+	if !t.LastUsedAt.IsZero() {
+		return fmt.Errorf("api_token already has a LastUsedAt")
+	}
+	if !t.RevokedAt.IsZero() {
+		return fmt.Errorf("api_token already has a RevokedAt")
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package sqldb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestCreateAPIToken(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u := userForTesting(t, tdb)
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	t1 := &pacta.APIToken{
+		User:      &pacta.User{ID: u.ID},
+		Name:      "notebook",
+		Scopes:    []pacta.APITokenScope{pacta.APITokenScope_ReadOnly, pacta.APITokenScope_Upload},
+		ExpiresAt: expiresAt,
+	}
+	id1, err := tdb.CreateAPIToken(tx, t1, "hash-1")
+	if err != nil {
+		t.Fatalf("creating api_token: %v", err)
+	}
+	t1.ID = id1
+	t1.CreatedAt = time.Now()
+
+	t2 := &pacta.APIToken{
+		User: &pacta.User{ID: u.ID},
+		Name: "ci",
+	}
+	id2, err := tdb.CreateAPIToken(tx, t2, "hash-2")
+	if err != nil {
+		t.Fatalf("creating api_token: %v", err)
+	}
+	t2.ID = id2
+	t2.CreatedAt = time.Now()
+
+	// Hashes must be unique.
+	_, err = tdb.CreateAPIToken(tx, &pacta.APIToken{User: &pacta.User{ID: u.ID}, Name: "dupe"}, "hash-1")
+	if err == nil {
+		t.Fatalf("expected error creating api_token with duplicate hash, got nil")
+	}
+
+	// Read By ID
+	actual, err := tdb.APIToken(tx, id1)
+	if err != nil {
+		t.Fatalf("getting api_token: %v", err)
+	}
+	if diff := cmp.Diff(t1, actual, apiTokenCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+
+	// Read By Hash
+	actual, err = tdb.APITokenByHash(tx, "hash-2")
+	if err != nil {
+		t.Fatalf("getting api_token by hash: %v", err)
+	}
+	if diff := cmp.Diff(t2, actual, apiTokenCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+	_, err = tdb.APITokenByHash(tx, "hash-3")
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+
+	// Read By User
+	actuals, err := tdb.APITokensByUser(tx, u.ID)
+	if err != nil {
+		t.Fatalf("getting api_tokens by user: %v", err)
+	}
+	if diff := cmp.Diff([]*pacta.APIToken{t1, t2}, actuals, apiTokenCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+}
+
+func TestUpdateAPIToken(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u := userForTesting(t, tdb)
+	id, err0 := tdb.CreateAPIToken(tx, &pacta.APIToken{User: &pacta.User{ID: u.ID}, Name: "notebook"}, "hash")
+	noErrDuringSetup(t, err0)
+
+	now := time.Now()
+	err := tdb.UpdateAPIToken(tx, id,
+		db.SetAPITokenLastUsedAt(now),
+		db.SetAPITokenRevokedAt(now))
+	if err != nil {
+		t.Fatalf("updating api_token: %v", err)
+	}
+
+	actual, err := tdb.APIToken(tx, id)
+	if err != nil {
+		t.Fatalf("getting api_token: %v", err)
+	}
+	expected := &pacta.APIToken{
+		ID:         id,
+		User:       &pacta.User{ID: u.ID},
+		Name:       "notebook",
+		CreatedAt:  now,
+		LastUsedAt: now,
+		RevokedAt:  now,
+	}
+	if diff := cmp.Diff(expected, actual, apiTokenCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+}
+
+func TestAPITokensDeletedWithUser(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u := userForTesting(t, tdb)
+	id, err0 := tdb.CreateAPIToken(tx, &pacta.APIToken{User: &pacta.User{ID: u.ID}, Name: "notebook"}, "hash")
+	noErrDuringSetup(t, err0)
+
+	if _, err := tdb.DeleteUser(tx, u.ID); err != nil {
+		t.Fatalf("deleting user: %v", err)
+	}
+
+	_, err := tdb.APIToken(tx, id)
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestAPITokenScopePersistability(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u := userForTesting(t, tdb)
+	for _, scope := range pacta.APITokenScopeValues {
+		id, err := tdb.CreateAPIToken(tx, &pacta.APIToken{
+			User:   &pacta.User{ID: u.ID},
+			Name:   string(scope),
+			Scopes: []pacta.APITokenScope{scope},
+		}, "hash-"+string(scope))
+		if err != nil {
+			t.Fatalf("creating api_token with scope %q: %v", scope, err)
+		}
+		actual, err := tdb.APIToken(tx, id)
+		if err != nil {
+			t.Fatalf("reading api_token with scope %q: %v", scope, err)
+		}
+		if diff := cmp.Diff([]pacta.APITokenScope{scope}, actual.Scopes); diff != "" {
+			t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+		}
+	}
+}
+
+func apiTokenCmpOpts() cmp.Option {
+	apiTokenLessFn := func(a, b *pacta.APIToken) bool {
+		return a.ID < b.ID
+	}
+	return cmp.Options{
+		cmpopts.EquateEmpty(),
+		cmpopts.EquateApproxTime(time.Second),
+		cmpopts.SortSlices(apiTokenLessFn),
+	}
+}
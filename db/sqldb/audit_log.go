@@ -18,6 +18,7 @@ const auditLogSelectColumns = `
 	audit_log.actor_type,
 	audit_log.actor_id,
 	audit_log.actor_owner_id,
+	audit_log.actor_api_token_id,
 	audit_log.primary_target_type,
 	audit_log.primary_target_id,
 	audit_log.primary_target_owner_id,
@@ -111,15 +112,15 @@ func (d *DB) buildCreateAuditLogQuery(tx db.Tx, a *pacta.AuditLog) (string, []in
 	sql := `
 		INSERT INTO audit_log 
 			(
-				id, action, actor_type, actor_id, actor_owner_id,
+				id, action, actor_type, actor_id, actor_owner_id, actor_api_token_id,
 				primary_target_type, primary_target_id, primary_target_owner_id,
 				secondary_target_type, secondary_target_id, secondary_target_owner_id
 			)
 			VALUES
-			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);
 	`
 	args := []interface{}{
-		id, a.Action, a.ActorType, a.ActorID, ownerFn(a.ActorOwner),
+		id, a.Action, a.ActorType, a.ActorID, ownerFn(a.ActorOwner), strToNilable(a.ActorAPITokenID),
 		a.PrimaryTargetType, a.PrimaryTargetID, ownerFn(a.PrimaryTargetOwner),
 		stt, a.SecondaryTargetID, ownerFn(a.SecondaryTargetOwner),
 	}
@@ -134,9 +135,9 @@ func rowToAuditLog(row rowScanner) (*pacta.AuditLog, error) {
 	a := &pacta.AuditLog{}
 	var actorType, primaryType string
 	var actorOwner, primaryOwner pacta.OwnerID
-	var secondaryType, secondaryOwner pgtype.Text
+	var secondaryType, secondaryOwner, actorAPIToken pgtype.Text
 	err := row.Scan(
-		&a.ID, &a.Action, &actorType, &a.ActorID, &actorOwner,
+		&a.ID, &a.Action, &actorType, &a.ActorID, &actorOwner, &actorAPIToken,
 		&primaryType, &a.PrimaryTargetID, &primaryOwner,
 		&secondaryType, &a.SecondaryTargetID, &secondaryOwner,
 		&a.CreatedAt,
@@ -158,6 +159,9 @@ func rowToAuditLog(row rowScanner) (*pacta.AuditLog, error) {
 	if secondaryOwner.Valid {
 		a.SecondaryTargetOwner = &pacta.Owner{ID: pacta.OwnerID(secondaryOwner.String)}
 	}
+	if actorAPIToken.Valid {
+		a.ActorAPITokenID = pacta.APITokenID(actorAPIToken.String)
+	}
 	if actorOwner != "" {
 		a.ActorOwner = &pacta.Owner{ID: actorOwner}
 	}
@@ -22,6 +22,7 @@ func TestCreateAuditLog(t *testing.T) {
 		ActorType:            pacta.AuditLogActorType_Owner,
 		ActorID:              "user1",
 		ActorOwner:           &pacta.Owner{ID: "owner1"},
+		ActorAPITokenID:      "token1",
 		PrimaryTargetType:    pacta.AuditLogTargetType_Portfolio,
 		PrimaryTargetID:      "portfolio-1",
 		PrimaryTargetOwner:   &pacta.Owner{ID: "user2"},
@@ -6,6 +6,7 @@ import (
 	"github.com/RMI/pacta/db"
 	"github.com/RMI/pacta/pacta"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const blobIDNamespace = "blob"
@@ -14,7 +15,8 @@ const blobSelectColumns = `
 	blob.blob_uri,
 	blob.file_type,
 	blob.file_name,
-	blob.created_at`
+	blob.created_at,
+	blob.content_md5`
 
 func (d *DB) Blob(tx db.Tx, id pacta.BlobID) (*pacta.Blob, error) {
 	rows, err := d.query(tx, `
@@ -61,10 +63,10 @@ func (d *DB) CreateBlob(tx db.Tx, b *pacta.Blob) (pacta.BlobID, error) {
 	id := pacta.BlobID(d.randomID(blobIDNamespace))
 	err := d.exec(tx, `
 		INSERT INTO blob 
-			(id, blob_uri, file_type, file_name)
+			(id, blob_uri, file_type, file_name, content_md5)
 			VALUES
-			($1, $2, $3, $4);
-	`, id, b.BlobURI, b.FileType, b.FileName)
+			($1, $2, $3, $4, $5);
+	`, id, b.BlobURI, b.FileType, b.FileName, strToNilable(b.ContentMD5))
 	if err != nil {
 		return "", fmt.Errorf("creating blob row: %w", err)
 	}
@@ -116,6 +118,7 @@ func (d *DB) BlobContexts(tx db.Tx, ids []pacta.BlobID) ([]*pacta.BlobContext, e
 	SELECT
 		analysis_artifact.blob_id as blob_id,
 		analysis_artifact.admin_debug_enabled,
+		analysis_artifact.admin_only,
 		analysis.owner_id as owner_id,
 		'ANALYSIS' as primary_target_type,
 		analysis.id as primary_target_id
@@ -128,15 +131,28 @@ func (d *DB) BlobContexts(tx db.Tx, ids []pacta.BlobID) ([]*pacta.BlobContext, e
 	SELECT
 		blob_id,
 		admin_debug_enabled,
+		FALSE as admin_only,
 		owner_id,
 		'INCOMPLETE_UPLOAD' as  primary_target_type,
 		incomplete_upload.id as primary_target_id
 	FROM incomplete_upload
 	WHERE blob_id IN `+whereInFmt+`
+) UNION ALL (
+	SELECT
+		log_blob_id,
+		admin_debug_enabled,
+		-- Parser logs are for debugging, and aren't shown to the owner.
+		TRUE as admin_only,
+		owner_id,
+		'INCOMPLETE_UPLOAD' as  primary_target_type,
+		incomplete_upload.id as primary_target_id
+	FROM incomplete_upload
+	WHERE log_blob_id IN `+whereInFmt+`
 ) UNION ALL (
 	SELECT
 		blob_id,
 		admin_debug_enabled,
+		FALSE as admin_only,
 		owner_id,
 		'PORTFOLIO' as primary_target_type,
 		portfolio.id as primary_target_id
@@ -154,10 +170,11 @@ func (d *DB) BlobContexts(tx db.Tx, ids []pacta.BlobID) ([]*pacta.BlobContext, e
 	for rows.Next() {
 		var blobID pacta.BlobID
 		var ade bool
+		var adminOnly bool
 		var ownerID pacta.OwnerID
 		var ptt string
 		var ptid string
-		err := rows.Scan(&blobID, &ade, &ownerID, &ptt, &ptid)
+		err := rows.Scan(&blobID, &ade, &adminOnly, &ownerID, &ptt, &ptid)
 		if err != nil {
 			return nil, fmt.Errorf("scanning blob owner: %w", err)
 		}
@@ -178,6 +195,7 @@ func (d *DB) BlobContexts(tx db.Tx, ids []pacta.BlobID) ([]*pacta.BlobContext, e
 		result = append(result, &pacta.BlobContext{
 			BlobID:               blobID,
 			AdminDebugEnabled:    ade,
+			AdminOnly:            adminOnly,
 			PrimaryTargetType:    pttParsed,
 			PrimaryTargetID:      ptid,
 			PrimaryTargetOwnerID: ownerID,
@@ -195,9 +213,10 @@ func (d *DB) BlobContexts(tx db.Tx, ids []pacta.BlobID) ([]*pacta.BlobContext, e
 func (db *DB) putBlob(tx db.Tx, b *pacta.Blob) error {
 	err := db.exec(tx, `
 		UPDATE blob SET
-			file_name = $2
+			file_name = $2,
+			content_md5 = $3
 		WHERE id = $1;
-		`, b.ID, b.FileName)
+		`, b.ID, b.FileName, strToNilable(b.ContentMD5))
 	if err != nil {
 		return fmt.Errorf("updating blob writable fields: %w", err)
 	}
@@ -211,16 +230,19 @@ func rowsToBlobs(rows pgx.Rows) ([]*pacta.Blob, error) {
 func rowToBlob(row rowScanner) (*pacta.Blob, error) {
 	b := &pacta.Blob{}
 	fileType := ""
+	var contentMD5 pgtype.Text
 	err := row.Scan(
 		&b.ID,
 		&b.BlobURI,
 		&fileType,
 		&b.FileName,
 		&b.CreatedAt,
+		&contentMD5,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scanning into blob: %w", err)
 	}
+	b.ContentMD5 = contentMD5.String
 	ft, err := pacta.ParseFileType(fileType)
 	if err != nil {
 		return nil, fmt.Errorf("parsing blob file_type: %w", err)
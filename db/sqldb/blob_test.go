@@ -79,7 +79,8 @@ func TestUpdateBlob(t *testing.T) {
 	b.ID = bID
 
 	name := "New Name"
-	err := tdb.UpdateBlob(tx, bID, db.SetBlobFileName(name))
+	contentMD5 := "0123456789abcdef0123456789abcdef"
+	err := tdb.UpdateBlob(tx, bID, db.SetBlobFileName(name), db.SetBlobContentMD5(contentMD5))
 	if err != nil {
 		t.Fatalf("update blob: %v", err)
 	}
@@ -88,6 +89,7 @@ func TestUpdateBlob(t *testing.T) {
 		t.Fatalf("getting blob: %v", err)
 	}
 	b.FileName = name
+	b.ContentMD5 = contentMD5
 	if diff := cmp.Diff(b, actual, blobCmpOpts()); diff != "" {
 		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
 	}
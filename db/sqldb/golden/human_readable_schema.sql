@@ -9,6 +9,10 @@ CREATE TYPE analysis_type AS ENUM (
     'audit',
     'report',
     'dashboard');
+CREATE TYPE api_token_scope AS ENUM (
+    'READ_ONLY',
+    'UPLOAD',
+    'RUN_ANALYSIS');
 CREATE TYPE audit_log_action AS ENUM (
     'CREATE',
     'UPDATE',
@@ -21,14 +25,21 @@ CREATE TYPE audit_log_action AS ENUM (
     'ENABLE_SHARING',
     'DISABLE_SHARING',
     'TRANSFER_OWNERSHIP',
-    'READ_METADATA');
+    'READ_METADATA',
+    'RETRY',
+    'CANCEL',
+    'REVOKE_SESSIONS');
 CREATE TYPE audit_log_actor_type AS ENUM (
     'USER',
     'ADMIN',
     'SUPER_ADMIN',
     'SYSTEM',
     'OWNER',
-    'PUBLIC');
+    'PUBLIC',
+    'INITIATIVE_VIEWER',
+    'INITIATIVE_PORTFOLIO_CONTRIBUTOR',
+    'INITIATIVE_ANALYST',
+    'INITIATIVE_MANAGER');
 CREATE TYPE audit_log_target_type AS ENUM (
     'USER',
     'PORTFOLIO',
@@ -38,11 +49,32 @@ CREATE TYPE audit_log_target_type AS ENUM (
     'ANALYSIS',
     'INCOMPLETE_UPLOAD',
     'INITIATIVE_INVITATION',
-    'ANALYSIS_ARTIFACT');
+    'ANALYSIS_ARTIFACT',
+    'TASK',
+    'API_TOKEN',
+    'UPLOAD_LIMITS');
 CREATE TYPE authn_mechanism AS ENUM (
-    'EMAIL_AND_PASS');
+    'EMAIL_AND_PASS',
+    'OIDC',
+    'ENTRA_ID',
+    'GOOGLE',
+    'OKTA');
+CREATE TYPE duplicate_policy AS ENUM (
+    'LINK',
+    'WARN',
+    'REJECT');
 CREATE TYPE failure_code AS ENUM (
-    'UNKNOWN');
+    'UNKNOWN',
+    'PARSE_INVALID_CSV',
+    'R_SCRIPT_FAILED',
+    'BLOB_IO',
+    'TIMEOUT',
+    'OUT_OF_MEMORY',
+    'CANCELLED',
+    'DUPLICATE',
+    'UNSUPPORTED_FILE',
+    'TOO_MANY_ROWS',
+    'INVALID_REPLACEMENT');
 CREATE TYPE file_type AS ENUM (
     'csv',
     'yaml',
@@ -65,11 +97,26 @@ CREATE TYPE file_type AS ENUM (
     'xlsx',
     'rds',
     'css.map');
+CREATE TYPE initiative_user_role AS ENUM (
+    'VIEWER',
+    'PORTFOLIO_CONTRIBUTOR',
+    'ANALYST',
+    'MANAGER');
 CREATE TYPE language AS ENUM (
     'en',
     'de',
     'fr',
     'es');
+CREATE TYPE task_state AS ENUM (
+    'QUEUED',
+    'RUNNING',
+    'SUCCEEDED',
+    'FAILED');
+CREATE TYPE task_type AS ENUM (
+    'parse_portfolio',
+    'create_report',
+    'create_audit',
+    'create_dashboard');
 
 
 CREATE TABLE analysis (
@@ -80,6 +127,7 @@ CREATE TABLE analysis (
 	failure_code failure_code,
 	failure_message text,
 	id text NOT NULL,
+	image_tag text,
 	name text NOT NULL,
 	owner_id text NOT NULL,
 	pacta_version_id text NOT NULL,
@@ -93,6 +141,7 @@ ALTER TABLE ONLY analysis ADD CONSTRAINT analysis_portfolio_snapshot_id_fkey FOR
 
 CREATE TABLE analysis_artifact (
 	admin_debug_enabled boolean NOT NULL,
+	admin_only boolean DEFAULT false NOT NULL,
 	analysis_id text NOT NULL,
 	blob_id text NOT NULL,
 	id text NOT NULL,
@@ -103,8 +152,25 @@ ALTER TABLE ONLY analysis_artifact ADD CONSTRAINT analysis_artifact_analysis_id_
 ALTER TABLE ONLY analysis_artifact ADD CONSTRAINT analysis_artifact_blob_id_fkey FOREIGN KEY (blob_id) REFERENCES blob(id) ON DELETE RESTRICT;
 
 
+CREATE TABLE api_token (
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	expires_at timestamp with time zone,
+	id text NOT NULL,
+	last_used_at timestamp with time zone,
+	name text NOT NULL,
+	revoked_at timestamp with time zone,
+	scopes api_token_scope[] DEFAULT '{}'::api_token_scope[] NOT NULL,
+	token_hash text NOT NULL,
+	user_id text NOT NULL);
+ALTER TABLE ONLY api_token ADD CONSTRAINT api_token_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY api_token ADD CONSTRAINT api_token_token_hash_key UNIQUE (token_hash);
+CREATE INDEX api_token_by_user_id ON api_token USING btree (user_id);
+ALTER TABLE ONLY api_token ADD CONSTRAINT api_token_user_id_fkey FOREIGN KEY (user_id) REFERENCES pacta_user(id) ON DELETE RESTRICT;
+
+
 CREATE TABLE audit_log (
 	action audit_log_action NOT NULL,
+	actor_api_token_id text,
 	actor_id text NOT NULL,
 	actor_owner_id text NOT NULL,
 	actor_type audit_log_actor_type NOT NULL,
@@ -121,12 +187,14 @@ ALTER TABLE ONLY audit_log ADD CONSTRAINT audit_log_pkey PRIMARY KEY (id);
 
 CREATE TABLE blob (
 	blob_uri text NOT NULL,
+	content_md5 text,
 	created_at timestamp with time zone DEFAULT now() NOT NULL,
 	file_name text NOT NULL,
 	file_type file_type NOT NULL,
 	id text NOT NULL);
 ALTER TABLE ONLY blob ADD CONSTRAINT blob_blob_uri_key UNIQUE (blob_uri);
 ALTER TABLE ONLY blob ADD CONSTRAINT blob_pkey PRIMARY KEY (id);
+CREATE INDEX blob_by_content_md5 ON blob USING btree (content_md5);
 
 
 CREATE TABLE incomplete_upload (
@@ -135,17 +203,25 @@ CREATE TABLE incomplete_upload (
 	completed_at timestamp with time zone,
 	created_at timestamp with time zone DEFAULT now() NOT NULL,
 	description text NOT NULL,
+	duplicate_policy duplicate_policy NOT NULL,
+	duplicate_portfolio_ids text[] DEFAULT '{}'::text[] NOT NULL,
 	failure_code failure_code,
 	failure_message text,
 	id text NOT NULL,
+	log_blob_id text,
 	name text NOT NULL,
 	owner_id text NOT NULL,
+	parse_result jsonb,
 	properties jsonb DEFAULT '{}'::jsonb NOT NULL,
-	ran_at timestamp with time zone);
+	ran_at timestamp with time zone,
+	replaces_portfolio_id text);
 ALTER TABLE ONLY incomplete_upload ADD CONSTRAINT incomplete_upload_pkey PRIMARY KEY (id);
 CREATE INDEX incomplete_upload_by_blob_id ON incomplete_upload USING btree (blob_id);
+CREATE INDEX incomplete_upload_by_log_blob_id ON incomplete_upload USING btree (log_blob_id);
 ALTER TABLE ONLY incomplete_upload ADD CONSTRAINT incomplete_upload_blob_id_fkey FOREIGN KEY (blob_id) REFERENCES blob(id) ON DELETE RESTRICT;
+ALTER TABLE ONLY incomplete_upload ADD CONSTRAINT incomplete_upload_log_blob_id_fkey FOREIGN KEY (log_blob_id) REFERENCES blob(id) ON DELETE RESTRICT;
 ALTER TABLE ONLY incomplete_upload ADD CONSTRAINT incomplete_upload_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES owner(id) ON DELETE RESTRICT;
+ALTER TABLE ONLY incomplete_upload ADD CONSTRAINT incomplete_upload_replaces_portfolio_id_fkey FOREIGN KEY (replaces_portfolio_id) REFERENCES portfolio(id) ON DELETE RESTRICT;
 
 
 CREATE TABLE initiative (
@@ -168,6 +244,7 @@ CREATE TABLE initiative_invitation (
 	created_at timestamp with time zone DEFAULT now() NOT NULL,
 	id text NOT NULL,
 	initiative_id text NOT NULL,
+	role initiative_user_role DEFAULT 'PORTFOLIO_CONTRIBUTOR'::initiative_user_role NOT NULL,
 	used_at timestamp with time zone,
 	used_by_user_id text);
 ALTER TABLE ONLY initiative_invitation ADD CONSTRAINT initiative_invitation_pkey PRIMARY KEY (id);
@@ -177,8 +254,7 @@ ALTER TABLE ONLY initiative_invitation ADD CONSTRAINT initiative_invitation_used
 
 CREATE TABLE initiative_user_relationship (
 	initiative_id text NOT NULL,
-	manager boolean NOT NULL,
-	member boolean NOT NULL,
+	role initiative_user_role NOT NULL,
 	updated_at timestamp with time zone DEFAULT now() NOT NULL,
 	user_id text NOT NULL);
 ALTER TABLE ONLY initiative_user_relationship ADD CONSTRAINT initiative_user_relationship_pkey PRIMARY KEY (user_id, initiative_id);
@@ -224,12 +300,16 @@ CREATE INDEX user_name_gin_index ON pacta_user USING gin (name gin_trgm_ops);
 
 CREATE TABLE pacta_version (
 	CONSTRAINT is_default_is_true_or_null CHECK (is_default),
+	benchmark_dataset text NOT NULL,
 	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	dashboard_digest text NOT NULL,
 	description text NOT NULL,
 	digest text NOT NULL,
 	id text NOT NULL,
+	inherit text NOT NULL,
 	is_default boolean,
-	name text NOT NULL);
+	name text NOT NULL,
+	pacta_dataset text NOT NULL);
 ALTER TABLE ONLY pacta_version ADD CONSTRAINT is_default_only_1_true UNIQUE (is_default);
 ALTER TABLE ONLY pacta_version ADD CONSTRAINT pacta_version_digest_key UNIQUE (digest);
 ALTER TABLE ONLY pacta_version ADD CONSTRAINT pacta_version_pkey PRIMARY KEY (id);
@@ -241,9 +321,12 @@ CREATE TABLE portfolio (
 	created_at timestamp with time zone DEFAULT now() NOT NULL,
 	description text NOT NULL,
 	id text NOT NULL,
+	investor_name text,
+	md5 text,
 	name text NOT NULL,
 	number_of_rows integer,
 	owner_id text NOT NULL,
+	portfolio_name text,
 	properties jsonb DEFAULT '{}'::jsonb NOT NULL);
 ALTER TABLE ONLY portfolio ADD CONSTRAINT portfolio_pkey PRIMARY KEY (id);
 CREATE INDEX portfolio_by_blob_id ON portfolio USING btree (blob_id);
@@ -283,6 +366,7 @@ ALTER TABLE ONLY portfolio_initiative_membership ADD CONSTRAINT portfolio_initia
 
 CREATE TABLE portfolio_snapshot (
 	CONSTRAINT snapshot_is_well_formed CHECK ((num_nonnulls(portfolio_id, portfolio_group_id, initiative_id) = 1)),
+	blob_ids text[],
 	id text NOT NULL,
 	initiative_id text,
 	portfolio_group_id text,
@@ -294,6 +378,31 @@ ALTER TABLE ONLY portfolio_snapshot ADD CONSTRAINT portfolio_snapshot_portfolio_
 ALTER TABLE ONLY portfolio_snapshot ADD CONSTRAINT portfolio_snapshot_portfolio_id_fkey FOREIGN KEY (portfolio_id) REFERENCES portfolio(id) ON DELETE RESTRICT;
 
 
+CREATE TABLE portfolio_version (
+	blob_id text NOT NULL,
+	id text NOT NULL,
+	md5 text,
+	number_of_rows integer,
+	portfolio_id text NOT NULL,
+	replaced_at timestamp with time zone DEFAULT now() NOT NULL,
+	version integer NOT NULL);
+ALTER TABLE ONLY portfolio_version ADD CONSTRAINT portfolio_version_pkey PRIMARY KEY (id);
+ALTER TABLE ONLY portfolio_version ADD CONSTRAINT portfolio_version_portfolio_id_version_key UNIQUE (portfolio_id, version);
+CREATE INDEX portfolio_version_by_blob_id ON portfolio_version USING btree (blob_id);
+ALTER TABLE ONLY portfolio_version ADD CONSTRAINT portfolio_version_blob_id_fkey FOREIGN KEY (blob_id) REFERENCES blob(id) ON DELETE RESTRICT;
+ALTER TABLE ONLY portfolio_version ADD CONSTRAINT portfolio_version_portfolio_id_fkey FOREIGN KEY (portfolio_id) REFERENCES portfolio(id) ON DELETE RESTRICT;
+
+
+CREATE TABLE revoked_session (
+	expires_at timestamp with time zone NOT NULL,
+	jti text NOT NULL,
+	revoked_at timestamp with time zone DEFAULT now() NOT NULL,
+	user_id text NOT NULL);
+ALTER TABLE ONLY revoked_session ADD CONSTRAINT revoked_session_pkey PRIMARY KEY (jti);
+CREATE INDEX revoked_session_by_user_id ON revoked_session USING btree (user_id);
+ALTER TABLE ONLY revoked_session ADD CONSTRAINT revoked_session_user_id_fkey FOREIGN KEY (user_id) REFERENCES pacta_user(id) ON DELETE RESTRICT;
+
+
 CREATE TABLE schema_migrations_history (
 	applied_at timestamp with time zone DEFAULT now() NOT NULL,
 	id integer NOT NULL,
@@ -301,9 +410,56 @@ CREATE TABLE schema_migrations_history (
 ALTER SEQUENCE schema_migrations_history_id_seq OWNED BY schema_migrations_history.id;
 
 
+CREATE TABLE task (
+	analysis_id text,
+	attempt_count integer DEFAULT 0 NOT NULL,
+	completed_at timestamp with time zone,
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	id text NOT NULL,
+	image_tag text,
+	owner_id text NOT NULL,
+	request jsonb,
+	runner_id text,
+	started_at timestamp with time zone,
+	state task_state NOT NULL,
+	task_type task_type NOT NULL);
+ALTER TABLE ONLY task ADD CONSTRAINT task_pkey PRIMARY KEY (id);
+CREATE INDEX task_by_analysis_id ON task USING btree (analysis_id);
+CREATE INDEX task_by_owner_id ON task USING btree (owner_id);
+ALTER TABLE ONLY task ADD CONSTRAINT task_analysis_id_fkey FOREIGN KEY (analysis_id) REFERENCES analysis(id) ON DELETE RESTRICT;
+ALTER TABLE ONLY task ADD CONSTRAINT task_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES owner(id) ON DELETE RESTRICT;
+
+
+CREATE TABLE upload_limits (
+	CONSTRAINT upload_limits_are_non_negative CHECK (((max_bytes >= 0) AND (max_rows >= 0))),
+	CONSTRAINT upload_limits_has_one_row CHECK (id),
+	id boolean DEFAULT true NOT NULL,
+	max_bytes bigint NOT NULL,
+	max_rows integer NOT NULL,
+	updated_at timestamp with time zone DEFAULT now() NOT NULL);
+ALTER TABLE ONLY upload_limits ADD CONSTRAINT upload_limits_pkey PRIMARY KEY (id);
+
+
+CREATE TABLE user_authn (
+	authn_id text NOT NULL,
+	authn_mechanism authn_mechanism NOT NULL,
+	created_at timestamp with time zone DEFAULT now() NOT NULL,
+	user_id text NOT NULL);
+ALTER TABLE ONLY user_authn ADD CONSTRAINT user_authn_pkey PRIMARY KEY (authn_mechanism, authn_id);
+CREATE INDEX user_authn_by_user_id ON user_authn USING btree (user_id);
+ALTER TABLE ONLY user_authn ADD CONSTRAINT user_authn_user_id_fkey FOREIGN KEY (user_id) REFERENCES pacta_user(id) ON DELETE RESTRICT;
+
+
 CREATE TABLE user_merges (
 	actor_user_id text NOT NULL,
 	from_user_id text NOT NULL,
 	merged_at timestamp with time zone DEFAULT now() NOT NULL,
 	to_user_id text NOT NULL);
+
+
+CREATE TABLE user_session_revocation (
+	revoked_before timestamp with time zone NOT NULL,
+	user_id text NOT NULL);
+ALTER TABLE ONLY user_session_revocation ADD CONSTRAINT user_session_revocation_pkey PRIMARY KEY (user_id);
+ALTER TABLE ONLY user_session_revocation ADD CONSTRAINT user_session_revocation_user_id_fkey FOREIGN KEY (user_id) REFERENCES pacta_user(id) ON DELETE RESTRICT;
 ALTER TABLE ONLY schema_migrations ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
\ No newline at end of file
@@ -43,6 +43,19 @@ CREATE TYPE public.analysis_type AS ENUM (
 
 ALTER TYPE public.analysis_type OWNER TO postgres;
 
+--
+-- Name: api_token_scope; Type: TYPE; Schema: public; Owner: postgres
+--
+
+CREATE TYPE public.api_token_scope AS ENUM (
+    'READ_ONLY',
+    'UPLOAD',
+    'RUN_ANALYSIS'
+);
+
+
+ALTER TYPE public.api_token_scope OWNER TO postgres;
+
 --
 -- Name: audit_log_action; Type: TYPE; Schema: public; Owner: postgres
 --
@@ -59,7 +72,10 @@ CREATE TYPE public.audit_log_action AS ENUM (
     'ENABLE_SHARING',
     'DISABLE_SHARING',
     'TRANSFER_OWNERSHIP',
-    'READ_METADATA'
+    'READ_METADATA',
+    'RETRY',
+    'CANCEL',
+    'REVOKE_SESSIONS'
 );
 
 
@@ -75,7 +91,11 @@ CREATE TYPE public.audit_log_actor_type AS ENUM (
     'SUPER_ADMIN',
     'SYSTEM',
     'OWNER',
-    'PUBLIC'
+    'PUBLIC',
+    'INITIATIVE_VIEWER',
+    'INITIATIVE_PORTFOLIO_CONTRIBUTOR',
+    'INITIATIVE_ANALYST',
+    'INITIATIVE_MANAGER'
 );
 
 
@@ -94,7 +114,10 @@ CREATE TYPE public.audit_log_target_type AS ENUM (
     'ANALYSIS',
     'INCOMPLETE_UPLOAD',
     'INITIATIVE_INVITATION',
-    'ANALYSIS_ARTIFACT'
+    'ANALYSIS_ARTIFACT',
+    'TASK',
+    'API_TOKEN',
+    'UPLOAD_LIMITS'
 );
 
 
@@ -105,18 +128,45 @@ ALTER TYPE public.audit_log_target_type OWNER TO postgres;
 --
 
 CREATE TYPE public.authn_mechanism AS ENUM (
-    'EMAIL_AND_PASS'
+    'EMAIL_AND_PASS',
+    'OIDC',
+    'ENTRA_ID',
+    'GOOGLE',
+    'OKTA'
 );
 
 
 ALTER TYPE public.authn_mechanism OWNER TO postgres;
 
+--
+-- Name: duplicate_policy; Type: TYPE; Schema: public; Owner: postgres
+--
+
+CREATE TYPE public.duplicate_policy AS ENUM (
+    'LINK',
+    'WARN',
+    'REJECT'
+);
+
+
+ALTER TYPE public.duplicate_policy OWNER TO postgres;
+
 --
 -- Name: failure_code; Type: TYPE; Schema: public; Owner: postgres
 --
 
 CREATE TYPE public.failure_code AS ENUM (
-    'UNKNOWN'
+    'UNKNOWN',
+    'PARSE_INVALID_CSV',
+    'R_SCRIPT_FAILED',
+    'BLOB_IO',
+    'TIMEOUT',
+    'OUT_OF_MEMORY',
+    'CANCELLED',
+    'DUPLICATE',
+    'UNSUPPORTED_FILE',
+    'TOO_MANY_ROWS',
+    'INVALID_REPLACEMENT'
 );
 
 
@@ -153,6 +203,20 @@ CREATE TYPE public.file_type AS ENUM (
 
 ALTER TYPE public.file_type OWNER TO postgres;
 
+--
+-- Name: initiative_user_role; Type: TYPE; Schema: public; Owner: postgres
+--
+
+CREATE TYPE public.initiative_user_role AS ENUM (
+    'VIEWER',
+    'PORTFOLIO_CONTRIBUTOR',
+    'ANALYST',
+    'MANAGER'
+);
+
+
+ALTER TYPE public.initiative_user_role OWNER TO postgres;
+
 --
 -- Name: language; Type: TYPE; Schema: public; Owner: postgres
 --
@@ -167,6 +231,34 @@ CREATE TYPE public.language AS ENUM (
 
 ALTER TYPE public.language OWNER TO postgres;
 
+--
+-- Name: task_state; Type: TYPE; Schema: public; Owner: postgres
+--
+
+CREATE TYPE public.task_state AS ENUM (
+    'QUEUED',
+    'RUNNING',
+    'SUCCEEDED',
+    'FAILED'
+);
+
+
+ALTER TYPE public.task_state OWNER TO postgres;
+
+--
+-- Name: task_type; Type: TYPE; Schema: public; Owner: postgres
+--
+
+CREATE TYPE public.task_type AS ENUM (
+    'parse_portfolio',
+    'create_report',
+    'create_audit',
+    'create_dashboard'
+);
+
+
+ALTER TYPE public.task_type OWNER TO postgres;
+
 --
 -- Name: track_applied_migration(); Type: FUNCTION; Schema: public; Owner: postgres
 --
@@ -207,7 +299,8 @@ CREATE TABLE public.analysis (
     ran_at timestamp with time zone,
     completed_at timestamp with time zone,
     failure_code public.failure_code,
-    failure_message text
+    failure_message text,
+    image_tag text
 );
 
 
@@ -222,12 +315,32 @@ CREATE TABLE public.analysis_artifact (
     analysis_id text NOT NULL,
     blob_id text NOT NULL,
     admin_debug_enabled boolean NOT NULL,
-    shared_to_public boolean NOT NULL
+    shared_to_public boolean NOT NULL,
+    admin_only boolean DEFAULT false NOT NULL
 );
 
 
 ALTER TABLE public.analysis_artifact OWNER TO postgres;
 
+--
+-- Name: api_token; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.api_token (
+    id text NOT NULL,
+    user_id text NOT NULL,
+    name text NOT NULL,
+    token_hash text NOT NULL,
+    scopes public.api_token_scope[] DEFAULT '{}'::public.api_token_scope[] NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    expires_at timestamp with time zone,
+    last_used_at timestamp with time zone,
+    revoked_at timestamp with time zone
+);
+
+
+ALTER TABLE public.api_token OWNER TO postgres;
+
 --
 -- Name: audit_log; Type: TABLE; Schema: public; Owner: postgres
 --
@@ -244,7 +357,8 @@ CREATE TABLE public.audit_log (
     secondary_target_type public.audit_log_target_type,
     secondary_target_id text NOT NULL,
     secondary_target_owner_id text,
-    id text NOT NULL
+    id text NOT NULL,
+    actor_api_token_id text
 );
 
 
@@ -259,7 +373,8 @@ CREATE TABLE public.blob (
     blob_uri text NOT NULL,
     file_type public.file_type NOT NULL,
     file_name text NOT NULL,
-    created_at timestamp with time zone DEFAULT now() NOT NULL
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    content_md5 text
 );
 
 
@@ -281,7 +396,12 @@ CREATE TABLE public.incomplete_upload (
     completed_at timestamp with time zone,
     failure_code public.failure_code,
     failure_message text,
-    properties jsonb DEFAULT '{}'::jsonb NOT NULL
+    properties jsonb DEFAULT '{}'::jsonb NOT NULL,
+    parse_result jsonb,
+    log_blob_id text,
+    duplicate_policy public.duplicate_policy NOT NULL,
+    duplicate_portfolio_ids text[] DEFAULT '{}'::text[] NOT NULL,
+    replaces_portfolio_id text
 );
 
 
@@ -317,7 +437,8 @@ CREATE TABLE public.initiative_invitation (
     created_at timestamp with time zone DEFAULT now() NOT NULL,
     used_at timestamp with time zone,
     initiative_id text NOT NULL,
-    used_by_user_id text
+    used_by_user_id text,
+    role public.initiative_user_role DEFAULT 'PORTFOLIO_CONTRIBUTOR'::public.initiative_user_role NOT NULL
 );
 
 
@@ -330,9 +451,8 @@ ALTER TABLE public.initiative_invitation OWNER TO postgres;
 CREATE TABLE public.initiative_user_relationship (
     user_id text NOT NULL,
     initiative_id text NOT NULL,
-    manager boolean NOT NULL,
-    member boolean NOT NULL,
-    updated_at timestamp with time zone DEFAULT now() NOT NULL
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    role public.initiative_user_role NOT NULL
 );
 
 
@@ -397,6 +517,10 @@ CREATE TABLE public.pacta_version (
     digest text NOT NULL,
     created_at timestamp with time zone DEFAULT now() NOT NULL,
     is_default boolean,
+    inherit text NOT NULL,
+    benchmark_dataset text NOT NULL,
+    pacta_dataset text NOT NULL,
+    dashboard_digest text NOT NULL,
     CONSTRAINT is_default_is_true_or_null CHECK (is_default)
 );
 
@@ -416,7 +540,10 @@ CREATE TABLE public.portfolio (
     blob_id text NOT NULL,
     admin_debug_enabled boolean NOT NULL,
     number_of_rows integer,
-    properties jsonb DEFAULT '{}'::jsonb NOT NULL
+    properties jsonb DEFAULT '{}'::jsonb NOT NULL,
+    investor_name text,
+    portfolio_name text,
+    md5 text
 );
 
 
@@ -474,12 +601,44 @@ CREATE TABLE public.portfolio_snapshot (
     portfolio_group_id text,
     initiative_id text,
     portfolio_ids text[],
+    blob_ids text[],
     CONSTRAINT snapshot_is_well_formed CHECK ((num_nonnulls(portfolio_id, portfolio_group_id, initiative_id) = 1))
 );
 
 
 ALTER TABLE public.portfolio_snapshot OWNER TO postgres;
 
+--
+-- Name: portfolio_version; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.portfolio_version (
+    id text NOT NULL,
+    portfolio_id text NOT NULL,
+    version integer NOT NULL,
+    blob_id text NOT NULL,
+    number_of_rows integer,
+    md5 text,
+    replaced_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.portfolio_version OWNER TO postgres;
+
+--
+-- Name: revoked_session; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.revoked_session (
+    jti text NOT NULL,
+    user_id text NOT NULL,
+    expires_at timestamp with time zone NOT NULL,
+    revoked_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.revoked_session OWNER TO postgres;
+
 --
 -- Name: schema_migrations; Type: TABLE; Schema: public; Owner: postgres
 --
@@ -527,6 +686,58 @@ ALTER TABLE public.schema_migrations_history_id_seq OWNER TO postgres;
 ALTER SEQUENCE public.schema_migrations_history_id_seq OWNED BY public.schema_migrations_history.id;
 
 
+--
+-- Name: task; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.task (
+    id text NOT NULL,
+    task_type public.task_type NOT NULL,
+    owner_id text NOT NULL,
+    analysis_id text,
+    request jsonb,
+    runner_id text,
+    image_tag text,
+    state public.task_state NOT NULL,
+    attempt_count integer DEFAULT 0 NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    started_at timestamp with time zone,
+    completed_at timestamp with time zone
+);
+
+
+ALTER TABLE public.task OWNER TO postgres;
+
+--
+-- Name: upload_limits; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.upload_limits (
+    id boolean DEFAULT true NOT NULL,
+    max_bytes bigint NOT NULL,
+    max_rows integer NOT NULL,
+    updated_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT upload_limits_are_non_negative CHECK (((max_bytes >= 0) AND (max_rows >= 0))),
+    CONSTRAINT upload_limits_has_one_row CHECK (id)
+);
+
+
+ALTER TABLE public.upload_limits OWNER TO postgres;
+
+--
+-- Name: user_authn; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_authn (
+    user_id text NOT NULL,
+    authn_mechanism public.authn_mechanism NOT NULL,
+    authn_id text NOT NULL,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.user_authn OWNER TO postgres;
+
 --
 -- Name: user_merges; Type: TABLE; Schema: public; Owner: postgres
 --
@@ -541,6 +752,18 @@ CREATE TABLE public.user_merges (
 
 ALTER TABLE public.user_merges OWNER TO postgres;
 
+--
+-- Name: user_session_revocation; Type: TABLE; Schema: public; Owner: postgres
+--
+
+CREATE TABLE public.user_session_revocation (
+    user_id text NOT NULL,
+    revoked_before timestamp with time zone NOT NULL
+);
+
+
+ALTER TABLE public.user_session_revocation OWNER TO postgres;
+
 --
 -- Name: schema_migrations_history id; Type: DEFAULT; Schema: public; Owner: postgres
 --
@@ -564,6 +787,22 @@ ALTER TABLE ONLY public.analysis
     ADD CONSTRAINT analysis_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: api_token api_token_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.api_token
+    ADD CONSTRAINT api_token_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: api_token api_token_token_hash_key; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.api_token
+    ADD CONSTRAINT api_token_token_hash_key UNIQUE (token_hash);
+
+
 --
 -- Name: audit_log audit_log_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -724,6 +963,30 @@ ALTER TABLE ONLY public.portfolio_snapshot
     ADD CONSTRAINT portfolio_snapshot_pkey PRIMARY KEY (id);
 
 
+--
+-- Name: portfolio_version portfolio_version_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.portfolio_version
+    ADD CONSTRAINT portfolio_version_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: portfolio_version portfolio_version_portfolio_id_version_key; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.portfolio_version
+    ADD CONSTRAINT portfolio_version_portfolio_id_version_key UNIQUE (portfolio_id, version);
+
+
+--
+-- Name: revoked_session revoked_session_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.revoked_session
+    ADD CONSTRAINT revoked_session_pkey PRIMARY KEY (jti);
+
+
 --
 -- Name: schema_migrations_history schema_migrations_history_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -740,6 +1003,38 @@ ALTER TABLE ONLY public.schema_migrations
     ADD CONSTRAINT schema_migrations_pkey PRIMARY KEY (version);
 
 
+--
+-- Name: task task_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.task
+    ADD CONSTRAINT task_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: upload_limits upload_limits_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.upload_limits
+    ADD CONSTRAINT upload_limits_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: user_authn user_authn_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_authn
+    ADD CONSTRAINT user_authn_pkey PRIMARY KEY (authn_mechanism, authn_id);
+
+
+--
+-- Name: user_session_revocation user_session_revocation_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_session_revocation
+    ADD CONSTRAINT user_session_revocation_pkey PRIMARY KEY (user_id);
+
+
 --
 -- Name: analysis_artifact_by_blob_id; Type: INDEX; Schema: public; Owner: postgres
 --
@@ -747,6 +1042,20 @@ ALTER TABLE ONLY public.schema_migrations
 CREATE INDEX analysis_artifact_by_blob_id ON public.analysis_artifact USING btree (blob_id);
 
 
+--
+-- Name: api_token_by_user_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX api_token_by_user_id ON public.api_token USING btree (user_id);
+
+
+--
+-- Name: blob_by_content_md5; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX blob_by_content_md5 ON public.blob USING btree (content_md5);
+
+
 --
 -- Name: incomplete_upload_by_blob_id; Type: INDEX; Schema: public; Owner: postgres
 --
@@ -754,6 +1063,13 @@ CREATE INDEX analysis_artifact_by_blob_id ON public.analysis_artifact USING btre
 CREATE INDEX incomplete_upload_by_blob_id ON public.incomplete_upload USING btree (blob_id);
 
 
+--
+-- Name: incomplete_upload_by_log_blob_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX incomplete_upload_by_log_blob_id ON public.incomplete_upload USING btree (log_blob_id);
+
+
 --
 -- Name: owner_by_initiative_id; Type: INDEX; Schema: public; Owner: postgres
 --
@@ -775,6 +1091,41 @@ CREATE INDEX owner_by_user_id ON public.owner USING btree (user_id);
 CREATE INDEX portfolio_by_blob_id ON public.portfolio USING btree (blob_id);
 
 
+--
+-- Name: portfolio_version_by_blob_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX portfolio_version_by_blob_id ON public.portfolio_version USING btree (blob_id);
+
+
+--
+-- Name: revoked_session_by_user_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX revoked_session_by_user_id ON public.revoked_session USING btree (user_id);
+
+
+--
+-- Name: task_by_analysis_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX task_by_analysis_id ON public.task USING btree (analysis_id);
+
+
+--
+-- Name: task_by_owner_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX task_by_owner_id ON public.task USING btree (owner_id);
+
+
+--
+-- Name: user_authn_by_user_id; Type: INDEX; Schema: public; Owner: postgres
+--
+
+CREATE INDEX user_authn_by_user_id ON public.user_authn USING btree (user_id);
+
+
 --
 -- Name: user_canonical_email_gin_index; Type: INDEX; Schema: public; Owner: postgres
 --
@@ -836,6 +1187,14 @@ ALTER TABLE ONLY public.analysis
     ADD CONSTRAINT analysis_portfolio_snapshot_id_fkey FOREIGN KEY (portfolio_snapshot_id) REFERENCES public.portfolio_snapshot(id) ON DELETE RESTRICT;
 
 
+--
+-- Name: api_token api_token_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.api_token
+    ADD CONSTRAINT api_token_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.pacta_user(id) ON DELETE RESTRICT;
+
+
 --
 -- Name: incomplete_upload incomplete_upload_blob_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -844,6 +1203,14 @@ ALTER TABLE ONLY public.incomplete_upload
     ADD CONSTRAINT incomplete_upload_blob_id_fkey FOREIGN KEY (blob_id) REFERENCES public.blob(id) ON DELETE RESTRICT;
 
 
+--
+-- Name: incomplete_upload incomplete_upload_log_blob_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.incomplete_upload
+    ADD CONSTRAINT incomplete_upload_log_blob_id_fkey FOREIGN KEY (log_blob_id) REFERENCES public.blob(id) ON DELETE RESTRICT;
+
+
 --
 -- Name: incomplete_upload incomplete_upload_owner_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -852,6 +1219,14 @@ ALTER TABLE ONLY public.incomplete_upload
     ADD CONSTRAINT incomplete_upload_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES public.owner(id) ON DELETE RESTRICT;
 
 
+--
+-- Name: incomplete_upload incomplete_upload_replaces_portfolio_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.incomplete_upload
+    ADD CONSTRAINT incomplete_upload_replaces_portfolio_id_fkey FOREIGN KEY (replaces_portfolio_id) REFERENCES public.portfolio(id) ON DELETE RESTRICT;
+
+
 --
 -- Name: initiative_invitation initiative_invitation_initiative_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
 --
@@ -980,6 +1355,62 @@ ALTER TABLE ONLY public.portfolio_snapshot
     ADD CONSTRAINT portfolio_snapshot_portfolio_id_fkey FOREIGN KEY (portfolio_id) REFERENCES public.portfolio(id) ON DELETE RESTRICT;
 
 
+--
+-- Name: portfolio_version portfolio_version_blob_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.portfolio_version
+    ADD CONSTRAINT portfolio_version_blob_id_fkey FOREIGN KEY (blob_id) REFERENCES public.blob(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: portfolio_version portfolio_version_portfolio_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.portfolio_version
+    ADD CONSTRAINT portfolio_version_portfolio_id_fkey FOREIGN KEY (portfolio_id) REFERENCES public.portfolio(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: revoked_session revoked_session_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.revoked_session
+    ADD CONSTRAINT revoked_session_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.pacta_user(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: task task_analysis_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.task
+    ADD CONSTRAINT task_analysis_id_fkey FOREIGN KEY (analysis_id) REFERENCES public.analysis(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: task task_owner_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.task
+    ADD CONSTRAINT task_owner_id_fkey FOREIGN KEY (owner_id) REFERENCES public.owner(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: user_authn user_authn_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_authn
+    ADD CONSTRAINT user_authn_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.pacta_user(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: user_session_revocation user_session_revocation_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: postgres
+--
+
+ALTER TABLE ONLY public.user_session_revocation
+    ADD CONSTRAINT user_session_revocation_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.pacta_user(id) ON DELETE RESTRICT;
+
+
 --
 -- PostgreSQL database dump complete
 --
@@ -22,7 +22,12 @@ const incompleteUploadSelectColumns = `
 	incomplete_upload.ran_at,
 	incomplete_upload.completed_at,
 	incomplete_upload.failure_code,
-	incomplete_upload.failure_message
+	incomplete_upload.failure_message,
+	incomplete_upload.parse_result,
+	incomplete_upload.log_blob_id,
+	incomplete_upload.duplicate_policy,
+	incomplete_upload.duplicate_portfolio_ids,
+	incomplete_upload.replaces_portfolio_id
 `
 
 func (d *DB) IncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) (*pacta.IncompleteUpload, error) {
@@ -40,6 +45,21 @@ func (d *DB) IncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) (*pacta.Inc
 	return exactlyOne("incomplete_upload", id, ius)
 }
 
+// LockIncompleteUpload locks the given incomplete upload until the end of the
+// transaction, so that checks made before changing it (or its tasks) can't
+// race.
+func (d *DB) LockIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) error {
+	var lockedID pacta.IncompleteUploadID
+	row := d.queryRow(tx, `SELECT id FROM incomplete_upload WHERE id = $1 FOR UPDATE;`, id)
+	if err := row.Scan(&lockedID); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return db.NotFound(id, "incomplete_upload")
+		}
+		return fmt.Errorf("locking incomplete_upload: %w", err)
+	}
+	return nil
+}
+
 func (d *DB) IncompleteUploads(tx db.Tx, ids []pacta.IncompleteUploadID) (map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, error) {
 	ids = dedupeIDs(ids)
 	rows, err := d.query(tx, `
@@ -82,10 +102,11 @@ func (d *DB) CreateIncompleteUpload(tx db.Tx, i *pacta.IncompleteUpload) (pacta.
 	i.ID = pacta.IncompleteUploadID(d.randomID("iu"))
 	err := d.exec(tx, `
 		INSERT INTO incomplete_upload 
-			(id, owner_id, admin_debug_enabled, blob_id, name, description, properties)
+			(id, owner_id, admin_debug_enabled, blob_id, name, description, properties, duplicate_policy, replaces_portfolio_id)
 			VALUES
-			($1, $2, $3, $4, $5, $6, $7);`,
-		i.ID, i.Owner.ID, i.AdminDebugEnabled, i.Blob.ID, i.Name, i.Description, i.Properties)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9);`,
+		i.ID, i.Owner.ID, i.AdminDebugEnabled, i.Blob.ID, i.Name, i.Description, i.Properties, i.DuplicatePolicy,
+		replacesPortfolioIDToNilable(i.ReplacesPortfolio))
 	if err != nil {
 		return "", fmt.Errorf("creating incomplete_upload: %w", err)
 	}
@@ -116,8 +137,8 @@ func (d *DB) UpdateIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID, mutat
 	return nil
 }
 
-func (d *DB) DeleteIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) (pacta.BlobURI, error) {
-	var buri pacta.BlobURI
+func (d *DB) DeleteIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) ([]pacta.BlobURI, error) {
+	var buris []pacta.BlobURI
 	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
 		iu, err := d.IncompleteUpload(tx, id)
 		if err != nil {
@@ -127,16 +148,24 @@ func (d *DB) DeleteIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID) (pact
 		if err != nil {
 			return fmt.Errorf("deleting incomplete_upload: %w", err)
 		}
-		buri, err = d.DeleteBlob(tx, iu.Blob.ID)
+		buri, err := d.DeleteBlob(tx, iu.Blob.ID)
 		if err != nil {
 			return fmt.Errorf("deleting blob: %w", err)
 		}
+		buris = append(buris, buri)
+		if iu.LogBlob != nil {
+			buri, err := d.DeleteBlob(tx, iu.LogBlob.ID)
+			if err != nil {
+				return fmt.Errorf("deleting log blob: %w", err)
+			}
+			buris = append(buris, buri)
+		}
 		return nil
 	})
 	if err != nil {
-		return "", fmt.Errorf("performing incomplete_upload deletion: %w", err)
+		return nil, fmt.Errorf("performing incomplete_upload deletion: %w", err)
 	}
-	return buri, nil
+	return buris, nil
 }
 
 func rowToIncompleteUpload(row rowScanner) (*pacta.IncompleteUpload, error) {
@@ -144,6 +173,10 @@ func rowToIncompleteUpload(row rowScanner) (*pacta.IncompleteUpload, error) {
 	var (
 		failureCode, failureMessage pgtype.Text
 		ranAt, completedAt          pgtype.Timestamptz
+		logBlobID                   pgtype.Text
+		replacesPortfolioID         pgtype.Text
+		duplicatePolicy             string
+		duplicatePortfolioIDs       []string
 	)
 	err := row.Scan(
 		&iu.ID,
@@ -158,6 +191,11 @@ func rowToIncompleteUpload(row rowScanner) (*pacta.IncompleteUpload, error) {
 		&completedAt,
 		&failureCode,
 		&failureMessage,
+		&iu.ParseResult,
+		&logBlobID,
+		&duplicatePolicy,
+		&duplicatePortfolioIDs,
+		&replacesPortfolioID,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scanning into incomplete_upload: %w", err)
@@ -177,6 +215,17 @@ func rowToIncompleteUpload(row rowScanner) (*pacta.IncompleteUpload, error) {
 	if failureMessage.Valid {
 		iu.FailureMessage = failureMessage.String
 	}
+	if logBlobID.Valid {
+		iu.LogBlob = &pacta.Blob{ID: pacta.BlobID(logBlobID.String)}
+	}
+	iu.DuplicatePolicy, err = pacta.ParseDuplicatePolicy(duplicatePolicy)
+	if err != nil {
+		return nil, fmt.Errorf("parsing duplicate policy: %w", err)
+	}
+	iu.DuplicatePortfolios = stringsToIDs[pacta.PortfolioID](duplicatePortfolioIDs)
+	if replacesPortfolioID.Valid {
+		iu.ReplacesPortfolio = &pacta.Portfolio{ID: pacta.PortfolioID(replacesPortfolioID.String)}
+	}
 	return iu, nil
 }
 
@@ -195,17 +244,38 @@ func (db *DB) putIncompleteUpload(tx db.Tx, iu *pacta.IncompleteUpload) error {
 			ran_at = $7,
 			completed_at = $8,
 			failure_code = $9,
-			failure_message = $10
+			failure_message = $10,
+			parse_result = $11,
+			log_blob_id = $12,
+			duplicate_policy = $13,
+			duplicate_portfolio_ids = $14,
+			replaces_portfolio_id = $15
 		WHERE id = $1;
 		`, iu.ID, iu.Owner.ID, iu.AdminDebugEnabled, iu.Name, iu.Description,
 		iu.Properties, timeToNilable(iu.RanAt), timeToNilable(iu.CompletedAt),
-		strToNilable(iu.FailureCode), strToNilable(iu.FailureMessage))
+		strToNilable(iu.FailureCode), strToNilable(iu.FailureMessage), iu.ParseResult,
+		logBlobIDToNilable(iu.LogBlob), iu.DuplicatePolicy, idsToStrings(iu.DuplicatePortfolios),
+		replacesPortfolioIDToNilable(iu.ReplacesPortfolio))
 	if err != nil {
 		return fmt.Errorf("updating incomplete_upload writable fields: %w", err)
 	}
 	return nil
 }
 
+func logBlobIDToNilable(b *pacta.Blob) *string {
+	if b == nil {
+		return nil
+	}
+	return strToNilable(b.ID)
+}
+
+func replacesPortfolioIDToNilable(p *pacta.Portfolio) *string {
+	if p == nil {
+		return nil
+	}
+	return strToNilable(p.ID)
+}
+
 func validateIncompleteUploadForCreation(p *pacta.IncompleteUpload) error {
 	if p.ID != "" {
 		return errors.New("incomplete_upload id must be empty")
@@ -222,5 +292,8 @@ func validateIncompleteUploadForCreation(p *pacta.IncompleteUpload) error {
 	if !p.CreatedAt.IsZero() {
 		return fmt.Errorf("incomplete_upload created_at must be zero")
 	}
+	if _, err := pacta.ParseDuplicatePolicy(string(p.DuplicatePolicy)); err != nil {
+		return fmt.Errorf("incomplete_upload duplicate_policy must be valid: %w", err)
+	}
 	return nil
 }
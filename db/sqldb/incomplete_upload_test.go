@@ -16,10 +16,12 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	b := blobForTesting(t, tdb)
+	logBlob := blobForTestingWithKey(t, tdb, "log")
 	u1 := userForTestingWithKey(t, tdb, "1")
 	o1 := ownerUserForTesting(t, tdb, u1)
 	u2 := userForTestingWithKey(t, tdb, "2")
 	o2 := ownerUserForTesting(t, tdb, u2)
+	replaced := portfolioForTestingWithKey(t, tdb, "replaced")
 	cmpOpts := incompleteUploadCmpOpts()
 
 	iu := &pacta.IncompleteUpload{
@@ -30,8 +32,9 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 			ESG:          ptr(true),
 			External:     ptr(false),
 		},
-		Owner: &pacta.Owner{ID: o1.ID},
-		Blob:  &pacta.Blob{ID: b.ID},
+		Owner:           &pacta.Owner{ID: o1.ID},
+		Blob:            &pacta.Blob{ID: b.ID},
+		DuplicatePolicy: pacta.DuplicatePolicy_Link,
 	}
 	id, err := tdb.CreateIncompleteUpload(tx, iu)
 	if err != nil {
@@ -56,6 +59,13 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 		t.Fatalf("incomplete_upload mismatch (-want +got):\n%s", diff)
 	}
 
+	if err := tdb.LockIncompleteUpload(tx, iu.ID); err != nil {
+		t.Fatalf("locking incomplete_upload: %v", err)
+	}
+	if err := tdb.LockIncompleteUpload(tx, "nonsense"); !db.IsNotFound(err) {
+		t.Fatalf("locking missing incomplete_upload: got err %v, want not found", err)
+	}
+
 	nName := "new-name"
 	nDesc := "new-description"
 	ranAt := time.UnixMilli(111111111)
@@ -63,6 +73,19 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 	failureCode := pacta.FailureCode_Unknown
 	failureMessage := "failureMessage"
 	hd := exampleHoldingsDate2
+	parseResult := &pacta.ParseResult{
+		InputMD5:           "input-md5",
+		InputEntries:       12,
+		GroupCols:          []string{"investor_name", "portfolio_name"},
+		SubportfoliosCount: 2,
+		Errors:             [][]string{{"row 3", "missing isin"}},
+		Parser: pacta.ParserInfo{
+			Package:        "workflow.portfolio.parsing",
+			PackageVersion: "0.1.0",
+			RVersion:       "4.3.2",
+			Dependencies:   []pacta.ParserDependency{{Package: "dplyr", Version: "1.1.4"}},
+		},
+	}
 	err = tdb.UpdateIncompleteUpload(tx, iu.ID,
 		db.SetIncompleteUploadName(nName),
 		db.SetIncompleteUploadDescription(nDesc),
@@ -76,6 +99,10 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 		db.SetIncompleteUploadPropertyESG(ptr(false)),
 		db.SetIncompleteUploadPropertyEngagementStrategy(ptr(true)),
 		db.SetIncompleteUploadPropertyExternal(nil),
+		db.SetIncompleteUploadParseResult(parseResult),
+		db.SetIncompleteUploadLogBlob(logBlob.ID),
+		db.SetIncompleteUploadDuplicatePortfolios([]pacta.PortfolioID{"pflo-1", "pflo-2"}),
+		db.SetIncompleteUploadReplacesPortfolio(replaced.ID),
 	)
 	if err != nil {
 		t.Fatalf("updating incomplete upload: %v", err)
@@ -92,6 +119,10 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 	iu.Properties.ESG = ptr(false)
 	iu.Properties.EngagementStrategy = ptr(true)
 	iu.Properties.External = nil
+	iu.ParseResult = parseResult
+	iu.LogBlob = &pacta.Blob{ID: logBlob.ID}
+	iu.DuplicatePortfolios = []pacta.PortfolioID{"pflo-1", "pflo-2"}
+	iu.ReplacesPortfolio = &pacta.Portfolio{ID: replaced.ID}
 
 	actual, err = tdb.IncompleteUpload(tx, iu.ID)
 	if err != nil {
@@ -116,7 +147,7 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 		t.Fatalf("mismatch (-want +got):\n%s", diff)
 	}
 
-	blobContexts, err := tdb.BlobContexts(tx, []pacta.BlobID{b.ID})
+	blobContexts, err := tdb.BlobContexts(tx, []pacta.BlobID{b.ID, logBlob.ID})
 	if err != nil {
 		t.Fatalf("reading blob owners: %v", err)
 	}
@@ -126,6 +157,13 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 		PrimaryTargetType:    "INCOMPLETE_UPLOAD",
 		PrimaryTargetID:      string(iu.ID),
 		AdminDebugEnabled:    true,
+	}, {
+		BlobID:               logBlob.ID,
+		PrimaryTargetOwnerID: o2.ID,
+		PrimaryTargetType:    "INCOMPLETE_UPLOAD",
+		PrimaryTargetID:      string(iu.ID),
+		AdminDebugEnabled:    true,
+		AdminOnly:            true,
 	}}
 	if diff := cmp.Diff(expectedBCs, blobContexts, cmpOpts); diff != "" {
 		t.Errorf("unexpected diff (+got -want): %v", diff)
@@ -135,7 +173,7 @@ func TestIncompleteUploadCRUD(t *testing.T) {
 	if err != nil {
 		t.Fatalf("deleting incompleteUpload: %v", err)
 	}
-	if diff := cmp.Diff(b.BlobURI, buris); diff != "" {
+	if diff := cmp.Diff([]pacta.BlobURI{b.BlobURI, logBlob.BlobURI}, buris); diff != "" {
 		t.Fatalf("blob uri mismatch (-want +got):\n%s", diff)
 	}
 
@@ -154,10 +192,11 @@ func TestFailureCodePersistability(t *testing.T) {
 	o := ownerUserForTesting(t, tdb, u)
 
 	iu := &pacta.IncompleteUpload{
-		Name:        "i-u-name",
-		Description: "i-u-description",
-		Owner:       &pacta.Owner{ID: o.ID},
-		Blob:        &pacta.Blob{ID: b.ID},
+		Name:            "i-u-name",
+		Description:     "i-u-description",
+		Owner:           &pacta.Owner{ID: o.ID},
+		Blob:            &pacta.Blob{ID: b.ID},
+		DuplicatePolicy: pacta.DuplicatePolicy_Warn,
 	}
 	id, err := tdb.CreateIncompleteUpload(tx, iu)
 	if err != nil {
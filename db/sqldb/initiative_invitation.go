@@ -15,7 +15,8 @@ const initiativeIntivtationSelectColumns = `
 	initiative_invitation.created_at,
 	initiative_invitation.used_at,
 	initiative_invitation.initiative_id,
-	initiative_invitation.used_by_user_id`
+	initiative_invitation.used_by_user_id,
+	initiative_invitation.role`
 
 func (d *DB) InitiativeInvitation(tx db.Tx, id pacta.InitiativeInvitationID) (*pacta.InitiativeInvitation, error) {
 	rows, err := d.query(tx, `
@@ -57,9 +58,9 @@ func (d *DB) CreateInitiativeInvitation(tx db.Tx, ii *pacta.InitiativeInvitation
 	}
 	err := d.exec(tx, `
 		INSERT INTO initiative_invitation
-			(id, initiative_id)					
+			(id, initiative_id, role)					
 			VALUES
-			($1, $2)`, ii.ID, ii.Initiative.ID)
+			($1, $2, $3)`, ii.ID, ii.Initiative.ID, ii.Role)
 	if err != nil {
 		return "", fmt.Errorf("creating initiative_invitation: %w", err)
 	}
@@ -101,16 +102,21 @@ func rowToInitiativeInvitation(row rowScanner) (*pacta.InitiativeInvitation, err
 	ii := &pacta.InitiativeInvitation{Initiative: &pacta.Initiative{}}
 	ubid := pgtype.Text{}
 	t := pgtype.Timestamptz{}
+	var role string
 	err := row.Scan(
 		&ii.ID,
 		&ii.CreatedAt,
 		&t,
 		&ii.Initiative.ID,
 		&ubid,
+		&role,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scanning into initiative_invitation: %w", err)
 	}
+	if ii.Role, err = pacta.ParseInitiativeUserRole(role); err != nil {
+		return nil, fmt.Errorf("parsing initiative invitation role: %w", err)
+	}
 	if ubid.Valid {
 		ii.UsedBy = &pacta.User{ID: pacta.UserID(ubid.String)}
 	}
@@ -134,6 +140,9 @@ func validateInitiativeInvitationForCreation(ii *pacta.InitiativeInvitation) err
 	if ii.UsedBy != nil {
 		return fmt.Errorf("InitiativeInvitation.UsedBy must be nil")
 	}
+	if _, err := pacta.ParseInitiativeUserRole(string(ii.Role)); err != nil {
+		return fmt.Errorf("InitiativeInvitation.Role is invalid: %w", err)
+	}
 	return nil
 }
 
@@ -21,6 +21,7 @@ func TestCreateInitiativeInvitation(t *testing.T) {
 	ii := &pacta.InitiativeInvitation{
 		ID:         presetID,
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_Analyst,
 	}
 	id, err := tdb.CreateInitiativeInvitation(tx, ii)
 	if err != nil {
@@ -39,6 +40,7 @@ func TestCreateInitiativeInvitation(t *testing.T) {
 	// Create without a preset id
 	ii2 := &pacta.InitiativeInvitation{
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 	}
 	id2, err := tdb.CreateInitiativeInvitation(tx, ii2)
 	if err != nil {
@@ -77,6 +79,7 @@ func TestUpdateInitiativeInvitation(t *testing.T) {
 	u := userForTesting(t, tdb)
 	iiid, err0 := tdb.CreateInitiativeInvitation(tx, &pacta.InitiativeInvitation{
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 	})
 	noErrDuringSetup(t, err0)
 
@@ -94,6 +97,7 @@ func TestUpdateInitiativeInvitation(t *testing.T) {
 	expected := &pacta.InitiativeInvitation{
 		ID:         iiid,
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 		CreatedAt:  time.Now(),
 		UsedAt:     time.Now(),
 		UsedBy:     &pacta.User{ID: u.ID},
@@ -110,6 +114,7 @@ func TestDeleteInitiativeInvitation(t *testing.T) {
 	i := initiativeForTesting(t, tdb)
 	iiid, err0 := tdb.CreateInitiativeInvitation(tx, &pacta.InitiativeInvitation{
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 	})
 	noErrDuringSetup(t, err0)
 
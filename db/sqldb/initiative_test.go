@@ -104,10 +104,12 @@ func TestDeleteInitiative(t *testing.T) {
 	u := userForTesting(t, tdb)
 	_, err0 := tdb.CreateInitiativeInvitation(tx, &pacta.InitiativeInvitation{
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 	})
 	iur := &pacta.InitiativeUserRelationship{
 		User:       &pacta.User{ID: u.ID},
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 	}
 	err1 := tdb.PutInitiativeUserRelationship(tx, iur)
 	noErrDuringSetup(t, err0, err1)
@@ -12,8 +12,7 @@ import (
 const initiativeUserRelationshipSelectColumns = `
 	initiative_user_relationship.initiative_id,
 	initiative_user_relationship.user_id,
-	initiative_user_relationship.member,
-	initiative_user_relationship.manager,
+	initiative_user_relationship.role,
 	initiative_user_relationship.updated_at
 `
 
@@ -69,14 +68,13 @@ func (d *DB) PutInitiativeUserRelationship(tx db.Tx, iur *pacta.InitiativeUserRe
 	updatedAt := time.Now()
 	err := d.exec(tx, `
 		INSERT INTO initiative_user_relationship
-			(initiative_id, user_id, member, manager, updated_at) 
+			(initiative_id, user_id, role, updated_at) 
 			VALUES
-			($1, $2, $3, $4, $5)
+			($1, $2, $3, $4)
 		ON CONFLICT (initiative_id, user_id) DO UPDATE SET
-			member = $3,
-			manager = $4,
-			updated_at = $5;
-		`, iur.Initiative.ID, iur.User.ID, iur.Member, iur.Manager, updatedAt)
+			role = $3,
+			updated_at = $4;
+		`, iur.Initiative.ID, iur.User.ID, iur.Role, updatedAt)
 	if err != nil {
 		return fmt.Errorf("updating initiative_user_relationship writable fields: %w", err)
 	}
@@ -104,6 +102,16 @@ func (d *DB) UpdateInitiativeUserRelationship(tx db.Tx, iid pacta.InitiativeID,
 	return d.PutInitiativeUserRelationship(tx, iur)
 }
 
+func (d *DB) DeleteInitiativeUserRelationship(tx db.Tx, iid pacta.InitiativeID, uid pacta.UserID) error {
+	err := d.exec(tx, `
+		DELETE FROM initiative_user_relationship
+		WHERE initiative_id = $1 AND user_id = $2;`, iid, uid)
+	if err != nil {
+		return fmt.Errorf("deleting initiative_user_relationship: %w", err)
+	}
+	return nil
+}
+
 func validateInitiativeUserRelationshipForPut(iur *pacta.InitiativeUserRelationship) error {
 	if iur.User == nil || iur.User.ID == "" {
 		return fmt.Errorf("user_id is required")
@@ -111,6 +119,9 @@ func validateInitiativeUserRelationshipForPut(iur *pacta.InitiativeUserRelations
 	if iur.Initiative == nil || iur.Initiative.ID == "" {
 		return fmt.Errorf("initiative_id is required")
 	}
+	if _, err := pacta.ParseInitiativeUserRole(string(iur.Role)); err != nil {
+		return fmt.Errorf("role is invalid: %w", err)
+	}
 	return nil
 }
 
@@ -119,16 +130,19 @@ func rowToInitiativeUserRelationship(row rowScanner) (*pacta.InitiativeUserRelat
 		Initiative: &pacta.Initiative{},
 		User:       &pacta.User{},
 	}
+	var role string
 	err := row.Scan(
 		&iuv.Initiative.ID,
 		&iuv.User.ID,
-		&iuv.Member,
-		&iuv.Manager,
+		&role,
 		&iuv.UpdatedAt,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("scanning into initiative_user_relationship: %w", err)
 	}
+	if iuv.Role, err = pacta.ParseInitiativeUserRole(role); err != nil {
+		return nil, fmt.Errorf("parsing initiative user role: %w", err)
+	}
 	return iuv, nil
 }
 
@@ -5,6 +5,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/RMI/pacta/db"
 	"github.com/RMI/pacta/pacta"
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
@@ -15,9 +16,13 @@ func TestCreateInitiativeUserRelationship(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      "pacta digest",
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	pvID, err0 := tdb.CreatePACTAVersion(tx, pv)
 	i := &pacta.Initiative{
@@ -38,6 +43,7 @@ func TestCreateInitiativeUserRelationship(t *testing.T) {
 	iur := &pacta.InitiativeUserRelationship{
 		User:       &pacta.User{ID: uid},
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_Viewer,
 	}
 
 	err := tdb.PutInitiativeUserRelationship(tx, iur)
@@ -62,9 +68,13 @@ func TestUpdateInitiativeUserRelationship(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      "pacta digest",
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	pvID, err0 := tdb.CreatePACTAVersion(tx, pv)
 	i := &pacta.Initiative{
@@ -83,11 +93,12 @@ func TestUpdateInitiativeUserRelationship(t *testing.T) {
 	iur := &pacta.InitiativeUserRelationship{
 		User:       &pacta.User{ID: uid},
 		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_Viewer,
 	}
 	err3 := tdb.PutInitiativeUserRelationship(tx, iur)
 	noErrDuringSetup(t, err0, err1, err2, err3)
 
-	iur.Manager = true
+	iur.Role = pacta.InitiativeUserRole_Manager
 	iur.UpdatedAt = time.Now()
 	err := tdb.PutInitiativeUserRelationship(tx, iur)
 	if err != nil {
@@ -101,8 +112,7 @@ func TestUpdateInitiativeUserRelationship(t *testing.T) {
 		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
 	}
 
-	iur.Member = true
-	iur.Manager = false
+	iur.Role = pacta.InitiativeUserRole_Analyst
 	iur.UpdatedAt = time.Now()
 	err = tdb.PutInitiativeUserRelationship(tx, iur)
 	if err != nil {
@@ -122,9 +132,13 @@ func TestListInitiativeUserRelationships(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      "pacta digest",
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	pvID, err0 := tdb.CreatePACTAVersion(tx, pv)
 	i1 := &pacta.Initiative{
@@ -156,20 +170,21 @@ func TestListInitiativeUserRelationships(t *testing.T) {
 	iurI1U1 := &pacta.InitiativeUserRelationship{
 		User:       &pacta.User{ID: u1},
 		Initiative: &pacta.Initiative{ID: i1.ID},
+		Role:       pacta.InitiativeUserRole_Viewer,
 		UpdatedAt:  time.Now(),
 	}
 	err5 := tdb.PutInitiativeUserRelationship(tx, iurI1U1)
 	iurI2U1 := &pacta.InitiativeUserRelationship{
 		User:       &pacta.User{ID: u1},
 		Initiative: &pacta.Initiative{ID: i2.ID},
-		Member:     true,
+		Role:       pacta.InitiativeUserRole_PortfolioContributor,
 		UpdatedAt:  time.Now(),
 	}
 	err6 := tdb.PutInitiativeUserRelationship(tx, iurI2U1)
 	iurI2U2 := &pacta.InitiativeUserRelationship{
 		User:       &pacta.User{ID: u2},
 		Initiative: &pacta.Initiative{ID: i2.ID},
-		Manager:    true,
+		Role:       pacta.InitiativeUserRole_Manager,
 		UpdatedAt:  time.Now(),
 	}
 	err7 := tdb.PutInitiativeUserRelationship(tx, iurI2U2)
@@ -200,6 +215,43 @@ func TestListInitiativeUserRelationships(t *testing.T) {
 	}
 }
 
+func TestDeleteInitiativeUserRelationship(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	i := initiativeForTesting(t, tdb)
+	u := userForTesting(t, tdb)
+	err0 := tdb.PutInitiativeUserRelationship(tx, &pacta.InitiativeUserRelationship{
+		User:       &pacta.User{ID: u.ID},
+		Initiative: &pacta.Initiative{ID: i.ID},
+		Role:       pacta.InitiativeUserRole_Analyst,
+	})
+	noErrDuringSetup(t, err0)
+
+	if err := tdb.DeleteInitiativeUserRelationship(tx, i.ID, u.ID); err != nil {
+		t.Fatalf("deleting initiative user relationship: %v", err)
+	}
+	if _, err := tdb.InitiativeUserRelationship(tx, i.ID, u.ID); !db.IsNotFound(err) {
+		t.Fatalf("expected not found error, got %v", err)
+	}
+}
+
+func TestPutInitiativeUserRelationshipRequiresRole(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	i := initiativeForTesting(t, tdb)
+	u := userForTesting(t, tdb)
+
+	err := tdb.PutInitiativeUserRelationship(tx, &pacta.InitiativeUserRelationship{
+		User:       &pacta.User{ID: u.ID},
+		Initiative: &pacta.Initiative{ID: i.ID},
+	})
+	if err == nil {
+		t.Fatal("expected error putting relationship without a role, got nil")
+	}
+}
+
 func initiativeUserRelationshipCmpOpts() cmp.Option {
 	initiativeUserRelationshipLessFn := func(a, b *pacta.InitiativeUserRelationship) bool {
 		if a.User.ID < b.User.ID {
@@ -0,0 +1,30 @@
+BEGIN;
+
+DROP TABLE task;
+DROP TYPE task_state;
+DROP TYPE task_type;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+ALTER TABLE audit_log 
+    ALTER primary_target_type TYPE TEXT,
+    ALTER secondary_target_type TYPE TEXT;
+
+DROP TYPE audit_log_target_type;
+CREATE TYPE audit_log_target_type AS ENUM (
+    'USER',
+    'PORTFOLIO',
+    'PORTFOLIO_GROUP',
+    'INITIATIVE',
+    'PACTA_VERSION',
+    'ANALYSIS',
+    'INCOMPLETE_UPLOAD',
+    'INITIATIVE_INVITATION',
+    'ANALYSIS_ARTIFACT');
+
+ALTER TABLE audit_log 
+    ALTER primary_target_type TYPE audit_log_target_type USING primary_target_type::audit_log_target_type,
+    ALTER secondary_target_type TYPE audit_log_target_type USING secondary_target_type::audit_log_target_type;
+
+COMMIT;
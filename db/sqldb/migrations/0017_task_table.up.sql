@@ -0,0 +1,35 @@
+BEGIN;
+
+CREATE TYPE task_type AS ENUM (
+    'parse_portfolio',
+    'create_report',
+    'create_audit',
+    'create_dashboard');
+
+CREATE TYPE task_state AS ENUM (
+    'QUEUED',
+    'RUNNING',
+    'SUCCEEDED',
+    'FAILED');
+
+CREATE TABLE task (
+    id TEXT PRIMARY KEY,
+    task_type task_type NOT NULL,
+    owner_id TEXT NOT NULL REFERENCES owner (id) ON DELETE RESTRICT,
+    analysis_id TEXT REFERENCES analysis (id) ON DELETE RESTRICT, -- Only set for analysis tasks
+    request JSONB, -- Set when the task is dispatched to the runner
+    runner_id TEXT,
+    image_tag TEXT,
+    state task_state NOT NULL,
+    attempt_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    started_at TIMESTAMPTZ,
+    completed_at TIMESTAMPTZ
+);
+
+CREATE INDEX task_by_owner_id ON task (owner_id);
+CREATE INDEX task_by_analysis_id ON task (analysis_id);
+
+ALTER TYPE audit_log_target_type ADD VALUE 'TASK';
+
+COMMIT;
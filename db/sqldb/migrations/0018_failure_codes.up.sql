@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TYPE failure_code ADD VALUE 'PARSE_INVALID_CSV';
+ALTER TYPE failure_code ADD VALUE 'R_SCRIPT_FAILED';
+ALTER TYPE failure_code ADD VALUE 'BLOB_IO';
+ALTER TYPE failure_code ADD VALUE 'TIMEOUT';
+ALTER TYPE failure_code ADD VALUE 'OUT_OF_MEMORY';
+
+COMMIT;
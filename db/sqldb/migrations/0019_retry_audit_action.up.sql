@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TYPE audit_log_action ADD VALUE 'RETRY';
+
+COMMIT;
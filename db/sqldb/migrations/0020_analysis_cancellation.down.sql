@@ -0,0 +1,49 @@
+BEGIN;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+UPDATE analysis SET failure_code = 'UNKNOWN' WHERE failure_code = 'CANCELLED';
+UPDATE incomplete_upload SET failure_code = 'UNKNOWN' WHERE failure_code = 'CANCELLED';
+
+ALTER TABLE analysis ALTER failure_code TYPE TEXT;
+ALTER TABLE incomplete_upload ALTER failure_code TYPE TEXT;
+
+DROP TYPE failure_code;
+CREATE TYPE failure_code AS ENUM (
+    'UNKNOWN',
+    'PARSE_INVALID_CSV',
+    'R_SCRIPT_FAILED',
+    'BLOB_IO',
+    'TIMEOUT',
+    'OUT_OF_MEMORY');
+
+ALTER TABLE analysis
+    ALTER failure_code TYPE failure_code USING failure_code::failure_code;
+ALTER TABLE incomplete_upload
+    ALTER failure_code TYPE failure_code USING failure_code::failure_code;
+
+UPDATE audit_log SET action = 'UPDATE' WHERE action = 'CANCEL';
+
+ALTER TABLE audit_log ALTER action TYPE TEXT;
+
+DROP TYPE audit_log_action;
+CREATE TYPE audit_log_action AS ENUM (
+    'CREATE',
+    'UPDATE',
+    'DELETE',
+    'ADD_TO',
+    'REMOVE_FROM',
+    'ENABLE_ADMIN_DEBUG',
+    'DISABLE_ADMIN_DEBUG',
+    'DOWNLOAD',
+    'ENABLE_SHARING',
+    'DISABLE_SHARING',
+    'TRANSFER_OWNERSHIP',
+    'READ_METADATA',
+    'RETRY');
+
+ALTER TABLE audit_log
+    ALTER action TYPE audit_log_action USING action::audit_log_action;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TYPE failure_code ADD VALUE 'CANCELLED';
+ALTER TYPE audit_log_action ADD VALUE 'CANCEL';
+
+COMMIT;
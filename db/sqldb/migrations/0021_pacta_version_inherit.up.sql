@@ -0,0 +1,8 @@
+BEGIN;
+
+-- All existing PACTA versions were run with the 2023Q4 configuration, which
+-- was previously hardcoded.
+ALTER TABLE pacta_version ADD COLUMN inherit TEXT NOT NULL DEFAULT 'GENERAL_2023Q4';
+ALTER TABLE pacta_version ALTER COLUMN inherit DROP DEFAULT;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE analysis DROP COLUMN image_tag;
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE analysis ADD COLUMN image_tag TEXT;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+ALTER TABLE pacta_version DROP COLUMN pacta_dataset;
+ALTER TABLE pacta_version DROP COLUMN benchmark_dataset;
+
+COMMIT;
@@ -0,0 +1,10 @@
+BEGIN;
+
+-- All existing PACTA versions were run against these datasets, which were
+-- previously hardcoded.
+ALTER TABLE pacta_version ADD COLUMN benchmark_dataset TEXT NOT NULL DEFAULT '65c1a416721b22a98c7925999ae03bc4';
+ALTER TABLE pacta_version ALTER COLUMN benchmark_dataset DROP DEFAULT;
+ALTER TABLE pacta_version ADD COLUMN pacta_dataset TEXT NOT NULL DEFAULT '2023Q4_20240718T150252Z';
+ALTER TABLE pacta_version ALTER COLUMN pacta_dataset DROP DEFAULT;
+
+COMMIT;
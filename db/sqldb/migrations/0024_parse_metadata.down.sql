@@ -0,0 +1,9 @@
+BEGIN;
+
+ALTER TABLE portfolio DROP COLUMN md5;
+ALTER TABLE portfolio DROP COLUMN portfolio_name;
+ALTER TABLE portfolio DROP COLUMN investor_name;
+
+ALTER TABLE incomplete_upload DROP COLUMN parse_result;
+
+COMMIT;
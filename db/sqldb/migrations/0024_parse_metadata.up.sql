@@ -0,0 +1,13 @@
+BEGIN;
+
+-- What the parser reported about each uploaded file, including row-level
+-- errors and the versions of the R packages that parsed it.
+ALTER TABLE incomplete_upload ADD COLUMN parse_result JSONB;
+
+-- Metadata about each parsed portfolio, from the parser. These are nullable
+-- because portfolios parsed before this migration don't have them.
+ALTER TABLE portfolio ADD COLUMN investor_name TEXT;
+ALTER TABLE portfolio ADD COLUMN portfolio_name TEXT;
+ALTER TABLE portfolio ADD COLUMN md5 TEXT;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+DROP INDEX incomplete_upload_by_log_blob_id;
+ALTER TABLE incomplete_upload DROP COLUMN log_blob_id;
+
+COMMIT;
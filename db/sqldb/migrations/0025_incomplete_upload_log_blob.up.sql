@@ -0,0 +1,7 @@
+BEGIN;
+
+-- The output of the R script that parsed this upload, for debugging.
+ALTER TABLE incomplete_upload ADD COLUMN log_blob_id TEXT REFERENCES blob (id) ON DELETE RESTRICT;
+CREATE INDEX incomplete_upload_by_log_blob_id ON incomplete_upload USING btree (log_blob_id);
+
+COMMIT;
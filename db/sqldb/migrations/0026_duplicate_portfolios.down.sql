@@ -0,0 +1,35 @@
+BEGIN;
+
+ALTER TABLE incomplete_upload DROP COLUMN duplicate_portfolio_ids;
+ALTER TABLE incomplete_upload DROP COLUMN duplicate_policy;
+
+DROP INDEX blob_by_content_md5;
+ALTER TABLE blob DROP COLUMN content_md5;
+
+DROP TYPE duplicate_policy;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+UPDATE analysis SET failure_code = 'UNKNOWN' WHERE failure_code = 'DUPLICATE';
+UPDATE incomplete_upload SET failure_code = 'UNKNOWN' WHERE failure_code = 'DUPLICATE';
+
+ALTER TABLE analysis ALTER failure_code TYPE TEXT;
+ALTER TABLE incomplete_upload ALTER failure_code TYPE TEXT;
+
+DROP TYPE failure_code;
+CREATE TYPE failure_code AS ENUM (
+    'UNKNOWN',
+    'PARSE_INVALID_CSV',
+    'R_SCRIPT_FAILED',
+    'BLOB_IO',
+    'TIMEOUT',
+    'OUT_OF_MEMORY',
+    'CANCELLED');
+
+ALTER TABLE analysis
+    ALTER failure_code TYPE failure_code USING failure_code::failure_code;
+ALTER TABLE incomplete_upload
+    ALTER failure_code TYPE failure_code USING failure_code::failure_code;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TYPE failure_code ADD VALUE 'DUPLICATE';
+
+CREATE TYPE duplicate_policy AS ENUM (
+    'LINK',
+    'WARN',
+    'REJECT');
+
+-- The hex-encoded MD5 of the blob's contents, used to find duplicate
+-- portfolios. Existing portfolios already have the parser's output hash.
+ALTER TABLE blob ADD COLUMN content_md5 TEXT;
+CREATE INDEX blob_by_content_md5 ON blob USING btree (content_md5);
+UPDATE blob SET content_md5 = portfolio.md5 FROM portfolio WHERE portfolio.blob_id = blob.id;
+
+-- Existing uploads created a new portfolio regardless of duplicates.
+ALTER TABLE incomplete_upload ADD COLUMN duplicate_policy duplicate_policy NOT NULL DEFAULT 'WARN';
+ALTER TABLE incomplete_upload ALTER COLUMN duplicate_policy DROP DEFAULT;
+ALTER TABLE incomplete_upload ADD COLUMN duplicate_portfolio_ids TEXT[] NOT NULL DEFAULT '{}';
+
+COMMIT;
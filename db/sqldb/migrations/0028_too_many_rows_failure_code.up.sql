@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TYPE failure_code ADD VALUE 'TOO_MANY_ROWS';
+
+COMMIT;
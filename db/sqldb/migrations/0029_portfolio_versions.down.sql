@@ -0,0 +1,34 @@
+BEGIN;
+
+ALTER TABLE portfolio_snapshot DROP COLUMN blob_ids;
+ALTER TABLE incomplete_upload DROP COLUMN replaces_portfolio_id;
+DROP TABLE portfolio_version;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+UPDATE analysis SET failure_code = 'UNKNOWN' WHERE failure_code = 'INVALID_REPLACEMENT';
+UPDATE incomplete_upload SET failure_code = 'UNKNOWN' WHERE failure_code = 'INVALID_REPLACEMENT';
+
+ALTER TABLE analysis ALTER failure_code TYPE TEXT;
+ALTER TABLE incomplete_upload ALTER failure_code TYPE TEXT;
+
+DROP TYPE failure_code;
+CREATE TYPE failure_code AS ENUM (
+    'UNKNOWN',
+    'PARSE_INVALID_CSV',
+    'R_SCRIPT_FAILED',
+    'BLOB_IO',
+    'TIMEOUT',
+    'OUT_OF_MEMORY',
+    'CANCELLED',
+    'DUPLICATE',
+    'UNSUPPORTED_FILE',
+    'TOO_MANY_ROWS');
+
+ALTER TABLE analysis
+    ALTER failure_code TYPE failure_code USING failure_code::failure_code;
+ALTER TABLE incomplete_upload
+    ALTER failure_code TYPE failure_code USING failure_code::failure_code;
+
+COMMIT;
@@ -0,0 +1,36 @@
+BEGIN;
+
+ALTER TYPE failure_code ADD VALUE 'INVALID_REPLACEMENT';
+
+-- A prior set of holdings for a portfolio, recorded when the portfolio's blob
+-- is replaced. The portfolio row always holds the current version.
+CREATE TABLE portfolio_version (
+    id TEXT PRIMARY KEY,
+    portfolio_id TEXT NOT NULL REFERENCES portfolio (id) ON DELETE RESTRICT,
+    version INTEGER NOT NULL,
+    blob_id TEXT NOT NULL REFERENCES blob (id) ON DELETE RESTRICT,
+    number_of_rows INTEGER,
+    md5 TEXT,
+    replaced_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    UNIQUE (portfolio_id, version)
+);
+
+CREATE INDEX portfolio_version_by_blob_id ON portfolio_version (blob_id);
+
+-- Set when an upload holds new holdings for an existing portfolio.
+ALTER TABLE incomplete_upload ADD COLUMN replaces_portfolio_id TEXT REFERENCES portfolio (id) ON DELETE RESTRICT;
+
+-- The blob of each portfolio in portfolio_ids when the snapshot was taken, so
+-- that analyses keep referring to the holdings they were run against. Existing
+-- snapshots get the portfolios' current blobs, which is what they'd have used.
+ALTER TABLE portfolio_snapshot ADD COLUMN blob_ids TEXT[];
+UPDATE portfolio_snapshot SET blob_ids = ARRAY(
+    SELECT portfolio.blob_id
+    FROM UNNEST(portfolio_snapshot.portfolio_ids) WITH ORDINALITY AS u(id, ord)
+    JOIN portfolio ON portfolio.id = u.id
+    ORDER BY u.ord)
+WHERE NOT EXISTS (
+    SELECT 1 FROM UNNEST(portfolio_snapshot.portfolio_ids) AS u(id)
+    WHERE NOT EXISTS (SELECT 1 FROM portfolio WHERE portfolio.id = u.id));
+
+COMMIT;
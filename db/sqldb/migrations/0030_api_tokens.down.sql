@@ -0,0 +1,33 @@
+BEGIN;
+
+ALTER TABLE audit_log DROP COLUMN actor_api_token_id;
+DROP TABLE api_token;
+DROP TYPE api_token_scope;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+DELETE FROM audit_log WHERE primary_target_type = 'API_TOKEN' OR secondary_target_type = 'API_TOKEN';
+
+ALTER TABLE audit_log
+    ALTER primary_target_type TYPE TEXT,
+    ALTER secondary_target_type TYPE TEXT;
+
+DROP TYPE audit_log_target_type;
+CREATE TYPE audit_log_target_type AS ENUM (
+    'USER',
+    'PORTFOLIO',
+    'PORTFOLIO_GROUP',
+    'INITIATIVE',
+    'PACTA_VERSION',
+    'ANALYSIS',
+    'INCOMPLETE_UPLOAD',
+    'INITIATIVE_INVITATION',
+    'ANALYSIS_ARTIFACT',
+    'TASK');
+
+ALTER TABLE audit_log
+    ALTER primary_target_type TYPE audit_log_target_type USING primary_target_type::audit_log_target_type,
+    ALTER secondary_target_type TYPE audit_log_target_type USING secondary_target_type::audit_log_target_type;
+
+COMMIT;
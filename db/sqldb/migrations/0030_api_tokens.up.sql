@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TYPE api_token_scope AS ENUM (
+    'READ_ONLY',
+    'UPLOAD',
+    'RUN_ANALYSIS');
+
+-- A user-managed credential for scripted access. Only a SHA-256 hash of the
+-- secret is stored. A token with no scopes has full access to the API.
+CREATE TABLE api_token (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES pacta_user (id) ON DELETE RESTRICT,
+    name TEXT NOT NULL,
+    token_hash TEXT NOT NULL UNIQUE,
+    scopes api_token_scope[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at TIMESTAMPTZ,
+    last_used_at TIMESTAMPTZ,
+    revoked_at TIMESTAMPTZ
+);
+
+CREATE INDEX api_token_by_user_id ON api_token (user_id);
+
+-- Set when the actor authenticated with an API token rather than a session.
+ALTER TABLE audit_log ADD COLUMN actor_api_token_id TEXT;
+
+ALTER TYPE audit_log_target_type ADD VALUE 'API_TOKEN';
+
+COMMIT;
@@ -0,0 +1,19 @@
+BEGIN;
+
+DROP TABLE user_authn;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+-- This fails if any user was created with an OIDC identity, those users need
+-- to be dealt with by hand first.
+
+ALTER TABLE pacta_user ALTER authn_mechanism TYPE TEXT;
+
+DROP TYPE authn_mechanism;
+CREATE TYPE authn_mechanism AS ENUM (
+    'EMAIL_AND_PASS');
+
+ALTER TABLE pacta_user
+    ALTER authn_mechanism TYPE authn_mechanism USING authn_mechanism::authn_mechanism;
+
+COMMIT;
@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TYPE authn_mechanism ADD VALUE 'OIDC';
+
+-- The identities a user can log in with. pacta_user.authn_mechanism and
+-- pacta_user.authn_id hold the identity the account was created with, which is
+-- also recorded here.
+CREATE TABLE user_authn (
+    user_id TEXT NOT NULL REFERENCES pacta_user (id) ON DELETE RESTRICT,
+    authn_mechanism authn_mechanism NOT NULL,
+    authn_id TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (authn_mechanism, authn_id)
+);
+
+CREATE INDEX user_authn_by_user_id ON user_authn (user_id);
+
+INSERT INTO user_authn (user_id, authn_mechanism, authn_id, created_at)
+    SELECT id, authn_mechanism, authn_id, created_at FROM pacta_user;
+
+COMMIT;
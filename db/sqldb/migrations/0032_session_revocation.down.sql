@@ -0,0 +1,33 @@
+BEGIN;
+
+DROP TABLE user_session_revocation;
+DROP TABLE revoked_session;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+UPDATE audit_log SET action = 'UPDATE' WHERE action = 'REVOKE_SESSIONS';
+
+ALTER TABLE audit_log ALTER action TYPE TEXT;
+
+DROP TYPE audit_log_action;
+CREATE TYPE audit_log_action AS ENUM (
+    'CREATE',
+    'UPDATE',
+    'DELETE',
+    'ADD_TO',
+    'REMOVE_FROM',
+    'ENABLE_ADMIN_DEBUG',
+    'DISABLE_ADMIN_DEBUG',
+    'DOWNLOAD',
+    'ENABLE_SHARING',
+    'DISABLE_SHARING',
+    'TRANSFER_OWNERSHIP',
+    'READ_METADATA',
+    'RETRY',
+    'CANCEL');
+
+ALTER TABLE audit_log
+    ALTER action TYPE audit_log_action USING action::audit_log_action;
+
+COMMIT;
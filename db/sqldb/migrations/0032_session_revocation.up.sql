@@ -0,0 +1,24 @@
+BEGIN;
+
+ALTER TYPE audit_log_action ADD VALUE 'REVOKE_SESSIONS';
+
+-- Individual sessions that were revoked before they expired, identified by the
+-- jti claim of their JWT. Once a token has expired, its row is no longer
+-- needed.
+CREATE TABLE revoked_session (
+    jti TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL REFERENCES pacta_user (id) ON DELETE RESTRICT,
+    expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX revoked_session_by_user_id ON revoked_session (user_id);
+
+-- All of a user's sessions that were issued before revoked_before are revoked,
+-- which is how we log a user out everywhere.
+CREATE TABLE user_session_revocation (
+    user_id TEXT PRIMARY KEY REFERENCES pacta_user (id) ON DELETE RESTRICT,
+    revoked_before TIMESTAMPTZ NOT NULL
+);
+
+COMMIT;
@@ -0,0 +1,42 @@
+BEGIN;
+
+ALTER TABLE initiative_invitation DROP COLUMN role;
+
+-- Only managers and contributors map onto the old flags, so viewers and
+-- analysts become members, like everyone else who wasn't a manager.
+ALTER TABLE initiative_user_relationship
+    ADD COLUMN manager BOOLEAN,
+    ADD COLUMN member BOOLEAN;
+UPDATE initiative_user_relationship SET
+    manager = (role = 'MANAGER'),
+    member = (role <> 'MANAGER');
+ALTER TABLE initiative_user_relationship
+    ALTER COLUMN manager SET NOT NULL,
+    ALTER COLUMN member SET NOT NULL,
+    DROP COLUMN role;
+
+DROP TYPE initiative_user_role;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+
+-- Initiative managers were previously logged as owners, and everyone else with
+-- a relationship as the public.
+UPDATE audit_log SET actor_type = 'OWNER' WHERE actor_type = 'INITIATIVE_MANAGER';
+UPDATE audit_log SET actor_type = 'PUBLIC' WHERE actor_type IN ('INITIATIVE_VIEWER', 'INITIATIVE_PORTFOLIO_CONTRIBUTOR', 'INITIATIVE_ANALYST');
+
+ALTER TABLE audit_log ALTER actor_type TYPE TEXT;
+
+DROP TYPE audit_log_actor_type;
+CREATE TYPE audit_log_actor_type AS ENUM (
+    'USER',
+    'ADMIN',
+    'SUPER_ADMIN',
+    'SYSTEM',
+    'OWNER',
+    'PUBLIC');
+
+ALTER TABLE audit_log
+    ALTER actor_type TYPE audit_log_actor_type USING actor_type::audit_log_actor_type;
+
+COMMIT;
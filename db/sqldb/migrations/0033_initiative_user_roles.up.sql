@@ -0,0 +1,29 @@
+BEGIN;
+
+CREATE TYPE initiative_user_role AS ENUM (
+    'VIEWER',
+    'PORTFOLIO_CONTRIBUTOR',
+    'ANALYST',
+    'MANAGER');
+
+-- Replaces the manager and member flags with a single role. Relationships where
+-- neither was set are left over from users leaving an initiative, so they're
+-- dropped rather than given a role.
+ALTER TABLE initiative_user_relationship ADD COLUMN role initiative_user_role;
+DELETE FROM initiative_user_relationship WHERE NOT manager AND NOT member;
+UPDATE initiative_user_relationship SET role = 'MANAGER' WHERE manager;
+UPDATE initiative_user_relationship SET role = 'PORTFOLIO_CONTRIBUTOR' WHERE NOT manager;
+ALTER TABLE initiative_user_relationship
+    ALTER COLUMN role SET NOT NULL,
+    DROP COLUMN manager,
+    DROP COLUMN member;
+
+-- The role given to whoever claims the invitation.
+ALTER TABLE initiative_invitation ADD COLUMN role initiative_user_role NOT NULL DEFAULT 'PORTFOLIO_CONTRIBUTOR';
+
+ALTER TYPE audit_log_actor_type ADD VALUE 'INITIATIVE_VIEWER';
+ALTER TYPE audit_log_actor_type ADD VALUE 'INITIATIVE_PORTFOLIO_CONTRIBUTOR';
+ALTER TYPE audit_log_actor_type ADD VALUE 'INITIATIVE_ANALYST';
+ALTER TYPE audit_log_actor_type ADD VALUE 'INITIATIVE_MANAGER';
+
+COMMIT;
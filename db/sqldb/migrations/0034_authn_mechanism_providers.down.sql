@@ -0,0 +1,21 @@
+BEGIN;
+
+-- There isn't a way to delete a value from an enum, so this is the workaround
+-- https://stackoverflow.com/a/56777227/17909149
+-- This fails if any user has an identity from one of the removed mechanisms,
+-- those need to be dealt with by hand first.
+
+ALTER TABLE pacta_user ALTER authn_mechanism TYPE TEXT;
+ALTER TABLE user_authn ALTER authn_mechanism TYPE TEXT;
+
+DROP TYPE authn_mechanism;
+CREATE TYPE authn_mechanism AS ENUM (
+    'EMAIL_AND_PASS',
+    'OIDC');
+
+ALTER TABLE pacta_user
+    ALTER authn_mechanism TYPE authn_mechanism USING authn_mechanism::authn_mechanism;
+ALTER TABLE user_authn
+    ALTER authn_mechanism TYPE authn_mechanism USING authn_mechanism::authn_mechanism;
+
+COMMIT;
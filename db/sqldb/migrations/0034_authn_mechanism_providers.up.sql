@@ -0,0 +1,10 @@
+BEGIN;
+
+-- Each configured OIDC issuer records its users under its own mechanism, these
+-- are the providers our partner institutions use. Anything else is recorded as
+-- the generic 'OIDC'.
+ALTER TYPE authn_mechanism ADD VALUE 'ENTRA_ID';
+ALTER TYPE authn_mechanism ADD VALUE 'GOOGLE';
+ALTER TYPE authn_mechanism ADD VALUE 'OKTA';
+
+COMMIT;
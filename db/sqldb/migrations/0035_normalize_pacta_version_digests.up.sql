@@ -0,0 +1,21 @@
+BEGIN;
+
+-- PACTA versions created before digests were validated can hold them as bare
+-- hex, with uppercase hex, or as a full image reference. This rewrites those to
+-- the 'sha256:<hex>' form, unless another version already has that digest.
+-- The server normalizes digests as it reads them too, so any left behind still
+-- work.
+UPDATE pacta_version AS pv
+SET digest = normalized.digest
+FROM (
+    SELECT id, 'sha256:' || substring(lower(trim(digest)) from '([0-9a-f]{64})$') AS digest
+    FROM pacta_version
+    WHERE lower(trim(digest)) ~ '^([^@]*@)?(sha256:)?[0-9a-f]{64}$'
+) AS normalized
+WHERE pv.id = normalized.id
+    AND pv.digest <> normalized.digest
+    AND NOT EXISTS (
+        SELECT 1 FROM pacta_version AS other WHERE other.digest = normalized.digest
+    );
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- The limits on each uploaded portfolio file, which admins can change. There's
+-- only ever one row, which the id check enforces. Zero means no limit.
+CREATE TABLE upload_limits (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE,
+    max_bytes BIGINT NOT NULL,
+    max_rows INTEGER NOT NULL,
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    CONSTRAINT upload_limits_has_one_row CHECK (id),
+    CONSTRAINT upload_limits_are_non_negative CHECK (max_bytes >= 0 AND max_rows >= 0)
+);
+
+-- These were the defaults of the --max_upload_bytes and --max_upload_rows
+-- flags that these limits replace.
+INSERT INTO upload_limits (max_bytes, max_rows) VALUES (100000000, 1000000);
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE analysis_artifact DROP COLUMN admin_only;
+
+COMMIT;
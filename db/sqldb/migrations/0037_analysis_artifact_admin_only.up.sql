@@ -0,0 +1,14 @@
+BEGIN;
+
+-- Admin-only artifacts, like the logs of the R scripts, are for debugging and
+-- can't be downloaded by the owner of the analysis or shared.
+ALTER TABLE analysis_artifact ADD COLUMN admin_only BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- Logs are uploaded to <container>/<analysis id>/<scope>/logs/<task id>.txt
+UPDATE analysis_artifact
+SET admin_only = TRUE
+FROM blob
+WHERE analysis_artifact.blob_id = blob.id
+    AND blob.blob_uri ~ ('/' || analysis_artifact.analysis_id || '/[^/]+/logs/[^/]+$');
+
+COMMIT;
@@ -0,0 +1,5 @@
+BEGIN;
+
+ALTER TABLE pacta_version DROP COLUMN dashboard_digest;
+
+COMMIT;
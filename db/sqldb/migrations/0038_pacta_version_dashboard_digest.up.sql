@@ -0,0 +1,9 @@
+BEGIN;
+
+-- Dashboards used to always run whatever image was tagged 'latest', so there's
+-- no digest to backfill existing PACTA versions with. Dashboards can't be run
+-- against those until an administrator sets one.
+ALTER TABLE pacta_version ADD COLUMN dashboard_digest TEXT NOT NULL DEFAULT '';
+ALTER TABLE pacta_version ALTER COLUMN dashboard_digest DROP DEFAULT;
+
+COMMIT;
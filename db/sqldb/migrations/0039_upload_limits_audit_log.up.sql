@@ -0,0 +1,6 @@
+BEGIN;
+
+-- Changes to the upload limits are audit logged, there's only one set of them.
+ALTER TYPE audit_log_target_type ADD VALUE 'UPLOAD_LIMITS';
+
+COMMIT;
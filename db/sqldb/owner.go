@@ -135,11 +135,15 @@ func (d *DB) DeleteOwner(tx db.Tx, oID pacta.OwnerID) ([]pacta.BlobURI, error) {
 			return fmt.Errorf("getting incomplete uploads for owner: %w", err)
 		}
 		for _, iu := range incompleteUploads {
-			newBuri, err := d.DeleteIncompleteUpload(tx, iu.ID)
+			iuBuris, err := d.DeleteIncompleteUpload(tx, iu.ID)
 			if err != nil {
 				return fmt.Errorf("deleting incomplete upload: %w", err)
 			}
-			buris = append(buris, newBuri)
+			buris = append(buris, iuBuris...)
+		}
+		err = d.exec(tx, `DELETE FROM task WHERE owner_id = $1;`, oID)
+		if err != nil {
+			return fmt.Errorf("deleting tasks for owner: %w", err)
 		}
 		err = d.exec(tx, `DELETE FROM owner WHERE id = $1;`, oID)
 		if err != nil {
@@ -14,6 +14,10 @@ const pactaVersionSelectColumns = `
 	pacta_version.name,
 	pacta_version.description,
 	pacta_version.digest,
+	pacta_version.dashboard_digest,
+	pacta_version.inherit,
+	pacta_version.benchmark_dataset,
+	pacta_version.pacta_dataset,
 	pacta_version.created_at,
 	COALESCE(pacta_version.is_default, false)`
 
@@ -68,10 +72,10 @@ func (d *DB) CreatePACTAVersion(tx db.Tx, pv *pacta.PACTAVersion) (pacta.PACTAVe
 	id := pacta.PACTAVersionID(d.randomID(pactaVersionIDNamespace))
 	err := d.exec(tx, `
 		INSERT INTO pacta_version 
-			(id, name, description, digest, is_default)
+			(id, name, description, digest, dashboard_digest, inherit, benchmark_dataset, pacta_dataset, is_default)
 			VALUES
-			($1, $2, $3, $4, $5);
-	`, id, pv.Name, pv.Description, pv.Digest, nil)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9);
+	`, id, pv.Name, pv.Description, pv.Digest, pv.DashboardDigest, pv.Inherit, pv.BenchmarkDataset, pv.PACTADataset, nil)
 	if err != nil {
 		return "", fmt.Errorf("creating pacta_version: %w", err)
 	}
@@ -142,6 +146,10 @@ func rowToPACTAVersion(row rowScanner) (*pacta.PACTAVersion, error) {
 		&p.Name,
 		&p.Description,
 		&p.Digest,
+		&p.DashboardDigest,
+		&p.Inherit,
+		&p.BenchmarkDataset,
+		&p.PACTADataset,
 		&p.CreatedAt,
 		&p.IsDefault,
 	)
@@ -165,9 +173,13 @@ func (d *DB) putPACTAVersion(tx db.Tx, pv *pacta.PACTAVersion) error {
 			name = $2,
 			description = $3,
 			digest = $4,
-			is_default = $5
+			dashboard_digest = $5,
+			inherit = $6,
+			benchmark_dataset = $7,
+			pacta_dataset = $8,
+			is_default = $9
 		WHERE id = $1;
-		`, pv.ID, pv.Name, pv.Description, pv.Digest, isDefault)
+		`, pv.ID, pv.Name, pv.Description, pv.Digest, pv.DashboardDigest, pv.Inherit, pv.BenchmarkDataset, pv.PACTADataset, isDefault)
 	if err != nil {
 		return fmt.Errorf("updating pacta_version writable fields: %w", err)
 	}
@@ -184,9 +196,21 @@ func validatePACTAVersionForCreation(pv *pacta.PACTAVersion) error {
 	if pv.Digest == "" {
 		return fmt.Errorf("digest is required")
 	}
+	if pv.DashboardDigest == "" {
+		return fmt.Errorf("dashboard_digest is required")
+	}
 	if pv.Name == "" {
 		return fmt.Errorf("name is required")
 	}
+	if pv.Inherit == "" {
+		return fmt.Errorf("inherit is required")
+	}
+	if pv.BenchmarkDataset == "" {
+		return fmt.Errorf("benchmark_dataset is required")
+	}
+	if pv.PACTADataset == "" {
+		return fmt.Errorf("pacta_dataset is required")
+	}
 	if pv.ID != "" {
 		return fmt.Errorf("cannot set id on creation")
 	}
@@ -2,7 +2,8 @@ package sqldb
 
 import (
 	"context"
-	"fmt"
+	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 	"time"
 
@@ -17,9 +18,13 @@ func TestCreatePACTAVersion(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      "pacta digest",
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	pvid, err := tdb.CreatePACTAVersion(tx, pv)
 	if err != nil {
@@ -48,7 +53,7 @@ func TestCreatePACTAVersion(t *testing.T) {
 
 	// Create should succeed with a different digest
 	pv3 := pv.Clone()
-	pv3.Digest = "different digest"
+	pv3.Digest = digestForTesting("different digest")
 	_, err = tdb.CreatePACTAVersion(tx, pv3)
 	if err != nil {
 		t.Fatalf("creating pacta_version: %v", err)
@@ -60,9 +65,13 @@ func TestUpdatePACTAVersion(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      "pacta digest",
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	pvID, err0 := tdb.CreatePACTAVersion(tx, pv)
 	noErrDuringSetup(t, err0)
@@ -71,8 +80,19 @@ func TestUpdatePACTAVersion(t *testing.T) {
 
 	name := "New Name"
 	desc := "New Description"
-	digest := "New Digest"
-	err := tdb.UpdatePACTAVersion(tx, pvID, db.SetPACTAVersionDescription(desc), db.SetPACTAVersionDigest(digest), db.SetPACTAVersionName(name))
+	digest := digestForTesting("new digest")
+	dashboardDigest := digestForTesting("new dashboard digest")
+	inherit := "GENERAL_2024Q4"
+	benchmarkDataset := "2024Q4_20250114T120000Z"
+	pactaDataset := "2024Q4_20250114T120000Z"
+	err := tdb.UpdatePACTAVersion(tx, pvID,
+		db.SetPACTAVersionDescription(desc),
+		db.SetPACTAVersionDigest(digest),
+		db.SetPACTAVersionDashboardDigest(dashboardDigest),
+		db.SetPACTAVersionInherit(inherit),
+		db.SetPACTAVersionBenchmarkDataset(benchmarkDataset),
+		db.SetPACTAVersionPACTADataset(pactaDataset),
+		db.SetPACTAVersionName(name))
 	if err != nil {
 		t.Fatalf("update pacta version: %v", err)
 	}
@@ -82,6 +102,10 @@ func TestUpdatePACTAVersion(t *testing.T) {
 	}
 	pv.Name = name
 	pv.Digest = digest
+	pv.DashboardDigest = dashboardDigest
+	pv.Inherit = inherit
+	pv.BenchmarkDataset = benchmarkDataset
+	pv.PACTADataset = pactaDataset
 	pv.Description = desc
 	if diff := cmp.Diff(pv, actual, pactaVersionCmpOpts()); diff != "" {
 		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
@@ -93,19 +117,31 @@ func TestDefaultPACTAVersion(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pvA := &pacta.PACTAVersion{
-		Name:        "pv 1",
-		Digest:      "111",
-		Description: "Pacta Version 1",
+		Name:             "pv 1",
+		Digest:           digestForTesting("111"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
+		Description:      "Pacta Version 1",
 	}
 	pvB := &pacta.PACTAVersion{
-		Name:        "pv 2",
-		Digest:      "222",
-		Description: "Pacta Version 2",
+		Name:             "pv 2",
+		Digest:           digestForTesting("222"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
+		Description:      "Pacta Version 2",
 	}
 	pvC := &pacta.PACTAVersion{
-		Name:        "pv 3",
-		Digest:      "333",
-		Description: "Pacta Version 3",
+		Name:             "pv 3",
+		Digest:           digestForTesting("333"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
+		Description:      "Pacta Version 3",
 	}
 	pvIDA, err0 := tdb.CreatePACTAVersion(tx, pvA)
 	pvA.ID = pvIDA
@@ -173,9 +209,13 @@ func TestPACTAVersionDeletion(t *testing.T) {
 	tdb := createDBForTesting(t)
 	tx := tdb.NoTxn(ctx)
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      "pacta digest",
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest"),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	pvID, err0 := tdb.CreatePACTAVersion(tx, pv)
 	noErrDuringSetup(t, err0)
@@ -218,6 +258,20 @@ func pactaVersionCmpOpts() cmp.Option {
 	}
 }
 
+// Realistic dataset identifiers, which are what the migration backfilled
+// existing PACTA versions with.
+const (
+	benchmarkDatasetForTesting = "65c1a416721b22a98c7925999ae03bc4"
+	pactaDatasetForTesting     = "2023Q4_20240718T150252Z"
+)
+
+// digestForTesting returns a valid image digest derived from the given key, so
+// that different keys give different digests.
+func digestForTesting(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
 func pactaVersionForTesting(t *testing.T, tdb *DB) *pacta.PACTAVersion {
 	t.Helper()
 	return pactaVersionForTestingWithKey(t, tdb, "only")
@@ -226,9 +280,13 @@ func pactaVersionForTesting(t *testing.T, tdb *DB) *pacta.PACTAVersion {
 func pactaVersionForTestingWithKey(t *testing.T, tdb *DB, key string) *pacta.PACTAVersion {
 	t.Helper()
 	pv := &pacta.PACTAVersion{
-		Name:        "pacta version",
-		Description: "pacta description",
-		Digest:      fmt.Sprintf("pacta digest %s", key),
+		Name:             "pacta version",
+		Description:      "pacta description",
+		Digest:           digestForTesting("pacta digest " + key),
+		DashboardDigest:  digestForTesting("dashboard digest"),
+		Inherit:          "GENERAL_2023Q4",
+		BenchmarkDataset: benchmarkDatasetForTesting,
+		PACTADataset:     pactaDatasetForTesting,
 	}
 	tx := tdb.NoTxn(context.Background())
 	pvID, err := tdb.CreatePACTAVersion(tx, pv)
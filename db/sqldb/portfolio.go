@@ -27,6 +27,9 @@ func portfolioQueryStanza(where string) string {
 		portfolio.blob_id,
 		portfolio.admin_debug_enabled,
 		portfolio.number_of_rows,
+		portfolio.investor_name,
+		portfolio.portfolio_name,
+		portfolio.md5,
 		portfolio_group_ids,
 		portfolio_group_created_ats,
 		initiative_ids,
@@ -99,6 +102,45 @@ func (d *DB) PortfoliosByOwner(tx db.Tx, ownerID pacta.OwnerID) ([]*pacta.Portfo
 	return pvs, nil
 }
 
+// PortfolioIDsByContentMD5 returns the owner's portfolios whose parsed
+// holdings have the given content hash, oldest first.
+func (d *DB) PortfolioIDsByContentMD5(tx db.Tx, ownerID pacta.OwnerID, contentMD5 string) ([]pacta.PortfolioID, error) {
+	rows, err := d.query(tx, `
+		SELECT portfolio.id
+		FROM portfolio
+		JOIN blob ON portfolio.blob_id = blob.id
+		WHERE portfolio.owner_id = $1 AND blob.content_md5 = $2
+		ORDER BY portfolio.created_at, portfolio.id;`, ownerID, contentMD5)
+	if err != nil {
+		return nil, fmt.Errorf("querying portfolios by content md5: %w", err)
+	}
+	return mapRowsToIDs[pacta.PortfolioID]("portfolio_by_content_md5", rows)
+}
+
+// DuplicatePortfolioIDsByOwner returns groups of the owner's portfolios that
+// have identical parsed holdings. Each group is ordered oldest first, and the
+// groups are ordered by their oldest portfolio.
+func (d *DB) DuplicatePortfolioIDsByOwner(tx db.Tx, ownerID pacta.OwnerID) ([][]pacta.PortfolioID, error) {
+	rows, err := d.query(tx, `
+		SELECT ARRAY_AGG(portfolio.id ORDER BY portfolio.created_at, portfolio.id)
+		FROM portfolio
+		JOIN blob ON portfolio.blob_id = blob.id
+		WHERE portfolio.owner_id = $1 AND blob.content_md5 IS NOT NULL
+		GROUP BY blob.content_md5
+		HAVING COUNT(*) > 1
+		ORDER BY MIN(portfolio.created_at);`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("querying duplicate portfolios: %w", err)
+	}
+	return mapRows("duplicate_portfolios", rows, func(row rowScanner) ([]pacta.PortfolioID, error) {
+		var ids []string
+		if err := row.Scan(&ids); err != nil {
+			return nil, fmt.Errorf("scanning duplicate portfolio ids: %w", err)
+		}
+		return stringsToIDs[pacta.PortfolioID](ids), nil
+	})
+}
+
 func (d *DB) CreatePortfolio(tx db.Tx, p *pacta.Portfolio) (pacta.PortfolioID, error) {
 	if err := validatePortfolioForCreation(p); err != nil {
 		return "", fmt.Errorf("validating portfolio for creation: %w", err)
@@ -106,10 +148,11 @@ func (d *DB) CreatePortfolio(tx db.Tx, p *pacta.Portfolio) (pacta.PortfolioID, e
 	p.ID = pacta.PortfolioID(d.randomID("pflo"))
 	err := d.exec(tx, `
 		INSERT INTO portfolio 
-			(id, owner_id, name, description, properties, blob_id, admin_debug_enabled, number_of_rows)
+			(id, owner_id, name, description, properties, blob_id, admin_debug_enabled, number_of_rows, investor_name, portfolio_name, md5)
 			VALUES
-			($1, $2, $3, $4, $5, $6, $7, $8);`,
-		p.ID, p.Owner.ID, p.Name, p.Description, p.Properties, p.Blob.ID, p.AdminDebugEnabled, p.NumberOfRows)
+			($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11);`,
+		p.ID, p.Owner.ID, p.Name, p.Description, p.Properties, p.Blob.ID, p.AdminDebugEnabled, p.NumberOfRows,
+		strToNilable(p.InvestorName), strToNilable(p.PortfolioName), strToNilable(p.MD5))
 	if err != nil {
 		return "", fmt.Errorf("creating portfolio: %w", err)
 	}
@@ -140,6 +183,78 @@ func (d *DB) UpdatePortfolio(tx db.Tx, id pacta.PortfolioID, mutations ...db.Upd
 	return nil
 }
 
+// ReplacePortfolioHoldings swaps the portfolio's holdings for those in the
+// given replacement, recording the portfolio's current holdings as a prior
+// version. Only the holdings fields of the replacement (Blob, NumberOfRows,
+// InvestorName, PortfolioName and MD5) are used, the portfolio otherwise keeps
+// its identity, memberships and properties.
+func (d *DB) ReplacePortfolioHoldings(tx db.Tx, id pacta.PortfolioID, replacement *pacta.Portfolio) (pacta.PortfolioVersionID, error) {
+	if replacement.Blob == nil || replacement.Blob.ID == "" {
+		return "", errors.New("replacement must contain a non-nil blob with a present ID")
+	}
+	if replacement.NumberOfRows < 0 {
+		return "", errors.New("replacement number_of_rows must be non-negative")
+	}
+	versionID := pacta.PortfolioVersionID(d.randomID("pfvr"))
+	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
+		// Locking the portfolio row serializes concurrent replacements, so
+		// version numbers are assigned in order.
+		var (
+			blobID       pacta.BlobID
+			numberOfRows pgtype.Int4
+			md5          pgtype.Text
+		)
+		row := d.queryRow(tx, `SELECT blob_id, number_of_rows, md5 FROM portfolio WHERE id = $1 FOR UPDATE;`, id)
+		if err := row.Scan(&blobID, &numberOfRows, &md5); err != nil {
+			if errors.Is(err, pgx.ErrNoRows) {
+				return db.NotFound(id, "portfolio")
+			}
+			return fmt.Errorf("reading current holdings: %w", err)
+		}
+		err := d.exec(tx, `
+			INSERT INTO portfolio_version
+				(id, portfolio_id, version, blob_id, number_of_rows, md5)
+				SELECT $1, $2, COALESCE(MAX(version), 0) + 1, $3, $4, $5
+				FROM portfolio_version WHERE portfolio_id = $2;`,
+			versionID, id, blobID, numberOfRows, md5)
+		if err != nil {
+			return fmt.Errorf("creating portfolio_version: %w", err)
+		}
+		err = d.exec(tx, `
+			UPDATE portfolio SET
+				blob_id = $2,
+				number_of_rows = $3,
+				investor_name = $4,
+				portfolio_name = $5,
+				md5 = $6
+			WHERE id = $1;`,
+			id, replacement.Blob.ID, replacement.NumberOfRows, strToNilable(replacement.InvestorName),
+			strToNilable(replacement.PortfolioName), strToNilable(replacement.MD5))
+		if err != nil {
+			return fmt.Errorf("updating portfolio holdings: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("replacing portfolio holdings: %w", err)
+	}
+	return versionID, nil
+}
+
+// PortfolioVersions returns the prior holdings of the portfolio, most recently
+// replaced first. The portfolio's current holdings aren't included.
+func (d *DB) PortfolioVersions(tx db.Tx, id pacta.PortfolioID) ([]*pacta.PortfolioVersion, error) {
+	rows, err := d.query(tx, `
+		SELECT id, portfolio_id, version, blob_id, number_of_rows, md5, replaced_at
+		FROM portfolio_version
+		WHERE portfolio_id = $1
+		ORDER BY version DESC;`, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying portfolio_versions: %w", err)
+	}
+	return mapRows("portfolio_version", rows, rowToPortfolioVersion)
+}
+
 func (d *DB) DeletePortfolio(tx db.Tx, id pacta.PortfolioID) ([]pacta.BlobURI, error) {
 	buris := []pacta.BlobURI{}
 	err := d.RunOrContinueTransaction(tx, func(db.Tx) error {
@@ -170,6 +285,18 @@ func (d *DB) DeletePortfolio(tx db.Tx, id pacta.PortfolioID) ([]pacta.BlobURI, e
 		if err != nil {
 			return fmt.Errorf("deleting portfolio_snapshots: %w", err)
 		}
+		err = d.exec(tx, `UPDATE incomplete_upload SET replaces_portfolio_id = NULL WHERE replaces_portfolio_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("unlinking incomplete_uploads: %w", err)
+		}
+		versions, err := d.PortfolioVersions(tx, id)
+		if err != nil {
+			return fmt.Errorf("reading portfolio_versions: %w", err)
+		}
+		err = d.exec(tx, `DELETE FROM portfolio_version WHERE portfolio_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("deleting portfolio_versions: %w", err)
+		}
 		err = d.exec(tx, `DELETE FROM portfolio WHERE id = $1;`, id)
 		if err != nil {
 			return fmt.Errorf("deleting portfolio: %w", err)
@@ -179,6 +306,13 @@ func (d *DB) DeletePortfolio(tx db.Tx, id pacta.PortfolioID) ([]pacta.BlobURI, e
 			return fmt.Errorf("deleting blob: %w", err)
 		}
 		buris = append(buris, buri)
+		for _, v := range versions {
+			buri, err := d.DeleteBlob(tx, v.Blob.ID)
+			if err != nil {
+				return fmt.Errorf("deleting blob of version %d: %w", v.Version, err)
+			}
+			buris = append(buris, buri)
+		}
 		return nil
 	})
 	if err != nil {
@@ -194,6 +328,7 @@ func rowToPortfolio(row rowScanner) (*pacta.Portfolio, error) {
 	initiativesIDs := []pgtype.Text{}
 	initiativesAddedByIDs := []pgtype.Text{}
 	initiativesCreatedAts := []pgtype.Timestamptz{}
+	var investorName, portfolioName, md5 pgtype.Text
 	err := row.Scan(
 		&p.ID,
 		&p.Owner.ID,
@@ -204,6 +339,9 @@ func rowToPortfolio(row rowScanner) (*pacta.Portfolio, error) {
 		&p.Blob.ID,
 		&p.AdminDebugEnabled,
 		&p.NumberOfRows,
+		&investorName,
+		&portfolioName,
+		&md5,
 		&groupsIDs,
 		&groupsCreatedAts,
 		&initiativesIDs,
@@ -213,6 +351,9 @@ func rowToPortfolio(row rowScanner) (*pacta.Portfolio, error) {
 	if err != nil {
 		return nil, fmt.Errorf("scanning into portfolio row: %w", err)
 	}
+	p.InvestorName = investorName.String
+	p.PortfolioName = portfolioName.String
+	p.MD5 = md5.String
 	if err := checkSizesEquivalent("groups", len(groupsIDs), len(groupsCreatedAts)); err != nil {
 		return nil, err
 	}
@@ -261,6 +402,29 @@ func rowToPortfolio(row rowScanner) (*pacta.Portfolio, error) {
 	return p, nil
 }
 
+func rowToPortfolioVersion(row rowScanner) (*pacta.PortfolioVersion, error) {
+	v := &pacta.PortfolioVersion{Portfolio: &pacta.Portfolio{}, Blob: &pacta.Blob{}}
+	var (
+		numberOfRows pgtype.Int4
+		md5          pgtype.Text
+	)
+	err := row.Scan(
+		&v.ID,
+		&v.Portfolio.ID,
+		&v.Version,
+		&v.Blob.ID,
+		&numberOfRows,
+		&md5,
+		&v.ReplacedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scanning into portfolio_version row: %w", err)
+	}
+	v.NumberOfRows = int(numberOfRows.Int32)
+	v.MD5 = md5.String
+	return v, nil
+}
+
 func rowsToPortfolios(rows pgx.Rows) ([]*pacta.Portfolio, error) {
 	return mapRows("portfolio", rows, rowToPortfolio)
 }
@@ -30,9 +30,12 @@ func TestPortfolioCRUD(t *testing.T) {
 			External:           ptr(true),
 			EngagementStrategy: ptr(false),
 		},
-		Owner:        &pacta.Owner{ID: o1.ID},
-		Blob:         &pacta.Blob{ID: b.ID},
-		NumberOfRows: 10,
+		Owner:         &pacta.Owner{ID: o1.ID},
+		Blob:          &pacta.Blob{ID: b.ID},
+		NumberOfRows:  10,
+		InvestorName:  "investor-name",
+		PortfolioName: "parsed-portfolio-name",
+		MD5:           "portfolio-md5",
 	}
 	id, err := tdb.CreatePortfolio(tx, p)
 	if err != nil {
@@ -135,6 +138,164 @@ func TestPortfolioCRUD(t *testing.T) {
 	}
 }
 
+func TestDuplicatePortfolios(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u1 := userForTestingWithKey(t, tdb, "1")
+	u2 := userForTestingWithKey(t, tdb, "2")
+	o1 := ownerUserForTesting(t, tdb, u1)
+	o2 := ownerUserForTesting(t, tdb, u2)
+
+	create := func(key string, o *pacta.Owner, contentMD5 string) pacta.PortfolioID {
+		t.Helper()
+		bID, err := tdb.CreateBlob(tx, &pacta.Blob{
+			FileType:   pacta.FileType_CSV,
+			BlobURI:    pacta.BlobURI("blob-uri-" + key),
+			FileName:   "file-name-" + key,
+			ContentMD5: contentMD5,
+		})
+		noErrDuringSetup(t, err)
+		pID, err := tdb.CreatePortfolio(tx, &pacta.Portfolio{
+			Name:  "portfolio-" + key,
+			Owner: &pacta.Owner{ID: o.ID},
+			Blob:  &pacta.Blob{ID: bID},
+		})
+		noErrDuringSetup(t, err)
+		return pID
+	}
+	a1 := create("a1", o1, "md5-a")
+	a2 := create("a2", o1, "md5-a")
+	b1 := create("b1", o1, "md5-b")
+	b2 := create("b2", o1, "md5-b")
+	b3 := create("b3", o1, "md5-b")
+	create("c1", o1, "md5-c")
+	create("n1", o1, "")
+	create("n2", o1, "")
+	create("a3", o2, "md5-a")
+
+	ids, err := tdb.PortfolioIDsByContentMD5(tx, o1.ID, "md5-a")
+	if err != nil {
+		t.Fatalf("reading portfolios by content md5: %v", err)
+	}
+	if diff := cmp.Diff([]pacta.PortfolioID{a1, a2}, ids); diff != "" {
+		t.Errorf("portfolio ids mismatch (-want +got):\n%s", diff)
+	}
+	ids, err = tdb.PortfolioIDsByContentMD5(tx, o2.ID, "md5-b")
+	if err != nil {
+		t.Fatalf("reading portfolios by content md5: %v", err)
+	}
+	if diff := cmp.Diff([]pacta.PortfolioID{}, ids, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("portfolio ids mismatch (-want +got):\n%s", diff)
+	}
+
+	groups, err := tdb.DuplicatePortfolioIDsByOwner(tx, o1.ID)
+	if err != nil {
+		t.Fatalf("reading duplicate portfolios: %v", err)
+	}
+	if diff := cmp.Diff([][]pacta.PortfolioID{{a1, a2}, {b1, b2, b3}}, groups); diff != "" {
+		t.Errorf("duplicate groups mismatch (-want +got):\n%s", diff)
+	}
+	groups, err = tdb.DuplicatePortfolioIDsByOwner(tx, o2.ID)
+	if err != nil {
+		t.Fatalf("reading duplicate portfolios: %v", err)
+	}
+	if diff := cmp.Diff([][]pacta.PortfolioID{}, groups, cmpopts.EquateEmpty()); diff != "" {
+		t.Errorf("duplicate groups mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestReplacePortfolioHoldings(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	p := portfolioForTesting(t, tdb)
+	b1 := p.Blob.ID
+	b2 := blobForTestingWithKey(t, tdb, "v2")
+	b3 := blobForTestingWithKey(t, tdb, "v3")
+
+	snapID, err := tdb.CreateSnapshotOfPortfolio(tx, p.ID)
+	noErrDuringSetup(t, err)
+
+	v1ID, err := tdb.ReplacePortfolioHoldings(tx, p.ID, &pacta.Portfolio{
+		Blob:          &pacta.Blob{ID: b2.ID},
+		NumberOfRows:  20,
+		InvestorName:  "investor-v2",
+		PortfolioName: "portfolio-v2",
+		MD5:           "md5-v2",
+	})
+	if err != nil {
+		t.Fatalf("replacing holdings: %v", err)
+	}
+	v2ID, err := tdb.ReplacePortfolioHoldings(tx, p.ID, &pacta.Portfolio{
+		Blob:         &pacta.Blob{ID: b3.ID},
+		NumberOfRows: 30,
+		MD5:          "md5-v3",
+	})
+	if err != nil {
+		t.Fatalf("replacing holdings: %v", err)
+	}
+
+	got, err := tdb.Portfolio(tx, p.ID)
+	if err != nil {
+		t.Fatalf("reading portfolio: %v", err)
+	}
+	want := p.Clone()
+	want.Blob = &pacta.Blob{ID: b3.ID}
+	want.NumberOfRows = 30
+	want.MD5 = "md5-v3"
+	if diff := cmp.Diff(want, got, portfolioCmpOpts()); diff != "" {
+		t.Errorf("portfolio mismatch (-want +got):\n%s", diff)
+	}
+
+	versions, err := tdb.PortfolioVersions(tx, p.ID)
+	if err != nil {
+		t.Fatalf("reading portfolio versions: %v", err)
+	}
+	wantVersions := []*pacta.PortfolioVersion{{
+		ID:           v2ID,
+		Portfolio:    &pacta.Portfolio{ID: p.ID},
+		Version:      2,
+		Blob:         &pacta.Blob{ID: b2.ID},
+		NumberOfRows: 20,
+		MD5:          "md5-v2",
+		ReplacedAt:   time.Now(),
+	}, {
+		ID:           v1ID,
+		Portfolio:    &pacta.Portfolio{ID: p.ID},
+		Version:      1,
+		Blob:         &pacta.Blob{ID: b1},
+		NumberOfRows: 10,
+		ReplacedAt:   time.Now(),
+	}}
+	if diff := cmp.Diff(wantVersions, versions, portfolioCmpOpts()); diff != "" {
+		t.Errorf("portfolio versions mismatch (-want +got):\n%s", diff)
+	}
+
+	// Snapshots keep the holdings they were taken with.
+	snap, err := tdb.PortfolioSnapshot(tx, snapID)
+	if err != nil {
+		t.Fatalf("reading snapshot: %v", err)
+	}
+	if diff := cmp.Diff([]pacta.BlobID{b1}, snap.BlobIDs); diff != "" {
+		t.Errorf("snapshot blob ids mismatch (-want +got):\n%s", diff)
+	}
+
+	_, err = tdb.ReplacePortfolioHoldings(tx, "nonexistent", &pacta.Portfolio{Blob: &pacta.Blob{ID: b3.ID}})
+	if !db.IsNotFound(err) {
+		t.Errorf("expected not found replacing holdings of a nonexistent portfolio, got %v", err)
+	}
+
+	buris, err := tdb.DeletePortfolio(tx, p.ID)
+	if err != nil {
+		t.Fatalf("deleting portfolio: %v", err)
+	}
+	wantBURIs := []pacta.BlobURI{b3.BlobURI, b2.BlobURI, pacta.BlobURI("blob-uri-only")}
+	if diff := cmp.Diff(wantBURIs, buris); diff != "" {
+		t.Errorf("blob uri mismatch (-want +got):\n%s", diff)
+	}
+}
+
 // TODO(grady) write a thorough portfolio deletion test
 
 func portfolioCmpOpts() cmp.Option {
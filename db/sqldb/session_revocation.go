@@ -0,0 +1,85 @@
+package sqldb
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+)
+
+// RevokeSession revokes a single session, identified by the jti claim of its
+// JWT. Revocations of sessions that have since expired are cleaned up along the
+// way, as expired tokens are rejected anyway.
+func (d *DB) RevokeSession(tx db.Tx, uid pacta.UserID, jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return fmt.Errorf("revoked_session missing required jti")
+	}
+	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
+		if err := d.exec(tx, `DELETE FROM revoked_session WHERE expires_at < NOW();`); err != nil {
+			return fmt.Errorf("deleting expired revoked_session rows: %w", err)
+		}
+		err := d.exec(tx, `
+			INSERT INTO revoked_session
+				(jti, user_id, expires_at)
+				VALUES
+				($1, $2, $3)
+			ON CONFLICT DO NOTHING;`, jti, uid, expiresAt)
+		if err != nil {
+			return fmt.Errorf("creating revoked_session row: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("revoking session: %w", err)
+	}
+	return nil
+}
+
+// RevokeUserSessionsBefore revokes all of the user's sessions that were issued
+// before the given time. It never un-revokes sessions, so an earlier time than
+// one that was already recorded has no effect.
+//
+// Issue times (the iat claim) only have second precision, so the time is
+// truncated to the second. Otherwise a session started just after revoking,
+// in the same second, would be issued "before" the revocation and rejected.
+func (d *DB) RevokeUserSessionsBefore(tx db.Tx, uid pacta.UserID, before time.Time) error {
+	before = before.Truncate(time.Second)
+	err := d.exec(tx, `
+		INSERT INTO user_session_revocation
+			(user_id, revoked_before)
+			VALUES
+			($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			revoked_before = GREATEST(user_session_revocation.revoked_before, EXCLUDED.revoked_before);`,
+		uid, before)
+	if err != nil {
+		return fmt.Errorf("upserting user_session_revocation row: %w", err)
+	}
+	return nil
+}
+
+// IsSessionRevoked returns true if the session with the given jti was revoked,
+// or if it was issued before the user's sessions were all revoked. Sessions
+// with an unknown jti or issue time should pass the zero value.
+//
+// We can't tell whether a session with an unknown issue time was started
+// before or after the user's sessions were revoked, so only revocations by jti
+// apply to it. Treating it as revoked instead would lock the user out for good,
+// as every new session would be rejected too.
+func (d *DB) IsSessionRevoked(tx db.Tx, uid pacta.UserID, jti string, issuedAt time.Time) (bool, error) {
+	var bulkRevokedBefore *time.Time
+	if !issuedAt.IsZero() {
+		bulkRevokedBefore = &issuedAt
+	}
+	var revoked bool
+	row := d.queryRow(tx, `
+		SELECT
+			EXISTS (SELECT 1 FROM revoked_session WHERE jti = $2 AND user_id = $1)
+			OR EXISTS (SELECT 1 FROM user_session_revocation WHERE user_id = $1 AND revoked_before > $3);`,
+		uid, jti, bulkRevokedBefore)
+	if err := row.Scan(&revoked); err != nil {
+		return false, fmt.Errorf("querying session revocations: %w", err)
+	}
+	return revoked, nil
+}
@@ -0,0 +1,100 @@
+package sqldb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/pacta"
+)
+
+func TestRevokeSession(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u1 := userForTestingWithKey(t, tdb, "1")
+	u2 := userForTestingWithKey(t, tdb, "2")
+	now := time.Now()
+
+	checkRevoked := func(desc string, want bool, uid pacta.UserID, jti string, issuedAt time.Time) {
+		t.Helper()
+		got, err := tdb.IsSessionRevoked(tx, uid, jti, issuedAt)
+		if err != nil {
+			t.Fatalf("%s: checking session revocation: %v", desc, err)
+		}
+		if got != want {
+			t.Errorf("%s: revoked = %t, want %t", desc, got, want)
+		}
+	}
+
+	checkRevoked("before revoking", false, u1.ID, "jti-1", now)
+
+	if err := tdb.RevokeSession(tx, u1.ID, "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatalf("revoking session: %v", err)
+	}
+	// Revoking twice is fine.
+	if err := tdb.RevokeSession(tx, u1.ID, "jti-1", now.Add(time.Hour)); err != nil {
+		t.Fatalf("revoking session again: %v", err)
+	}
+	checkRevoked("revoked session", true, u1.ID, "jti-1", now)
+	checkRevoked("other session", false, u1.ID, "jti-2", now)
+	checkRevoked("other user", false, u2.ID, "jti-1", now)
+
+	if err := tdb.RevokeSession(tx, u1.ID, "", now.Add(time.Hour)); err == nil {
+		t.Error("expected error revoking session without jti, got nil")
+	}
+}
+
+func TestRevokeUserSessionsBefore(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u := userForTesting(t, tdb)
+	now := time.Now()
+
+	checkRevoked := func(desc string, want bool, jti string, issuedAt time.Time) {
+		t.Helper()
+		got, err := tdb.IsSessionRevoked(tx, u.ID, jti, issuedAt)
+		if err != nil {
+			t.Fatalf("%s: checking session revocation: %v", desc, err)
+		}
+		if got != want {
+			t.Errorf("%s: revoked = %t, want %t", desc, got, want)
+		}
+	}
+
+	checkRevoked("without issue time before revoking", false, "", time.Time{})
+
+	if err := tdb.RevokeUserSessionsBefore(tx, u.ID, now); err != nil {
+		t.Fatalf("revoking sessions: %v", err)
+	}
+	checkRevoked("issued before", true, "jti-1", now.Add(-time.Minute))
+	checkRevoked("issued after", false, "jti-2", now.Add(time.Minute))
+	// Issue times are truncated to the second, like the iat claim.
+	checkRevoked("issued in the same second", false, "jti-2", now.Truncate(time.Second))
+	checkRevoked("without issue time", false, "", time.Time{})
+
+	if err := tdb.RevokeSession(tx, u.ID, "jti-4", now.Add(time.Hour)); err != nil {
+		t.Fatalf("revoking session: %v", err)
+	}
+	checkRevoked("revoked without issue time", true, "jti-4", time.Time{})
+
+	// An earlier time doesn't un-revoke anything.
+	if err := tdb.RevokeUserSessionsBefore(tx, u.ID, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("revoking sessions: %v", err)
+	}
+	checkRevoked("issued before, after earlier revocation", true, "jti-1", now.Add(-time.Minute))
+
+	if err := tdb.RevokeUserSessionsBefore(tx, u.ID, now.Add(time.Hour)); err != nil {
+		t.Fatalf("revoking sessions: %v", err)
+	}
+	checkRevoked("issued after, after later revocation", true, "jti-2", now.Add(time.Minute))
+
+	// Revocations don't stop the user from being deleted.
+	if err := tdb.RevokeSession(tx, u.ID, "jti-3", now.Add(time.Hour)); err != nil {
+		t.Fatalf("revoking session: %v", err)
+	}
+	if _, err := tdb.DeleteUser(tx, u.ID); err != nil {
+		t.Fatalf("deleting user: %v", err)
+	}
+}
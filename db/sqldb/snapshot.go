@@ -47,7 +47,7 @@ func (d *DB) PortfolioSnapshot(tx db.Tx, psID pacta.PortfolioSnapshotID) (*pacta
 }
 
 const snapshotQuery = `
-SELECT id, portfolio_id, portfolio_group_id, initiative_id, portfolio_ids
+SELECT id, portfolio_id, portfolio_group_id, initiative_id, portfolio_ids, blob_ids
 FROM portfolio_snapshot
 `
 
@@ -105,23 +105,63 @@ func (d *DB) createSnapshot(tx db.Tx, pID pacta.PortfolioID, pgID pacta.Portfoli
 	for _, id := range portfolioIDs {
 		s := string(id)
 		if !included[s] {
+			included[s] = true
 			canonical = append(canonical, s)
 		}
 	}
 	sort.Strings(canonical)
+	blobIDs, err := d.currentBlobIDs(tx, canonical)
+	if err != nil {
+		return "", fmt.Errorf("reading portfolio blobs: %w", err)
+	}
 	snapshotID := pacta.PortfolioSnapshotID(d.randomID("pfsn"))
-	err := d.exec(tx, `
+	err = d.exec(tx, `
 		INSERT INTO portfolio_snapshot
-			(id, portfolio_id, portfolio_group_id, initiative_id, portfolio_ids)
+			(id, portfolio_id, portfolio_group_id, initiative_id, portfolio_ids, blob_ids)
 			VALUES
-			($1, $2, $3, $4, $5);`,
-		snapshotID, strToNilable(pID), strToNilable(pgID), strToNilable(iID), canonical)
+			($1, $2, $3, $4, $5, $6);`,
+		snapshotID, strToNilable(pID), strToNilable(pgID), strToNilable(iID), canonical, blobIDs)
 	if err != nil {
 		return "", fmt.Errorf("creating portfolio_snapshot: %w", err)
 	}
 	return snapshotID, nil
 }
 
+// currentBlobIDs returns the blob currently holding each of the given
+// portfolios' holdings, in the same order.
+func (d *DB) currentBlobIDs(tx db.Tx, portfolioIDs []string) ([]string, error) {
+	blobIDs := []string{}
+	if len(portfolioIDs) == 0 {
+		return blobIDs, nil
+	}
+	rows, err := d.query(tx, `
+		SELECT id, blob_id FROM portfolio
+		WHERE id IN `+createWhereInFmt(len(portfolioIDs))+`;`, idsToInterface(portfolioIDs)...)
+	if err != nil {
+		return nil, fmt.Errorf("querying portfolio blob ids: %w", err)
+	}
+	byPortfolio := make(map[string]string)
+	err = forEachRow("portfolio_blob_id", rows, func(row rowScanner) error {
+		var pID, bID string
+		if err := row.Scan(&pID, &bID); err != nil {
+			return fmt.Errorf("scanning portfolio blob id: %w", err)
+		}
+		byPortfolio[pID] = bID
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range portfolioIDs {
+		bID, ok := byPortfolio[id]
+		if !ok {
+			return nil, db.NotFound(pacta.PortfolioID(id), "portfolio")
+		}
+		blobIDs = append(blobIDs, bID)
+	}
+	return blobIDs, nil
+}
+
 func rowsToPortfolioSnapshots(rows pgx.Rows) ([]*pacta.PortfolioSnapshot, error) {
 	return mapRows("portfolio_snapshot", rows, rowToPortfolioSnapshot)
 }
@@ -130,15 +170,17 @@ func rowToPortfolioSnapshot(row rowScanner) (*pacta.PortfolioSnapshot, error) {
 	var (
 		pID, pgID, iID pgtype.Text
 		portfolioIDs   []string
+		blobIDs        []string
 		id             pacta.PortfolioSnapshotID
 	)
-	err := row.Scan(&id, &pID, &pgID, &iID, &portfolioIDs)
+	err := row.Scan(&id, &pID, &pgID, &iID, &portfolioIDs, &blobIDs)
 	if err != nil {
 		return nil, fmt.Errorf("reading portfolio snapshot: %w", err)
 	}
 	ps := &pacta.PortfolioSnapshot{
 		ID:           id,
 		PortfolioIDs: stringsToIDs[pacta.PortfolioID](portfolioIDs),
+		BlobIDs:      stringsToIDs[pacta.BlobID](blobIDs),
 	}
 	if pID.Valid {
 		ps.Portfolio = &pacta.Portfolio{
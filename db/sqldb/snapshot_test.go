@@ -51,6 +51,17 @@ func TestSnapshotCreation(t *testing.T) {
 		if diff := cmp.Diff(ids, actual.PortfolioIDs, portfolioSnapshotCmpOpts()); diff != "" {
 			t.Fatalf("mismatch (-want +got):\n%s", diff)
 		}
+		blobIDs := make(map[pacta.PortfolioID]pacta.BlobID)
+		for _, p := range ps {
+			blobIDs[p.ID] = p.Blob.ID
+		}
+		wantBlobIDs := make([]pacta.BlobID, len(actual.PortfolioIDs))
+		for i, id := range actual.PortfolioIDs {
+			wantBlobIDs[i] = blobIDs[id]
+		}
+		if diff := cmp.Diff(wantBlobIDs, actual.BlobIDs, cmpopts.EquateEmpty()); diff != "" {
+			t.Fatalf("blob ids mismatch (-want +got):\n%s", diff)
+		}
 	}
 
 	pg1Snap, err := tdb.CreateSnapshotOfPortfolioGroup(tx, pg1.ID)
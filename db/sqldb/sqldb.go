@@ -359,6 +359,14 @@ func stringsToIDs[T ~string](strs []string) []T {
 	return ts
 }
 
+func idsToStrings[T ~string](ts []T) []string {
+	strs := make([]string, len(ts))
+	for i, t := range ts {
+		strs[i] = string(t)
+	}
+	return strs
+}
+
 func asMap[K ~string, V any](vs []V, idFn func(v V) K) map[K]V {
 	result := make(map[K]V, len(vs))
 	for _, v := range vs {
@@ -98,6 +98,29 @@ func TestSchemaHistory(t *testing.T) {
 		{ID: 14, Version: 14}, // 0014_add_more_report_file_types
 		{ID: 15, Version: 15}, // 0015_add_more_report_file_types
 		{ID: 16, Version: 16}, // 0016_add_dashboard_analysis_type
+		{ID: 17, Version: 17}, // 0017_task_table
+		{ID: 18, Version: 18}, // 0018_failure_codes
+		{ID: 19, Version: 19}, // 0019_retry_audit_action
+		{ID: 20, Version: 20}, // 0020_analysis_cancellation
+		{ID: 21, Version: 21}, // 0021_pacta_version_inherit
+		{ID: 22, Version: 22}, // 0022_analysis_image_tag
+		{ID: 23, Version: 23}, // 0023_pacta_version_datasets
+		{ID: 24, Version: 24}, // 0024_parse_metadata
+		{ID: 25, Version: 25}, // 0025_incomplete_upload_log_blob
+		{ID: 26, Version: 26}, // 0026_duplicate_portfolios
+		{ID: 27, Version: 27}, // 0027_unsupported_file_failure_code
+		{ID: 28, Version: 28}, // 0028_too_many_rows_failure_code
+		{ID: 29, Version: 29}, // 0029_portfolio_versions
+		{ID: 30, Version: 30}, // 0030_api_tokens
+		{ID: 31, Version: 31}, // 0031_user_authn
+		{ID: 32, Version: 32}, // 0032_session_revocation
+		{ID: 33, Version: 33}, // 0033_initiative_user_roles
+		{ID: 34, Version: 34}, // 0034_authn_mechanism_providers
+		{ID: 35, Version: 35}, // 0035_normalize_pacta_version_digests
+		{ID: 36, Version: 36}, // 0036_upload_limits
+		{ID: 37, Version: 37}, // 0037_analysis_artifact_admin_only
+		{ID: 38, Version: 38}, // 0038_pacta_version_dashboard_digest
+		{ID: 39, Version: 39}, // 0039_upload_limits_audit_log
 	}
 
 	if diff := cmp.Diff(want, got); diff != "" {
@@ -0,0 +1,300 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const taskSelectColumns = `
+	task.id,
+	task.task_type,
+	task.owner_id,
+	task.analysis_id,
+	task.request::TEXT,
+	task.runner_id,
+	task.image_tag,
+	task.state,
+	task.attempt_count,
+	task.created_at,
+	task.started_at,
+	task.completed_at
+`
+
+func (d *DB) Task(tx db.Tx, id pacta.TaskID) (*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE id = $1;`, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying task: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return exactlyOne("task", id, ts)
+}
+
+func (d *DB) TasksByOwner(tx db.Tx, ownerID pacta.OwnerID) ([]*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE owner_id = $1
+		ORDER BY created_at;`, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tasks: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return ts, nil
+}
+
+func (d *DB) TasksForAnalysis(tx db.Tx, analysisID pacta.AnalysisID) ([]*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE analysis_id = $1
+		ORDER BY created_at;`, analysisID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tasks: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return ts, nil
+}
+
+// TasksForIncompleteUpload returns the parse tasks whose most recent request
+// included the given incomplete upload.
+func (d *DB) TasksForIncompleteUpload(tx db.Tx, iuID pacta.IncompleteUploadID) ([]*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE task_type = 'parse_portfolio'
+			AND request->'IncompleteUploadIDs' ? $1
+		ORDER BY created_at;`, iuID)
+	if err != nil {
+		return nil, fmt.Errorf("querying tasks: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return ts, nil
+}
+
+// UnfinishedTasks returns all tasks that are still QUEUED or RUNNING, oldest
+// first.
+func (d *DB) UnfinishedTasks(tx db.Tx) ([]*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE state IN ('QUEUED', 'RUNNING')
+		ORDER BY created_at;`)
+	if err != nil {
+		return nil, fmt.Errorf("querying unfinished tasks: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return ts, nil
+}
+
+// LockTask locks and returns the given task, waiting for any other
+// transaction holding the lock to finish first, so the task's state is current.
+func (d *DB) LockTask(tx db.Tx, id pacta.TaskID) (*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE id = $1
+		FOR UPDATE;`, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying task: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return exactlyOne("task", id, ts)
+}
+
+// LockUnfinishedTask locks and returns the given task if it's still QUEUED or
+// RUNNING. If the task has finished, or another transaction already holds the
+// lock, a not found error is returned instead of waiting.
+func (d *DB) LockUnfinishedTask(tx db.Tx, id pacta.TaskID) (*pacta.Task, error) {
+	rows, err := d.query(tx, `
+		SELECT `+taskSelectColumns+`
+		FROM task
+		WHERE id = $1
+			AND state IN ('QUEUED', 'RUNNING')
+		FOR UPDATE SKIP LOCKED;`, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying task: %w", err)
+	}
+	ts, err := rowsToTasks(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to tasks: %w", err)
+	}
+	return exactlyOne("task", id, ts)
+}
+
+func (d *DB) CreateTask(tx db.Tx, t *pacta.Task) (pacta.TaskID, error) {
+	if err := validateTaskForCreation(t); err != nil {
+		return "", fmt.Errorf("validating task for creation: %w", err)
+	}
+	var analysisID *string
+	if t.Analysis != nil {
+		analysisID = strToNilable(t.Analysis.ID)
+	}
+	t.ID = pacta.TaskID(d.randomID("task"))
+	err := d.exec(tx, `
+		INSERT INTO task
+			(id, task_type, owner_id, analysis_id, request, state, attempt_count)
+			VALUES
+			($1, $2, $3, $4, $5, $6, $7);`,
+		t.ID, t.Type, t.Owner.ID, analysisID, strToNilable(t.Request), t.State, t.AttemptCount)
+	if err != nil {
+		return "", fmt.Errorf("creating task: %w", err)
+	}
+	return t.ID, nil
+}
+
+func (d *DB) UpdateTask(tx db.Tx, id pacta.TaskID, mutations ...db.UpdateTaskFn) error {
+	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
+		// Locking the task row serializes concurrent updates, so mutations that
+		// depend on the task's state see the latest one.
+		t, err := d.LockTask(tx, id)
+		if err != nil {
+			return fmt.Errorf("reading task: %w", err)
+		}
+		for i, m := range mutations {
+			err := m(t)
+			if err != nil {
+				return fmt.Errorf("running %d-th mutation: %w", i, err)
+			}
+		}
+		err = d.putTask(tx, t)
+		if err != nil {
+			return fmt.Errorf("putting task: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("updating task: %w", err)
+	}
+	return nil
+}
+
+func rowToTask(row rowScanner) (*pacta.Task, error) {
+	t := &pacta.Task{Owner: &pacta.Owner{}}
+	var (
+		taskType, state             string
+		analysisID                  pgtype.Text
+		request, runnerID, imageTag pgtype.Text
+		startedAt, completedAt      pgtype.Timestamptz
+	)
+	err := row.Scan(
+		&t.ID,
+		&taskType,
+		&t.Owner.ID,
+		&analysisID,
+		&request,
+		&runnerID,
+		&imageTag,
+		&state,
+		&t.AttemptCount,
+		&t.CreatedAt,
+		&startedAt,
+		&completedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("scanning into task: %w", err)
+	}
+	t.Type, err = pacta.ParseTaskType(taskType)
+	if err != nil {
+		return nil, fmt.Errorf("parsing task type: %w", err)
+	}
+	t.State, err = pacta.ParseTaskState(state)
+	if err != nil {
+		return nil, fmt.Errorf("parsing task state: %w", err)
+	}
+	if analysisID.Valid {
+		t.Analysis = &pacta.Analysis{ID: pacta.AnalysisID(analysisID.String)}
+	}
+	if request.Valid {
+		t.Request = request.String
+	}
+	if runnerID.Valid {
+		t.RunnerID = runnerID.String
+	}
+	if imageTag.Valid {
+		t.ImageTag = imageTag.String
+	}
+	if startedAt.Valid {
+		t.StartedAt = startedAt.Time
+	}
+	if completedAt.Valid {
+		t.CompletedAt = completedAt.Time
+	}
+	return t, nil
+}
+
+func rowsToTasks(rows pgx.Rows) ([]*pacta.Task, error) {
+	return mapRows("task", rows, rowToTask)
+}
+
+func (d *DB) putTask(tx db.Tx, t *pacta.Task) error {
+	err := d.exec(tx, `
+		UPDATE task SET
+			owner_id = $2,
+			request = $3,
+			runner_id = $4,
+			image_tag = $5,
+			state = $6,
+			attempt_count = $7,
+			started_at = $8,
+			completed_at = $9
+		WHERE id = $1;
+		`,
+		t.ID,
+		t.Owner.ID,
+		strToNilable(t.Request),
+		strToNilable(t.RunnerID),
+		strToNilable(t.ImageTag),
+		t.State,
+		t.AttemptCount,
+		timeToNilable(t.StartedAt),
+		timeToNilable(t.CompletedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("updating task writable fields: %w", err)
+	}
+	return nil
+}
+
+func validateTaskForCreation(t *pacta.Task) error {
+	if t.ID != "" {
+		return fmt.Errorf("task id must be absent")
+	}
+	if !t.CreatedAt.IsZero() {
+		return fmt.Errorf("task created_at must be zero")
+	}
+	if t.Owner == nil || t.Owner.ID == "" {
+		return fmt.Errorf("task owner must be present")
+	}
+	if t.Type == "" {
+		return fmt.Errorf("task type must be present")
+	}
+	if t.State == "" {
+		return fmt.Errorf("task state must be present")
+	}
+	return nil
+}
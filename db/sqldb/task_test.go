@@ -0,0 +1,204 @@
+package sqldb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func TestTaskCRUD(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u1 := userForTestingWithKey(t, tdb, "User1")
+	o1 := ownerUserForTesting(t, tdb, u1)
+	u2 := userForTestingWithKey(t, tdb, "User2")
+	o2 := ownerUserForTesting(t, tdb, u2)
+	a := analysisForTesting(t, tdb, o1)
+	cmpOpts := taskCmpOpts()
+
+	// Retries carry on the attempt count of the task they're retrying, and
+	// parse tasks record their request up front.
+	parseTask := &pacta.Task{
+		Type:         pacta.TaskType_ParsePortfolio,
+		Owner:        &pacta.Owner{ID: o1.ID},
+		Request:      `{"IncompleteUploadIDs": ["incompleteupload.0"]}`,
+		State:        pacta.TaskState_Queued,
+		AttemptCount: 1,
+	}
+	id, err := tdb.CreateTask(tx, parseTask)
+	if err != nil {
+		t.Fatalf("creating task: %v", err)
+	}
+	parseTask.ID = id
+	parseTask.CreatedAt = time.Now()
+
+	auditTask := &pacta.Task{
+		Type:     pacta.TaskType_CreateAudit,
+		Owner:    &pacta.Owner{ID: o1.ID},
+		Analysis: &pacta.Analysis{ID: a.ID},
+		State:    pacta.TaskState_Queued,
+	}
+	id, err = tdb.CreateTask(tx, auditTask)
+	if err != nil {
+		t.Fatalf("creating task: %v", err)
+	}
+	auditTask.ID = id
+	auditTask.CreatedAt = time.Now()
+
+	actual, err := tdb.Task(tx, parseTask.ID)
+	if err != nil {
+		t.Fatalf("reading task: %v", err)
+	}
+	if diff := cmp.Diff(parseTask, actual, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	// A queued task can already be found by the uploads it's for.
+	ts, err := tdb.TasksForIncompleteUpload(tx, "incompleteupload.0")
+	if err != nil {
+		t.Fatalf("reading tasks for incomplete upload: %v", err)
+	}
+	if diff := cmp.Diff([]*pacta.Task{parseTask}, ts, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	startedAt := time.UnixMilli(111111111)
+	completedAt := time.UnixMilli(222222222)
+	err = tdb.UpdateTask(tx, auditTask.ID,
+		db.SetTaskRequest(`{"AnalysisID":"analysis.123"}`),
+		db.SetTaskRunnerID("runner-id"),
+		db.SetTaskImageTag("example.com/runner:latest"),
+		db.SetTaskState(pacta.TaskState_Succeeded),
+		db.IncrementTaskAttemptCount(),
+		db.SetTaskStartedAt(startedAt),
+		db.SetTaskCompletedAt(completedAt),
+	)
+	if err != nil {
+		t.Fatalf("updating task: %v", err)
+	}
+	auditTask.Request = `{"AnalysisID": "analysis.123"}` // JSONB normalizes whitespace
+	auditTask.RunnerID = "runner-id"
+	auditTask.ImageTag = "example.com/runner:latest"
+	auditTask.State = pacta.TaskState_Succeeded
+	auditTask.AttemptCount = 1
+	auditTask.StartedAt = startedAt
+	auditTask.CompletedAt = completedAt
+
+	// A task that has already finished isn't moved back to RUNNING.
+	if err := tdb.UpdateTask(tx, auditTask.ID, db.SetTaskStartedIfQueued(time.UnixMilli(333333333))); err != nil {
+		t.Fatalf("updating task: %v", err)
+	}
+
+	ts, err = tdb.UnfinishedTasks(tx)
+	if err != nil {
+		t.Fatalf("reading unfinished tasks: %v", err)
+	}
+	if diff := cmp.Diff([]*pacta.Task{parseTask}, ts, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	lt, err := tdb.LockUnfinishedTask(tx, parseTask.ID)
+	if err != nil {
+		t.Fatalf("locking unfinished task: %v", err)
+	}
+	if diff := cmp.Diff(parseTask, lt, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+	if _, err := tdb.LockUnfinishedTask(tx, auditTask.ID); !db.IsNotFound(err) {
+		t.Fatalf("locking finished task: got err %v, want not found", err)
+	}
+	lt, err = tdb.LockTask(tx, auditTask.ID)
+	if err != nil {
+		t.Fatalf("locking task: %v", err)
+	}
+	if diff := cmp.Diff(auditTask, lt, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	ts, err = tdb.TasksForAnalysis(tx, a.ID)
+	if err != nil {
+		t.Fatalf("reading tasks for analysis: %v", err)
+	}
+	if diff := cmp.Diff([]*pacta.Task{auditTask}, ts, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	err = tdb.UpdateTask(tx, parseTask.ID, db.SetTaskRequest(`{"IncompleteUploadIDs":["incompleteupload.1","incompleteupload.2"]}`))
+	if err != nil {
+		t.Fatalf("updating task request: %v", err)
+	}
+	parseTask.Request = `{"IncompleteUploadIDs": ["incompleteupload.1", "incompleteupload.2"]}` // JSONB normalizes whitespace
+
+	ts, err = tdb.TasksForIncompleteUpload(tx, "incompleteupload.2")
+	if err != nil {
+		t.Fatalf("reading tasks for incomplete upload: %v", err)
+	}
+	if diff := cmp.Diff([]*pacta.Task{parseTask}, ts, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+	ts, err = tdb.TasksForIncompleteUpload(tx, "incompleteupload.3")
+	if err != nil {
+		t.Fatalf("reading tasks for incomplete upload: %v", err)
+	}
+	if len(ts) != 0 {
+		t.Fatalf("expected no tasks for unrelated incomplete upload, got %d", len(ts))
+	}
+
+	err = tdb.UpdateTask(tx, parseTask.ID, db.SetTaskOwner(o2.ID))
+	if err != nil {
+		t.Fatalf("updating task owner: %v", err)
+	}
+	parseTask.Owner = &pacta.Owner{ID: o2.ID}
+
+	ts, err = tdb.TasksByOwner(tx, o2.ID)
+	if err != nil {
+		t.Fatalf("reading tasks by owner: %v", err)
+	}
+	if diff := cmp.Diff([]*pacta.Task{parseTask}, ts, cmpOpts); diff != "" {
+		t.Fatalf("mismatch (-want +got):\n%s", diff)
+	}
+
+	if _, err := tdb.DeleteAnalysis(tx, a.ID); err != nil {
+		t.Fatalf("deleting analysis: %v", err)
+	}
+	_, err = tdb.Task(tx, auditTask.ID)
+	if !db.IsNotFound(err) {
+		t.Fatalf("expected task to be deleted with analysis, got %v", err)
+	}
+}
+
+func taskCmpOpts() cmp.Option {
+	return cmp.Options{
+		cmpopts.EquateEmpty(),
+		cmpopts.EquateApproxTime(time.Second),
+	}
+}
+
+func analysisForTesting(t *testing.T, tdb *DB, o *pacta.Owner) *pacta.Analysis {
+	t.Helper()
+	ctx := context.Background()
+	tx := tdb.NoTxn(ctx)
+	pv := pactaVersionForTesting(t, tdb)
+	pg := portfolioGroupForTesting(t, tdb, o)
+	s := snapshotPortfolioGroupForTesting(t, tdb, pg)
+	a := &pacta.Analysis{
+		AnalysisType:      pacta.AnalysisType_Audit,
+		Owner:             &pacta.Owner{ID: o.ID},
+		PACTAVersion:      &pacta.PACTAVersion{ID: pv.ID},
+		PortfolioSnapshot: &pacta.PortfolioSnapshot{ID: s.ID},
+		Name:              "analysis-name",
+		Description:       "analysis-description",
+	}
+	id, err := tdb.CreateAnalysis(tx, a)
+	if err != nil {
+		t.Fatalf("creating analysis: %v", err)
+	}
+	a.ID = id
+	return a
+}
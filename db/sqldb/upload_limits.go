@@ -0,0 +1,52 @@
+package sqldb
+
+import (
+	"fmt"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+)
+
+// UploadLimits returns the limits on uploaded portfolio files. There's always
+// exactly one set of them, which is created by the migration that added them.
+func (d *DB) UploadLimits(tx db.Tx) (*pacta.UploadLimits, error) {
+	ul := &pacta.UploadLimits{}
+	row := d.queryRow(tx, `
+		SELECT max_bytes, max_rows, updated_at
+		FROM upload_limits;`)
+	if err := row.Scan(&ul.MaxBytes, &ul.MaxRows, &ul.UpdatedAt); err != nil {
+		return nil, fmt.Errorf("querying upload_limits: %w", err)
+	}
+	return ul, nil
+}
+
+func (d *DB) UpdateUploadLimits(tx db.Tx, mutations ...db.UpdateUploadLimitsFn) error {
+	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
+		ul, err := d.UploadLimits(tx)
+		if err != nil {
+			return fmt.Errorf("reading upload_limits: %w", err)
+		}
+		for i, m := range mutations {
+			err := m(ul)
+			if err != nil {
+				return fmt.Errorf("running %d-th mutation: %w", i, err)
+			}
+		}
+		if ul.MaxBytes < 0 || ul.MaxRows < 0 {
+			return fmt.Errorf("upload limits can't be negative")
+		}
+		err = d.exec(tx, `
+			UPDATE upload_limits SET
+				max_bytes = $1,
+				max_rows = $2,
+				updated_at = NOW();`, ul.MaxBytes, ul.MaxRows)
+		if err != nil {
+			return fmt.Errorf("updating upload_limits writable fields: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("updating upload_limits: %w", err)
+	}
+	return nil
+}
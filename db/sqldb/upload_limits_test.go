@@ -0,0 +1,40 @@
+package sqldb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/RMI/pacta/db"
+)
+
+func TestUploadLimits(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+
+	ul, err := tdb.UploadLimits(tx)
+	if err != nil {
+		t.Fatalf("reading upload limits: %v", err)
+	}
+	if ul.MaxBytes != 100*1000*1000 || ul.MaxRows != 1000*1000 {
+		t.Errorf("default upload limits = %d bytes, %d rows, want 100000000 bytes, 1000000 rows", ul.MaxBytes, ul.MaxRows)
+	}
+
+	if err := tdb.UpdateUploadLimits(tx, db.SetUploadLimitsMaxBytes(1024)); err != nil {
+		t.Fatalf("updating upload limits: %v", err)
+	}
+	got, err := tdb.UploadLimits(tx)
+	if err != nil {
+		t.Fatalf("reading upload limits: %v", err)
+	}
+	if got.MaxBytes != 1024 || got.MaxRows != ul.MaxRows {
+		t.Errorf("updated upload limits = %d bytes, %d rows, want 1024 bytes, %d rows", got.MaxBytes, got.MaxRows, ul.MaxRows)
+	}
+	if !got.UpdatedAt.After(ul.UpdatedAt) {
+		t.Errorf("updated_at = %v, want after %v", got.UpdatedAt, ul.UpdatedAt)
+	}
+
+	if err := tdb.UpdateUploadLimits(tx, db.SetUploadLimitsMaxRows(-1)); err == nil {
+		t.Error("expected error setting a negative row limit, got nil")
+	}
+}
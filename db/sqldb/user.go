@@ -38,11 +38,14 @@ func (d *DB) User(tx db.Tx, id pacta.UserID) (*pacta.User, error) {
 	return exactlyOne("user", id, us)
 }
 
+// UserByAuthn returns the user that the given identity is linked to, which
+// isn't necessarily the identity the user was created with.
 func (d *DB) UserByAuthn(tx db.Tx, authnMechanism pacta.AuthnMechanism, authnID string) (*pacta.User, error) {
 	rows, err := d.query(tx, `
 		SELECT `+userSelectColumns+`
-		FROM pacta_user 
-		WHERE authn_mechanism = $1 AND authn_id = $2;`, authnMechanism, authnID)
+		FROM pacta_user
+		JOIN user_authn ON user_authn.user_id = pacta_user.id
+		WHERE user_authn.authn_mechanism = $1 AND user_authn.authn_id = $2;`, authnMechanism, authnID)
 	if err != nil {
 		return nil, fmt.Errorf("querying user: %w", err)
 	}
@@ -53,6 +56,11 @@ func (d *DB) UserByAuthn(tx db.Tx, authnMechanism pacta.AuthnMechanism, authnID
 	return exactlyOne("user", fmt.Sprintf("%s:%s", authnMechanism, authnID), us)
 }
 
+// GetOrCreateUserByAuthn returns the user linked to the given identity. If
+// there isn't one, but there is a user with the same canonical email, the
+// identity is linked to that user, otherwise a new user is created. Callers
+// must only pass emails that the identity provider has verified, and that it's
+// trusted to assert, see session.Issuer.EmailDomains.
 func (d *DB) GetOrCreateUserByAuthn(tx db.Tx, authnMechanism pacta.AuthnMechanism, authnID, enteredEmail, canonicalEmail string) (*pacta.User, error) {
 	var user *pacta.User
 	err := d.RunOrContinueTransaction(tx, func(tx db.Tx) error {
@@ -64,6 +72,17 @@ func (d *DB) GetOrCreateUserByAuthn(tx db.Tx, authnMechanism pacta.AuthnMechanis
 		if !db.IsNotFound(err) {
 			return fmt.Errorf("looking up user by authn: %w", err)
 		}
+		u, err = d.userByCanonicalEmail(tx, canonicalEmail)
+		if err == nil {
+			if err := d.createUserAuthn(tx, u.ID, authnMechanism, authnID); err != nil {
+				return fmt.Errorf("linking authn to existing user: %w", err)
+			}
+			user = u
+			return nil
+		}
+		if !db.IsNotFound(err) {
+			return fmt.Errorf("looking up user by canonical email: %w", err)
+		}
 		uID, err := d.createUser(tx, &pacta.User{
 			CanonicalEmail: canonicalEmail,
 			EnteredEmail:   enteredEmail,
@@ -86,6 +105,69 @@ func (d *DB) GetOrCreateUserByAuthn(tx db.Tx, authnMechanism pacta.AuthnMechanis
 	return user, nil
 }
 
+func (d *DB) userByCanonicalEmail(tx db.Tx, canonicalEmail string) (*pacta.User, error) {
+	rows, err := d.query(tx, `
+		SELECT `+userSelectColumns+`
+		FROM pacta_user
+		WHERE canonical_email = $1;`, canonicalEmail)
+	if err != nil {
+		return nil, fmt.Errorf("querying user: %w", err)
+	}
+	us, err := rowsToUsers(rows)
+	if err != nil {
+		return nil, fmt.Errorf("translating rows to users: %w", err)
+	}
+	return exactlyOne("user", canonicalEmail, us)
+}
+
+// UserAuthns returns the identities linked to the given user, oldest first.
+func (d *DB) UserAuthns(tx db.Tx, id pacta.UserID) ([]*pacta.UserAuthn, error) {
+	rows, err := d.query(tx, `
+		SELECT user_id, authn_mechanism, authn_id, created_at
+		FROM user_authn
+		WHERE user_id = $1
+		ORDER BY created_at, authn_mechanism, authn_id;`, id)
+	if err != nil {
+		return nil, fmt.Errorf("querying user_authn: %w", err)
+	}
+	return mapRows("user_authn", rows, rowToUserAuthn)
+}
+
+// TransferUserAuthns moves all of the identities linked to one user to
+// another, so that they can be used to log in as the latter.
+func (d *DB) TransferUserAuthns(tx db.Tx, fromUserID, toUserID pacta.UserID) error {
+	err := d.exec(tx, `UPDATE user_authn SET user_id = $2 WHERE user_id = $1;`, fromUserID, toUserID)
+	if err != nil {
+		return fmt.Errorf("transferring user_authn rows: %w", err)
+	}
+	return nil
+}
+
+func (d *DB) createUserAuthn(tx db.Tx, id pacta.UserID, authnMechanism pacta.AuthnMechanism, authnID string) error {
+	err := d.exec(tx, `
+		INSERT INTO user_authn
+			(user_id, authn_mechanism, authn_id)
+			VALUES
+			($1, $2, $3);`, id, authnMechanism, authnID)
+	if err != nil {
+		return fmt.Errorf("creating user_authn row: %w", err)
+	}
+	return nil
+}
+
+func rowToUserAuthn(row rowScanner) (*pacta.UserAuthn, error) {
+	ua := &pacta.UserAuthn{User: &pacta.User{}}
+	var mech string
+	err := row.Scan(&ua.User.ID, &mech, &ua.AuthnID, &ua.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("scanning into user_authn: %w", err)
+	}
+	if ua.AuthnMechanism, err = pacta.ParseAuthnMechanism(mech); err != nil {
+		return nil, fmt.Errorf("parsing user_authn authn_mechanism: %w", err)
+	}
+	return ua, nil
+}
+
 func (d *DB) Users(tx db.Tx, ids []pacta.UserID) (map[pacta.UserID]*pacta.User, error) {
 	ids = dedupeIDs(ids)
 	rows, err := d.query(tx, `
@@ -203,6 +285,9 @@ func (d *DB) createUser(tx db.Tx, u *pacta.User) (pacta.UserID, error) {
 		if err != nil {
 			return fmt.Errorf("creating pacta_user row for %q: %w", id, err)
 		}
+		if err := d.createUserAuthn(tx, id, u.AuthnMechanism, u.AuthnID); err != nil {
+			return fmt.Errorf("creating user_authn for %q: %w", id, err)
+		}
 		_, err = d.createOwner(tx, &pacta.Owner{User: &pacta.User{ID: id}})
 		if err != nil {
 			return fmt.Errorf("creating owner: %w", err)
@@ -262,6 +347,22 @@ func (d *DB) DeleteUser(tx db.Tx, id pacta.UserID) ([]pacta.BlobURI, error) {
 		if err != nil {
 			return fmt.Errorf("clearing portfolio_initiative_membership.added_by_user_id: %w", err)
 		}
+		err = d.exec(tx, `DELETE FROM revoked_session WHERE user_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("deleting revoked_session rows: %w", err)
+		}
+		err = d.exec(tx, `DELETE FROM user_session_revocation WHERE user_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("deleting user_session_revocation rows: %w", err)
+		}
+		err = d.exec(tx, `DELETE FROM user_authn WHERE user_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("deleting user_authn rows: %w", err)
+		}
+		err = d.exec(tx, `DELETE FROM api_token WHERE user_id = $1;`, id)
+		if err != nil {
+			return fmt.Errorf("deleting api_token rows: %w", err)
+		}
 		err = d.exec(tx, `DELETE FROM pacta_user WHERE id = $1;`, id)
 		if err != nil {
 			return fmt.Errorf("deleting actual user: %w", err)
@@ -411,6 +411,71 @@ func TestDeleteUser(t *testing.T) {
 	}
 }
 
+func TestGetOrCreateUserByAuthnLinksByEmail(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u := userForTesting(t, tdb)
+
+	// A new identity with the same canonical email is linked to the existing user.
+	actual, err := tdb.GetOrCreateUserByAuthn(tx, pacta.AuthnMechanism_OIDC, "https://issuer.example.com#sub", "other-entered-email", u.CanonicalEmail)
+	if err != nil {
+		t.Fatalf("getting or creating user by authn: %v", err)
+	}
+	if diff := cmp.Diff(u, actual, userCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+	actual, err = tdb.UserByAuthn(tx, pacta.AuthnMechanism_OIDC, "https://issuer.example.com#sub")
+	if err != nil {
+		t.Fatalf("getting user by authn: %v", err)
+	}
+	if diff := cmp.Diff(u, actual, userCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+
+	authns, err := tdb.UserAuthns(tx, u.ID)
+	if err != nil {
+		t.Fatalf("getting user authns: %v", err)
+	}
+	eAuthns := []*pacta.UserAuthn{{
+		User:           &pacta.User{ID: u.ID},
+		AuthnMechanism: u.AuthnMechanism,
+		AuthnID:        u.AuthnID,
+		CreatedAt:      time.Now(),
+	}, {
+		User:           &pacta.User{ID: u.ID},
+		AuthnMechanism: pacta.AuthnMechanism_OIDC,
+		AuthnID:        "https://issuer.example.com#sub",
+		CreatedAt:      time.Now(),
+	}}
+	if diff := cmp.Diff(eAuthns, authns, userCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+}
+
+func TestTransferUserAuthns(t *testing.T) {
+	ctx := context.Background()
+	tdb := createDBForTesting(t)
+	tx := tdb.NoTxn(ctx)
+	u1 := userForTestingWithKey(t, tdb, "1")
+	u2 := userForTestingWithKey(t, tdb, "2")
+
+	if err := tdb.TransferUserAuthns(tx, u1.ID, u2.ID); err != nil {
+		t.Fatalf("transferring user authns: %v", err)
+	}
+	if _, err := tdb.DeleteUser(tx, u1.ID); err != nil {
+		t.Fatalf("deleting user: %v", err)
+	}
+
+	actual, err := tdb.UserByAuthn(tx, u1.AuthnMechanism, u1.AuthnID)
+	if err != nil {
+		t.Fatalf("getting user by authn: %v", err)
+	}
+	if diff := cmp.Diff(u2, actual, userCmpOpts()); diff != "" {
+		t.Fatalf("unexpected diff (-want +got)\n%s", diff)
+	}
+}
+
 func TestAuthnMechanismPersistability(t *testing.T) {
 	testUserEnumConvertability(
 		t,
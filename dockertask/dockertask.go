@@ -5,6 +5,7 @@
 package dockertask
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"path/filepath"
@@ -13,17 +14,24 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 	"go.uber.org/zap"
 
 	specs "github.com/opencontainers/image-spec/specs-go/v1"
 )
 
+// localBlobMount is where the local blob directory is mounted in containers.
+const localBlobMount = "/mnt/blobs"
+
 type Runner struct {
 	client *client.Client
 	logger *zap.Logger
 	sp     *ServicePrincipal
 
 	repoRoot string
+	// localBlobDir is the directory the server stores blobs in when it uses
+	// the local filesystem, or empty if it uses Azure.
+	localBlobDir string
 }
 
 type ServicePrincipal struct {
@@ -32,13 +40,22 @@ type ServicePrincipal struct {
 	ClientSecret string
 }
 
-func NewRunner(logger *zap.Logger, sp *ServicePrincipal, repoRoot string) (*Runner, error) {
+// NewRunner returns a Runner for the local Docker daemon. If localBlobDir is
+// set, it's mounted into each task's container, and tasks are told to use it
+// for blob storage too.
+func NewRunner(logger *zap.Logger, sp *ServicePrincipal, repoRoot, localBlobDir string) (*Runner, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv)
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize Docker client: %w", err)
 	}
+	if localBlobDir != "" {
+		// Docker only bind-mounts absolute paths.
+		if localBlobDir, err = filepath.Abs(localBlobDir); err != nil {
+			return nil, fmt.Errorf("failed to resolve local blob directory: %w", err)
+		}
+	}
 
-	return &Runner{client: cli, logger: logger, sp: sp, repoRoot: repoRoot}, nil
+	return &Runner{client: cli, logger: logger, sp: sp, repoRoot: repoRoot, localBlobDir: localBlobDir}, nil
 }
 
 func (r *Runner) Run(ctx context.Context, taskCfg *task.Config) (task.RunnerID, error) {
@@ -52,11 +69,19 @@ func (r *Runner) Run(ctx context.Context, taskCfg *task.Config) (task.RunnerID,
 	for _, e := range taskCfg.Env {
 		env = append(env, e.Key+"="+e.Value)
 	}
+	binds := []string{
+		filepath.Join(r.repoRoot, "workflow-data") + ":/mnt/workflow-data:ro",
+	}
+	flags := append([]string{}, taskCfg.Flags...)
+	if r.localBlobDir != "" {
+		binds = append(binds, r.localBlobDir+":"+localBlobMount)
+		flags = append(flags, "--use_local_blob=true", "--local_blob_dir="+localBlobMount)
+	}
 	cfg := &container.Config{
 		Image: taskCfg.Image.String(),
 
 		// Run the script, tell it to output data to our mounted location.
-		Cmd:        taskCfg.Flags,
+		Cmd:        flags,
 		Entrypoint: taskCfg.Command,
 
 		Env: env,
@@ -72,9 +97,7 @@ func (r *Runner) Run(ctx context.Context, taskCfg *task.Config) (task.RunnerID,
 
 	hostCfg := &container.HostConfig{
 		// AutoRemove: true,
-		Binds: []string{
-			filepath.Join(r.repoRoot, "workflow-data") + ":/mnt/workflow-data:ro",
-		},
+		Binds: binds,
 	}
 
 	resp, err := r.client.ContainerCreate(ctx, cfg, hostCfg, nil /* net config */, platform, "" /* random name */)
@@ -102,3 +125,55 @@ func (r *Runner) Run(ctx context.Context, taskCfg *task.Config) (task.RunnerID,
 
 	return task.RunnerID(resp.ID), nil
 }
+
+func (r *Runner) Status(ctx context.Context, runnerID task.RunnerID) (task.Status, error) {
+	info, err := r.client.ContainerInspect(ctx, string(runnerID))
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect PACTA container: %w", err)
+	}
+	if info.State == nil {
+		return task.StatusUnknown, nil
+	}
+	switch info.State.Status {
+	case "created":
+		return task.StatusPending, nil
+	case "running", "restarting", "paused":
+		return task.StatusRunning, nil
+	case "exited":
+		if info.State.ExitCode == 0 {
+			return task.StatusSucceeded, nil
+		}
+		return task.StatusFailed, nil
+	case "dead":
+		return task.StatusFailed, nil
+	default:
+		return task.StatusUnknown, nil
+	}
+}
+
+func (r *Runner) Logs(ctx context.Context, runnerID task.RunnerID) ([]byte, error) {
+	rc, err := r.client.ContainerLogs(ctx, string(runnerID), container.LogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Timestamps: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get PACTA container logs: %w", err)
+	}
+	defer rc.Close()
+
+	// Without a TTY, Docker multiplexes stdout and stderr into one stream, we
+	// interleave them back together.
+	var buf bytes.Buffer
+	if _, err := stdcopy.StdCopy(&buf, &buf, rc); err != nil {
+		return nil, fmt.Errorf("failed to read PACTA container logs: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Runner) Cancel(ctx context.Context, runnerID task.RunnerID) error {
+	if err := r.client.ContainerKill(ctx, string(runnerID), "SIGKILL"); err != nil {
+		return fmt.Errorf("failed to kill PACTA container: %w", err)
+	}
+	return nil
+}
@@ -1,4 +1,4 @@
-import { type InitiativeUserRelationship, type InitiativeInvitation } from '@/openapi/generated/pacta'
+import { type InitiativeUserRelationship, type InitiativeInvitation, InitiativeUserRole } from '@/openapi/generated/pacta'
 
 export const useInitiativeData = async (id: string) => {
   const prefix = `useInitiativeData[${id}]`
@@ -13,7 +13,7 @@ export const useInitiativeData = async (id: string) => {
     meRelationships.value = await pactaClient.listInitiativeUserRelationshipsByUser(maybeMe.value.id)
   }
 
-  const isManagerByMe = computed(() => meRelationships.value.some((r) => r.initiativeId === id && r.manager))
+  const isManagerByMe = computed(() => meRelationships.value.some((r) => r.initiativeId === id && r.role === InitiativeUserRole.INITIATIVE_USER_ROLE_MANAGER))
 
   const canManageByMe = computed(() => isAdmin.value || isSuperAdmin.value || isManagerByMe.value)
 
@@ -32,11 +32,12 @@ export const useInitiativeData = async (id: string) => {
     useSimpleAsyncData(`${prefix}.getInvitations`, maybeLookUpInvitationsByInitiative),
   ])
 
-  const isMember = computed(() => initiative.value.initiativeUserRelationships.some((r) => r.member))
-  const isManager = computed(() => initiative.value.initiativeUserRelationships.some((r) => r.manager))
+  const myRole = computed(() => initiative.value.initiativeUserRelationships.find((r) => r.userId === maybeMe.value?.id)?.role)
+  const isMember = computed(() => myRole.value !== undefined)
+  const isManager = computed(() => myRole.value === InitiativeUserRole.INITIATIVE_USER_ROLE_MANAGER)
   const canManage = computed(() => canManageByMe.value || isManager.value)
 
-  const canJoinIfLoggedIn = computed(() => !isMember.value && !isManager.value && initiative.value.isAcceptingNewMembers && !initiative.value.requiresInvitationToJoin)
+  const canJoinIfLoggedIn = computed(() => !isMember.value && initiative.value.isAcceptingNewMembers && !initiative.value.requiresInvitationToJoin)
   const canDirectlyJoin = computed(() => canJoinIfLoggedIn.value && maybeMe.value)
 
   return {
@@ -47,6 +48,7 @@ export const useInitiativeData = async (id: string) => {
     canManage,
     isMember,
     isManager,
+    myRole,
     canDirectlyJoin,
     canJoinIfLoggedIn,
   }
@@ -30,6 +30,30 @@ const createEditorPactaVersionFields = (translation: Translation): EditorPactaVe
       validation: [Validation.NotEmpty],
       helpText: tt('The SHA hash of the docker image that should correspond to this version of the PACTA version.'),
     },
+    dashboardDigest: {
+      name: 'dashboardDigest',
+      label: tt('Dashboard Docker Image Digest'),
+      validation: [Validation.NotEmpty],
+      helpText: tt('The SHA hash of the dashboard docker image that dashboards run with this version should use.'),
+    },
+    inherit: {
+      name: 'inherit',
+      label: tt('Inherited Configuration'),
+      validation: [Validation.NotEmpty],
+      helpText: tt('The workflow.pacta configuration that analyses run with this version should use, like GENERAL_2023Q4.'),
+    },
+    benchmarkDataset: {
+      name: 'benchmarkDataset',
+      label: tt('Benchmark Dataset'),
+      validation: [Validation.NotEmpty],
+      helpText: tt('The benchmark dataset that analyses run with this version should use, like 65c1a416721b22a98c7925999ae03bc4.'),
+    },
+    pactaDataset: {
+      name: 'pactaDataset',
+      label: tt('PACTA Dataset'),
+      validation: [Validation.NotEmpty],
+      helpText: tt('The PACTA dataset that analyses run with this version should use, like 2023Q4_20240718T150252Z.'),
+    },
     isDefault: {
       name: 'isDefault',
       label: tt('Is Default Version'),
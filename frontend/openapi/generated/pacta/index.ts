@@ -19,6 +19,8 @@ export type { AnalysisArtifact } from './models/AnalysisArtifact';
 export type { AnalysisArtifactChanges } from './models/AnalysisArtifactChanges';
 export type { AnalysisChanges } from './models/AnalysisChanges';
 export { AnalysisType } from './models/AnalysisType';
+export type { APIToken } from './models/APIToken';
+export { APITokenScope } from './models/APITokenScope';
 export type { AuditLog } from './models/AuditLog';
 export { AuditLogAction } from './models/AuditLogAction';
 export { AuditLogActorType } from './models/AuditLogActorType';
@@ -32,6 +34,9 @@ export type { Blob } from './models/Blob';
 export type { CompletePortfolioUploadReq } from './models/CompletePortfolioUploadReq';
 export type { CompletePortfolioUploadReqItem } from './models/CompletePortfolioUploadReqItem';
 export type { CompletePortfolioUploadResp } from './models/CompletePortfolioUploadResp';
+export type { CreateAPITokenReq } from './models/CreateAPITokenReq';
+export type { CreateAPITokenResp } from './models/CreateAPITokenResp';
+export { DuplicatePolicy } from './models/DuplicatePolicy';
 export type { Error } from './models/Error';
 export { FailureCode } from './models/FailureCode';
 export { FileType } from './models/FileType';
@@ -48,15 +53,20 @@ export type { InitiativeInvitation } from './models/InitiativeInvitation';
 export type { InitiativeInvitationCreate } from './models/InitiativeInvitationCreate';
 export type { InitiativeUserRelationship } from './models/InitiativeUserRelationship';
 export type { InitiativeUserRelationshipChanges } from './models/InitiativeUserRelationshipChanges';
+export { InitiativeUserRole } from './models/InitiativeUserRole';
 export { Language } from './models/Language';
 export type { ListAnalysesReq } from './models/ListAnalysesReq';
 export type { ListAnalysesResp } from './models/ListAnalysesResp';
+export type { ListAPITokensResp } from './models/ListAPITokensResp';
 export type { ListIncompleteUploadsReq } from './models/ListIncompleteUploadsReq';
 export type { ListIncompleteUploadsResp } from './models/ListIncompleteUploadsResp';
 export type { ListPortfolioGroupsReq } from './models/ListPortfolioGroupsReq';
 export type { ListPortfolioGroupsResp } from './models/ListPortfolioGroupsResp';
+export type { ListPortfolioHoldingsResp } from './models/ListPortfolioHoldingsResp';
 export type { ListPortfoliosReq } from './models/ListPortfoliosReq';
 export type { ListPortfoliosResp } from './models/ListPortfoliosResp';
+export type { ListPortfolioVersionsResp } from './models/ListPortfolioVersionsResp';
+export type { ListTasksResp } from './models/ListTasksResp';
 export type { MergeUsersReq } from './models/MergeUsersReq';
 export type { MergeUsersResp } from './models/MergeUsersResp';
 export type { NewPortfolioAsset } from './models/NewPortfolioAsset';
@@ -66,6 +76,9 @@ export type { PactaVersionChanges } from './models/PactaVersionChanges';
 export type { PactaVersionCreate } from './models/PactaVersionCreate';
 export type { ParsePortfolioReq } from './models/ParsePortfolioReq';
 export type { ParsePortfolioResp } from './models/ParsePortfolioResp';
+export type { ParserDependency } from './models/ParserDependency';
+export type { ParseResult } from './models/ParseResult';
+export type { ParserInfo } from './models/ParserInfo';
 export type { Portfolio } from './models/Portfolio';
 export type { PortfolioChanges } from './models/PortfolioChanges';
 export type { PortfolioGroup } from './models/PortfolioGroup';
@@ -74,15 +87,29 @@ export type { PortfolioGroupCreate } from './models/PortfolioGroupCreate';
 export type { PortfolioGroupMembershipIds } from './models/PortfolioGroupMembershipIds';
 export type { PortfolioGroupMembershipPortfolio } from './models/PortfolioGroupMembershipPortfolio';
 export type { PortfolioGroupMembershipPortfolioGroup } from './models/PortfolioGroupMembershipPortfolioGroup';
+export type { PortfolioHolding } from './models/PortfolioHolding';
+export type { PortfolioHoldingsCurrencyTotal } from './models/PortfolioHoldingsCurrencyTotal';
+export type { PortfolioHoldingsSummary } from './models/PortfolioHoldingsSummary';
 export type { PortfolioInitiativeMembershipInitiative } from './models/PortfolioInitiativeMembershipInitiative';
 export type { PortfolioInitiativeMembershipPortfolio } from './models/PortfolioInitiativeMembershipPortfolio';
 export type { PortfolioSnapshot } from './models/PortfolioSnapshot';
+export type { PortfolioVersion } from './models/PortfolioVersion';
+export type { ReplacePortfolioHoldingsReq } from './models/ReplacePortfolioHoldingsReq';
+export type { RevokeSessionsReq } from './models/RevokeSessionsReq';
 export type { RunAnalysisReq } from './models/RunAnalysisReq';
 export type { RunAnalysisResp } from './models/RunAnalysisResp';
+export { RunnerStatus } from './models/RunnerStatus';
 export type { StartPortfolioUploadReq } from './models/StartPortfolioUploadReq';
 export type { StartPortfolioUploadReqItem } from './models/StartPortfolioUploadReqItem';
 export type { StartPortfolioUploadResp } from './models/StartPortfolioUploadResp';
 export type { StartPortfolioUploadRespItem } from './models/StartPortfolioUploadRespItem';
+export type { Task } from './models/Task';
+export type { TaskLogs } from './models/TaskLogs';
+export { TaskState } from './models/TaskState';
+export { TaskType } from './models/TaskType';
+export type { UploadError } from './models/UploadError';
+export type { UploadLimits } from './models/UploadLimits';
+export type { UploadLimitsChanges } from './models/UploadLimitsChanges';
 export type { User } from './models/User';
 export type { UserChanges } from './models/UserChanges';
 export type { UserQueryReq } from './models/UserQueryReq';
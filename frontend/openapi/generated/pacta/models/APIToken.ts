@@ -0,0 +1,38 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { APITokenScope } from './APITokenScope';
+
+export type APIToken = {
+    /**
+     * the unique identifier of the token
+     */
+    id: string;
+    /**
+     * the name the user gave the token, to tell their tokens apart
+     */
+    name: string;
+    /**
+     * what the token can be used for. Any token can read data, a token with no scopes can do anything its user can.
+     */
+    scopes: Array<APITokenScope>;
+    /**
+     * when the token was created
+     */
+    createdAt: string;
+    /**
+     * when the token stops working, unset if it doesn't expire
+     */
+    expiresAt?: string;
+    /**
+     * roughly when the token was last used, unset if it has never been used
+     */
+    lastUsedAt?: string;
+    /**
+     * when the token was revoked, unset if it hasn't been
+     */
+    revokedAt?: string;
+};
+
@@ -0,0 +1,10 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export enum APITokenScope {
+    API_TOKEN_SCOPE_READ_ONLY = 'APITokenScopeReadOnly',
+    API_TOKEN_SCOPE_UPLOAD = 'APITokenScopeUpload',
+    API_TOKEN_SCOPE_RUN_ANALYSIS = 'APITokenScopeRunAnalysis',
+}
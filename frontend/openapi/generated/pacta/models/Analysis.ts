@@ -53,6 +53,10 @@ export type Analysis = {
      * The english description of the failure, if any
      */
     failureMessage?: string;
+    /**
+     * The full reference of the container image that ran this analysis, if it has finished
+     */
+    imageTag?: string;
     /**
      * The list of artifacts that were generated by this analysis
      */
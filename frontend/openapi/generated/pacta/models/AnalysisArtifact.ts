@@ -18,6 +18,10 @@ export type AnalysisArtifact = {
      * Whether this artifact is publicly accessible
      */
     sharedToPublic: boolean;
+    /**
+     * Whether this artifact is only for debugging, like the logs of the analysis, and can only be downloaded by admins when admin debug mode is enabled
+     */
+    adminOnly: boolean;
     /**
      * Information about the file/artifact itself
      */
@@ -28,6 +28,10 @@ export type AuditLog = {
      * the owner id of the actor that initiated this action, not populated if the system initiated the action
      */
     actorOwnerId?: string;
+    /**
+     * the id of the API token the actor used, if they weren't using a login session
+     */
+    actorApiTokenId?: string;
     /**
      * the action that generated this audit log
      */
@@ -16,4 +16,7 @@ export enum AuditLogAction {
     AUDIT_LOG_ACTION_DISABLE_SHARING = 'AuditLogActionDisableSharing',
     AUDIT_LOG_ACTION_READ_METADATA = 'AuditLogActionReadMetadata',
     AUDIT_LOG_ACTION_TRANSFER_OWNERSHIP = 'AuditLogActionTransferOwnership',
+    AUDIT_LOG_ACTION_RETRY = 'AuditLogActionRetry',
+    AUDIT_LOG_ACTION_CANCEL = 'AuditLogActionCancel',
+    AUDIT_LOG_ACTION_REVOKE_SESSIONS = 'AuditLogActionRevokeSessions',
 }
@@ -9,4 +9,8 @@ export enum AuditLogActorType {
     AUDIT_LOG_ACTOR_TYPE_ADMIN = 'AuditLogActorTypeAdmin',
     AUDIT_LOG_ACTOR_TYPE_SUPER_ADMIN = 'AuditLogActorTypeSuperAdmin',
     AUDIT_LOG_ACTOR_TYPE_SYSTEM = 'AuditLogActorTypeSystem',
+    AUDIT_LOG_ACTOR_TYPE_INITIATIVE_VIEWER = 'AuditLogActorTypeInitiativeViewer',
+    AUDIT_LOG_ACTOR_TYPE_INITIATIVE_PORTFOLIO_CONTRIBUTOR = 'AuditLogActorTypeInitiativePortfolioContributor',
+    AUDIT_LOG_ACTOR_TYPE_INITIATIVE_ANALYST = 'AuditLogActorTypeInitiativeAnalyst',
+    AUDIT_LOG_ACTOR_TYPE_INITIATIVE_MANAGER = 'AuditLogActorTypeInitiativeManager',
 }
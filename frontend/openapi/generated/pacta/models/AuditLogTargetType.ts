@@ -13,4 +13,7 @@ export enum AuditLogTargetType {
     AUDIT_LOG_TARGET_TYPE_PACTA_VERSION = 'AuditLogTargetTypePactaVersion',
     AUDIT_LOG_TARGET_TYPE_ANALYSIS = 'AuditLogTargetTypeAnalysis',
     AUDIT_LOG_TARGET_TYPE_ANALYSIS_ARTIFACT = 'AuditLogTargetTypeAnalysisArtifact',
+    AUDIT_LOG_TARGET_TYPE_TASK = 'AuditLogTargetTypeTask',
+    AUDIT_LOG_TARGET_TYPE_API_TOKEN = 'AuditLogTargetTypeAPIToken',
+    AUDIT_LOG_TARGET_TYPE_UPLOAD_LIMITS = 'AuditLogTargetTypeUploadLimits',
 }
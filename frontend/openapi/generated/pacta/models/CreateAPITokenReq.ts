@@ -0,0 +1,22 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { APITokenScope } from './APITokenScope';
+
+export type CreateAPITokenReq = {
+    /**
+     * a name for the token, to tell it apart from the user's other tokens
+     */
+    name: string;
+    /**
+     * what the token can be used for, leave empty for full access
+     */
+    scopes?: Array<APITokenScope>;
+    /**
+     * when the token should stop working, leave unset for a token that doesn't expire
+     */
+    expiresAt?: string;
+};
+
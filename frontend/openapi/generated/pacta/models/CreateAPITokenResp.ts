@@ -0,0 +1,15 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { APIToken } from './APIToken';
+
+export type CreateAPITokenResp = {
+    token: APIToken;
+    /**
+     * the token's secret, to be sent as a bearer token. It can't be retrieved again.
+     */
+    secret: string;
+};
+
@@ -0,0 +1,13 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+/**
+ * What to do when an upload contains holdings identical to an existing portfolio
+ */
+export enum DuplicatePolicy {
+    DUPLICATE_POLICY_LINK = 'DuplicatePolicyLINK',
+    DUPLICATE_POLICY_WARN = 'DuplicatePolicyWARN',
+    DUPLICATE_POLICY_REJECT = 'DuplicatePolicyREJECT',
+}
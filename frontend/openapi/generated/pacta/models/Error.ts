@@ -3,6 +3,8 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { UploadError } from './UploadError';
+
 export type Error = {
     /**
      * Human readable error message (in English)
@@ -14,5 +16,9 @@ export type Error = {
      * An example might be getting a 401 Unauthorized because you're logged in with multiple emails and haven't selected one, the error_id could be 'multiple_emails'.
      */
     error_id: string;
+    /**
+     * If uploaded portfolio files were rejected, why each of them was.
+     */
+    upload_errors?: Array<UploadError>;
 };
 
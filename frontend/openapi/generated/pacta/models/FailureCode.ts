@@ -5,4 +5,14 @@
 
 export enum FailureCode {
     FAILURE_CODE_UNKNOWN = 'FailureCodeUNKNOWN',
+    FAILURE_CODE_PARSE_INVALID_CSV = 'FailureCodeParseInvalidCSV',
+    FAILURE_CODE_R_SCRIPT_FAILED = 'FailureCodeRScriptFailed',
+    FAILURE_CODE_BLOB_IO = 'FailureCodeBlobIO',
+    FAILURE_CODE_TIMEOUT = 'FailureCodeTimeout',
+    FAILURE_CODE_OUT_OF_MEMORY = 'FailureCodeOutOfMemory',
+    FAILURE_CODE_CANCELLED = 'FailureCodeCancelled',
+    FAILURE_CODE_DUPLICATE = 'FailureCodeDuplicate',
+    FAILURE_CODE_UNSUPPORTED_FILE = 'FailureCodeUnsupportedFile',
+    FAILURE_CODE_TOO_MANY_ROWS = 'FailureCodeTooManyRows',
+    FAILURE_CODE_INVALID_REPLACEMENT = 'FailureCodeInvalidReplacement',
 }
@@ -3,9 +3,11 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { DuplicatePolicy } from './DuplicatePolicy';
 import type { FailureCode } from './FailureCode';
 import type { HoldingsDate } from './HoldingsDate';
 import type { OptionalBoolean } from './OptionalBoolean';
+import type { ParseResult } from './ParseResult';
 
 export type IncompleteUpload = {
     /**
@@ -57,5 +59,18 @@ export type IncompleteUpload = {
      * Flag to indicate whether admin debug mode is enabled
      */
     adminDebugEnabled: boolean;
+    /**
+     * What the portfolio parser reported about this upload, if it has been parsed
+     */
+    parseResult?: ParseResult;
+    duplicatePolicy: DuplicatePolicy;
+    /**
+     * Existing portfolios with the same holdings as a portfolio parsed from this upload
+     */
+    duplicatePortfolioIds: Array<string>;
+    /**
+     * The ID of the blob holding the portfolio parser's output for this upload, if it has been parsed. It can be downloaded via AccessBlobContent when admin debugging is enabled.
+     */
+    logBlobId?: string;
 };
 
@@ -3,6 +3,8 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { InitiativeUserRole } from './InitiativeUserRole';
+
 export type InitiativeInvitation = {
     /**
      * the human-readable id identifying this initiative invitation
@@ -12,6 +14,7 @@ export type InitiativeInvitation = {
      * the id of the initiative that this invitation is for
      */
     initiativeId: string;
+    role: InitiativeUserRole;
     /**
      * the time at which this initiative invitation was used, if it has been used
      */
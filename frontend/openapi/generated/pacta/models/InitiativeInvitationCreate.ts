@@ -3,6 +3,8 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { InitiativeUserRole } from './InitiativeUserRole';
+
 export type InitiativeInvitationCreate = {
     /**
      * the human-readable id identifying this initiative invitation
@@ -12,5 +14,6 @@ export type InitiativeInvitationCreate = {
      * the id of the initiative that this invitation is for
      */
     initiativeId: string;
+    role?: InitiativeUserRole;
 };
 
@@ -3,6 +3,8 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { InitiativeUserRole } from './InitiativeUserRole';
+
 export type InitiativeUserRelationship = {
     /**
      * the inititative that this relationship describes
@@ -12,14 +14,7 @@ export type InitiativeUserRelationship = {
      * the user that this relationship describes
      */
     userId: string;
-    /**
-     * whether this user is a manager of the initiative
-     */
-    manager: boolean;
-    /**
-     * whether this user is a member of the initiative
-     */
-    member: boolean;
+    role: InitiativeUserRole;
     /**
      * the time at which this relationship was last updated
      */
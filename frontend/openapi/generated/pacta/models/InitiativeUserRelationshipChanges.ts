@@ -3,14 +3,9 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { InitiativeUserRole } from './InitiativeUserRole';
+
 export type InitiativeUserRelationshipChanges = {
-    /**
-     * whether this user is a manager of the initiative
-     */
-    manager?: boolean;
-    /**
-     * whether this user is a member of the initiative
-     */
-    member?: boolean;
+    role: InitiativeUserRole;
 };
 
@@ -0,0 +1,18 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+/**
+ * What a user can do within an initiative:
+ *   * viewers can see the initiative's aggregate results
+ *   * portfolio contributors can also add their own portfolios to the initiative
+ *   * analysts can see results, run analyses on the initiative and download all of its portfolios
+ *   * managers can do all of the above, and manage the initiative, its users, invitations and portfolios
+ */
+export enum InitiativeUserRole {
+    INITIATIVE_USER_ROLE_VIEWER = 'InitiativeUserRoleViewer',
+    INITIATIVE_USER_ROLE_PORTFOLIO_CONTRIBUTOR = 'InitiativeUserRolePortfolioContributor',
+    INITIATIVE_USER_ROLE_ANALYST = 'InitiativeUserRoleAnalyst',
+    INITIATIVE_USER_ROLE_MANAGER = 'InitiativeUserRoleManager',
+}
@@ -0,0 +1,14 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { APIToken } from './APIToken';
+
+export type ListAPITokensResp = {
+    /**
+     * the user's API tokens, newest first
+     */
+    items: Array<APIToken>;
+};
+
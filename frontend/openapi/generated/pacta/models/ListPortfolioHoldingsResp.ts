@@ -0,0 +1,21 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { PortfolioHolding } from './PortfolioHolding';
+import type { PortfolioHoldingsSummary } from './PortfolioHoldingsSummary';
+
+export type ListPortfolioHoldingsResp = {
+    holdings: Array<PortfolioHolding>;
+    summary: PortfolioHoldingsSummary;
+    /**
+     * describes whether there are more holdings to query
+     */
+    hasNextPage: boolean;
+    /**
+     * the parameter to re-request with to continue this query on the next page of results
+     */
+    cursor: string;
+};
+
@@ -0,0 +1,14 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { PortfolioVersion } from './PortfolioVersion';
+
+export type ListPortfolioVersionsResp = {
+    /**
+     * the versions of the portfolio's holdings, starting with the current one
+     */
+    items: Array<PortfolioVersion>;
+};
+
@@ -7,5 +7,9 @@ import type { Portfolio } from './Portfolio';
 
 export type ListPortfoliosResp = {
     items: Array<Portfolio>;
+    /**
+     * Only set when duplicates are requested. Each group holds the IDs of portfolios with identical holdings, oldest first.
+     */
+    duplicateGroups?: Array<Array<string>>;
 };
 
@@ -17,9 +17,25 @@ export type PactaVersion = {
      */
     description: string;
     /**
-     * The hash (typically SHA256) that uniquely identifies this version of the PACTA model.
+     * The digest (like sha256:<64 hex characters>) or tag of the runner image for this version of the PACTA model.
      */
     digest: string;
+    /**
+     * The digest (like sha256:<64 hex characters>) or tag of the dashboard image for this version of the PACTA model.
+     */
+    dashboardDigest: string;
+    /**
+     * The workflow.pacta configuration (like GENERAL_2023Q4) that analyses run with this version of the PACTA model should use.
+     */
+    inherit: string;
+    /**
+     * The benchmark dataset (like 65c1a416721b22a98c7925999ae03bc4) that analyses run with this version of the PACTA model should use.
+     */
+    benchmarkDataset: string;
+    /**
+     * The PACTA dataset (like 2023Q4_20240718T150252Z) that analyses run with this version of the PACTA model should use.
+     */
+    pactaDataset: string;
     /**
      * The time at which this version of the PACTA model was created
      */
@@ -0,0 +1,31 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { ParserInfo } from './ParserInfo';
+
+export type ParseResult = {
+    /**
+     * The MD5 hash of the uploaded file
+     */
+    inputMd5: string;
+    /**
+     * The number of entries the parser read from the uploaded file
+     */
+    inputEntries: number;
+    /**
+     * The columns the parser used to split the uploaded file into portfolios
+     */
+    groupCols: Array<string>;
+    /**
+     * The number of portfolios the parser split the uploaded file into
+     */
+    subportfoliosCount: number;
+    /**
+     * The errors the parser encountered, each of which is a list of messages
+     */
+    errors: Array<Array<string>>;
+    parser: ParserInfo;
+};
+
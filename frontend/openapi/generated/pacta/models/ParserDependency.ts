@@ -0,0 +1,16 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type ParserDependency = {
+    /**
+     * The name of the R package
+     */
+    package: string;
+    /**
+     * The version of the R package
+     */
+    version: string;
+};
+
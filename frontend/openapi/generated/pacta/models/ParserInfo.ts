@@ -0,0 +1,30 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { ParserDependency } from './ParserDependency';
+
+export type ParserInfo = {
+    /**
+     * When the parser ran, as reported by the parser
+     */
+    timestamp: string;
+    /**
+     * The R package that parsed the file
+     */
+    package: string;
+    /**
+     * The version of the R package that parsed the file
+     */
+    packageVersion: string;
+    /**
+     * The version of R the parser ran on
+     */
+    rVersion: string;
+    /**
+     * The R packages the parser depends on, and their versions
+     */
+    dependencies: Array<ParserDependency>;
+};
+
@@ -38,6 +38,18 @@ export type Portfolio = {
      * The number of rows in the portfolio
      */
     numberOfRows: number;
+    /**
+     * The investor name the parser found for this portfolio in the uploaded file, if any
+     */
+    investorName?: string;
+    /**
+     * The portfolio name the parser found for this portfolio in the uploaded file, if any
+     */
+    portfolioName?: string;
+    /**
+     * The MD5 hash of the parsed portfolio file, if known
+     */
+    md5?: string;
     /**
      * The list of portfolio groups that this portfolio is a member of
      */
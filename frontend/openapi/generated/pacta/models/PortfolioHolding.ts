@@ -0,0 +1,26 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type PortfolioHolding = {
+    /**
+     * The position of this holding in the portfolio, starting at 1
+     */
+    rowNumber: number;
+    /**
+     * The ISIN of the holding, empty if it was missing
+     */
+    isin: string;
+    /**
+     * The market value of the holding, unset if it was missing or not a number
+     */
+    marketValue?: number;
+    /**
+     * The currency of the market value, as an upper-case code
+     */
+    currency: string;
+    investorName?: string;
+    portfolioName?: string;
+};
+
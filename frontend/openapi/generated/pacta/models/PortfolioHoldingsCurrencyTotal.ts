@@ -0,0 +1,11 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type PortfolioHoldingsCurrencyTotal = {
+    currency: string;
+    totalValue: number;
+    rowCount: number;
+};
+
@@ -0,0 +1,30 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { PortfolioHoldingsCurrencyTotal } from './PortfolioHoldingsCurrencyTotal';
+
+export type PortfolioHoldingsSummary = {
+    /**
+     * The number of holdings in the portfolio
+     */
+    rowCount: number;
+    /**
+     * The sum of the market values of all holdings, regardless of currency
+     */
+    totalValue: number;
+    /**
+     * The number of holdings without an ISIN
+     */
+    missingIdentifierRowCount: number;
+    /**
+     * The number of holdings without a numeric market value
+     */
+    missingValueRowCount: number;
+    /**
+     * The total value and number of holdings in each currency, in order of first appearance
+     */
+    currencies: Array<PortfolioHoldingsCurrencyTotal>;
+};
+
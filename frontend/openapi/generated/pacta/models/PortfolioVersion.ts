@@ -0,0 +1,24 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type PortfolioVersion = {
+    /**
+     * the 1-based number of this version, the portfolio's original holdings are version 1
+     */
+    version: number;
+    /**
+     * the number of rows in this version of the holdings
+     */
+    numberOfRows: number;
+    /**
+     * when these holdings became the portfolio's holdings
+     */
+    createdAt: string;
+    /**
+     * when these holdings were replaced, unset for the current version
+     */
+    replacedAt?: string;
+};
+
@@ -0,0 +1,12 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type ReplacePortfolioHoldingsReq = {
+    /**
+     * the incomplete upload holding the new holdings
+     */
+    incompleteUploadId: string;
+};
+
@@ -0,0 +1,12 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type RevokeSessionsReq = {
+    /**
+     * if true, only the session making the request is revoked, otherwise all of the user's sessions are
+     */
+    currentSessionOnly?: boolean;
+};
+
@@ -0,0 +1,13 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export enum RunnerStatus {
+    RUNNER_STATUS_PENDING = 'RunnerStatusPending',
+    RUNNER_STATUS_RUNNING = 'RunnerStatusRunning',
+    RUNNER_STATUS_SUCCEEDED = 'RunnerStatusSucceeded',
+    RUNNER_STATUS_FAILED = 'RunnerStatusFailed',
+    RUNNER_STATUS_CANCELLED = 'RunnerStatusCancelled',
+    RUNNER_STATUS_UNKNOWN = 'RunnerStatusUnknown',
+}
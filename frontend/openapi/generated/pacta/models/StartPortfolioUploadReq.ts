@@ -3,6 +3,7 @@
 /* tslint:disable */
 /* eslint-disable */
 
+import type { DuplicatePolicy } from './DuplicatePolicy';
 import type { HoldingsDate } from './HoldingsDate';
 import type { OptionalBoolean } from './OptionalBoolean';
 import type { StartPortfolioUploadReqItem } from './StartPortfolioUploadReqItem';
@@ -25,5 +26,9 @@ export type StartPortfolioUploadReq = {
      * If set, this portfolio represents engagement strategy data or not, if unset it represents no user input
      */
     propertyEngagementStrategy: OptionalBoolean;
+    /**
+     * What to do if an uploaded file matches an existing portfolio, defaults to warning
+     */
+    duplicatePolicy?: DuplicatePolicy;
 };
 
@@ -0,0 +1,60 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+import type { RunnerStatus } from './RunnerStatus';
+import type { TaskState } from './TaskState';
+import type { TaskType } from './TaskType';
+
+export type Task = {
+    /**
+     * the system assigned unique identifier of the task
+     */
+    id: string;
+    /**
+     * the kind of work this task performs
+     */
+    taskType: TaskType;
+    /**
+     * where the task is in its lifecycle
+     */
+    state: TaskState;
+    /**
+     * the analysis this task is running, if it is an analysis task
+     */
+    analysisId?: string;
+    /**
+     * the identifier of the underlying execution of the task, if it has been started
+     */
+    runnerId?: string;
+    /**
+     * the container image the task was run with, if it has been dispatched
+     */
+    imageTag?: string;
+    /**
+     * the number of times this task has been dispatched to the runner
+     */
+    attemptCount: number;
+    /**
+     * The time at which this task was created
+     */
+    createdAt: string;
+    /**
+     * The time at which this task started running, if set
+     */
+    startedAt?: string;
+    /**
+     * The time at which this task finished running (successfully or not), if set
+     */
+    completedAt?: string;
+    /**
+     * the id of the owner of the task
+     */
+    ownerId: string;
+    /**
+     * the live status of the task as reported by the task runner, only populated when fetching a single running task
+     */
+    runnerStatus?: RunnerStatus;
+};
+
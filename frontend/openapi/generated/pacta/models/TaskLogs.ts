@@ -0,0 +1,12 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type TaskLogs = {
+    /**
+     * the combined stdout and stderr of the task
+     */
+    logs: string;
+};
+
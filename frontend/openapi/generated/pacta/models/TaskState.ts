@@ -0,0 +1,11 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export enum TaskState {
+    TASK_STATE_QUEUED = 'TaskStateQueued',
+    TASK_STATE_RUNNING = 'TaskStateRunning',
+    TASK_STATE_SUCCEEDED = 'TaskStateSucceeded',
+    TASK_STATE_FAILED = 'TaskStateFailed',
+}
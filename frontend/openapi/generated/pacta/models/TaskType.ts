@@ -0,0 +1,11 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export enum TaskType {
+    TASK_TYPE_PARSE_PORTFOLIO = 'TaskTypeParsePortfolio',
+    TASK_TYPE_CREATE_REPORT = 'TaskTypeCreateReport',
+    TASK_TYPE_CREATE_AUDIT = 'TaskTypeCreateAudit',
+    TASK_TYPE_CREATE_DASHBOARD = 'TaskTypeCreateDashboard',
+}
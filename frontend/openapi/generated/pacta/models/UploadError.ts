@@ -0,0 +1,28 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type UploadError = {
+    /**
+     * The ID of the incomplete upload that was rejected.
+     */
+    incomplete_upload_id: string;
+    /**
+     * The name of the file that was uploaded.
+     */
+    file_name: string;
+    /**
+     * Why the upload was rejected, like UPLOAD_NOT_FOUND, UPLOAD_EMPTY, UPLOAD_TOO_LARGE or UPLOAD_INVALID_CONTENT.
+     */
+    error_id: string;
+    /**
+     * The size of the uploaded file in bytes, set for UPLOAD_TOO_LARGE.
+     */
+    size?: number;
+    /**
+     * The largest file size allowed in bytes, set for UPLOAD_TOO_LARGE.
+     */
+    limit?: number;
+};
+
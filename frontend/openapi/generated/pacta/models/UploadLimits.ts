@@ -0,0 +1,20 @@
+/* generated using openapi-typescript-codegen -- do no edit */
+/* istanbul ignore file */
+/* tslint:disable */
+/* eslint-disable */
+
+export type UploadLimits = {
+    /**
+     * The largest portfolio file, in bytes, that can be uploaded. If zero, there is no limit.
+     */
+    maxBytes: number;
+    /**
+     * The most holdings rows that an uploaded portfolio file (or each sheet or archive entry of it) can have. If zero, there is no limit.
+     */
+    maxRows: number;
+    /**
+     * When the limits were last changed
+     */
+    updatedAt: string;
+};
+
@@ -11,6 +11,8 @@ import type { AuditLogQueryReq } from '../models/AuditLogQueryReq';
 import type { AuditLogQueryResp } from '../models/AuditLogQueryResp';
 import type { CompletePortfolioUploadReq } from '../models/CompletePortfolioUploadReq';
 import type { CompletePortfolioUploadResp } from '../models/CompletePortfolioUploadResp';
+import type { CreateAPITokenReq } from '../models/CreateAPITokenReq';
+import type { CreateAPITokenResp } from '../models/CreateAPITokenResp';
 import type { FindUserByMeResp } from '../models/FindUserByMeResp';
 import type { IncompleteUpload } from '../models/IncompleteUpload';
 import type { IncompleteUploadChanges } from '../models/IncompleteUploadChanges';
@@ -22,10 +24,14 @@ import type { InitiativeInvitation } from '../models/InitiativeInvitation';
 import type { InitiativeInvitationCreate } from '../models/InitiativeInvitationCreate';
 import type { InitiativeUserRelationship } from '../models/InitiativeUserRelationship';
 import type { InitiativeUserRelationshipChanges } from '../models/InitiativeUserRelationshipChanges';
+import type { ListAPITokensResp } from '../models/ListAPITokensResp';
 import type { ListAnalysesResp } from '../models/ListAnalysesResp';
 import type { ListIncompleteUploadsResp } from '../models/ListIncompleteUploadsResp';
 import type { ListPortfolioGroupsResp } from '../models/ListPortfolioGroupsResp';
+import type { ListPortfolioHoldingsResp } from '../models/ListPortfolioHoldingsResp';
+import type { ListPortfolioVersionsResp } from '../models/ListPortfolioVersionsResp';
 import type { ListPortfoliosResp } from '../models/ListPortfoliosResp';
+import type { ListTasksResp } from '../models/ListTasksResp';
 import type { MergeUsersReq } from '../models/MergeUsersReq';
 import type { MergeUsersResp } from '../models/MergeUsersResp';
 import type { PactaVersion } from '../models/PactaVersion';
@@ -37,10 +43,16 @@ import type { PortfolioGroup } from '../models/PortfolioGroup';
 import type { PortfolioGroupChanges } from '../models/PortfolioGroupChanges';
 import type { PortfolioGroupCreate } from '../models/PortfolioGroupCreate';
 import type { PortfolioGroupMembershipIds } from '../models/PortfolioGroupMembershipIds';
+import type { ReplacePortfolioHoldingsReq } from '../models/ReplacePortfolioHoldingsReq';
+import type { RevokeSessionsReq } from '../models/RevokeSessionsReq';
 import type { RunAnalysisReq } from '../models/RunAnalysisReq';
 import type { RunAnalysisResp } from '../models/RunAnalysisResp';
 import type { StartPortfolioUploadReq } from '../models/StartPortfolioUploadReq';
 import type { StartPortfolioUploadResp } from '../models/StartPortfolioUploadResp';
+import type { Task } from '../models/Task';
+import type { TaskLogs } from '../models/TaskLogs';
+import type { UploadLimits } from '../models/UploadLimits';
+import type { UploadLimitsChanges } from '../models/UploadLimitsChanges';
 import type { User } from '../models/User';
 import type { UserChanges } from '../models/UserChanges';
 import type { UserQueryReq } from '../models/UserQueryReq';
@@ -179,6 +191,36 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Returns the limits on uploaded portfolio files
+     * @returns UploadLimits the current upload limits
+     * @throws ApiError
+     */
+    public getUploadLimits(): CancelablePromise<UploadLimits> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/upload-limits',
+        });
+    }
+
+    /**
+     * Updates the limits on uploaded portfolio files
+     * Updates the limits on uploaded portfolio files, which only admins can do
+     * @param requestBody Upload limits to update
+     * @returns void
+     * @throws ApiError
+     */
+    public updateUploadLimits(
+        requestBody: UploadLimitsChanges,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'PATCH',
+            url: '/upload-limits',
+            body: requestBody,
+            mediaType: 'application/json',
+        });
+    }
+
     /**
      * Merges two users together
      * Merges two users together
@@ -275,6 +317,25 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Returns the analyses that have been run on the initiative
+     * Returns the analyses run on the initiative's portfolios, which anyone with a role in the initiative can see.
+     * @param id ID of the initiative to fetch analyses for
+     * @returns ListAnalysesResp
+     * @throws ApiError
+     */
+    public listInitiativeAnalyses(
+        id: string,
+    ): CancelablePromise<ListAnalysesResp> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/initiative/{id}/analyses',
+            path: {
+                'id': id,
+            },
+        });
+    }
+
     /**
      * Returns all initiatives
      * @returns Initiative gets all initiatives
@@ -443,6 +504,28 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Removes a user from an initiative
+     * Deletes the given user's relationship with the given initiative
+     * @param initiativeId ID of the initiative
+     * @param userId ID of the user
+     * @returns void
+     * @throws ApiError
+     */
+    public deleteInitiativeUserRelationship(
+        initiativeId: string,
+        userId: string,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'DELETE',
+            url: '/initiative/{initiativeId}/user-relationship/{userId}',
+            path: {
+                'initiativeId': initiativeId,
+                'userId': userId,
+            },
+        });
+    }
+
     /**
      * creates an initiative portfolio relationship
      * creates a membership relationship between the portfolio and the initiative
@@ -687,15 +770,43 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Retries parsing a failed incomplete upload
+     * Clears the failure on an incomplete upload and starts parsing it again
+     * @param id ID of incomplete upload to retry
+     * @returns void
+     * @throws ApiError
+     */
+    public retryIncompleteUpload(
+        id: string,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/incomplete-upload/{id}:retry',
+            path: {
+                'id': id,
+            },
+            errors: {
+                409: `the incomplete upload has not failed, or is still being parsed`,
+            },
+        });
+    }
+
     /**
      * Gets the list of portfolios that the user is the owner of
+     * @param duplicates If true, only portfolios with the same holdings as another of the user's portfolios are returned, along with how they are grouped
      * @returns ListPortfoliosResp
      * @throws ApiError
      */
-    public listPortfolios(): CancelablePromise<ListPortfoliosResp> {
+    public listPortfolios(
+        duplicates?: boolean,
+    ): CancelablePromise<ListPortfoliosResp> {
         return this.httpRequest.request({
             method: 'GET',
             url: '/portfolios',
+            query: {
+                'duplicates': duplicates,
+            },
         });
     }
 
@@ -760,6 +871,78 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Returns the holdings in a portfolio
+     * Returns a page of the rows of a parsed portfolio, along with summary statistics over all of its rows
+     * @param id ID of the portfolio to read holdings from
+     * @param cursor The cursor from a previous response, to continue reading from where it left off
+     * @param limit The maximum number of holdings to return, defaults to 100
+     * @returns ListPortfolioHoldingsResp the requested page of holdings
+     * @throws ApiError
+     */
+    public listPortfolioHoldings(
+        id: string,
+        cursor?: string,
+        limit?: number,
+    ): CancelablePromise<ListPortfolioHoldingsResp> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/portfolio/{id}/holdings',
+            path: {
+                'id': id,
+            },
+            query: {
+                'cursor': cursor,
+                'limit': limit,
+            },
+        });
+    }
+
+    /**
+     * Returns the history of a portfolio's holdings
+     * Returns the current holdings of a portfolio and each set of holdings it had before they were replaced, newest first
+     * @param id ID of the portfolio to read the history of
+     * @returns ListPortfolioVersionsResp the versions of the portfolio's holdings
+     * @throws ApiError
+     */
+    public listPortfolioVersions(
+        id: string,
+    ): CancelablePromise<ListPortfolioVersionsResp> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/portfolio/{id}/versions',
+            path: {
+                'id': id,
+            },
+        });
+    }
+
+    /**
+     * Replaces the holdings of a portfolio with a new upload
+     * Starts parsing an upload whose holdings will replace those of the portfolio once parsed. The portfolio keeps its identity, memberships and analyses, and its previous holdings are kept as a prior version.
+     * @param id ID of the portfolio to replace the holdings of
+     * @param requestBody The incomplete upload holding the new holdings, which must have been uploaded to storage
+     * @returns void
+     * @throws ApiError
+     */
+    public replacePortfolioHoldings(
+        id: string,
+        requestBody: ReplacePortfolioHoldingsReq,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/portfolio/{id}:replace-holdings',
+            path: {
+                'id': id,
+            },
+            body: requestBody,
+            mediaType: 'application/json',
+            errors: {
+                409: `the incomplete upload has already been parsed`,
+            },
+        });
+    }
+
     /**
      * gets info about the logged in user
      * Returns the logged in user, if the user is logged in, otherwise returns empty
@@ -773,6 +956,74 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Logs the logged in user out everywhere
+     * Revokes all of the logged in user's login sessions, or only the current one, so that their tokens can't be used again even if they haven't expired. API tokens aren't affected, they're revoked separately.
+     * @param requestBody which of the user's sessions to revoke
+     * @returns void
+     * @throws ApiError
+     */
+    public revokeMySessions(
+        requestBody: RevokeSessionsReq,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/user/me:revoke-sessions',
+            body: requestBody,
+            mediaType: 'application/json',
+        });
+    }
+
+    /**
+     * Returns the logged in user's API tokens
+     * Returns the API tokens the logged in user has created, including revoked and expired ones. Token secrets are never returned.
+     * @returns ListAPITokensResp the user's API tokens
+     * @throws ApiError
+     */
+    public listAPITokens(): CancelablePromise<ListAPITokensResp> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/user/me/tokens',
+        });
+    }
+
+    /**
+     * Creates an API token for the logged in user
+     * Creates an API token that can be used as a bearer token in place of a login session, for scripted access to the API. The secret is only returned here, and can't be retrieved later.
+     * @param requestBody the properties of the token to create
+     * @returns CreateAPITokenResp the token was created
+     * @throws ApiError
+     */
+    public createAPIToken(
+        requestBody: CreateAPITokenReq,
+    ): CancelablePromise<CreateAPITokenResp> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/user/me/tokens',
+            body: requestBody,
+            mediaType: 'application/json',
+        });
+    }
+
+    /**
+     * Revokes an API token
+     * Revokes one of the logged in user's API tokens, after which it can no longer be used. The token is kept so it can still be seen in listings and audit logs.
+     * @param id ID of the API token to revoke
+     * @returns void
+     * @throws ApiError
+     */
+    public revokeAPIToken(
+        id: string,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'DELETE',
+            url: '/user/me/tokens/{id}',
+            path: {
+                'id': id,
+            },
+        });
+    }
+
     /**
      * Gets the list of users that the user is able to view, currently an admin-only action
      * @param requestBody A request describing which users should be returned
@@ -864,6 +1115,25 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Logs a user out everywhere
+     * Revokes all of a user's login sessions, so that their tokens can't be used again even if they haven't expired. Admins can revoke any user's sessions.
+     * @param id ID of the user whose sessions should be revoked
+     * @returns void
+     * @throws ApiError
+     */
+    public revokeUserSessions(
+        id: string,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/user/{id}:revoke-sessions',
+            path: {
+                'id': id,
+            },
+        });
+    }
+
     /**
      * queries the platform's audit logs
      * returns back audit logs that matc the user's query
@@ -991,6 +1261,110 @@ export class DefaultService {
         });
     }
 
+    /**
+     * Retries a failed analysis
+     * Clears the failure on an analysis and runs it again against the same portfolio snapshot
+     * @param id ID of analysis to retry
+     * @returns void
+     * @throws ApiError
+     */
+    public retryAnalysis(
+        id: string,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/analysis/{id}:retry',
+            path: {
+                'id': id,
+            },
+            errors: {
+                409: `the analysis has not failed, or is still running`,
+            },
+        });
+    }
+
+    /**
+     * Cancels a running analysis
+     * Stops the task running the analysis and marks the analysis as cancelled
+     * @param id ID of analysis to cancel
+     * @returns void
+     * @throws ApiError
+     */
+    public cancelAnalysis(
+        id: string,
+    ): CancelablePromise<void> {
+        return this.httpRequest.request({
+            method: 'POST',
+            url: '/analysis/{id}:cancel',
+            path: {
+                'id': id,
+            },
+            errors: {
+                409: `the analysis has already completed`,
+            },
+        });
+    }
+
+    /**
+     * Returns the tasks that have been run for an analysis
+     * Returns the history of async tasks run for the given analysis, oldest first
+     * @param id ID of analysis to fetch tasks for
+     * @returns ListTasksResp the tasks for the analysis
+     * @throws ApiError
+     */
+    public listTasksForAnalysis(
+        id: string,
+    ): CancelablePromise<ListTasksResp> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/analysis/{id}/tasks',
+            path: {
+                'id': id,
+            },
+        });
+    }
+
+    /**
+     * Returns a task by ID
+     * Returns the status and metadata of a single async task
+     * @param id ID of task to fetch
+     * @returns Task task response
+     * @throws ApiError
+     */
+    public findTaskById(
+        id: string,
+    ): CancelablePromise<Task> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/tasks/{id}',
+            path: {
+                'id': id,
+            },
+        });
+    }
+
+    /**
+     * Returns the logs of a task
+     * Returns the combined stdout and stderr of a task from the underlying runner, only available to administrators
+     * @param id ID of task to fetch logs for
+     * @returns TaskLogs task logs response
+     * @throws ApiError
+     */
+    public findTaskLogs(
+        id: string,
+    ): CancelablePromise<TaskLogs> {
+        return this.httpRequest.request({
+            method: 'GET',
+            url: '/tasks/{id}/logs',
+            path: {
+                'id': id,
+            },
+            errors: {
+                501: `the task runner doesn't support retrieving logs`,
+            },
+        });
+    }
+
     /**
      * Updates writable analysis artifact properties
      * Updates an analysis artifact's settable properties
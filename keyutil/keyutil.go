@@ -6,10 +6,14 @@ import (
 	"crypto/ed25519"
 	"crypto/rand"
 	"crypto/x509"
+	"encoding/json"
 	"encoding/pem"
 	"errors"
 	"fmt"
 	"os"
+
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
 )
 
 var (
@@ -130,6 +134,68 @@ func DecodeED25519PrivateKey(privDER []byte) (ed25519.PrivateKey, error) {
 	return privED, nil
 }
 
+// ED25519JWK wraps an Ed25519 public or private key in a JWK with the given key
+// ID, so that tokens signed with it name the key in their kid header, and
+// verifiers can pick the right key out of a key set.
+func ED25519JWK(kid string, key any) (jwk.Key, error) {
+	switch key.(type) {
+	case ed25519.PublicKey, ed25519.PrivateKey:
+		// These are the only ones we currently support.
+	default:
+		return nil, fmt.Errorf("key was of type %T, expected an Ed25519 key", key)
+	}
+
+	k, err := jwk.FromRaw(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make JWK: %w", err)
+	}
+	if err := k.Set(jwk.KeyIDKey, kid); err != nil {
+		return nil, fmt.Errorf("failed to set key ID: %w", err)
+	}
+	if err := k.Set(jwk.AlgorithmKey, jwa.EdDSA); err != nil {
+		return nil, fmt.Errorf("failed to set algorithm: %w", err)
+	}
+	return k, nil
+}
+
+// AddToJWKSFile adds the public half of the given key to the JWKS document at
+// the given path, creating it if it doesn't exist. Any key already in the
+// document with the same key ID is replaced.
+func AddToJWKSFile(out string, key jwk.Key) error {
+	pub, err := key.PublicKey()
+	if err != nil {
+		return fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	set := jwk.NewSet()
+	if _, err := os.Stat(out); err == nil {
+		if set, err = jwk.ReadFile(out); err != nil {
+			return fmt.Errorf("failed to read existing JWKS file: %w", err)
+		}
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to check for existing JWKS file: %w", err)
+	}
+
+	if existing, ok := set.LookupKeyID(pub.KeyID()); ok {
+		if err := set.RemoveKey(existing); err != nil {
+			return fmt.Errorf("failed to remove existing key %q: %w", pub.KeyID(), err)
+		}
+	}
+	if err := set.AddKey(pub); err != nil {
+		return fmt.Errorf("failed to add key: %w", err)
+	}
+
+	dat, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal JWKS: %w", err)
+	}
+	if err := os.WriteFile(out, dat, 0644); err != nil {
+		return fmt.Errorf("failed to write JWKS file: %w", err)
+	}
+
+	return nil
+}
+
 func decodeFromFile(name, typ string) ([]byte, error) {
 	dat, err := os.ReadFile(name)
 	if err != nil {
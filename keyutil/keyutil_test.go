@@ -8,6 +8,9 @@ import (
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
 )
 
 func TestRoundTripKeyGeneration(t *testing.T) {
@@ -60,3 +63,61 @@ func TestRoundTripKeyGeneration(t *testing.T) {
 		t.Fatal("public key failed to verify signature produced by private key over mesage")
 	}
 }
+
+func TestAddToJWKSFile(t *testing.T) {
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+	randReader = rand.New(rand.NewSource(0))
+
+	newKey := func(kid string) jwk.Key {
+		_, priv, err := ed25519.GenerateKey(randReader)
+		if err != nil {
+			t.Fatalf("failed to generate key: %v", err)
+		}
+		k, err := ED25519JWK(kid, priv)
+		if err != nil {
+			t.Fatalf("ED25519JWK: %v", err)
+		}
+		return k
+	}
+
+	oldKey, newKeySameID, otherKey := newKey("key-1"), newKey("key-1"), newKey("key-2")
+	for _, k := range []jwk.Key{oldKey, otherKey, newKeySameID} {
+		if err := AddToJWKSFile(jwksPath, k); err != nil {
+			t.Fatalf("AddToJWKSFile: %v", err)
+		}
+	}
+
+	set, err := jwk.ReadFile(jwksPath)
+	if err != nil {
+		t.Fatalf("failed to read JWKS file: %v", err)
+	}
+	var kids []string
+	for i := 0; i < set.Len(); i++ {
+		k, _ := set.Key(i)
+		if _, ok := k.(jwk.OKPPrivateKey); ok {
+			t.Errorf("key %q in JWKS file is a private key", k.KeyID())
+		}
+		kids = append(kids, k.KeyID())
+	}
+	if diff := cmp.Diff([]string{"key-2", "key-1"}, kids); diff != "" {
+		t.Errorf("unexpected key IDs in JWKS file (-want +got)\n%s", diff)
+	}
+
+	// Messages signed with the replacement key should verify against the set, and
+	// ones signed with the key it replaced shouldn't.
+	msg := []byte("I am a test message!")
+	signed, err := jws.Sign(msg, jws.WithKey(jwa.EdDSA, newKeySameID))
+	if err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	if _, err := jws.Verify(signed, jws.WithKeySet(set)); err != nil {
+		t.Errorf("failed to verify message signed with current key: %v", err)
+	}
+	signed, err = jws.Sign(msg, jws.WithKey(jwa.EdDSA, oldKey))
+	if err != nil {
+		t.Fatalf("failed to sign message: %v", err)
+	}
+	if _, err := jws.Verify(signed, jws.WithKeySet(set)); err == nil {
+		t.Error("message signed with replaced key was verified, expected an error")
+	}
+}
@@ -71,6 +71,7 @@ type Error struct {
 	// These are additional metadata that do not need to be set.
 	fields  []zap.Field
 	errorID ErrorID
+	details any
 }
 
 func (e *Error) Error() string {
@@ -150,6 +151,20 @@ func (e *Error) WithErrorID(errID ErrorID) *Error {
 	return e
 }
 
+// WithDetails adds structured information intended for client apps, like
+// which of several inputs were invalid, and returns the error for chaining
+// purposes. Like the ErrorID, the details can be accessed in a
+// ResponseConverter and included in the response body.
+func (e *Error) WithDetails(details any) *Error {
+	e.details = details
+	return e
+}
+
+// Details returns whatever was passed to WithDetails, or nil.
+func (e *Error) Details() any {
+	return e.details
+}
+
 // AtDebug overrides the default level for the error and logs at DEBUG level.
 func (e *Error) AtDebug() *Error {
 	e.level = debugLevel
@@ -13,7 +13,10 @@ import (
 )
 
 func TestClonePACTAVersion(t *testing.T)               { testClone(t, &PACTAVersion{}) }
+func TestCloneUploadLimits(t *testing.T)               { testClone(t, &UploadLimits{}) }
 func TestCloneUser(t *testing.T)                       { testClone(t, &User{}) }
+func TestCloneUserAuthn(t *testing.T)                  { testClone(t, &UserAuthn{}) }
+func TestCloneAPIToken(t *testing.T)                   { testClone(t, &APIToken{}) }
 func TestCloneInitiative(t *testing.T)                 { testClone(t, &Initiative{}) }
 func TestCloneInitiativeInvitation(t *testing.T)       { testClone(t, &InitiativeInvitation{}) }
 func TestCloneInitiativeUserRelationship(t *testing.T) { testClone(t, &InitiativeUserRelationship{}) }
@@ -26,6 +29,7 @@ func TestClonePortfolioGroupMembership(t *testing.T)   { testClone(t, &Portfolio
 func TestClonePortfolioSnapshot(t *testing.T)          { testClone(t, &PortfolioSnapshot{}) }
 func TestCloneAnalysis(t *testing.T)                   { testClone(t, &Analysis{}) }
 func TestCloneAnalysisArtifact(t *testing.T)           { testClone(t, &AnalysisArtifact{}) }
+func TestCloneTask(t *testing.T)                       { testClone(t, &Task{}) }
 func TestCloneAuditLog(t *testing.T)                   { testClone(t, &AuditLog{}) }
 func TestClonePortfolioInitiativeMembership(t *testing.T) {
 	testClone(t, &PortfolioInitiativeMembership{})
@@ -37,10 +37,30 @@ func TestParseFailureCode(t *testing.T) {
 }
 
 // need
+func TestParseAPITokenScope(t *testing.T) {
+	testParseEnum(t, APITokenScopeValues, ParseAPITokenScope)
+}
+
+func TestParseInitiativeUserRole(t *testing.T) {
+	testParseEnum(t, InitiativeUserRoleValues, ParseInitiativeUserRole)
+}
+
 func TestParseAnalysisType(t *testing.T) {
 	testParseEnum(t, AnalysisTypeValues, ParseAnalysisType)
 }
 
+func TestParseDuplicatePolicy(t *testing.T) {
+	testParseEnum(t, DuplicatePolicyValues, ParseDuplicatePolicy)
+}
+
+func TestParseTaskType(t *testing.T) {
+	testParseEnum(t, TaskTypeValues, ParseTaskType)
+}
+
+func TestParseTaskState(t *testing.T) {
+	testParseEnum(t, TaskStateValues, ParseTaskState)
+}
+
 // need
 func TestParseAuditLogAction(t *testing.T) {
 	testParseEnum(t, AuditLogActionValues, ParseAuditLogAction)
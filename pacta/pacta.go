@@ -10,20 +10,56 @@ import (
 type AuthnMechanism string
 
 const (
+	// AuthnMechanism_EmailAndPass covers tokens issued by the RMI credential
+	// service.
 	AuthnMechanism_EmailAndPass AuthnMechanism = "EMAIL_AND_PASS"
+	// AuthnMechanism_OIDC covers tokens issued by an external OpenID Connect
+	// provider, like a partner institution's SSO, that doesn't have a more
+	// specific mechanism below.
+	AuthnMechanism_OIDC AuthnMechanism = "OIDC"
+	// AuthnMechanism_EntraID covers tokens issued by a Microsoft Entra ID
+	// tenant.
+	AuthnMechanism_EntraID AuthnMechanism = "ENTRA_ID"
+	// AuthnMechanism_Google covers tokens issued by Google Workspace.
+	AuthnMechanism_Google AuthnMechanism = "GOOGLE"
+	// AuthnMechanism_Okta covers tokens issued by an Okta organization.
+	AuthnMechanism_Okta AuthnMechanism = "OKTA"
 )
 
 var AuthnMechanismValues = []AuthnMechanism{
 	AuthnMechanism_EmailAndPass,
+	AuthnMechanism_OIDC,
+	AuthnMechanism_EntraID,
+	AuthnMechanism_Google,
+	AuthnMechanism_Okta,
 }
 
 func ParseAuthnMechanism(s string) (AuthnMechanism, error) {
-	if s == "EMAIL_AND_PASS" {
+	switch s {
+	case "EMAIL_AND_PASS":
 		return AuthnMechanism_EmailAndPass, nil
+	case "OIDC":
+		return AuthnMechanism_OIDC, nil
+	case "ENTRA_ID":
+		return AuthnMechanism_EntraID, nil
+	case "GOOGLE":
+		return AuthnMechanism_Google, nil
+	case "OKTA":
+		return AuthnMechanism_Okta, nil
 	}
 	return "", fmt.Errorf("unknown AuthnMechanism: %q", s)
 }
 
+// IsOIDC reports whether tokens for the mechanism come from an external OpenID
+// Connect provider, and so carry the standard OIDC claims.
+func (a AuthnMechanism) IsOIDC() bool {
+	switch a {
+	case AuthnMechanism_OIDC, AuthnMechanism_EntraID, AuthnMechanism_Google, AuthnMechanism_Okta:
+		return true
+	}
+	return false
+}
+
 type Language string
 
 const (
@@ -59,12 +95,16 @@ func ParseLanguage(s string) (Language, error) {
 
 type PACTAVersionID string
 type PACTAVersion struct {
-	ID          PACTAVersionID
-	Name        string
-	Description string
-	Digest      string
-	CreatedAt   time.Time
-	IsDefault   bool
+	ID               PACTAVersionID
+	Name             string
+	Description      string
+	Digest           string
+	DashboardDigest  string
+	Inherit          string
+	BenchmarkDataset string
+	PACTADataset     string
+	CreatedAt        time.Time
+	IsDefault        bool
 }
 
 func (o *PACTAVersion) Clone() *PACTAVersion {
@@ -72,12 +112,36 @@ func (o *PACTAVersion) Clone() *PACTAVersion {
 		return nil
 	}
 	return &PACTAVersion{
-		ID:          o.ID,
-		Name:        o.Name,
-		Description: o.Description,
-		Digest:      o.Digest,
-		CreatedAt:   o.CreatedAt,
-		IsDefault:   o.IsDefault,
+		ID:               o.ID,
+		Name:             o.Name,
+		Description:      o.Description,
+		Digest:           o.Digest,
+		DashboardDigest:  o.DashboardDigest,
+		Inherit:          o.Inherit,
+		BenchmarkDataset: o.BenchmarkDataset,
+		PACTADataset:     o.PACTADataset,
+		CreatedAt:        o.CreatedAt,
+		IsDefault:        o.IsDefault,
+	}
+}
+
+// UploadLimits limit the size of each uploaded portfolio file, zero means no
+// limit. Rows are counted by the parser, after workbooks and archives have
+// been split into CSVs.
+type UploadLimits struct {
+	MaxBytes  int64
+	MaxRows   int
+	UpdatedAt time.Time
+}
+
+func (o *UploadLimits) Clone() *UploadLimits {
+	if o == nil {
+		return nil
+	}
+	return &UploadLimits{
+		MaxBytes:  o.MaxBytes,
+		MaxRows:   o.MaxRows,
+		UpdatedAt: o.UpdatedAt,
 	}
 }
 
@@ -115,6 +179,84 @@ func (o *User) Clone() *User {
 	}
 }
 
+// UserAuthn is an identity that a user can log in with. A user has at least
+// one, the one their account was created with, which is also recorded on the
+// User itself.
+type UserAuthn struct {
+	User           *User
+	AuthnMechanism AuthnMechanism
+	AuthnID        string
+	CreatedAt      time.Time
+}
+
+func (o *UserAuthn) Clone() *UserAuthn {
+	if o == nil {
+		return nil
+	}
+	return &UserAuthn{
+		User:           o.User.Clone(),
+		AuthnMechanism: o.AuthnMechanism,
+		AuthnID:        o.AuthnID,
+		CreatedAt:      o.CreatedAt,
+	}
+}
+
+// APIToken is a user-managed credential for scripted access to the API. The
+// secret itself is never stored, only a hash of it.
+type APITokenID string
+type APIToken struct {
+	ID         APITokenID
+	User       *User
+	Name       string
+	Scopes     []APITokenScope
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	LastUsedAt time.Time
+	RevokedAt  time.Time
+}
+
+func (o *APIToken) Clone() *APIToken {
+	if o == nil {
+		return nil
+	}
+	return &APIToken{
+		ID:         o.ID,
+		User:       o.User.Clone(),
+		Name:       o.Name,
+		Scopes:     cloneSlice(o.Scopes),
+		CreatedAt:  o.CreatedAt,
+		ExpiresAt:  o.ExpiresAt,
+		LastUsedAt: o.LastUsedAt,
+		RevokedAt:  o.RevokedAt,
+	}
+}
+
+type APITokenScope string
+
+const (
+	APITokenScope_ReadOnly    APITokenScope = "READ_ONLY"
+	APITokenScope_Upload      APITokenScope = "UPLOAD"
+	APITokenScope_RunAnalysis APITokenScope = "RUN_ANALYSIS"
+)
+
+var APITokenScopeValues = []APITokenScope{
+	APITokenScope_ReadOnly,
+	APITokenScope_Upload,
+	APITokenScope_RunAnalysis,
+}
+
+func ParseAPITokenScope(s string) (APITokenScope, error) {
+	switch s {
+	case "READ_ONLY":
+		return APITokenScope_ReadOnly, nil
+	case "UPLOAD":
+		return APITokenScope_Upload, nil
+	case "RUN_ANALYSIS":
+		return APITokenScope_RunAnalysis, nil
+	}
+	return "", fmt.Errorf("unknown APITokenScope: %q", s)
+}
+
 type InitiativeID string
 type Initiative struct {
 	ID                             InitiativeID
@@ -159,9 +301,11 @@ type InitiativeInvitationID string
 type InitiativeInvitation struct {
 	ID         InitiativeInvitationID
 	Initiative *Initiative
-	CreatedAt  time.Time
-	UsedAt     time.Time
-	UsedBy     *User
+	// Role is given to the user that claims the invitation.
+	Role      InitiativeUserRole
+	CreatedAt time.Time
+	UsedAt    time.Time
+	UsedBy    *User
 }
 
 func (o *InitiativeInvitation) Clone() *InitiativeInvitation {
@@ -171,6 +315,7 @@ func (o *InitiativeInvitation) Clone() *InitiativeInvitation {
 	return &InitiativeInvitation{
 		ID:         o.ID,
 		Initiative: o.Initiative.Clone(),
+		Role:       o.Role,
 		CreatedAt:  o.CreatedAt,
 		UsedAt:     o.UsedAt,
 		UsedBy:     o.UsedBy.Clone(),
@@ -180,8 +325,7 @@ func (o *InitiativeInvitation) Clone() *InitiativeInvitation {
 type InitiativeUserRelationship struct {
 	Initiative *Initiative
 	User       *User
-	Manager    bool
-	Member     bool
+	Role       InitiativeUserRole
 	UpdatedAt  time.Time
 }
 
@@ -192,12 +336,87 @@ func (o *InitiativeUserRelationship) Clone() *InitiativeUserRelationship {
 	return &InitiativeUserRelationship{
 		Initiative: o.Initiative.Clone(),
 		User:       o.User.Clone(),
-		Manager:    o.Manager,
-		Member:     o.Member,
+		Role:       o.Role,
 		UpdatedAt:  o.UpdatedAt,
 	}
 }
 
+// InitiativeUserRole is what a user can do within an initiative. Roles aren't
+// strictly ordered, so use the Can* methods rather than comparing roles.
+type InitiativeUserRole string
+
+const (
+	// Viewers can see the initiative's aggregate results.
+	InitiativeUserRole_Viewer InitiativeUserRole = "VIEWER"
+	// Portfolio contributors can also add their own portfolios to the initiative.
+	InitiativeUserRole_PortfolioContributor InitiativeUserRole = "PORTFOLIO_CONTRIBUTOR"
+	// Analysts can also run analyses on the initiative's portfolios, and
+	// download all of them.
+	InitiativeUserRole_Analyst InitiativeUserRole = "ANALYST"
+	// Managers can do all of the above, and manage the initiative, its users and
+	// its portfolios.
+	InitiativeUserRole_Manager InitiativeUserRole = "MANAGER"
+)
+
+var InitiativeUserRoleValues = []InitiativeUserRole{
+	InitiativeUserRole_Viewer,
+	InitiativeUserRole_PortfolioContributor,
+	InitiativeUserRole_Analyst,
+	InitiativeUserRole_Manager,
+}
+
+func ParseInitiativeUserRole(s string) (InitiativeUserRole, error) {
+	switch s {
+	case "VIEWER":
+		return InitiativeUserRole_Viewer, nil
+	case "PORTFOLIO_CONTRIBUTOR":
+		return InitiativeUserRole_PortfolioContributor, nil
+	case "ANALYST":
+		return InitiativeUserRole_Analyst, nil
+	case "MANAGER":
+		return InitiativeUserRole_Manager, nil
+	}
+	return "", fmt.Errorf("unknown InitiativeUserRole: %q", s)
+}
+
+// CanViewResults is true for every role. The zero value, which means the user
+// has no relationship with the initiative, can't do anything.
+func (r InitiativeUserRole) CanViewResults() bool {
+	switch r {
+	case InitiativeUserRole_Viewer, InitiativeUserRole_PortfolioContributor, InitiativeUserRole_Analyst, InitiativeUserRole_Manager:
+		return true
+	}
+	return false
+}
+
+func (r InitiativeUserRole) CanContributePortfolios() bool {
+	return r == InitiativeUserRole_PortfolioContributor || r == InitiativeUserRole_Manager
+}
+
+func (r InitiativeUserRole) CanAnalyze() bool {
+	return r == InitiativeUserRole_Analyst || r == InitiativeUserRole_Manager
+}
+
+func (r InitiativeUserRole) CanManage() bool {
+	return r == InitiativeUserRole_Manager
+}
+
+// ActorType is what actions taken through the role are audit logged as, or
+// empty for the zero value.
+func (r InitiativeUserRole) ActorType() AuditLogActorType {
+	switch r {
+	case InitiativeUserRole_Viewer:
+		return AuditLogActorType_InitiativeViewer
+	case InitiativeUserRole_PortfolioContributor:
+		return AuditLogActorType_InitiativePortfolioContributor
+	case InitiativeUserRole_Analyst:
+		return AuditLogActorType_InitiativeAnalyst
+	case InitiativeUserRole_Manager:
+		return AuditLogActorType_InitiativeManager
+	}
+	return ""
+}
+
 type FileType string
 
 const (
@@ -310,6 +529,8 @@ type Blob struct {
 	FileType  FileType
 	FileName  string
 	CreatedAt time.Time
+	// ContentMD5 is the hex-encoded MD5 hash of the blob's contents, if known.
+	ContentMD5 string
 }
 
 func (o *Blob) Clone() *Blob {
@@ -317,11 +538,12 @@ func (o *Blob) Clone() *Blob {
 		return nil
 	}
 	return &Blob{
-		ID:        o.ID,
-		BlobURI:   o.BlobURI,
-		FileType:  o.FileType,
-		FileName:  o.FileName,
-		CreatedAt: o.CreatedAt,
+		ID:         o.ID,
+		BlobURI:    o.BlobURI,
+		FileType:   o.FileType,
+		FileName:   o.FileName,
+		CreatedAt:  o.CreatedAt,
+		ContentMD5: o.ContentMD5,
 	}
 }
 
@@ -331,6 +553,7 @@ type BlobContext struct {
 	PrimaryTargetID      string
 	PrimaryTargetOwnerID OwnerID
 	AdminDebugEnabled    bool
+	AdminOnly            bool
 }
 
 func (o *BlobContext) Clone() *BlobContext {
@@ -343,6 +566,7 @@ func (o *BlobContext) Clone() *BlobContext {
 		PrimaryTargetID:      o.PrimaryTargetID,
 		PrimaryTargetOwnerID: o.PrimaryTargetOwnerID,
 		AdminDebugEnabled:    o.AdminDebugEnabled,
+		AdminOnly:            o.AdminOnly,
 	}
 }
 
@@ -367,17 +591,59 @@ func (o *Owner) Clone() *Owner {
 type FailureCode string
 
 const (
-	FailureCode_Unknown FailureCode = "UNKNOWN"
+	FailureCode_Unknown         FailureCode = "UNKNOWN"
+	FailureCode_ParseInvalidCSV FailureCode = "PARSE_INVALID_CSV"
+	FailureCode_RScriptFailed   FailureCode = "R_SCRIPT_FAILED"
+	FailureCode_BlobIO          FailureCode = "BLOB_IO"
+	FailureCode_Timeout         FailureCode = "TIMEOUT"
+	FailureCode_OutOfMemory     FailureCode = "OUT_OF_MEMORY"
+	FailureCode_Cancelled       FailureCode = "CANCELLED"
+	FailureCode_Duplicate       FailureCode = "DUPLICATE"
+	FailureCode_UnsupportedFile FailureCode = "UNSUPPORTED_FILE"
+	FailureCode_TooManyRows     FailureCode = "TOO_MANY_ROWS"
+	// FailureCode_InvalidReplacement means an upload meant to replace the
+	// holdings of an existing portfolio didn't parse into exactly one portfolio.
+	FailureCode_InvalidReplacement FailureCode = "INVALID_REPLACEMENT"
 )
 
 var FailureCodeValues = []FailureCode{
 	FailureCode_Unknown,
+	FailureCode_ParseInvalidCSV,
+	FailureCode_RScriptFailed,
+	FailureCode_BlobIO,
+	FailureCode_Timeout,
+	FailureCode_OutOfMemory,
+	FailureCode_Cancelled,
+	FailureCode_Duplicate,
+	FailureCode_UnsupportedFile,
+	FailureCode_TooManyRows,
+	FailureCode_InvalidReplacement,
 }
 
 func ParseFailureCode(s string) (FailureCode, error) {
 	switch s {
 	case "UNKNOWN":
 		return FailureCode_Unknown, nil
+	case "PARSE_INVALID_CSV":
+		return FailureCode_ParseInvalidCSV, nil
+	case "R_SCRIPT_FAILED":
+		return FailureCode_RScriptFailed, nil
+	case "BLOB_IO":
+		return FailureCode_BlobIO, nil
+	case "TIMEOUT":
+		return FailureCode_Timeout, nil
+	case "OUT_OF_MEMORY":
+		return FailureCode_OutOfMemory, nil
+	case "CANCELLED":
+		return FailureCode_Cancelled, nil
+	case "DUPLICATE":
+		return FailureCode_Duplicate, nil
+	case "UNSUPPORTED_FILE":
+		return FailureCode_UnsupportedFile, nil
+	case "TOO_MANY_ROWS":
+		return FailureCode_TooManyRows, nil
+	case "INVALID_REPLACEMENT":
+		return FailureCode_InvalidReplacement, nil
 	}
 	return "", fmt.Errorf("unknown FailureCode: %q", s)
 }
@@ -411,6 +677,90 @@ func (o PortfolioProperties) Clone() PortfolioProperties {
 	}
 }
 
+// ParseResult is what the portfolio parser reported about a single uploaded
+// file, whether or not any portfolios could be parsed from it.
+type ParseResult struct {
+	InputMD5           string
+	InputEntries       int
+	GroupCols          []string
+	SubportfoliosCount int
+	Errors             [][]string
+	Parser             ParserInfo
+}
+
+func (o *ParseResult) Clone() *ParseResult {
+	if o == nil {
+		return nil
+	}
+	errs := cloneSlice(o.Errors)
+	for i, e := range errs {
+		errs[i] = cloneSlice(e)
+	}
+	return &ParseResult{
+		InputMD5:           o.InputMD5,
+		InputEntries:       o.InputEntries,
+		GroupCols:          cloneSlice(o.GroupCols),
+		SubportfoliosCount: o.SubportfoliosCount,
+		Errors:             errs,
+		Parser:             o.Parser.Clone(),
+	}
+}
+
+// ParserInfo identifies the R package (and its dependencies) that parsed a file.
+type ParserInfo struct {
+	Timestamp      string
+	Package        string
+	PackageVersion string
+	RVersion       string
+	Dependencies   []ParserDependency
+}
+
+func (o ParserInfo) Clone() ParserInfo {
+	return ParserInfo{
+		Timestamp:      o.Timestamp,
+		Package:        o.Package,
+		PackageVersion: o.PackageVersion,
+		RVersion:       o.RVersion,
+		Dependencies:   cloneSlice(o.Dependencies),
+	}
+}
+
+type ParserDependency struct {
+	Package string
+	Version string
+}
+
+// DuplicatePolicy determines what happens when an upload parses to a portfolio
+// with the same contents as one the owner already has.
+type DuplicatePolicy string
+
+const (
+	// DuplicatePolicy_Link uses the existing portfolio instead of creating a new one.
+	DuplicatePolicy_Link DuplicatePolicy = "LINK"
+	// DuplicatePolicy_Warn creates a new portfolio, and records the existing one on the upload.
+	DuplicatePolicy_Warn DuplicatePolicy = "WARN"
+	// DuplicatePolicy_Reject fails the upload.
+	DuplicatePolicy_Reject DuplicatePolicy = "REJECT"
+)
+
+var DuplicatePolicyValues = []DuplicatePolicy{
+	DuplicatePolicy_Link,
+	DuplicatePolicy_Warn,
+	DuplicatePolicy_Reject,
+}
+
+func ParseDuplicatePolicy(s string) (DuplicatePolicy, error) {
+	switch s {
+	case "LINK":
+		return DuplicatePolicy_Link, nil
+	case "WARN":
+		return DuplicatePolicy_Warn, nil
+	case "REJECT":
+		return DuplicatePolicy_Reject, nil
+	}
+	return "", fmt.Errorf("unknown DuplicatePolicy: %q", s)
+}
+
 type IncompleteUploadID string
 type IncompleteUpload struct {
 	ID                IncompleteUploadID
@@ -425,6 +775,15 @@ type IncompleteUpload struct {
 	AdminDebugEnabled bool
 	Owner             *Owner
 	Blob              *Blob
+	ParseResult       *ParseResult
+	LogBlob           *Blob
+	DuplicatePolicy   DuplicatePolicy
+	// DuplicatePortfolios are the existing portfolios found to have the same
+	// contents as a portfolio parsed from this upload.
+	DuplicatePortfolios []PortfolioID
+	// ReplacesPortfolio is set when this upload holds new holdings for an
+	// existing portfolio, rather than creating new portfolios.
+	ReplacesPortfolio *Portfolio
 }
 
 func (o *IncompleteUpload) Clone() *IncompleteUpload {
@@ -432,18 +791,23 @@ func (o *IncompleteUpload) Clone() *IncompleteUpload {
 		return nil
 	}
 	return &IncompleteUpload{
-		ID:                o.ID,
-		Name:              o.Name,
-		Description:       o.Description,
-		CreatedAt:         o.CreatedAt,
-		Properties:        o.Properties.Clone(),
-		RanAt:             o.RanAt,
-		CompletedAt:       o.CompletedAt,
-		FailureCode:       o.FailureCode,
-		FailureMessage:    o.FailureMessage,
-		AdminDebugEnabled: o.AdminDebugEnabled,
-		Owner:             o.Owner.Clone(),
-		Blob:              o.Blob.Clone(),
+		ID:                  o.ID,
+		Name:                o.Name,
+		Description:         o.Description,
+		CreatedAt:           o.CreatedAt,
+		Properties:          o.Properties.Clone(),
+		RanAt:               o.RanAt,
+		CompletedAt:         o.CompletedAt,
+		FailureCode:         o.FailureCode,
+		FailureMessage:      o.FailureMessage,
+		AdminDebugEnabled:   o.AdminDebugEnabled,
+		Owner:               o.Owner.Clone(),
+		Blob:                o.Blob.Clone(),
+		ParseResult:         o.ParseResult.Clone(),
+		LogBlob:             o.LogBlob.Clone(),
+		DuplicatePolicy:     o.DuplicatePolicy,
+		DuplicatePortfolios: cloneSlice(o.DuplicatePortfolios),
+		ReplacesPortfolio:   o.ReplacesPortfolio.Clone(),
 	}
 }
 
@@ -458,6 +822,9 @@ type Portfolio struct {
 	Blob                           *Blob
 	AdminDebugEnabled              bool
 	NumberOfRows                   int
+	InvestorName                   string
+	PortfolioName                  string
+	MD5                            string
 	PortfolioGroupMemberships      []*PortfolioGroupMembership
 	PortfolioInitiativeMemberships []*PortfolioInitiativeMembership
 }
@@ -476,11 +843,42 @@ func (o *Portfolio) Clone() *Portfolio {
 		Blob:                           o.Blob.Clone(),
 		AdminDebugEnabled:              o.AdminDebugEnabled,
 		NumberOfRows:                   o.NumberOfRows,
+		InvestorName:                   o.InvestorName,
+		PortfolioName:                  o.PortfolioName,
+		MD5:                            o.MD5,
 		PortfolioGroupMemberships:      cloneAll(o.PortfolioGroupMemberships),
 		PortfolioInitiativeMemberships: cloneAll(o.PortfolioInitiativeMemberships),
 	}
 }
 
+// PortfolioVersion is a prior set of holdings for a portfolio, recorded when the
+// portfolio's holdings are replaced.
+type PortfolioVersionID string
+type PortfolioVersion struct {
+	ID           PortfolioVersionID
+	Portfolio    *Portfolio
+	Version      int
+	Blob         *Blob
+	NumberOfRows int
+	MD5          string
+	ReplacedAt   time.Time
+}
+
+func (o *PortfolioVersion) Clone() *PortfolioVersion {
+	if o == nil {
+		return nil
+	}
+	return &PortfolioVersion{
+		ID:           o.ID,
+		Portfolio:    o.Portfolio.Clone(),
+		Version:      o.Version,
+		Blob:         o.Blob.Clone(),
+		NumberOfRows: o.NumberOfRows,
+		MD5:          o.MD5,
+		ReplacedAt:   o.ReplacedAt,
+	}
+}
+
 type PortfolioGroupID string
 type PortfolioGroup struct {
 	ID                        PortfolioGroupID
@@ -543,8 +941,12 @@ func (o *PortfolioInitiativeMembership) Clone() *PortfolioInitiativeMembership {
 
 type PortfolioSnapshotID string
 type PortfolioSnapshot struct {
-	ID             PortfolioSnapshotID
-	PortfolioIDs   []PortfolioID
+	ID           PortfolioSnapshotID
+	PortfolioIDs []PortfolioID
+	// BlobIDs are the holdings of each portfolio in PortfolioIDs at the time the
+	// snapshot was taken, in the same order. It's empty for snapshots that
+	// predate portfolio versioning.
+	BlobIDs        []BlobID
 	Portfolio      *Portfolio
 	PortfolioGroup *PortfolioGroup
 	Initiatiative  *Initiative
@@ -562,6 +964,7 @@ func (o *PortfolioSnapshot) Clone() *PortfolioSnapshot {
 		PortfolioGroup: o.PortfolioGroup.Clone(),
 		Initiatiative:  o.Initiatiative.Clone(),
 		PortfolioIDs:   pids,
+		BlobIDs:        cloneSlice(o.BlobIDs),
 	}
 }
 
@@ -604,6 +1007,7 @@ type Analysis struct {
 	CompletedAt       time.Time
 	FailureCode       FailureCode
 	FailureMessage    string
+	ImageTag          string
 	Artifacts         []*AnalysisArtifact
 }
 
@@ -624,6 +1028,7 @@ func (o *Analysis) Clone() *Analysis {
 		CompletedAt:       o.CompletedAt,
 		FailureCode:       o.FailureCode,
 		FailureMessage:    o.FailureMessage,
+		ImageTag:          o.ImageTag,
 		Artifacts:         cloneAll(o.Artifacts),
 	}
 }
@@ -635,6 +1040,9 @@ type AnalysisArtifact struct {
 	Blob              *Blob
 	AdminDebugEnabled bool
 	SharedToPublic    bool
+	// AdminOnly artifacts, like task logs, can only be downloaded by admins,
+	// and only when AdminDebugEnabled is set.
+	AdminOnly bool
 }
 
 func (o *AnalysisArtifact) Clone() *AnalysisArtifact {
@@ -647,6 +1055,111 @@ func (o *AnalysisArtifact) Clone() *AnalysisArtifact {
 		Blob:              o.Blob.Clone(),
 		AdminDebugEnabled: o.AdminDebugEnabled,
 		SharedToPublic:    o.SharedToPublic,
+		AdminOnly:         o.AdminOnly,
+	}
+}
+
+type TaskType string
+
+const (
+	TaskType_ParsePortfolio  TaskType = "parse_portfolio"
+	TaskType_CreateReport    TaskType = "create_report"
+	TaskType_CreateAudit     TaskType = "create_audit"
+	TaskType_CreateDashboard TaskType = "create_dashboard"
+)
+
+var TaskTypeValues = []TaskType{
+	TaskType_ParsePortfolio,
+	TaskType_CreateReport,
+	TaskType_CreateAudit,
+	TaskType_CreateDashboard,
+}
+
+func ParseTaskType(s string) (TaskType, error) {
+	switch s {
+	case "parse_portfolio":
+		return TaskType_ParsePortfolio, nil
+	case "create_report":
+		return TaskType_CreateReport, nil
+	case "create_audit":
+		return TaskType_CreateAudit, nil
+	case "create_dashboard":
+		return TaskType_CreateDashboard, nil
+	}
+	return "", fmt.Errorf("unknown TaskType: %q", s)
+}
+
+type TaskState string
+
+const (
+	TaskState_Queued    TaskState = "QUEUED"
+	TaskState_Running   TaskState = "RUNNING"
+	TaskState_Succeeded TaskState = "SUCCEEDED"
+	TaskState_Failed    TaskState = "FAILED"
+)
+
+var TaskStateValues = []TaskState{
+	TaskState_Queued,
+	TaskState_Running,
+	TaskState_Succeeded,
+	TaskState_Failed,
+}
+
+func ParseTaskState(s string) (TaskState, error) {
+	switch s {
+	case "QUEUED":
+		return TaskState_Queued, nil
+	case "RUNNING":
+		return TaskState_Running, nil
+	case "SUCCEEDED":
+		return TaskState_Succeeded, nil
+	case "FAILED":
+		return TaskState_Failed, nil
+	}
+	return "", fmt.Errorf("unknown TaskState: %q", s)
+}
+
+type TaskID string
+type Task struct {
+	ID   TaskID
+	Type TaskType
+	// Owner is the owner of the entities that the task operates on.
+	Owner *Owner
+	// Analysis is only set for tasks that run an analysis.
+	Analysis *Analysis
+	// Request is the JSON-encoded request sent to the task runner, populated
+	// when the task is dispatched.
+	Request string
+	// RunnerID is the substrate-specific identifier for the task execution,
+	// like a Container Apps Job execution name.
+	RunnerID string
+	// ImageTag is the fully-qualified image the task ran with, like
+	// 'rmisa.azurecr.io/runner:latest'.
+	ImageTag     string
+	State        TaskState
+	AttemptCount int
+	CreatedAt    time.Time
+	StartedAt    time.Time
+	CompletedAt  time.Time
+}
+
+func (o *Task) Clone() *Task {
+	if o == nil {
+		return nil
+	}
+	return &Task{
+		ID:           o.ID,
+		Type:         o.Type,
+		Owner:        o.Owner.Clone(),
+		Analysis:     o.Analysis.Clone(),
+		Request:      o.Request,
+		RunnerID:     o.RunnerID,
+		ImageTag:     o.ImageTag,
+		State:        o.State,
+		AttemptCount: o.AttemptCount,
+		CreatedAt:    o.CreatedAt,
+		StartedAt:    o.StartedAt,
+		CompletedAt:  o.CompletedAt,
 	}
 }
 
@@ -665,6 +1178,9 @@ const (
 	AuditLogAction_DisableSharing    AuditLogAction = "DISABLE_SHARING"
 	AuditLogAction_ReadMetadata      AuditLogAction = "READ_METADATA"
 	AuditLogAction_TransferOwnership AuditLogAction = "TRANSFER_OWNERSHIP"
+	AuditLogAction_Retry             AuditLogAction = "RETRY"
+	AuditLogAction_Cancel            AuditLogAction = "CANCEL"
+	AuditLogAction_RevokeSessions    AuditLogAction = "REVOKE_SESSIONS"
 )
 
 var AuditLogActionValues = []AuditLogAction{
@@ -680,6 +1196,9 @@ var AuditLogActionValues = []AuditLogAction{
 	AuditLogAction_DisableSharing,
 	AuditLogAction_ReadMetadata,
 	AuditLogAction_TransferOwnership,
+	AuditLogAction_Retry,
+	AuditLogAction_Cancel,
+	AuditLogAction_RevokeSessions,
 }
 
 func ParseAuditLogAction(s string) (AuditLogAction, error) {
@@ -708,6 +1227,12 @@ func ParseAuditLogAction(s string) (AuditLogAction, error) {
 		return AuditLogAction_ReadMetadata, nil
 	case "TRANSFER_OWNERSHIP":
 		return AuditLogAction_TransferOwnership, nil
+	case "RETRY":
+		return AuditLogAction_Retry, nil
+	case "CANCEL":
+		return AuditLogAction_Cancel, nil
+	case "REVOKE_SESSIONS":
+		return AuditLogAction_RevokeSessions, nil
 	}
 	return "", fmt.Errorf("unknown AuditLogAction: %q", s)
 }
@@ -720,6 +1245,12 @@ const (
 	AuditLogActorType_Admin      AuditLogActorType = "ADMIN"
 	AuditLogActorType_SuperAdmin AuditLogActorType = "SUPER_ADMIN"
 	AuditLogActorType_System     AuditLogActorType = "SYSTEM"
+
+	// Actors authorized by their role in an initiative.
+	AuditLogActorType_InitiativeViewer               AuditLogActorType = "INITIATIVE_VIEWER"
+	AuditLogActorType_InitiativePortfolioContributor AuditLogActorType = "INITIATIVE_PORTFOLIO_CONTRIBUTOR"
+	AuditLogActorType_InitiativeAnalyst              AuditLogActorType = "INITIATIVE_ANALYST"
+	AuditLogActorType_InitiativeManager              AuditLogActorType = "INITIATIVE_MANAGER"
 )
 
 var AuditLogActorTypeValues = []AuditLogActorType{
@@ -728,6 +1259,10 @@ var AuditLogActorTypeValues = []AuditLogActorType{
 	AuditLogActorType_Admin,
 	AuditLogActorType_SuperAdmin,
 	AuditLogActorType_System,
+	AuditLogActorType_InitiativeViewer,
+	AuditLogActorType_InitiativePortfolioContributor,
+	AuditLogActorType_InitiativeAnalyst,
+	AuditLogActorType_InitiativeManager,
 }
 
 func ParseAuditLogActorType(s string) (AuditLogActorType, error) {
@@ -742,6 +1277,14 @@ func ParseAuditLogActorType(s string) (AuditLogActorType, error) {
 		return AuditLogActorType_SuperAdmin, nil
 	case "SYSTEM":
 		return AuditLogActorType_System, nil
+	case "INITIATIVE_VIEWER":
+		return AuditLogActorType_InitiativeViewer, nil
+	case "INITIATIVE_PORTFOLIO_CONTRIBUTOR":
+		return AuditLogActorType_InitiativePortfolioContributor, nil
+	case "INITIATIVE_ANALYST":
+		return AuditLogActorType_InitiativeAnalyst, nil
+	case "INITIATIVE_MANAGER":
+		return AuditLogActorType_InitiativeManager, nil
 	}
 	return "", fmt.Errorf("unknown AuditLogActorType: %q", s)
 }
@@ -758,6 +1301,9 @@ const (
 	AuditLogTargetType_PACTAVersion         AuditLogTargetType = "PACTA_VERSION"
 	AuditLogTargetType_Analysis             AuditLogTargetType = "ANALYSIS"
 	AuditLogTargetType_AnalysisArtifact     AuditLogTargetType = "ANALYSIS_ARTIFACT"
+	AuditLogTargetType_Task                 AuditLogTargetType = "TASK"
+	AuditLogTargetType_APIToken             AuditLogTargetType = "API_TOKEN"
+	AuditLogTargetType_UploadLimits         AuditLogTargetType = "UPLOAD_LIMITS"
 )
 
 var AuditLogTargetTypeValues = []AuditLogTargetType{
@@ -770,6 +1316,9 @@ var AuditLogTargetTypeValues = []AuditLogTargetType{
 	AuditLogTargetType_PACTAVersion,
 	AuditLogTargetType_Analysis,
 	AuditLogTargetType_AnalysisArtifact,
+	AuditLogTargetType_Task,
+	AuditLogTargetType_APIToken,
+	AuditLogTargetType_UploadLimits,
 }
 
 func ParseAuditLogTargetType(s string) (AuditLogTargetType, error) {
@@ -792,6 +1341,12 @@ func ParseAuditLogTargetType(s string) (AuditLogTargetType, error) {
 		return AuditLogTargetType_Analysis, nil
 	case "ANALYSIS_ARTIFACT":
 		return AuditLogTargetType_AnalysisArtifact, nil
+	case "TASK":
+		return AuditLogTargetType_Task, nil
+	case "API_TOKEN":
+		return AuditLogTargetType_APIToken, nil
+	case "UPLOAD_LIMITS":
+		return AuditLogTargetType_UploadLimits, nil
 	}
 	return "", fmt.Errorf("unknown AuditLogTargetType: %q", s)
 }
@@ -803,6 +1358,7 @@ type AuditLog struct {
 	ActorType            AuditLogActorType
 	ActorID              string
 	ActorOwner           *Owner
+	ActorAPITokenID      APITokenID
 	Action               AuditLogAction
 	PrimaryTargetType    AuditLogTargetType
 	PrimaryTargetID      string
@@ -822,6 +1378,7 @@ func (o *AuditLog) Clone() *AuditLog {
 		ActorType:            o.ActorType,
 		ActorID:              o.ActorID,
 		ActorOwner:           o.ActorOwner.Clone(),
+		ActorAPITokenID:      o.ActorAPITokenID,
 		Action:               o.Action,
 		PrimaryTargetType:    o.PrimaryTargetType,
 		PrimaryTargetID:      o.PrimaryTargetID,
@@ -852,3 +1409,12 @@ func clonePtr[T any](in *T) *T {
 	out := *in
 	return &out
 }
+
+func cloneSlice[T any](in []T) []T {
+	if in == nil {
+		return nil
+	}
+	out := make([]T, len(in))
+	copy(out, in)
+	return out
+}
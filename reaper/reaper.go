@@ -0,0 +1,287 @@
+// Package reaper finds async tasks that have been running for longer than we'd
+// ever expect them to, and marks them (and the analyses or incomplete uploads
+// they were working on) as failed.
+//
+// Task runners are fire-and-forget, so if a container crashes or is killed
+// before it can publish a completion or failure event, nothing else will ever
+// tell the server that the task is done.
+package reaper
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
+	"go.uber.org/zap"
+)
+
+const (
+	// systemActorID identifies the reaper as the actor in audit logs.
+	systemActorID = "task-reaper"
+	// systemOwnerID is the owner we attribute SYSTEM actions to.
+	systemOwnerID = "SYSTEM"
+)
+
+// errTaskClaimed is returned when a task finished, or is being reaped by
+// another server replica, after we loaded it.
+var errTaskClaimed = errors.New("task was finished or claimed by another reaper")
+
+type DB interface {
+	NoTxn(context.Context) db.Tx
+	Transactional(context.Context, func(tx db.Tx) error) error
+
+	UnfinishedTasks(tx db.Tx) ([]*pacta.Task, error)
+	LockUnfinishedTask(tx db.Tx, id pacta.TaskID) (*pacta.Task, error)
+	UpdateTask(tx db.Tx, id pacta.TaskID, mutations ...db.UpdateTaskFn) error
+
+	Analysis(tx db.Tx, id pacta.AnalysisID) (*pacta.Analysis, error)
+	UpdateAnalysis(tx db.Tx, id pacta.AnalysisID, mutations ...db.UpdateAnalysisFn) error
+
+	IncompleteUploads(tx db.Tx, ids []pacta.IncompleteUploadID) (map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, error)
+	UpdateIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID, mutations ...db.UpdateIncompleteUploadFn) error
+
+	CreateAuditLogs(tx db.Tx, as []*pacta.AuditLog) error
+}
+
+// Runner is optionally used to check with the underlying task runner whether a
+// task is still alive before we give up on it.
+type Runner interface {
+	TaskRunning(ctx context.Context, runnerID task.RunnerID) (bool, error)
+}
+
+type Config struct {
+	DB     DB
+	Logger *zap.Logger
+
+	// Deadlines is the maximum amount of time a task of a given type can run
+	// for before it is marked as failed. Every task.Type must have a deadline.
+	Deadlines map[task.Type]time.Duration
+
+	// Interval is how often to check for stuck tasks.
+	Interval time.Duration
+
+	// Runner, if set, is consulted before marking a task failed. Tasks that
+	// the runner reports as still running are left alone.
+	Runner Runner
+
+	Now func() time.Time
+}
+
+func (c *Config) validate() error {
+	if c.DB == nil {
+		return errors.New("no DB given")
+	}
+	if c.Logger == nil {
+		return errors.New("no logger given")
+	}
+	for _, tt := range []task.Type{task.ParsePortfolio, task.CreateReport, task.CreateAudit, task.CreateDashboard} {
+		d, ok := c.Deadlines[tt]
+		if !ok {
+			return fmt.Errorf("no deadline given for task type %q", tt)
+		}
+		if d <= 0 {
+			return fmt.Errorf("deadline for task type %q must be positive, was %s", tt, d)
+		}
+	}
+	if c.Interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+	if c.Now == nil {
+		return errors.New("no now function given")
+	}
+	return nil
+}
+
+type Reaper struct {
+	db        DB
+	logger    *zap.Logger
+	deadlines map[task.Type]time.Duration
+	interval  time.Duration
+	runner    Runner
+	now       func() time.Time
+}
+
+func New(cfg *Config) (*Reaper, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, fmt.Errorf("invalid config given: %w", err)
+	}
+	return &Reaper{
+		db:        cfg.DB,
+		logger:    cfg.Logger,
+		deadlines: cfg.Deadlines,
+		interval:  cfg.Interval,
+		runner:    cfg.Runner,
+		now:       cfg.Now,
+	}, nil
+}
+
+// Run checks for stuck tasks every interval until the context is cancelled.
+func (r *Reaper) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		if err := r.ReapOnce(ctx); err != nil {
+			r.logger.Error("failed to reap stuck tasks", zap.Error(err))
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReapOnce marks all tasks that are past their deadline as failed. Errors
+// reaping individual tasks are logged, so that one bad task doesn't block the
+// rest.
+func (r *Reaper) ReapOnce(ctx context.Context) error {
+	ts, err := r.db.UnfinishedTasks(r.db.NoTxn(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to load unfinished tasks: %w", err)
+	}
+	now := r.now()
+	for _, t := range ts {
+		deadline, ok := r.deadlines[task.Type(t.Type)]
+		if !ok {
+			r.logger.Error("no deadline for task type, skipping", zap.String("task_id", string(t.ID)), zap.String("task_type", string(t.Type)))
+			continue
+		}
+		start := t.CreatedAt
+		if !t.StartedAt.IsZero() {
+			start = t.StartedAt
+		}
+		if now.Sub(start) < deadline {
+			continue
+		}
+		if r.runner != nil && t.RunnerID != "" {
+			running, err := r.runner.TaskRunning(ctx, task.RunnerID(t.RunnerID))
+			if err != nil {
+				r.logger.Warn("failed to check task status with runner, reaping anyway", zap.String("task_id", string(t.ID)), zap.Error(err))
+			} else if running {
+				r.logger.Warn("task is past its deadline but still running, skipping", zap.String("task_id", string(t.ID)), zap.Duration("run_time", now.Sub(start)))
+				continue
+			}
+		}
+		if err := r.reap(ctx, t.ID, now); errors.Is(err, errTaskClaimed) {
+			r.logger.Debug("task was finished or claimed by another reaper, skipping", zap.String("task_id", string(t.ID)))
+			continue
+		} else if err != nil {
+			r.logger.Error("failed to reap task", zap.String("task_id", string(t.ID)), zap.Error(err))
+			continue
+		}
+		r.logger.Info("reaped stuck task",
+			zap.String("task_id", string(t.ID)),
+			zap.String("task_type", string(t.Type)),
+			zap.Duration("run_time", now.Sub(start)))
+	}
+	return nil
+}
+
+// reap marks the given task and what it was working on as failed. Every server
+// replica runs a reaper, so the task is locked first and re-read, to make sure
+// only one of them reaps it, and only if it still hasn't finished.
+func (r *Reaper) reap(ctx context.Context, id pacta.TaskID, now time.Time) error {
+	return r.db.Transactional(ctx, func(tx db.Tx) error {
+		t, err := r.db.LockUnfinishedTask(tx, id)
+		if db.IsNotFound(err) {
+			return errTaskClaimed
+		} else if err != nil {
+			return fmt.Errorf("locking task: %w", err)
+		}
+		failureMessage := fmt.Sprintf("task %s did not complete within %s", t.ID, r.deadlines[task.Type(t.Type)])
+		var auditLogs []*pacta.AuditLog
+		switch t.Type {
+		case pacta.TaskType_ParsePortfolio:
+			ius, err := r.incompleteUploadsForTask(tx, t)
+			if err != nil {
+				return fmt.Errorf("loading incomplete uploads: %w", err)
+			}
+			for _, iu := range ius {
+				if !iu.CompletedAt.IsZero() {
+					continue
+				}
+				err := r.db.UpdateIncompleteUpload(tx, iu.ID,
+					db.SetIncompleteUploadFailureCode(pacta.FailureCode_Timeout),
+					db.SetIncompleteUploadFailureMessage(failureMessage),
+					db.SetIncompleteUploadCompletedAt(now))
+				if err != nil {
+					return fmt.Errorf("updating incomplete upload %q: %w", iu.ID, err)
+				}
+				auditLogs = append(auditLogs, auditLog(pacta.AuditLogTargetType_IncompleteUpload, string(iu.ID), iu.Owner.ID, t))
+			}
+		case pacta.TaskType_CreateAudit, pacta.TaskType_CreateReport, pacta.TaskType_CreateDashboard:
+			if t.Analysis == nil {
+				return fmt.Errorf("analysis task has no analysis")
+			}
+			a, err := r.db.Analysis(tx, t.Analysis.ID)
+			if err != nil {
+				return fmt.Errorf("reading analysis: %w", err)
+			}
+			if a.CompletedAt.IsZero() {
+				err := r.db.UpdateAnalysis(tx, a.ID,
+					db.SetAnalysisFailureCode(pacta.FailureCode_Timeout),
+					db.SetAnalysisFailureMessage(failureMessage),
+					db.SetAnalysisCompletedAt(now))
+				if err != nil {
+					return fmt.Errorf("updating analysis: %w", err)
+				}
+				auditLogs = append(auditLogs, auditLog(pacta.AuditLogTargetType_Analysis, string(a.ID), a.Owner.ID, t))
+			}
+		default:
+			return fmt.Errorf("unknown task type %q", t.Type)
+		}
+		err = r.db.UpdateTask(tx, t.ID,
+			db.SetTaskState(pacta.TaskState_Failed),
+			db.SetTaskCompletedAt(now))
+		if err != nil {
+			return fmt.Errorf("updating task: %w", err)
+		}
+		auditLogs = append(auditLogs, auditLog(pacta.AuditLogTargetType_Task, string(t.ID), t.Owner.ID, nil))
+		if err := r.db.CreateAuditLogs(tx, auditLogs); err != nil {
+			return fmt.Errorf("creating audit logs: %w", err)
+		}
+		return nil
+	})
+}
+
+// incompleteUploadsForTask returns the incomplete uploads a parse task was
+// working on. Tasks that were never dispatched won't have a request recorded,
+// in which case there's nothing to update besides the task itself.
+func (r *Reaper) incompleteUploadsForTask(tx db.Tx, t *pacta.Task) (map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, error) {
+	if t.Request == "" {
+		return nil, nil
+	}
+	var req task.ParsePortfolioRequest
+	if err := json.Unmarshal([]byte(t.Request), &req); err != nil {
+		return nil, fmt.Errorf("failed to decode task request: %w", err)
+	}
+	if len(req.IncompleteUploadIDs) == 0 {
+		return nil, nil
+	}
+	return r.db.IncompleteUploads(tx, req.IncompleteUploadIDs)
+}
+
+// auditLog records the reaper updating the given target. If the update was
+// made on behalf of a task, that task is recorded as the secondary target.
+func auditLog(targetType pacta.AuditLogTargetType, targetID string, targetOwnerID pacta.OwnerID, t *pacta.Task) *pacta.AuditLog {
+	al := &pacta.AuditLog{
+		ActorType:          pacta.AuditLogActorType_System,
+		ActorID:            systemActorID,
+		ActorOwner:         &pacta.Owner{ID: systemOwnerID},
+		Action:             pacta.AuditLogAction_Update,
+		PrimaryTargetType:  targetType,
+		PrimaryTargetID:    targetID,
+		PrimaryTargetOwner: &pacta.Owner{ID: targetOwnerID},
+	}
+	if t != nil {
+		al.SecondaryTargetType = pacta.AuditLogTargetType_Task
+		al.SecondaryTargetID = string(t.ID)
+		al.SecondaryTargetOwner = &pacta.Owner{ID: t.Owner.ID}
+	}
+	return al
+}
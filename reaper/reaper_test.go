@@ -0,0 +1,308 @@
+package reaper
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/RMI/pacta/task"
+	"github.com/google/go-cmp/cmp"
+	"go.uber.org/zap/zaptest"
+)
+
+func TestReapOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, env := setup(t, now)
+
+	ownerID := pacta.OwnerID("owner.id1")
+	env.db.analyses = map[pacta.AnalysisID]*pacta.Analysis{
+		"analysis.stuck": {ID: "analysis.stuck", Owner: &pacta.Owner{ID: ownerID}},
+		"analysis.fresh": {ID: "analysis.fresh", Owner: &pacta.Owner{ID: ownerID}},
+	}
+	env.db.incompleteUploads = map[pacta.IncompleteUploadID]*pacta.IncompleteUpload{
+		"incompleteupload.stuck": {ID: "incompleteupload.stuck", Owner: &pacta.Owner{ID: ownerID}},
+	}
+	env.db.tasks = []*pacta.Task{
+		{
+			ID:        "task.stuck-report",
+			Type:      pacta.TaskType_CreateReport,
+			Owner:     &pacta.Owner{ID: ownerID},
+			Analysis:  &pacta.Analysis{ID: "analysis.stuck"},
+			State:     pacta.TaskState_Running,
+			CreatedAt: now.Add(-3 * time.Hour),
+			StartedAt: now.Add(-2 * time.Hour),
+		},
+		{
+			ID:        "task.fresh-report",
+			Type:      pacta.TaskType_CreateReport,
+			Owner:     &pacta.Owner{ID: ownerID},
+			Analysis:  &pacta.Analysis{ID: "analysis.fresh"},
+			State:     pacta.TaskState_Running,
+			CreatedAt: now.Add(-2 * time.Minute),
+			StartedAt: now.Add(-time.Minute),
+		},
+		{
+			ID:        "task.stuck-parse",
+			Type:      pacta.TaskType_ParsePortfolio,
+			Owner:     &pacta.Owner{ID: ownerID},
+			Request:   `{"IncompleteUploadIDs":["incompleteupload.stuck"]}`,
+			State:     pacta.TaskState_Queued,
+			CreatedAt: now.Add(-time.Hour),
+		},
+	}
+
+	if err := r.ReapOnce(context.Background()); err != nil {
+		t.Fatalf("ReapOnce: %v", err)
+	}
+
+	gotStates := map[pacta.TaskID]pacta.TaskState{}
+	for _, tsk := range env.db.tasks {
+		gotStates[tsk.ID] = tsk.State
+	}
+	wantStates := map[pacta.TaskID]pacta.TaskState{
+		"task.stuck-report": pacta.TaskState_Failed,
+		"task.fresh-report": pacta.TaskState_Running,
+		"task.stuck-parse":  pacta.TaskState_Failed,
+	}
+	if diff := cmp.Diff(wantStates, gotStates); diff != "" {
+		t.Errorf("unexpected task states (-want +got):\n%s", diff)
+	}
+
+	if got, want := env.db.analyses["analysis.stuck"].FailureCode, pacta.FailureCode_Timeout; got != want {
+		t.Errorf("stuck analysis failure code = %q, want %q", got, want)
+	}
+	if got := env.db.analyses["analysis.fresh"].FailureCode; got != "" {
+		t.Errorf("fresh analysis failure code = %q, want none", got)
+	}
+	if got, want := env.db.incompleteUploads["incompleteupload.stuck"].FailureCode, pacta.FailureCode_Timeout; got != want {
+		t.Errorf("stuck incomplete upload failure code = %q, want %q", got, want)
+	}
+
+	// One log for each updated entity, plus one for each task.
+	if got, want := len(env.db.gotAuditLogs), 4; got != want {
+		t.Fatalf("got %d audit logs, want %d", got, want)
+	}
+	for _, al := range env.db.gotAuditLogs {
+		if al.ActorType != pacta.AuditLogActorType_System {
+			t.Errorf("audit log for %q had actor type %q, want %q", al.PrimaryTargetID, al.ActorType, pacta.AuditLogActorType_System)
+		}
+	}
+}
+
+func TestReapOnceSkipsRunningTasks(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, env := setup(t, now)
+	env.runner.running = map[task.RunnerID]bool{"runner.id1": true}
+
+	ownerID := pacta.OwnerID("owner.id1")
+	env.db.analyses = map[pacta.AnalysisID]*pacta.Analysis{
+		"analysis.id1": {ID: "analysis.id1", Owner: &pacta.Owner{ID: ownerID}},
+	}
+	env.db.tasks = []*pacta.Task{{
+		ID:        "task.id1",
+		Type:      pacta.TaskType_CreateAudit,
+		Owner:     &pacta.Owner{ID: ownerID},
+		Analysis:  &pacta.Analysis{ID: "analysis.id1"},
+		RunnerID:  "runner.id1",
+		State:     pacta.TaskState_Running,
+		CreatedAt: now.Add(-3 * time.Hour),
+		StartedAt: now.Add(-3 * time.Hour),
+	}}
+
+	if err := r.ReapOnce(context.Background()); err != nil {
+		t.Fatalf("ReapOnce: %v", err)
+	}
+
+	if got, want := env.db.tasks[0].State, pacta.TaskState_Running; got != want {
+		t.Errorf("task state = %q, want %q", got, want)
+	}
+	if n := len(env.db.gotAuditLogs); n != 0 {
+		t.Errorf("got %d audit logs, want none", n)
+	}
+}
+
+func TestReapOnceSkipsClaimedTasks(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r, env := setup(t, now)
+
+	ownerID := pacta.OwnerID("owner.id1")
+	env.db.analyses = map[pacta.AnalysisID]*pacta.Analysis{
+		"analysis.id1": {ID: "analysis.id1", Owner: &pacta.Owner{ID: ownerID}},
+	}
+	env.db.tasks = []*pacta.Task{{
+		ID:        "task.id1",
+		Type:      pacta.TaskType_CreateDashboard,
+		Owner:     &pacta.Owner{ID: ownerID},
+		Analysis:  &pacta.Analysis{ID: "analysis.id1"},
+		State:     pacta.TaskState_Running,
+		CreatedAt: now.Add(-3 * time.Hour),
+		StartedAt: now.Add(-3 * time.Hour),
+	}}
+	// Another replica is already reaping this task.
+	env.db.locked = map[pacta.TaskID]bool{"task.id1": true}
+
+	if err := r.ReapOnce(context.Background()); err != nil {
+		t.Fatalf("ReapOnce: %v", err)
+	}
+
+	if got, want := env.db.tasks[0].State, pacta.TaskState_Running; got != want {
+		t.Errorf("task state = %q, want %q", got, want)
+	}
+	if got := env.db.analyses["analysis.id1"].FailureCode; got != "" {
+		t.Errorf("analysis failure code = %q, want none", got)
+	}
+	if n := len(env.db.gotAuditLogs); n != 0 {
+		t.Errorf("got %d audit logs, want none", n)
+	}
+}
+
+type testEnv struct {
+	db     *testDB
+	runner *testRunner
+}
+
+func setup(t *testing.T, now time.Time) (*Reaper, *testEnv) {
+	env := &testEnv{
+		db:     &testDB{},
+		runner: &testRunner{},
+	}
+	r, err := New(&Config{
+		DB:     env.db,
+		Logger: zaptest.NewLogger(t),
+		Deadlines: map[task.Type]time.Duration{
+			task.ParsePortfolio:  30 * time.Minute,
+			task.CreateReport:    time.Hour,
+			task.CreateAudit:     time.Hour,
+			task.CreateDashboard: time.Hour,
+		},
+		Interval: time.Minute,
+		Runner:   env.runner,
+		Now:      func() time.Time { return now },
+	})
+	if err != nil {
+		t.Fatalf("failed to init reaper: %v", err)
+	}
+	return r, env
+}
+
+type testTx struct{}
+
+func (testTx) Commit() error   { return nil }
+func (testTx) Rollback() error { return nil }
+
+type testDB struct {
+	// Recording inputs
+	gotAuditLogs []*pacta.AuditLog
+
+	// Hardcoded state, updated in place
+	tasks             []*pacta.Task
+	analyses          map[pacta.AnalysisID]*pacta.Analysis
+	incompleteUploads map[pacta.IncompleteUploadID]*pacta.IncompleteUpload
+	// locked tasks are being held by another transaction.
+	locked map[pacta.TaskID]bool
+}
+
+func (tdb *testDB) NoTxn(ctx context.Context) db.Tx {
+	return testTx{}
+}
+
+func (tdb *testDB) Transactional(ctx context.Context, fn func(tx db.Tx) error) error {
+	return fn(testTx{})
+}
+
+func (tdb *testDB) UnfinishedTasks(tx db.Tx) ([]*pacta.Task, error) {
+	var out []*pacta.Task
+	for _, t := range tdb.tasks {
+		if t.State == pacta.TaskState_Queued || t.State == pacta.TaskState_Running {
+			out = append(out, t.Clone())
+		}
+	}
+	return out, nil
+}
+
+func (tdb *testDB) LockUnfinishedTask(tx db.Tx, id pacta.TaskID) (*pacta.Task, error) {
+	for _, t := range tdb.tasks {
+		if t.ID != id || tdb.locked[id] {
+			continue
+		}
+		if t.State == pacta.TaskState_Queued || t.State == pacta.TaskState_Running {
+			return t.Clone(), nil
+		}
+	}
+	return nil, db.NotFound(id, "task")
+}
+
+func (tdb *testDB) UpdateTask(tx db.Tx, id pacta.TaskID, mutations ...db.UpdateTaskFn) error {
+	for _, t := range tdb.tasks {
+		if t.ID != id {
+			continue
+		}
+		for _, m := range mutations {
+			if err := m(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("task %q not found", id)
+}
+
+func (tdb *testDB) Analysis(tx db.Tx, id pacta.AnalysisID) (*pacta.Analysis, error) {
+	a, ok := tdb.analyses[id]
+	if !ok {
+		return nil, fmt.Errorf("analysis %q not found", id)
+	}
+	return a.Clone(), nil
+}
+
+func (tdb *testDB) UpdateAnalysis(tx db.Tx, id pacta.AnalysisID, mutations ...db.UpdateAnalysisFn) error {
+	a, ok := tdb.analyses[id]
+	if !ok {
+		return fmt.Errorf("analysis %q not found", id)
+	}
+	for _, m := range mutations {
+		if err := m(a); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tdb *testDB) IncompleteUploads(tx db.Tx, ids []pacta.IncompleteUploadID) (map[pacta.IncompleteUploadID]*pacta.IncompleteUpload, error) {
+	out := make(map[pacta.IncompleteUploadID]*pacta.IncompleteUpload)
+	for _, id := range ids {
+		if iu, ok := tdb.incompleteUploads[id]; ok {
+			out[id] = iu.Clone()
+		}
+	}
+	return out, nil
+}
+
+func (tdb *testDB) UpdateIncompleteUpload(tx db.Tx, id pacta.IncompleteUploadID, mutations ...db.UpdateIncompleteUploadFn) error {
+	iu, ok := tdb.incompleteUploads[id]
+	if !ok {
+		return fmt.Errorf("incomplete upload %q not found", id)
+	}
+	for _, m := range mutations {
+		if err := m(iu); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (tdb *testDB) CreateAuditLogs(tx db.Tx, as []*pacta.AuditLog) error {
+	tdb.gotAuditLogs = append(tdb.gotAuditLogs, as...)
+	return nil
+}
+
+type testRunner struct {
+	running map[task.RunnerID]bool
+}
+
+func (tr *testRunner) TaskRunning(ctx context.Context, runnerID task.RunnerID) (bool, error) {
+	return tr.running[runnerID], nil
+}
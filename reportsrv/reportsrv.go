@@ -52,6 +52,8 @@ type DB interface {
 	GetOwnerForUser(tx db.Tx, userID pacta.UserID) (pacta.OwnerID, error)
 	CreateAuditLog(tx db.Tx, log *pacta.AuditLog) (pacta.AuditLogID, error)
 	User(tx db.Tx, id pacta.UserID) (*pacta.User, error)
+	PortfolioSnapshots(tx db.Tx, ids []pacta.PortfolioSnapshotID) (map[pacta.PortfolioSnapshotID]*pacta.PortfolioSnapshot, error)
+	InitiativeUserRelationship(tx db.Tx, iid pacta.InitiativeID, uid pacta.UserID) (*pacta.InitiativeUserRelationship, error)
 }
 
 type Blob interface {
@@ -162,7 +164,7 @@ func (s *Server) serveReport(w http.ResponseWriter, r *http.Request) {
 			s.logger.Error("blob had invalid URI", zap.String("analysis_artifact_id", string(aa.ID)), zap.String("blob_uri", uri))
 			continue
 		}
-		_, uriPath, ok := strings.Cut(path, "/")
+		uriPath, ok := assetPath(path, reportPath)
 		if !ok {
 			s.logger.Error("path had no UUID prefix", zap.String("analysis_artifact_id", string(aa.ID)), zap.String("blob_uri", uri), zap.String("blob_path", path))
 			continue
@@ -200,6 +202,22 @@ func (s *Server) serveReport(w http.ResponseWriter, r *http.Request) {
 	return
 }
 
+// assetPath returns the path of an analysis output blob relative to the
+// analysis' output, e.g. report-output/report/index.html. Outputs are stored
+// under <analysis ID>/<scope>/, where the scope is the kind of snapshot the
+// analysis was run on, or just <analysis ID>/ for older analyses.
+func assetPath(blobPath, reportPath string) (string, bool) {
+	_, rest, ok := strings.Cut(blobPath, "/")
+	if !ok {
+		return "", false
+	}
+	if strings.HasPrefix(rest, reportPath) {
+		return rest, true
+	}
+	_, rest, ok = strings.Cut(rest, "/")
+	return rest, ok
+}
+
 func (s *Server) doAuthzAndAuditLog(a *pacta.Analysis, aa *pacta.AnalysisArtifact, w http.ResponseWriter, r *http.Request) bool {
 	ctx := r.Context()
 	const unauthenticatedUserID = "unauthenticated user"
@@ -249,6 +267,16 @@ func (s *Server) doAuthzAndAuditLog(a *pacta.Analysis, aa *pacta.AnalysisArtifac
 		auditLog.ActorType = pacta.AuditLogActorType_Owner
 		return allowIfAuditLogSaves()
 	}
+	role, err := s.initiativeRole(ctx, a, actorID)
+	if err != nil {
+		s.logger.Error("failed to get initiative role", zap.String("user_id", string(actorID)), zap.String("analysis_id", string(a.ID)), zap.Error(err))
+		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		return false
+	}
+	if role.CanViewResults() {
+		auditLog.ActorType = role.ActorType()
+		return allowIfAuditLogSaves()
+	}
 	if aa.AdminDebugEnabled {
 		user, err := s.db.User(s.db.NoTxn(ctx), actorID)
 		if err != nil {
@@ -266,6 +294,31 @@ func (s *Server) doAuthzAndAuditLog(a *pacta.Analysis, aa *pacta.AnalysisArtifac
 	return false
 }
 
+// initiativeRole returns the user's role in the initiative that the analysis
+// was run on, or the zero value if it wasn't run on an initiative or the user
+// isn't part of it. This matches the API's checks for analysis artifacts.
+func (s *Server) initiativeRole(ctx context.Context, a *pacta.Analysis, uID pacta.UserID) (pacta.InitiativeUserRole, error) {
+	if a.PortfolioSnapshot == nil {
+		return "", nil
+	}
+	snapshots, err := s.db.PortfolioSnapshots(s.db.NoTxn(ctx), []pacta.PortfolioSnapshotID{a.PortfolioSnapshot.ID})
+	if err != nil {
+		return "", fmt.Errorf("failed to look up portfolio snapshot: %w", err)
+	}
+	snapshot, ok := snapshots[a.PortfolioSnapshot.ID]
+	if !ok || snapshot.Initiatiative == nil {
+		return "", nil
+	}
+	iur, err := s.db.InitiativeUserRelationship(s.db.NoTxn(ctx), snapshot.Initiatiative.ID, uID)
+	if err != nil {
+		if db.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to look up initiative user relationship: %w", err)
+	}
+	return iur.Role, nil
+}
+
 func fileTypeToMIME(ft pacta.FileType) string {
 	switch ft {
 	case pacta.FileType_CSV:
@@ -179,6 +179,216 @@ func TestServeReport(t *testing.T) {
 	}
 }
 
+func TestServeReportScopedLayout(t *testing.T) {
+	srv, env := setup(t)
+	router := chi.NewRouter()
+	srv.RegisterHandlers(router)
+
+	userID := pacta.UserID("user.id1")
+	ownerID := pacta.OwnerID("owner.id1")
+	env.db.users = []*pacta.User{{ID: userID}}
+	env.db.userToOwner = map[pacta.UserID]pacta.OwnerID{userID: ownerID}
+
+	env.db.analyses = []*pacta.Analysis{{
+		ID:           "analysis.report",
+		Owner:        &pacta.Owner{ID: ownerID},
+		AnalysisType: pacta.AnalysisType_Report,
+	}, {
+		ID:           "analysis.dashboard",
+		Owner:        &pacta.Owner{ID: ownerID},
+		AnalysisType: pacta.AnalysisType_Dashboard,
+	}}
+	// The fake returns every artifact for every analysis, so this also checks
+	// that assets are matched on their full path.
+	env.db.analysisArtifacts = []*pacta.AnalysisArtifact{
+		{ID: "analysisartifact.id1", AnalysisID: "analysis.report", Blob: &pacta.Blob{ID: "blob.id1"}},
+		{ID: "analysisartifact.id2", AnalysisID: "analysis.report", Blob: &pacta.Blob{ID: "blob.id2"}},
+		{ID: "analysisartifact.id3", AnalysisID: "analysis.dashboard", Blob: &pacta.Blob{ID: "blob.id3"}},
+	}
+	env.db.blobs = map[pacta.BlobID]*pacta.Blob{
+		"blob.id1": {
+			ID:       "blob.id1",
+			BlobURI:  "test://reports/1111-2222-3333-4444/portfolio_group/report-output/report/index.html",
+			FileType: pacta.FileType_HTML,
+		},
+		"blob.id2": {
+			ID:       "blob.id2",
+			BlobURI:  "test://reports/1111-2222-3333-4444/portfolio_group/report-output/report/lib/app.js",
+			FileType: pacta.FileType_JS,
+		},
+		"blob.id3": {
+			ID:       "blob.id3",
+			BlobURI:  "test://dashboards/5555-6666-7777-8888/initiative/dashboard-output/index.html",
+			FileType: pacta.FileType_HTML,
+		},
+	}
+	env.blob.blobContents = map[string]string{
+		"test://reports/1111-2222-3333-4444/portfolio_group/report-output/report/index.html": "report index",
+		"test://reports/1111-2222-3333-4444/portfolio_group/report-output/report/lib/app.js": "report js",
+		"test://dashboards/5555-6666-7777-8888/initiative/dashboard-output/index.html":       "dashboard index",
+	}
+
+	cases := []struct {
+		analysisID string
+		path       string
+		wantCode   int
+		wantBody   string
+	}{{
+		analysisID: "analysis.report",
+		path:       "/report/analysis.report/",
+		wantCode:   http.StatusOK,
+		wantBody:   "report index",
+	}, {
+		analysisID: "analysis.report",
+		path:       "/report/analysis.report/lib/app.js",
+		wantCode:   http.StatusOK,
+		wantBody:   "report js",
+	}, {
+		analysisID: "analysis.report",
+		path:       "/report/analysis.report/portfolio_group/report-output/report/index.html",
+		wantCode:   http.StatusNotFound,
+	}, {
+		analysisID: "analysis.dashboard",
+		path:       "/report/analysis.dashboard/index.html",
+		wantCode:   http.StatusOK,
+		wantBody:   "dashboard index",
+	}}
+
+	for _, c := range cases {
+		t.Run(c.path, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"analysis_id"},
+					Values: []string{c.analysisID},
+				},
+			})
+			ctx = session.WithUserID(ctx, userID)
+			r := httptest.NewRequest(http.MethodGet, c.path, nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			if got := w.Result().StatusCode; got != c.wantCode {
+				t.Fatalf("got status code %d, want %d", got, c.wantCode)
+			}
+			if c.wantCode != http.StatusOK {
+				return
+			}
+			if got := w.Body.String(); got != c.wantBody {
+				t.Errorf("Response body = %q, want %q", got, c.wantBody)
+			}
+		})
+	}
+}
+
+func TestServeReportInitiativeRoles(t *testing.T) {
+	srv, env := setup(t)
+	router := chi.NewRouter()
+	srv.RegisterHandlers(router)
+
+	ownerUserID, viewerID, outsiderID := pacta.UserID("user.owner"), pacta.UserID("user.viewer"), pacta.UserID("user.outsider")
+	env.db.users = []*pacta.User{{ID: ownerUserID}, {ID: viewerID}, {ID: outsiderID}}
+	env.db.userToOwner = map[pacta.UserID]pacta.OwnerID{
+		ownerUserID: "owner.owner",
+		viewerID:    "owner.viewer",
+		outsiderID:  "owner.outsider",
+	}
+	env.db.analyses = []*pacta.Analysis{{
+		ID:                "analysis.initiative",
+		Owner:             &pacta.Owner{ID: "owner.owner"},
+		AnalysisType:      pacta.AnalysisType_Dashboard,
+		PortfolioSnapshot: &pacta.PortfolioSnapshot{ID: "portfoliosnapshot.initiative"},
+	}, {
+		ID:                "analysis.group",
+		Owner:             &pacta.Owner{ID: "owner.owner"},
+		AnalysisType:      pacta.AnalysisType_Dashboard,
+		PortfolioSnapshot: &pacta.PortfolioSnapshot{ID: "portfoliosnapshot.group"},
+	}}
+	env.db.snapshots = map[pacta.PortfolioSnapshotID]*pacta.PortfolioSnapshot{
+		"portfoliosnapshot.initiative": {ID: "portfoliosnapshot.initiative", Initiatiative: &pacta.Initiative{ID: "initiative.id1"}},
+		"portfoliosnapshot.group":      {ID: "portfoliosnapshot.group", PortfolioGroup: &pacta.PortfolioGroup{ID: "portfoliogroup.id1"}},
+	}
+	env.db.initiativeRoles = map[pacta.UserID]pacta.InitiativeUserRole{
+		viewerID: pacta.InitiativeUserRole_Viewer,
+	}
+	env.db.analysisArtifacts = []*pacta.AnalysisArtifact{
+		{ID: "analysisartifact.id1", Blob: &pacta.Blob{ID: "blob.id1"}},
+	}
+	env.db.blobs = map[pacta.BlobID]*pacta.Blob{
+		"blob.id1": {
+			ID:       "blob.id1",
+			BlobURI:  "test://dashboards/5555-6666-7777-8888/initiative/dashboard-output/index.html",
+			FileType: pacta.FileType_HTML,
+		},
+	}
+	env.blob.blobContents = map[string]string{
+		"test://dashboards/5555-6666-7777-8888/initiative/dashboard-output/index.html": "dashboard index",
+	}
+
+	cases := []struct {
+		desc          string
+		userID        pacta.UserID
+		analysisID    string
+		wantCode      int
+		wantActorType pacta.AuditLogActorType
+	}{{
+		desc:          "owner",
+		userID:        ownerUserID,
+		analysisID:    "analysis.initiative",
+		wantCode:      http.StatusOK,
+		wantActorType: pacta.AuditLogActorType_Owner,
+	}, {
+		desc:          "initiative viewer",
+		userID:        viewerID,
+		analysisID:    "analysis.initiative",
+		wantCode:      http.StatusOK,
+		wantActorType: pacta.AuditLogActorType_InitiativeViewer,
+	}, {
+		desc:       "not in initiative",
+		userID:     outsiderID,
+		analysisID: "analysis.initiative",
+		wantCode:   http.StatusUnauthorized,
+	}, {
+		desc:       "analysis not run on an initiative",
+		userID:     viewerID,
+		analysisID: "analysis.group",
+		wantCode:   http.StatusUnauthorized,
+	}}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			env.db.gotAuditLogs = nil
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, &chi.Context{
+				URLParams: chi.RouteParams{
+					Keys:   []string{"analysis_id"},
+					Values: []string{c.analysisID},
+				},
+			})
+			ctx = session.WithUserID(ctx, c.userID)
+			r := httptest.NewRequest(http.MethodGet, "/report/"+c.analysisID+"/index.html", nil).WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, r)
+
+			if got := w.Result().StatusCode; got != c.wantCode {
+				t.Fatalf("got status code %d, want %d", got, c.wantCode)
+			}
+			if c.wantCode != http.StatusOK {
+				if len(env.db.gotAuditLogs) != 0 {
+					t.Errorf("got %d audit logs for unauthorized request, want none", len(env.db.gotAuditLogs))
+				}
+				return
+			}
+			if len(env.db.gotAuditLogs) != 1 {
+				t.Fatalf("got %d audit logs, want 1", len(env.db.gotAuditLogs))
+			}
+			if got := env.db.gotAuditLogs[0].ActorType; got != c.wantActorType {
+				t.Errorf("audit log actor type = %q, want %q", got, c.wantActorType)
+			}
+		})
+	}
+}
+
 type testEnv struct {
 	db   *testDB
 	blob *testBlob
@@ -214,6 +424,8 @@ type testDB struct {
 	blobs             map[pacta.BlobID]*pacta.Blob
 	userToOwner       map[pacta.UserID]pacta.OwnerID
 	users             []*pacta.User
+	snapshots         map[pacta.PortfolioSnapshotID]*pacta.PortfolioSnapshot
+	initiativeRoles   map[pacta.UserID]pacta.InitiativeUserRole
 }
 
 func (tdb *testDB) NoTxn(ctx context.Context) db.Tx {
@@ -261,6 +473,22 @@ func (tdb *testDB) Analysis(tx db.Tx, id pacta.AnalysisID) (*pacta.Analysis, err
 	return nil, fmt.Errorf("analysis %q not found", id)
 }
 
+func (tdb *testDB) PortfolioSnapshots(tx db.Tx, ids []pacta.PortfolioSnapshotID) (map[pacta.PortfolioSnapshotID]*pacta.PortfolioSnapshot, error) {
+	return tdb.snapshots, nil
+}
+
+func (tdb *testDB) InitiativeUserRelationship(tx db.Tx, iid pacta.InitiativeID, uid pacta.UserID) (*pacta.InitiativeUserRelationship, error) {
+	role, ok := tdb.initiativeRoles[uid]
+	if !ok {
+		return nil, db.NotFound(uid, "initiative_user_relationship")
+	}
+	return &pacta.InitiativeUserRelationship{
+		Initiative: &pacta.Initiative{ID: iid},
+		User:       &pacta.User{ID: uid},
+		Role:       role,
+	}, nil
+}
+
 type testBlob struct {
 	// Recording input
 	gotURIs []string
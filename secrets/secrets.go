@@ -14,9 +14,20 @@ import (
 )
 
 type PACTAConfig struct {
-	AuthVerificationKey AuthVerificationKey
-	Postgres            *pgxpool.Config
-	RunnerConfig        RunnerConfig
+	AuthVerificationKeys AuthVerificationKeys
+	Postgres             *pgxpool.Config
+	RunnerConfig         RunnerConfig
+}
+
+// AuthVerificationKeys are the keys that JWTs from the credential service can
+// be signed with. There's more than one while a signing key is being rotated.
+type AuthVerificationKeys struct {
+	// Keys each have a distinct ID, which tokens name in their kid header.
+	Keys []AuthVerificationKey
+	// JWKSFile and JWKSURL optionally point to a JWKS document with more keys,
+	// which should be reloaded periodically. At most one of them is set.
+	JWKSFile string
+	JWKSURL  string
 }
 
 type AuthVerificationKey struct {
@@ -42,9 +53,15 @@ type Image struct {
 }
 
 type RawPACTAConfig struct {
-	PostgresConfig      *RawPostgresConfig
-	AuthVerificationKey *RawAuthVerificationKey
-	RunnerConfig        *RawRunnerConfig
+	PostgresConfig       *RawPostgresConfig
+	AuthVerificationKeys *RawAuthVerificationKeys
+	RunnerConfig         *RawRunnerConfig
+}
+
+type RawAuthVerificationKeys struct {
+	Keys     []*RawAuthVerificationKey
+	JWKSFile string
+	JWKSURL  string
 }
 
 type RawAuthVerificationKey struct {
@@ -80,9 +97,9 @@ func LoadPACTA(rawCfg *RawPACTAConfig) (*PACTAConfig, error) {
 		return nil, fmt.Errorf("failed to load 'postgres' config: %w", err)
 	}
 
-	authVerificationKey, err := parseAuthVerificationKey(rawCfg.AuthVerificationKey)
+	authVerificationKeys, err := parseAuthVerificationKeys(rawCfg.AuthVerificationKeys)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse auth verification key config: %w", err)
+		return nil, fmt.Errorf("failed to parse auth verification keys config: %w", err)
 	}
 
 	runnerConfig, err := parseRunnerConfig(rawCfg.RunnerConfig)
@@ -91,9 +108,9 @@ func LoadPACTA(rawCfg *RawPACTAConfig) (*PACTAConfig, error) {
 	}
 
 	return &PACTAConfig{
-		Postgres:            pgxCfg,
-		AuthVerificationKey: authVerificationKey,
-		RunnerConfig:        runnerConfig,
+		Postgres:             pgxCfg,
+		AuthVerificationKeys: authVerificationKeys,
+		RunnerConfig:         runnerConfig,
 	}, nil
 }
 
@@ -148,6 +165,40 @@ func loadPGXConfig(pgCfg *RawPostgresConfig) (*pgxpool.Config, error) {
 	return pgxCfg, nil
 }
 
+func parseAuthVerificationKeys(avks *RawAuthVerificationKeys) (AuthVerificationKeys, error) {
+	if avks == nil {
+		return AuthVerificationKeys{}, errors.New("no auth_public_keys were provided")
+	}
+
+	if avks.JWKSFile != "" && avks.JWKSURL != "" {
+		return AuthVerificationKeys{}, errors.New("only one of auth_jwks_file and auth_jwks_url can be provided")
+	}
+
+	if len(avks.Keys) == 0 && avks.JWKSFile == "" && avks.JWKSURL == "" {
+		return AuthVerificationKeys{}, errors.New("no auth_public_keys or auth JWKS document were provided")
+	}
+
+	var keys []AuthVerificationKey
+	seen := make(map[string]bool)
+	for i, avk := range avks.Keys {
+		key, err := parseAuthVerificationKey(avk)
+		if err != nil {
+			return AuthVerificationKeys{}, fmt.Errorf("failed to parse auth_public_key %d: %w", i, err)
+		}
+		if seen[key.ID] {
+			return AuthVerificationKeys{}, fmt.Errorf("auth_public_key.id %q was provided more than once", key.ID)
+		}
+		seen[key.ID] = true
+		keys = append(keys, key)
+	}
+
+	return AuthVerificationKeys{
+		Keys:     keys,
+		JWKSFile: avks.JWKSFile,
+		JWKSURL:  avks.JWKSURL,
+	}, nil
+}
+
 func parseAuthVerificationKey(avk *RawAuthVerificationKey) (AuthVerificationKey, error) {
 	if avk == nil {
 		return AuthVerificationKey{}, errors.New("no auth_public_key was provided")
@@ -0,0 +1,129 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"go.uber.org/zap"
+)
+
+// APITokenPrefix starts every API token secret, which lets us tell them apart
+// from the JWTs issued by the credential service.
+const APITokenPrefix = "pacta_pat_"
+
+// We don't record every use of a token, just enough to tell users whether a
+// token is still in use.
+const apiTokenLastUsedGranularity = time.Minute
+
+type apiTokenKey struct{}
+
+type APITokenDB interface {
+	NoTxn(context.Context) db.Tx
+	APITokenByHash(tx db.Tx, tokenHash string) (*pacta.APIToken, error)
+	UpdateAPIToken(tx db.Tx, id pacta.APITokenID, mutations ...db.UpdateAPITokenFn) error
+}
+
+// NewAPITokenSecret returns a new random token secret, and the hash of it that
+// should be stored.
+func NewAPITokenSecret() (string, string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", fmt.Errorf("failed to generate token secret: %w", err)
+	}
+	secret := APITokenPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return secret, HashAPITokenSecret(secret), nil
+}
+
+func HashAPITokenSecret(secret string) string {
+	h := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(h[:])
+}
+
+func WithAPIToken(c context.Context, t *pacta.APIToken) context.Context {
+	return context.WithValue(c, apiTokenKey{}, t)
+}
+
+// APITokenFromContext returns the API token the request was authenticated
+// with, or nil if it wasn't authenticated with one.
+func APITokenFromContext(ctx context.Context) *pacta.APIToken {
+	t, ok := ctx.Value(apiTokenKey{}).(*pacta.APIToken)
+	if !ok {
+		return nil
+	}
+	return t
+}
+
+// WithAPITokenAuthn authenticates requests that carry an API token as their
+// bearer token. Those requests skip the otherwise middleware entirely, as they
+// have no JWT. Requests without an API token go through otherwise as normal.
+// allowed decides whether the token may be used for the given request, based
+// on its scopes.
+func WithAPITokenAuthn(
+	logger *zap.Logger,
+	d APITokenDB,
+	now func() time.Time,
+	allowed func(*pacta.APIToken, *http.Request) bool,
+	otherwise ...func(http.Handler) http.Handler,
+) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		fallback := next
+		for i := len(otherwise) - 1; i >= 0; i-- {
+			fallback = otherwise[i](fallback)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret, ok := apiTokenSecretFromRequest(r)
+			if !ok {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			ctx := r.Context()
+			t, err := d.APITokenByHash(d.NoTxn(ctx), HashAPITokenSecret(secret))
+			if err != nil {
+				if !db.IsNotFound(err) {
+					logger.Error("failed to look up api token", zap.Error(err))
+				}
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			n := now()
+			if !t.RevokedAt.IsZero() || (!t.ExpiresAt.IsZero() && !n.Before(t.ExpiresAt)) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if !allowed(t, r) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+			if n.Sub(t.LastUsedAt) > apiTokenLastUsedGranularity {
+				if err := d.UpdateAPIToken(d.NoTxn(ctx), t.ID, db.SetAPITokenLastUsedAt(n)); err != nil {
+					// Not worth failing the request over.
+					logger.Warn("failed to record api token use", zap.String("api_token_id", string(t.ID)), zap.Error(err))
+				}
+			}
+			ctx = WithUserID(ctx, t.User.ID)
+			ctx = WithAPIToken(ctx, t)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func apiTokenSecretFromRequest(r *http.Request) (string, bool) {
+	authz := r.Header.Get("Authorization")
+	if len(authz) < 7 || !strings.EqualFold(authz[:7], "BEARER ") {
+		return "", false
+	}
+	secret := strings.TrimSpace(authz[7:])
+	if !strings.HasPrefix(secret, APITokenPrefix) {
+		return "", false
+	}
+	return secret, true
+}
@@ -0,0 +1,194 @@
+package session
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"go.uber.org/zap"
+)
+
+func TestWithAPITokenAuthn(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tokens := map[string]*pacta.APIToken{
+		"pacta_pat_valid": {
+			ID:   "apitoken.valid",
+			User: &pacta.User{ID: "user.valid"},
+		},
+		"pacta_pat_recent": {
+			ID:         "apitoken.recent",
+			User:       &pacta.User{ID: "user.recent"},
+			LastUsedAt: now.Add(-time.Second),
+		},
+		"pacta_pat_revoked": {
+			ID:        "apitoken.revoked",
+			User:      &pacta.User{ID: "user.revoked"},
+			RevokedAt: now.Add(-time.Hour),
+		},
+		"pacta_pat_expired": {
+			ID:        "apitoken.expired",
+			User:      &pacta.User{ID: "user.expired"},
+			ExpiresAt: now,
+		},
+		"pacta_pat_forbidden": {
+			ID:   "apitoken.forbidden",
+			User: &pacta.User{ID: "user.forbidden"},
+		},
+	}
+	allowed := func(t *pacta.APIToken, r *http.Request) bool {
+		return t.ID != "apitoken.forbidden"
+	}
+
+	tests := []struct {
+		desc         string
+		authz        string
+		wantStatus   int
+		wantFallback bool
+		wantUserID   pacta.UserID
+		wantTokenID  pacta.APITokenID
+		wantLastUsed bool
+	}{
+		{
+			desc:         "valid token",
+			authz:        "Bearer pacta_pat_valid",
+			wantStatus:   http.StatusOK,
+			wantUserID:   "user.valid",
+			wantTokenID:  "apitoken.valid",
+			wantLastUsed: true,
+		},
+		{
+			desc:        "recently used token",
+			authz:       "BEARER pacta_pat_recent",
+			wantStatus:  http.StatusOK,
+			wantUserID:  "user.recent",
+			wantTokenID: "apitoken.recent",
+		},
+		{
+			desc:       "unknown token",
+			authz:      "Bearer pacta_pat_unknown",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "revoked token",
+			authz:      "Bearer pacta_pat_revoked",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "expired token",
+			authz:      "Bearer pacta_pat_expired",
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "token not allowed for request",
+			authz:      "Bearer pacta_pat_forbidden",
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			desc:         "jwt",
+			authz:        "Bearer eyJhbGciOiJFZERTQSJ9.e30.sig",
+			wantStatus:   http.StatusOK,
+			wantFallback: true,
+		},
+		{
+			desc:         "no authorization",
+			wantStatus:   http.StatusOK,
+			wantFallback: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			d := &fakeAPITokenDB{tokens: tokens, lastUsed: make(map[pacta.APITokenID]time.Time)}
+			var (
+				gotFallback bool
+				gotCtx      context.Context
+			)
+			fallback := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					gotFallback = true
+					next.ServeHTTP(w, r)
+				})
+			}
+			nowFn := func() time.Time { return now }
+			h := WithAPITokenAuthn(zap.NewNop(), d, nowFn, allowed, fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotCtx = r.Context()
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/portfolios", nil)
+			if test.authz != "" {
+				r.Header.Set("Authorization", test.authz)
+			}
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != test.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, test.wantStatus)
+			}
+			if gotFallback != test.wantFallback {
+				t.Fatalf("fallback called = %t, want %t", gotFallback, test.wantFallback)
+			}
+			if test.wantStatus != http.StatusOK || test.wantFallback {
+				if gotFallback && APITokenFromContext(gotCtx) != nil {
+					t.Error("expected no API token in context for fallback request")
+				}
+				return
+			}
+
+			// Both values must survive, the user ID isn't overwritten by the token.
+			userID, err := UserIDFromContext(gotCtx)
+			if err != nil {
+				t.Fatalf("getting user ID: %v", err)
+			}
+			if userID != test.wantUserID {
+				t.Errorf("user ID = %q, want %q", userID, test.wantUserID)
+			}
+			tok := APITokenFromContext(gotCtx)
+			if tok == nil {
+				t.Fatal("expected API token in context")
+			}
+			if tok.ID != test.wantTokenID {
+				t.Errorf("API token ID = %q, want %q", tok.ID, test.wantTokenID)
+			}
+			gotLastUsed, ok := d.lastUsed[test.wantTokenID]
+			if ok != test.wantLastUsed {
+				t.Fatalf("last used updated = %t, want %t", ok, test.wantLastUsed)
+			}
+			if ok && !gotLastUsed.Equal(now) {
+				t.Errorf("last used = %v, want %v", gotLastUsed, now)
+			}
+		})
+	}
+}
+
+type fakeAPITokenDB struct {
+	tokens   map[string]*pacta.APIToken
+	lastUsed map[pacta.APITokenID]time.Time
+}
+
+func (f *fakeAPITokenDB) NoTxn(context.Context) db.Tx {
+	return nil
+}
+
+func (f *fakeAPITokenDB) APITokenByHash(tx db.Tx, tokenHash string) (*pacta.APIToken, error) {
+	for secret, t := range f.tokens {
+		if HashAPITokenSecret(secret) == tokenHash {
+			return t.Clone(), nil
+		}
+	}
+	return nil, db.NotFound(tokenHash, "api_token")
+}
+
+func (f *fakeAPITokenDB) UpdateAPIToken(tx db.Tx, id pacta.APITokenID, mutations ...db.UpdateAPITokenFn) error {
+	t := &pacta.APIToken{ID: id}
+	for _, m := range mutations {
+		if err := m(t); err != nil {
+			return err
+		}
+	}
+	f.lastUsed[id] = t.LastUsedAt
+	return nil
+}
@@ -0,0 +1,188 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.uber.org/zap"
+)
+
+// KeySet holds the keys that JWTs can be verified with, either the Ed25519 keys
+// of the credential service or the keys of an external identity provider.
+// Besides the keys it's created with, it can load more from a JWKS document,
+// which is reloaded periodically so that keys can be rotated without a
+// redeploy.
+type KeySet struct {
+	logger   *zap.Logger
+	static   jwk.Set
+	load     func(context.Context) (jwk.Set, error)
+	keyTypes []jwa.KeyType
+
+	mu   sync.RWMutex
+	keys jwk.Set
+}
+
+// NewKeySet returns a key set with the given keys, plus any loaded by load,
+// which can be nil. Keys from load never replace a static key with the same ID.
+func NewKeySet(logger *zap.Logger, static jwk.Set, load func(context.Context) (jwk.Set, error)) *KeySet {
+	return newKeySet(logger, static, load, jwa.OKP)
+}
+
+// NewProviderKeySet returns a key set with only the keys loaded by load, for
+// verifying tokens from an external identity provider. Unlike the credential
+// service, providers usually sign with RSA or EC keys, so those are accepted
+// too.
+func NewProviderKeySet(logger *zap.Logger, load func(context.Context) (jwk.Set, error)) *KeySet {
+	return newKeySet(logger, jwk.NewSet(), load, jwa.OKP, jwa.RSA, jwa.EC)
+}
+
+func newKeySet(logger *zap.Logger, static jwk.Set, load func(context.Context) (jwk.Set, error), keyTypes ...jwa.KeyType) *KeySet {
+	return &KeySet{
+		logger:   logger,
+		static:   static,
+		load:     load,
+		keyTypes: keyTypes,
+		keys:     static,
+	}
+}
+
+// Run reloads the key set on the given interval until the context is
+// cancelled. Failed reloads keep the previous keys.
+func (ks *KeySet) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if err := ks.Reload(ctx); err != nil {
+			ks.logger.Error("failed to reload JWT verification keys", zap.Error(err))
+		}
+	}
+}
+
+// Reload replaces the loaded keys with the latest ones from the JWKS document.
+func (ks *KeySet) Reload(ctx context.Context) error {
+	if ks.load == nil {
+		return nil
+	}
+	loaded, err := ks.load(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load keys: %w", err)
+	}
+	keys, err := ks.static.Clone()
+	if err != nil {
+		return fmt.Errorf("failed to copy static keys: %w", err)
+	}
+	for i := 0; i < loaded.Len(); i++ {
+		k, _ := loaded.Key(i)
+		if !slices.Contains(ks.keyTypes, k.KeyType()) {
+			ks.logger.Warn("skipping JWT verification key with unsupported key type", zap.String("kid", k.KeyID()), zap.String("key_type", string(k.KeyType())))
+			continue
+		}
+		if _, ok := keys.LookupKeyID(k.KeyID()); ok {
+			continue
+		}
+		if err := keys.AddKey(k); err != nil {
+			return fmt.Errorf("failed to add key %q: %w", k.KeyID(), err)
+		}
+	}
+	ks.mu.Lock()
+	defer ks.mu.Unlock()
+	ks.keys = keys
+	return nil
+}
+
+// FetchKeys implements jws.KeyProvider. Tokens that name a key are only
+// verified with that key. Tokens from before we had more than one key don't
+// name one, so those are tried against every key. Either way, a key is only
+// used with the algorithm in the token's header if it's one that the key
+// supports.
+func (ks *KeySet) FetchKeys(_ context.Context, sink jws.KeySink, sig *jws.Signature, _ *jws.Message) error {
+	ks.mu.RLock()
+	keys := ks.keys
+	ks.mu.RUnlock()
+	alg := sig.ProtectedHeaders().Algorithm()
+
+	if kid := sig.ProtectedHeaders().KeyID(); kid != "" {
+		k, ok := keys.LookupKeyID(kid)
+		if !ok {
+			return fmt.Errorf("no key with ID %q", kid)
+		}
+		if !keySupportsAlgorithm(k, alg) {
+			return fmt.Errorf("key %q can't be used with algorithm %q", kid, alg)
+		}
+		sink.Key(alg, k)
+		return nil
+	}
+	for i := 0; i < keys.Len(); i++ {
+		k, _ := keys.Key(i)
+		if keySupportsAlgorithm(k, alg) {
+			sink.Key(alg, k)
+		}
+	}
+	return nil
+}
+
+func keySupportsAlgorithm(k jwk.Key, alg jwa.SignatureAlgorithm) bool {
+	if ka := k.Algorithm().String(); ka != "" && ka != alg.String() {
+		return false
+	}
+	switch k.KeyType() {
+	case jwa.OKP:
+		return alg == jwa.EdDSA
+	case jwa.RSA:
+		switch alg {
+		case jwa.RS256, jwa.RS384, jwa.RS512, jwa.PS256, jwa.PS384, jwa.PS512:
+			return true
+		}
+	case jwa.EC:
+		switch alg {
+		case jwa.ES256, jwa.ES384, jwa.ES512:
+			return true
+		}
+	}
+	return false
+}
+
+// Verifier works like jwtauth.Verifier, but verifies tokens with any of the
+// keys in the key set.
+func Verifier(ks *KeySet) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			token, err := ks.verifyRequest(r)
+			ctx := jwtauth.NewContext(r.Context(), token, err)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+func (ks *KeySet) verifyRequest(r *http.Request) (jwt.Token, error) {
+	tokStr := jwtauth.TokenFromHeader(r)
+	if tokStr == "" {
+		tokStr = jwtauth.TokenFromCookie(r)
+	}
+	if tokStr == "" {
+		return nil, jwtauth.ErrNoTokenFound
+	}
+	// Like jwtauth, we validate separately so that the errors match.
+	token, err := jwt.ParseString(tokStr, jwt.WithKeyProvider(ks), jwt.WithValidate(false))
+	if err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+	if err := jwt.Validate(token); err != nil {
+		return token, jwtauth.ErrorReason(err)
+	}
+	return token, nil
+}
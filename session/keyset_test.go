@@ -0,0 +1,307 @@
+package session
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/keyutil"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jws"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.uber.org/zap"
+)
+
+func TestKeySetRotation(t *testing.T) {
+	ctx := context.Background()
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+
+	oldKey, newKey, newerKey := keyWithIDForTesting(t, "old"), keyWithIDForTesting(t, "new"), keyWithIDForTesting(t, "newer")
+	unknownKey := keyWithIDForTesting(t, "unknown")
+	// A key in the JWKS document can't replace a statically configured one.
+	impostorKey := keyWithIDForTesting(t, "old")
+
+	static := jwk.NewSet()
+	oldPub, err := oldKey.PublicKey()
+	if err != nil {
+		t.Fatalf("getting public key: %v", err)
+	}
+	if err := static.AddKey(oldPub); err != nil {
+		t.Fatalf("adding static key: %v", err)
+	}
+	for _, k := range []jwk.Key{newKey, impostorKey} {
+		if err := keyutil.AddToJWKSFile(jwksPath, k); err != nil {
+			t.Fatalf("adding key to JWKS file: %v", err)
+		}
+	}
+	ks := NewKeySet(zap.NewNop(), static, func(context.Context) (jwk.Set, error) {
+		return jwk.ReadFile(jwksPath)
+	})
+
+	check := func(desc string, key jwk.Key, withKID, wantValid bool) {
+		t.Helper()
+		tok := signWithKeyForTesting(t, key, withKID)
+		if gotValid := verifyForTesting(ks, tok); gotValid != wantValid {
+			t.Errorf("%s: valid = %t, want %t", desc, gotValid, wantValid)
+		}
+	}
+
+	// Before the first reload, only the static keys are known.
+	check("old key before reload", oldKey, true, true)
+	check("new key before reload", newKey, true, false)
+
+	if err := ks.Reload(ctx); err != nil {
+		t.Fatalf("reloading keys: %v", err)
+	}
+	check("old key", oldKey, true, true)
+	check("old key without kid", oldKey, false, true)
+	check("new key", newKey, true, true)
+	check("new key without kid", newKey, false, true)
+	check("unknown key", unknownKey, true, false)
+	check("unknown key without kid", unknownKey, false, false)
+	check("impostor key", impostorKey, true, false)
+
+	if err := keyutil.AddToJWKSFile(jwksPath, newerKey); err != nil {
+		t.Fatalf("adding key to JWKS file: %v", err)
+	}
+	check("newer key before reload", newerKey, true, false)
+	if err := ks.Reload(ctx); err != nil {
+		t.Fatalf("reloading keys: %v", err)
+	}
+	check("newer key", newerKey, true, true)
+	check("new key after second reload", newKey, true, true)
+}
+
+func TestProviderKeySet(t *testing.T) {
+	ctx := context.Background()
+	jwksPath := filepath.Join(t.TempDir(), "jwks.json")
+
+	rsaPriv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating RSA key: %v", err)
+	}
+	ecPriv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating EC key: %v", err)
+	}
+	rsaKey, ecKey := rawKeyWithIDForTesting(t, "rsa", rsaPriv), rawKeyWithIDForTesting(t, "ec", ecPriv)
+	edKey := keyWithIDForTesting(t, "ed")
+	for _, k := range []jwk.Key{rsaKey, ecKey, edKey} {
+		if err := keyutil.AddToJWKSFile(jwksPath, k); err != nil {
+			t.Fatalf("adding key to JWKS file: %v", err)
+		}
+	}
+	load := func(context.Context) (jwk.Set, error) { return jwk.ReadFile(jwksPath) }
+
+	providerKS := NewProviderKeySet(zap.NewNop(), load)
+	// The credential service only uses Ed25519 keys, so its key set ignores the
+	// others.
+	credentialKS := NewKeySet(zap.NewNop(), jwk.NewSet(), load)
+	for _, ks := range []*KeySet{providerKS, credentialKS} {
+		if err := ks.Reload(ctx); err != nil {
+			t.Fatalf("reloading keys: %v", err)
+		}
+	}
+
+	// A classic attack is to sign with HMAC, using the public key as the secret.
+	rsaPub, err := rsaKey.PublicKey()
+	if err != nil {
+		t.Fatalf("getting public key: %v", err)
+	}
+	rsaPubJSON, err := json.Marshal(rsaPub)
+	if err != nil {
+		t.Fatalf("marshalling public key: %v", err)
+	}
+	hmacKey, err := jwk.FromRaw(rsaPubJSON)
+	if err != nil {
+		t.Fatalf("making HMAC key: %v", err)
+	}
+
+	tests := []struct {
+		desc           string
+		key            jwk.Key
+		alg            jwa.SignatureAlgorithm
+		kid            string
+		wantProvider   bool
+		wantCredential bool
+	}{
+		{desc: "RSA key", key: rsaKey, alg: jwa.RS256, kid: "rsa", wantProvider: true},
+		{desc: "RSA key without kid", key: rsaKey, alg: jwa.RS256, wantProvider: true},
+		{desc: "RSA-PSS", key: rsaKey, alg: jwa.PS256, kid: "rsa", wantProvider: true},
+		{desc: "EC key", key: ecKey, alg: jwa.ES256, kid: "ec", wantProvider: true},
+		{desc: "EC key without kid", key: ecKey, alg: jwa.ES256, wantProvider: true},
+		{desc: "Ed25519 key", key: edKey, alg: jwa.EdDSA, kid: "ed", wantProvider: true, wantCredential: true},
+		{desc: "algorithm doesn't match named key", key: ecKey, alg: jwa.ES256, kid: "rsa"},
+		{desc: "HMAC with public key", key: hmacKey, alg: jwa.HS256, kid: "rsa"},
+		{desc: "HMAC with public key without kid", key: hmacKey, alg: jwa.HS256},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			tok := signWithAlgForTesting(t, test.key, test.alg, test.kid)
+			if got := verifyForTesting(providerKS, tok); got != test.wantProvider {
+				t.Errorf("provider key set valid = %t, want %t", got, test.wantProvider)
+			}
+			if got := verifyForTesting(credentialKS, tok); got != test.wantCredential {
+				t.Errorf("credential service key set valid = %t, want %t", got, test.wantCredential)
+			}
+		})
+	}
+}
+
+func TestVerifierErrors(t *testing.T) {
+	key := keyWithIDForTesting(t, "key")
+	pub, err := key.PublicKey()
+	if err != nil {
+		t.Fatalf("getting public key: %v", err)
+	}
+	static := jwk.NewSet()
+	if err := static.AddKey(pub); err != nil {
+		t.Fatalf("adding static key: %v", err)
+	}
+	ks := NewKeySet(zap.NewNop(), static, nil)
+
+	tests := []struct {
+		desc    string
+		token   string
+		wantErr error
+	}{
+		{
+			desc:    "no token",
+			wantErr: jwtauth.ErrNoTokenFound,
+		},
+		{
+			desc:  "valid",
+			token: signWithKeyForTesting(t, key, true),
+		},
+		{
+			desc:    "expired",
+			token:   signWithExpiryForTesting(t, key, -time.Hour),
+			wantErr: jwtauth.ErrExpired,
+		},
+		{
+			desc:    "garbage",
+			token:   "not.a.token",
+			wantErr: jwtauth.ErrUnauthorized,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var gotErr error
+			h := Verifier(ks)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _, gotErr = jwtauth.FromContext(r.Context())
+			}))
+			r := httptest.NewRequest(http.MethodGet, "/portfolios", nil)
+			if test.token != "" {
+				r.Header.Set("Authorization", "Bearer "+test.token)
+			}
+			h.ServeHTTP(httptest.NewRecorder(), r)
+			if gotErr != test.wantErr {
+				t.Errorf("error = %v, want %v", gotErr, test.wantErr)
+			}
+		})
+	}
+}
+
+func keyWithIDForTesting(t *testing.T, kid string) jwk.Key {
+	t.Helper()
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	k, err := keyutil.ED25519JWK(kid, priv)
+	if err != nil {
+		t.Fatalf("making JWK: %v", err)
+	}
+	return k
+}
+
+func rawKeyWithIDForTesting(t *testing.T, kid string, raw any) jwk.Key {
+	t.Helper()
+	k, err := jwk.FromRaw(raw)
+	if err != nil {
+		t.Fatalf("making JWK: %v", err)
+	}
+	if err := k.Set(jwk.KeyIDKey, kid); err != nil {
+		t.Fatalf("setting key ID: %v", err)
+	}
+	return k
+}
+
+// signWithAlgForTesting signs a token with the given key and algorithm, naming
+// the given key ID in the header, if any.
+func signWithAlgForTesting(t *testing.T, key jwk.Key, alg jwa.SignatureAlgorithm, kid string) string {
+	t.Helper()
+	key, err := key.Clone()
+	if err != nil {
+		t.Fatalf("cloning key: %v", err)
+	}
+	if err := key.Remove(jwk.KeyIDKey); err != nil {
+		t.Fatalf("removing key ID: %v", err)
+	}
+	tok, err := jwt.NewBuilder().
+		Subject("test123").
+		Expiration(time.Now().Add(time.Hour)).
+		Build()
+	if err != nil {
+		t.Fatalf("building token: %v", err)
+	}
+	hdrs := jws.NewHeaders()
+	if kid != "" {
+		if err := hdrs.Set(jws.KeyIDKey, kid); err != nil {
+			t.Fatalf("setting key ID: %v", err)
+		}
+	}
+	signed, err := jwt.Sign(tok, jwt.WithKey(alg, key, jws.WithProtectedHeaders(hdrs)))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return string(signed)
+}
+
+func signWithKeyForTesting(t *testing.T, key jwk.Key, withKID bool) string {
+	t.Helper()
+	if !withKID {
+		var err error
+		if key, err = key.Clone(); err != nil {
+			t.Fatalf("cloning key: %v", err)
+		}
+		if err := key.Remove(jwk.KeyIDKey); err != nil {
+			t.Fatalf("removing key ID: %v", err)
+		}
+	}
+	return signWithExpiryForTesting(t, key, time.Hour)
+}
+
+func signWithExpiryForTesting(t *testing.T, key jwk.Key, expiresIn time.Duration) string {
+	t.Helper()
+	tok, err := jwt.NewBuilder().
+		Subject("test123").
+		Expiration(time.Now().Add(expiresIn)).
+		Build()
+	if err != nil {
+		t.Fatalf("building token: %v", err)
+	}
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.EdDSA, key))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return string(signed)
+}
+
+func verifyForTesting(ks *KeySet, tok string) bool {
+	r := httptest.NewRequest(http.MethodGet, "/portfolios", nil)
+	r.Header.Set("Authorization", "Bearer "+tok)
+	_, err := ks.verifyRequest(r)
+	return err == nil
+}
@@ -0,0 +1,140 @@
+package session
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.uber.org/zap"
+)
+
+// Issuer is an external identity provider whose tokens we accept in addition
+// to the ones issued by the credential service.
+type Issuer struct {
+	// URL must exactly match the iss claim of the provider's tokens.
+	URL string
+	// Audience must be in the aud claim of the provider's tokens, usually it's
+	// the client ID we're registered with.
+	Audience string
+	// Mechanism is what users authenticated by this issuer are recorded as.
+	Mechanism pacta.AuthnMechanism
+	// KeySet holds the keys that the provider signs tokens with, usually loaded
+	// from its JWKS document.
+	KeySet *KeySet
+	// EmailDomains are the domains of the email addresses that the provider is
+	// trusted to assert, usually just the partner institution's own. Accounts
+	// are linked by email, so without this a provider could sign in as anyone.
+	EmailDomains []string
+}
+
+// AllowsEmail reports whether the issuer is trusted to assert the given email
+// address.
+func (i *Issuer) AllowsEmail(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := email[at+1:]
+	for _, d := range i.EmailDomains {
+		if strings.EqualFold(d, domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// AuthnID returns the identifier we store for the subject of one of the
+// issuer's tokens. Subjects are only unique per issuer, so they're namespaced
+// by the issuer's URL.
+func (i *Issuer) AuthnID(sub string) string {
+	return i.URL + "#" + sub
+}
+
+// WithIssuerAuthn authenticates requests that carry a token from one of the
+// given issuers. The verified token is put in the context the same way
+// jwtauth.Verifier does, so code that reads claims works with either kind of
+// token. Requests with any other token go through otherwise as normal.
+func WithIssuerAuthn(
+	logger *zap.Logger,
+	d DB,
+	issuers []*Issuer,
+	otherwise ...func(http.Handler) http.Handler,
+) func(http.Handler) http.Handler {
+	byURL := make(map[string]*Issuer)
+	for _, iss := range issuers {
+		byURL[iss.URL] = iss
+	}
+	return func(next http.Handler) http.Handler {
+		fallback := next
+		for i := len(otherwise) - 1; i >= 0; i-- {
+			fallback = otherwise[i](fallback)
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokStr := jwtauth.TokenFromHeader(r)
+			if tokStr == "" {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			// We only peek at the issuer to decide which keys to verify with, the
+			// token isn't trusted until it's been parsed again below.
+			unverified, err := jwt.ParseInsecure([]byte(tokStr))
+			if err != nil {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			iss, ok := byURL[unverified.Issuer()]
+			if !ok {
+				fallback.ServeHTTP(w, r)
+				return
+			}
+			tok, err := jwt.ParseString(tokStr,
+				jwt.WithKeyProvider(iss.KeySet),
+				jwt.WithValidate(true),
+				jwt.WithIssuer(iss.URL),
+				jwt.WithAudience(iss.Audience),
+			)
+			if err != nil {
+				logger.Info("rejected token from issuer", zap.String("issuer", iss.URL), zap.Error(err))
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if tok.Subject() == "" {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			if email, _ := tok.PrivateClaims()["email"].(string); !iss.AllowsEmail(email) {
+				logger.Info("rejected token for email outside of issuer's domains", zap.String("issuer", iss.URL), zap.String("email", email))
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+			ctx := jwtauth.NewContext(r.Context(), tok, nil)
+			authnID := iss.AuthnID(tok.Subject())
+			ctx = WithAuthnIdentity(ctx, iss.Mechanism, authnID)
+			user, err := d.UserByAuthn(d.NoTxn(ctx), iss.Mechanism, authnID)
+			if err != nil && !db.IsNotFound(err) {
+				logger.Error("failed to look up user by authn", zap.String("issuer", iss.URL), zap.Error(err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+			if err == nil {
+				revoked, err := d.IsSessionRevoked(d.NoTxn(ctx), user.ID, tok.JwtID(), issuedAt(tok))
+				if err != nil {
+					logger.Error("failed to check if session was revoked", zap.String("issuer", iss.URL), zap.Error(err))
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+					return
+				}
+				if revoked {
+					http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+					return
+				}
+				ctx = WithUserID(ctx, user.ID)
+			}
+			// Users that haven't completed the authentication followup yet don't
+			// exist, just like with WithAuthn they get a context without a user ID.
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,256 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
+	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwa"
+	"github.com/lestrrat-go/jwx/v2/jwk"
+	"github.com/lestrrat-go/jwx/v2/jwt"
+	"go.uber.org/zap"
+)
+
+const (
+	testIssuerURL = "https://idp.example.com"
+	testAudience  = "pacta-client"
+)
+
+func TestWithIssuerAuthn(t *testing.T) {
+	key, keySet := keysForTesting(t)
+	otherKey, _ := keysForTesting(t)
+	d := &fakeDB{
+		users: map[string]pacta.UserID{
+			testIssuerURL + "#linked-sub":  "user.linked",
+			testIssuerURL + "#revoked-sub": "user.revoked",
+		},
+		revoked: map[pacta.UserID]bool{"user.revoked": true},
+	}
+	issuers := []*Issuer{{
+		URL:          testIssuerURL,
+		Audience:     testAudience,
+		Mechanism:    pacta.AuthnMechanism_EntraID,
+		KeySet:       keySet,
+		EmailDomains: []string{"example.com"},
+	}}
+
+	tests := []struct {
+		desc          string
+		token         string
+		wantStatus    int
+		wantFallback  bool
+		wantUserID    pacta.UserID
+		wantAuthnID   string
+		wantMechanism pacta.AuthnMechanism
+	}{
+		{
+			desc:          "linked user",
+			token:         signForTesting(t, key, testIssuerURL, testAudience, "linked-sub", time.Hour),
+			wantStatus:    http.StatusOK,
+			wantUserID:    "user.linked",
+			wantAuthnID:   testIssuerURL + "#linked-sub",
+			wantMechanism: pacta.AuthnMechanism_EntraID,
+		},
+		{
+			desc:          "unlinked user",
+			token:         signForTesting(t, key, testIssuerURL, testAudience, "new-sub", time.Hour),
+			wantStatus:    http.StatusOK,
+			wantAuthnID:   testIssuerURL + "#new-sub",
+			wantMechanism: pacta.AuthnMechanism_EntraID,
+		},
+		{
+			desc:       "revoked session",
+			token:      signForTesting(t, key, testIssuerURL, testAudience, "revoked-sub", time.Hour),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "wrong key",
+			token:      signForTesting(t, otherKey, testIssuerURL, testAudience, "linked-sub", time.Hour),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "email outside of issuer's domains",
+			token:      signWithEmailForTesting(t, key, testIssuerURL, testAudience, "linked-sub", "admin@pacta.example.org", time.Hour),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "no email",
+			token:      signWithEmailForTesting(t, key, testIssuerURL, testAudience, "linked-sub", "", time.Hour),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "wrong audience",
+			token:      signForTesting(t, key, testIssuerURL, "someone-else", "linked-sub", time.Hour),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:       "expired",
+			token:      signForTesting(t, key, testIssuerURL, testAudience, "linked-sub", -time.Hour),
+			wantStatus: http.StatusUnauthorized,
+		},
+		{
+			desc:         "other issuer",
+			token:        signForTesting(t, otherKey, "https://other.example.com", testAudience, "linked-sub", time.Hour),
+			wantStatus:   http.StatusOK,
+			wantFallback: true,
+		},
+		{
+			desc:         "not a jwt",
+			token:        "pacta_pat_abc",
+			wantStatus:   http.StatusOK,
+			wantFallback: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			var (
+				gotFallback bool
+				gotCtx      context.Context
+			)
+			fallback := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					gotFallback = true
+					next.ServeHTTP(w, r)
+				})
+			}
+			h := WithIssuerAuthn(zap.NewNop(), d, issuers, fallback)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotCtx = r.Context()
+			}))
+
+			r := httptest.NewRequest(http.MethodGet, "/portfolios", nil)
+			r.Header.Set("Authorization", "Bearer "+test.token)
+			w := httptest.NewRecorder()
+			h.ServeHTTP(w, r)
+
+			if w.Code != test.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, test.wantStatus)
+			}
+			if gotFallback != test.wantFallback {
+				t.Fatalf("fallback called = %t, want %t", gotFallback, test.wantFallback)
+			}
+			if test.wantStatus != http.StatusOK || test.wantFallback {
+				return
+			}
+
+			userID, _ := UserIDFromContext(gotCtx)
+			if userID != test.wantUserID {
+				t.Errorf("user ID = %q, want %q", userID, test.wantUserID)
+			}
+			mech, authnID, err := AuthnIdentityFromContext(gotCtx)
+			if err != nil {
+				t.Fatalf("getting authn identity: %v", err)
+			}
+			if mech != test.wantMechanism || authnID != test.wantAuthnID {
+				t.Errorf("authn identity = (%q, %q), want (%q, %q)", mech, authnID, test.wantMechanism, test.wantAuthnID)
+			}
+			tok, _, err := jwtauth.FromContext(gotCtx)
+			if err != nil || tok == nil {
+				t.Fatalf("expected token in context, got %v, %v", tok, err)
+			}
+		})
+	}
+}
+
+// keysForTesting returns a new RSA signing key, like most providers use, and a
+// key set containing its public half that was loaded from a JWKS file, like
+// we'd load in prod.
+func keysForTesting(t *testing.T) (jwk.Key, *KeySet) {
+	t.Helper()
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generating key: %v", err)
+	}
+	privKey, err := jwk.FromRaw(priv)
+	if err != nil {
+		t.Fatalf("making private JWK: %v", err)
+	}
+	pubKey, err := jwk.FromRaw(&priv.PublicKey)
+	if err != nil {
+		t.Fatalf("making public JWK: %v", err)
+	}
+	for _, k := range []jwk.Key{privKey, pubKey} {
+		if err := k.Set(jwk.KeyIDKey, "test-key"); err != nil {
+			t.Fatalf("setting key ID: %v", err)
+		}
+	}
+
+	set := jwk.NewSet()
+	if err := set.AddKey(pubKey); err != nil {
+		t.Fatalf("adding key to set: %v", err)
+	}
+	dat, err := json.Marshal(set)
+	if err != nil {
+		t.Fatalf("marshalling JWKS: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, dat, 0600); err != nil {
+		t.Fatalf("writing JWKS file: %v", err)
+	}
+	ks := NewProviderKeySet(zap.NewNop(), func(context.Context) (jwk.Set, error) {
+		return jwk.ReadFile(path)
+	})
+	if err := ks.Reload(context.Background()); err != nil {
+		t.Fatalf("loading JWKS file: %v", err)
+	}
+	return privKey, ks
+}
+
+func signForTesting(t *testing.T, key jwk.Key, iss, aud, sub string, expiresIn time.Duration) string {
+	t.Helper()
+	return signWithEmailForTesting(t, key, iss, aud, sub, sub+"@example.com", expiresIn)
+}
+
+func signWithEmailForTesting(t *testing.T, key jwk.Key, iss, aud, sub, email string, expiresIn time.Duration) string {
+	t.Helper()
+	now := time.Now()
+	b := jwt.NewBuilder().
+		Issuer(iss).
+		Audience([]string{aud}).
+		Subject(sub).
+		IssuedAt(now).
+		Expiration(now.Add(expiresIn))
+	if email != "" {
+		b = b.Claim("email", email).Claim("email_verified", true)
+	}
+	tok, err := b.Build()
+	if err != nil {
+		t.Fatalf("building token: %v", err)
+	}
+	signed, err := jwt.Sign(tok, jwt.WithKey(jwa.RS256, key))
+	if err != nil {
+		t.Fatalf("signing token: %v", err)
+	}
+	return string(signed)
+}
+
+type fakeDB struct {
+	users   map[string]pacta.UserID
+	revoked map[pacta.UserID]bool
+}
+
+func (f *fakeDB) NoTxn(context.Context) db.Tx {
+	return nil
+}
+
+func (f *fakeDB) UserByAuthn(tx db.Tx, mech pacta.AuthnMechanism, authnID string) (*pacta.User, error) {
+	id, ok := f.users[authnID]
+	if !ok || mech != pacta.AuthnMechanism_EntraID {
+		return nil, db.NotFound(authnID, "user")
+	}
+	return &pacta.User{ID: id}, nil
+}
+
+func (f *fakeDB) IsSessionRevoked(tx db.Tx, uid pacta.UserID, jti string, issuedAt time.Time) (bool, error) {
+	return f.revoked[uid], nil
+}
@@ -2,18 +2,22 @@ package session
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"time"
 
 	"github.com/RMI/pacta/db"
 	"github.com/RMI/pacta/oapierr"
 	"github.com/RMI/pacta/pacta"
 	"github.com/go-chi/jwtauth/v5"
+	"github.com/lestrrat-go/jwx/v2/jwt"
 	"go.uber.org/zap"
 )
 
-type userIDKey = struct{}
-type allowedAnonymousKey = struct{}
+type userIDKey struct{}
+type allowedAnonymousKey struct{}
+type authnIdentityKey struct{}
 
 const allowedAnonymousValue = "allowedAnonymous"
 
@@ -21,6 +25,25 @@ func WithUserID(c context.Context, id pacta.UserID) context.Context {
 	return context.WithValue(c, userIDKey{}, id)
 }
 
+type authnIdentity struct {
+	mech    pacta.AuthnMechanism
+	authnID string
+}
+
+// WithAuthnIdentity records the identity that the request's token was issued
+// to. It's set even when that identity isn't linked to a user yet.
+func WithAuthnIdentity(c context.Context, mech pacta.AuthnMechanism, authnID string) context.Context {
+	return context.WithValue(c, authnIdentityKey{}, &authnIdentity{mech: mech, authnID: authnID})
+}
+
+func AuthnIdentityFromContext(ctx context.Context) (pacta.AuthnMechanism, string, error) {
+	id, ok := ctx.Value(authnIdentityKey{}).(*authnIdentity)
+	if !ok || id == nil {
+		return "", "", oapierr.Unauthorized("no authn identity in context")
+	}
+	return id.mech, id.authnID, nil
+}
+
 func WithAllowedAnonymous(c context.Context) context.Context {
 	return context.WithValue(c, allowedAnonymousKey{}, allowedAnonymousValue)
 }
@@ -28,8 +51,13 @@ func WithAllowedAnonymous(c context.Context) context.Context {
 type DB interface {
 	NoTxn(context.Context) db.Tx
 	UserByAuthn(tx db.Tx, mech pacta.AuthnMechanism, authnID string) (*pacta.User, error)
+	IsSessionRevoked(tx db.Tx, uid pacta.UserID, jti string, issuedAt time.Time) (bool, error)
 }
 
+// errSessionRevoked is returned when a token was valid, but the user has since
+// revoked the session it belongs to.
+var errSessionRevoked = errors.New("session has been revoked")
+
 func UserIDFromContext(ctx context.Context) (pacta.UserID, error) {
 	userID, ok := ctx.Value(userIDKey{}).(pacta.UserID)
 	if !ok {
@@ -53,22 +81,43 @@ func IsAllowedAnonymous(ctx context.Context) bool {
 	return s == allowedAnonymousValue
 }
 
+// issuedAt returns when the token was issued, for checking it against
+// revocations. Tokens without an iat claim fall back to their nbf claim, which
+// issuers set to the issue time in practice. Tokens with neither return the
+// zero time, see IsSessionRevoked for how those are handled.
+func issuedAt(tok jwt.Token) time.Time {
+	if iat := tok.IssuedAt(); !iat.IsZero() {
+		return iat
+	}
+	return tok.NotBefore()
+}
+
+// WithAuthn identifies the user for requests authenticated with a JWT from the
+// credential service.
 func WithAuthn(logger *zap.Logger, d DB) func(http.Handler) http.Handler {
 	fn := func(c context.Context) (context.Context, error) {
 		token, _, err := jwtauth.FromContext(c)
 		if err != nil {
-			return nil, fmt.Errorf("error getting authorization token: %w", err)
+			return c, fmt.Errorf("error getting authorization token: %w", err)
 		}
 		if token == nil {
-			return nil, fmt.Errorf("nil authorization token")
+			return c, fmt.Errorf("nil authorization token")
 		}
 		authnID := token.Subject()
 		if authnID == "" {
-			return nil, fmt.Errorf("couldn't find authn id in jwt")
+			return c, fmt.Errorf("couldn't find authn id in jwt")
 		}
+		c = WithAuthnIdentity(c, pacta.AuthnMechanism_EmailAndPass, authnID)
 		user, err := d.UserByAuthn(d.NoTxn(c), pacta.AuthnMechanism_EmailAndPass, authnID)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get user by authn: %w", err)
+			return c, fmt.Errorf("failed to get user by authn: %w", err)
+		}
+		revoked, err := d.IsSessionRevoked(d.NoTxn(c), user.ID, token.JwtID(), issuedAt(token))
+		if err != nil {
+			return c, fmt.Errorf("failed to check if session was revoked: %w", err)
+		}
+		if revoked {
+			return c, errSessionRevoked
 		}
 		return WithUserID(c, user.ID), nil
 	}
@@ -76,7 +125,13 @@ func WithAuthn(logger *zap.Logger, d DB) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx, err := fn(r.Context())
+			if errors.Is(err, errSessionRevoked) {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
 			if err != nil {
+				// The identity, if we found one, is still useful for creating the user.
+				r = r.WithContext(ctx)
 				// Optionally log errors here when debugging authentication access.
 				// logger.Warn("couldn't authenticate", zap.Error(err))
 				// http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
@@ -0,0 +1,41 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v2/jwt"
+)
+
+func TestIssuedAt(t *testing.T) {
+	iat := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	nbf := iat.Add(time.Minute)
+	tests := []struct {
+		desc string
+		iat  time.Time
+		nbf  time.Time
+		want time.Time
+	}{
+		{desc: "iat", iat: iat, nbf: nbf, want: iat},
+		{desc: "nbf without iat", nbf: nbf, want: nbf},
+		{desc: "neither", want: time.Time{}},
+	}
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			b := jwt.NewBuilder().Subject("user")
+			if !test.iat.IsZero() {
+				b = b.IssuedAt(test.iat)
+			}
+			if !test.nbf.IsZero() {
+				b = b.NotBefore(test.nbf)
+			}
+			tok, err := b.Build()
+			if err != nil {
+				t.Fatalf("building token: %v", err)
+			}
+			if got := issuedAt(tok); !got.Equal(test.want) {
+				t.Errorf("issuedAt() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,11 @@ package task
 
 import (
 	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/RMI/pacta/async/parsed"
 	"github.com/RMI/pacta/pacta"
@@ -24,32 +29,122 @@ const (
 	CreateDashboard = Type("create_dashboard")
 )
 
+// Status is the state of a task's execution, as reported by the underlying
+// runner. Unlike pacta.TaskState, this reflects what the container is actually
+// doing, regardless of whether the task has reported back to us.
+type Status string
+
+const (
+	StatusPending   = Status("pending")
+	StatusRunning   = Status("running")
+	StatusSucceeded = Status("succeeded")
+	StatusFailed    = Status("failed")
+	StatusCancelled = Status("cancelled")
+	StatusUnknown   = Status("unknown")
+)
+
 type ParsePortfolioRequest struct {
 	IncompleteUploadIDs []pacta.IncompleteUploadID
 	BlobURIs            []pacta.BlobURI
+	// Uploads ties each of the BlobURIs back to the incomplete upload it belongs
+	// to. It's empty for requests made before it was added.
+	Uploads []*ParsePortfolioUpload
+	// MaxRows is the most rows that any one of the CSVs given to the parser can
+	// have, zero means no limit.
+	MaxRows int
+}
+
+// ParsePortfolioUpload is one of the files being parsed, along with the
+// properties that the portfolios parsed from it should have.
+type ParsePortfolioUpload struct {
+	IncompleteUploadID pacta.IncompleteUploadID
+	BlobURI            pacta.BlobURI
+	Properties         pacta.PortfolioProperties
 }
 
 type ParsePortfolioResponseItem struct {
+	// Source is the BlobURI of the input file that this portfolio was parsed from.
 	Source    pacta.BlobURI
 	Blob      pacta.Blob
 	Portfolio parsed.Portfolio
 }
 
+// ParsePortfolioSource is what the parser reported about one of the input
+// files, including row-level errors and the parser's own version information.
+type ParsePortfolioSource struct {
+	Source pacta.BlobURI
+	// Entry is the workbook sheet or archive entry that File was extracted from,
+	// empty if Source was a CSV and given to the parser as-is.
+	Entry string
+	File  parsed.SourceFile
+}
+
+// ParsePortfolioLog is a copy of the parser's output log for one of the
+// incomplete uploads it parsed.
+type ParsePortfolioLog struct {
+	IncompleteUploadID pacta.IncompleteUploadID
+	Blob               pacta.Blob
+}
+
+// ParsePortfolioRejectedFile is an uploaded file that wasn't given to the
+// parser, because it isn't in a format that we can read. The other files in the
+// request are parsed without it.
+type ParsePortfolioRejectedFile struct {
+	Source         pacta.BlobURI
+	FailureCode    pacta.FailureCode
+	FailureMessage string
+}
+
 type ParsePortfolioResponse struct {
-	TaskID  ID
-	Request *ParsePortfolioRequest
-	Outputs []*ParsePortfolioResponseItem
+	TaskID   ID
+	Request  *ParsePortfolioRequest
+	Outputs  []*ParsePortfolioResponseItem
+	Sources  []*ParsePortfolioSource
+	Logs     []*ParsePortfolioLog
+	Rejected []*ParsePortfolioRejectedFile
+}
+
+// AnalysisScope is the kind of entity an analysis was run on, which determines
+// how many portfolios are analyzed together.
+type AnalysisScope string
+
+const (
+	AnalysisScopePortfolio      = AnalysisScope("portfolio")
+	AnalysisScopePortfolioGroup = AnalysisScope("portfolio_group")
+	AnalysisScopeInitiative     = AnalysisScope("initiative")
+)
+
+// AnalysisPortfolio describes the portfolio(s) being analyzed, which the R
+// workflows use to label their outputs.
+type AnalysisPortfolio struct {
+	Name         string
+	HoldingsDate *pacta.HoldingsDate
+	Scope        AnalysisScope
 }
 
 type CreateAuditRequest struct {
 	AnalysisID pacta.AnalysisID
 	BlobURIs   []pacta.BlobURI
+	Portfolio  *AnalysisPortfolio
+	// Inherit is the workflow.pacta configuration to run with, taken from the
+	// analysis' PACTA version.
+	Inherit string
+	// ImageVersion is the digest or tag of the runner image to run with, also
+	// taken from the analysis' PACTA version.
+	ImageVersion string
+	// BenchmarkDataset and PACTADataset identify the mounted datasets to run
+	// against, also taken from the analysis' PACTA version.
+	BenchmarkDataset string
+	PACTADataset     string
 }
 
 type AnalysisArtifact struct {
 	BlobURI  pacta.BlobURI
 	FileName string
 	FileType pacta.FileType
+	// AdminOnly marks artifacts that are only for debugging, like the logs of
+	// the R scripts, which the owner of the analysis shouldn't see.
+	AdminOnly bool
 }
 
 type CreateAuditResponse struct {
@@ -59,8 +154,13 @@ type CreateAuditResponse struct {
 }
 
 type CreateReportRequest struct {
-	AnalysisID pacta.AnalysisID
-	BlobURIs   []pacta.BlobURI
+	AnalysisID       pacta.AnalysisID
+	BlobURIs         []pacta.BlobURI
+	Portfolio        *AnalysisPortfolio
+	Inherit          string
+	ImageVersion     string
+	BenchmarkDataset string
+	PACTADataset     string
 }
 
 type CreateReportResponse struct {
@@ -72,6 +172,13 @@ type CreateReportResponse struct {
 type CreateDashboardRequest struct {
 	AnalysisID pacta.AnalysisID
 	BlobURIs   []pacta.BlobURI
+	Portfolio  *AnalysisPortfolio
+	Inherit    string
+	// ImageVersion is the digest or tag of the dashboard image to run with,
+	// taken from the analysis' PACTA version.
+	ImageVersion     string
+	BenchmarkDataset string
+	PACTADataset     string
 }
 
 type CreateDashboardResponse struct {
@@ -80,6 +187,33 @@ type CreateDashboardResponse struct {
 	Artifacts []*AnalysisArtifact
 }
 
+// ParsePortfolioFailedResponse is published when a ParsePortfolio task fails
+// before it could produce a ParsePortfolioResponse.
+type ParsePortfolioFailedResponse struct {
+	TaskID         ID
+	Request        *ParsePortfolioRequest
+	FailureCode    pacta.FailureCode
+	FailureMessage string
+	// Sources and Logs are set if the parser ran, but we couldn't use what it
+	// produced.
+	Sources []*ParsePortfolioSource
+	Logs    []*ParsePortfolioLog
+	// Rejected files fail with their own code and message, rather than the
+	// task's.
+	Rejected []*ParsePortfolioRejectedFile
+}
+
+// AnalysisFailedResponse is published when a CreateAudit, CreateReport, or
+// CreateDashboard task fails before producing its artifacts.
+type AnalysisFailedResponse struct {
+	TaskID         ID
+	AnalysisID     pacta.AnalysisID
+	FailureCode    pacta.FailureCode
+	FailureMessage string
+	// Artifacts holds the log of the R script, if it got far enough to run.
+	Artifacts []*AnalysisArtifact
+}
+
 type EnvVar struct {
 	Key   string
 	Value string
@@ -96,6 +230,87 @@ type Image struct {
 	Base BaseImage
 	// Like 'latest'
 	Tag string
+	// Like 'sha256:0123...', takes precedence over Tag when set.
+	Digest string
+}
+
+var (
+	digestRegexp     = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+	bareDigestRegexp = regexp.MustCompile(`^[a-fA-F0-9]{64}$`)
+	// From https://github.com/distribution/reference/blob/main/regexp.go
+	tagRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	// Datasets are directory names under the dataset mounts, so they can't
+	// contain slashes or be '.' or '..'.
+	datasetRegexp = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	// Inherit configurations name the quarter they're for, like GENERAL_2023Q4.
+	quarterRegexp = regexp.MustCompile(`(\d{4})Q([1-4])`)
+)
+
+// DefaultHoldingsDate is the holdings date used for portfolios that don't have
+// one, which is the last day of the quarter that the inherit configuration is
+// for.
+func DefaultHoldingsDate(inherit string) (*pacta.HoldingsDate, error) {
+	m := quarterRegexp.FindStringSubmatch(inherit)
+	if m == nil {
+		return nil, fmt.Errorf("inherit configuration %q doesn't name a quarter", inherit)
+	}
+	year, err := strconv.Atoi(m[1])
+	if err != nil {
+		return nil, fmt.Errorf("parsing year of inherit configuration %q: %w", inherit, err)
+	}
+	quarter := int(m[2][0] - '0')
+	// Day zero of the month after the quarter is its last day.
+	return &pacta.HoldingsDate{Time: time.Date(year, time.Month(3*quarter+1), 0, 0, 0, 0, 0, time.UTC)}, nil
+}
+
+// WithVersion returns the image at the given version, which is either a digest
+// like 'sha256:<64 hex characters>' or a tag like 'v1.2.3'. Versions are
+// normalized with NormalizeImageVersion first.
+func (i *BaseImage) WithVersion(version string) (*Image, error) {
+	version, err := NormalizeImageVersion(version)
+	if err != nil {
+		return nil, err
+	}
+	if digestRegexp.MatchString(version) {
+		return &Image{Base: *i, Digest: version}, nil
+	}
+	return &Image{Base: *i, Tag: version}, nil
+}
+
+// NormalizeImageVersion returns the canonical form of a digest or tag. PACTA
+// versions created before digests were validated hold them in a few other
+// forms, which are all accepted:
+//   - a bare digest, like '<64 hex characters>'
+//   - a digest with uppercase hex characters
+//   - a full image reference, like 'rmisa.azurecr.io/runner@sha256:<...>'
+//
+// A bare digest is a valid tag too, but it's always treated as a digest.
+func NormalizeImageVersion(version string) (string, error) {
+	v := strings.TrimSpace(version)
+	// Only digests can follow an '@' in an image reference.
+	ref, v, isRef := strings.Cut(v, "@")
+	if !isRef {
+		v = ref
+	}
+	if bareDigestRegexp.MatchString(v) {
+		v = "sha256:" + v
+	}
+	if lower := strings.ToLower(v); strings.HasPrefix(lower, "sha256:") {
+		v = lower
+	}
+	if digestRegexp.MatchString(v) || (!isRef && tagRegexp.MatchString(v)) {
+		return v, nil
+	}
+	return "", fmt.Errorf("%q is neither a sha256 image digest nor a valid tag", version)
+}
+
+// ValidateDataset checks that the given dataset identifier names a single
+// directory under a dataset mount.
+func ValidateDataset(dataset string) error {
+	if !datasetRegexp.MatchString(dataset) {
+		return fmt.Errorf("%q is not a valid dataset identifier", dataset)
+	}
+	return nil
 }
 
 func (i *BaseImage) WithTag(tag string) string {
@@ -109,7 +324,21 @@ func (i *BaseImage) WithTag(tag string) string {
 	return buf.String()
 }
 
+func (i *BaseImage) WithDigest(digest string) string {
+	var buf bytes.Buffer
+	// <registry>/<name>@<digest>
+	buf.WriteString(i.Registry)
+	buf.WriteRune('/')
+	buf.WriteString(i.Name)
+	buf.WriteRune('@')
+	buf.WriteString(digest)
+	return buf.String()
+}
+
 func (i *Image) String() string {
+	if i.Digest != "" {
+		return i.Base.WithDigest(i.Digest)
+	}
 	return i.Base.WithTag(i.Tag)
 }
 
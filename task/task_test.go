@@ -0,0 +1,131 @@
+package task
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWithVersion(t *testing.T) {
+	base := &BaseImage{Registry: "example.azurecr.io", Name: "runner"}
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	tests := []struct {
+		desc    string
+		version string
+		want    string
+		wantErr bool
+	}{
+		{
+			desc:    "digest",
+			version: digest,
+			want:    "example.azurecr.io/runner@" + digest,
+		},
+		{
+			desc:    "tag",
+			version: "v1.2.3",
+			want:    "example.azurecr.io/runner:v1.2.3",
+		},
+		{
+			desc:    "empty",
+			version: "",
+			wantErr: true,
+		},
+		{
+			desc:    "short digest",
+			version: "sha256:0123",
+			wantErr: true,
+		},
+		{
+			desc:    "uppercase digest",
+			version: "sha256:0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF0123456789ABCDEF",
+			want:    "example.azurecr.io/runner@" + digest,
+		},
+		{
+			desc:    "bare digest",
+			version: "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			want:    "example.azurecr.io/runner@" + digest,
+		},
+		{
+			desc:    "image reference",
+			version: "rmisa.azurecr.io/runner@" + digest,
+			want:    "example.azurecr.io/runner@" + digest,
+		},
+		{
+			desc:    "whitespace",
+			version: " " + digest + "\n",
+			want:    "example.azurecr.io/runner@" + digest,
+		},
+		{
+			desc:    "tag in image reference",
+			version: "rmisa.azurecr.io/runner@v1.2.3",
+			wantErr: true,
+		},
+		{
+			desc:    "other digest algorithm",
+			version: "sha512:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			wantErr: true,
+		},
+		{
+			desc:    "invalid tag",
+			version: "pacta digest",
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.desc, func(t *testing.T) {
+			img, err := base.WithVersion(test.version)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("WithVersion(%q) = %q, want an error", test.version, img.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("WithVersion(%q): %v", test.version, err)
+			}
+			if got := img.String(); got != test.want {
+				t.Errorf("WithVersion(%q) = %q, want %q", test.version, got, test.want)
+			}
+		})
+	}
+}
+
+func TestValidateDataset(t *testing.T) {
+	valid := []string{"65c1a416721b22a98c7925999ae03bc4", "2023Q4_20240718T150252Z"}
+	for _, dataset := range valid {
+		if err := ValidateDataset(dataset); err != nil {
+			t.Errorf("ValidateDataset(%q): %v", dataset, err)
+		}
+	}
+	invalid := []string{"", ".", "..", "../etc", "2023Q4/data", "/mnt"}
+	for _, dataset := range invalid {
+		if err := ValidateDataset(dataset); err == nil {
+			t.Errorf("ValidateDataset(%q) succeeded, want an error", dataset)
+		}
+	}
+}
+
+func TestDefaultHoldingsDate(t *testing.T) {
+	tests := map[string]string{
+		"GENERAL_2023Q4": "2023-12-31",
+		"GENERAL_2024Q1": "2024-03-31",
+		"2022Q2":         "2022-06-30",
+		"PA_2021Q3_v2":   "2021-09-30",
+	}
+	for inherit, want := range tests {
+		got, err := DefaultHoldingsDate(inherit)
+		if err != nil {
+			t.Errorf("DefaultHoldingsDate(%q): %v", inherit, err)
+			continue
+		}
+		if s := got.Time.Format(time.DateOnly); s != want {
+			t.Errorf("DefaultHoldingsDate(%q) = %s, want %s", inherit, s, want)
+		}
+	}
+	for _, inherit := range []string{"", "GENERAL", "GENERAL_2023Q5", "GENERAL_23Q4"} {
+		if _, err := DefaultHoldingsDate(inherit); err == nil {
+			t.Errorf("DefaultHoldingsDate(%q) succeeded, want an error", inherit)
+		}
+	}
+}
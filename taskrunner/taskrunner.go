@@ -2,8 +2,11 @@
 // analysis, regardless of the underlying substrate we'll run the external
 // processing logic on (e.g Docker or locally).
 //
-// TODO: We use the tag "latest" throughout. For most cases, we'll want to
-// version this and take in the image tag as part of the request.
+// Audits, reports and dashboards run their image at the version given in the
+// request, which comes from the PACTA version of the analysis.
+//
+// TODO: Parsing still uses the tag "latest", we'll want to version that as
+// well.
 package taskrunner
 
 import (
@@ -12,9 +15,12 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"path"
+	"time"
 
+	"github.com/RMI/pacta/db"
+	"github.com/RMI/pacta/pacta"
 	"github.com/RMI/pacta/task"
-	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -33,6 +39,10 @@ type Config struct {
 	Logger *zap.Logger
 
 	Runner Runner
+
+	// DB is where we record the dispatch and status of tasks. Tasks must be
+	// created (in the QUEUED state) before being passed to the TaskRunner.
+	DB DB
 }
 
 func (c *Config) validate() error {
@@ -60,6 +70,10 @@ func (c *Config) validate() error {
 		return errors.New("no runner given")
 	}
 
+	if c.DB == nil {
+		return errors.New("no DB given")
+	}
+
 	return nil
 }
 
@@ -75,11 +89,24 @@ func validateImage(bi *task.BaseImage) error {
 
 type Runner interface {
 	Run(ctx context.Context, cfg *task.Config) (task.RunnerID, error)
+	// Status returns the current state of a previously started task.
+	Status(ctx context.Context, runnerID task.RunnerID) (task.Status, error)
+	// Logs returns the combined stdout and stderr of a task. Runners that don't
+	// support retrieving logs return an error wrapping errors.ErrUnsupported.
+	Logs(ctx context.Context, runnerID task.RunnerID) ([]byte, error)
+	// Cancel stops a running task.
+	Cancel(ctx context.Context, runnerID task.RunnerID) error
+}
+
+type DB interface {
+	NoTxn(context.Context) db.Tx
+	UpdateTask(tx db.Tx, id pacta.TaskID, mutations ...db.UpdateTaskFn) error
 }
 
 type TaskRunner struct {
 	logger         *zap.Logger
 	runner         Runner
+	db             DB
 	dashboardImage *task.BaseImage
 	runnerImage    *task.BaseImage
 	parserImage    *task.BaseImage
@@ -94,6 +121,7 @@ func New(cfg *Config) (*TaskRunner, error) {
 	return &TaskRunner{
 		logger:         cfg.Logger,
 		runner:         cfg.Runner,
+		db:             cfg.DB,
 		dashboardImage: cfg.DashboardImage,
 		runnerImage:    cfg.RunnerImage,
 		parserImage:    cfg.ParserImage,
@@ -117,12 +145,40 @@ func encodeRequest[T TaskRequest](req *T) (string, error) {
 	return value, nil
 }
 
-func (tr *TaskRunner) ParsePortfolio(ctx context.Context, req *task.ParsePortfolioRequest) (task.ID, task.RunnerID, error) {
+const (
+	// benchmarkDataMount and pactaDataMount are where the benchmark and PACTA
+	// datasets are mounted in task containers, with one directory per dataset.
+	benchmarkDataMount = "/mnt/benchmark-data"
+	pactaDataMount     = "/mnt/pacta-data"
+)
+
+// datasetEnv returns the environment variables pointing a task at the given
+// datasets, which come from the PACTA version of the analysis.
+func datasetEnv(benchmarkDataset, pactaDataset string) ([]task.EnvVar, error) {
+	if err := task.ValidateDataset(benchmarkDataset); err != nil {
+		return nil, fmt.Errorf("invalid benchmark dataset: %w", err)
+	}
+	if err := task.ValidateDataset(pactaDataset); err != nil {
+		return nil, fmt.Errorf("invalid PACTA dataset: %w", err)
+	}
+	return []task.EnvVar{
+		{
+			Key:   "BENCHMARK_DIR",
+			Value: path.Join(benchmarkDataMount, benchmarkDataset),
+		},
+		{
+			Key:   "PACTA_DATA_DIR",
+			Value: path.Join(pactaDataMount, pactaDataset),
+		},
+	}, nil
+}
+
+func (tr *TaskRunner) ParsePortfolio(ctx context.Context, taskID task.ID, req *task.ParsePortfolioRequest) (task.RunnerID, error) {
 	value, err := encodeRequest(req)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to encode ParsePortfolioRequest: %w", err)
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("failed to encode ParsePortfolioRequest: %w", err))
 	}
-	return tr.run(ctx, "/parser", withTag(tr.parserImage, "latest"), []task.EnvVar{
+	return tr.run(ctx, taskID, value, "/parser", withTag(tr.parserImage, "latest"), []task.EnvVar{
 		{
 			Key:   "TASK_TYPE",
 			Value: string(task.ParsePortfolio),
@@ -131,24 +187,33 @@ func (tr *TaskRunner) ParsePortfolio(ctx context.Context, req *task.ParsePortfol
 			Key:   "PARSE_PORTFOLIO_REQUEST",
 			Value: value,
 		},
-		// TODO(brandon): Unhardcode these
+		// Parsing doesn't read either dataset, but the parser still expects both
+		// directories to be set, so we point it at the mounts themselves.
 		{
 			Key:   "BENCHMARK_DIR",
-			Value: "/mnt/benchmark-data/65c1a416721b22a98c7925999ae03bc4",
+			Value: benchmarkDataMount,
 		},
 		{
 			Key:   "PACTA_DATA_DIR",
-			Value: "/mnt/pacta-data/2023Q4_20240718T150252Z",
+			Value: pactaDataMount,
 		},
 	})
 }
 
-func (tr *TaskRunner) CreateAudit(ctx context.Context, req *task.CreateAuditRequest) (task.ID, task.RunnerID, error) {
+func (tr *TaskRunner) CreateAudit(ctx context.Context, taskID task.ID, req *task.CreateAuditRequest) (task.RunnerID, error) {
 	value, err := encodeRequest(req)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to encode CreateAuditRequest: %w", err)
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("failed to encode CreateAuditRequest: %w", err))
 	}
-	return tr.run(ctx, "/runner", withTag(tr.runnerImage, "latest"), []task.EnvVar{
+	env, err := datasetEnv(req.BenchmarkDataset, req.PACTADataset)
+	if err != nil {
+		return tr.failBeforeDispatch(taskID, err)
+	}
+	image, err := tr.runnerImage.WithVersion(req.ImageVersion)
+	if err != nil {
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("invalid runner image version: %w", err))
+	}
+	return tr.run(ctx, taskID, value, "/runner", image, append([]task.EnvVar{
 		{
 			Key:   "TASK_TYPE",
 			Value: string(task.CreateAudit),
@@ -157,24 +222,23 @@ func (tr *TaskRunner) CreateAudit(ctx context.Context, req *task.CreateAuditRequ
 			Key:   "CREATE_AUDIT_REQUEST",
 			Value: value,
 		},
-		// TODO(brandon): Unhardcode these
-		{
-			Key:   "BENCHMARK_DIR",
-			Value: "/mnt/benchmark-data/65c1a416721b22a98c7925999ae03bc4",
-		},
-		{
-			Key:   "PACTA_DATA_DIR",
-			Value: "/mnt/pacta-data/2023Q4_20240718T150252Z",
-		},
-	})
+	}, env...))
 }
 
-func (tr *TaskRunner) CreateReport(ctx context.Context, req *task.CreateReportRequest) (task.ID, task.RunnerID, error) {
+func (tr *TaskRunner) CreateReport(ctx context.Context, taskID task.ID, req *task.CreateReportRequest) (task.RunnerID, error) {
 	value, err := encodeRequest(req)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to encode CreateReportRequest: %w", err)
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("failed to encode CreateReportRequest: %w", err))
 	}
-	return tr.run(ctx, "/runner", withTag(tr.runnerImage, "latest"), []task.EnvVar{
+	env, err := datasetEnv(req.BenchmarkDataset, req.PACTADataset)
+	if err != nil {
+		return tr.failBeforeDispatch(taskID, err)
+	}
+	image, err := tr.runnerImage.WithVersion(req.ImageVersion)
+	if err != nil {
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("invalid runner image version: %w", err))
+	}
+	return tr.run(ctx, taskID, value, "/runner", image, append([]task.EnvVar{
 		{
 			Key:   "TASK_TYPE",
 			Value: string(task.CreateReport),
@@ -183,24 +247,23 @@ func (tr *TaskRunner) CreateReport(ctx context.Context, req *task.CreateReportRe
 			Key:   "CREATE_REPORT_REQUEST",
 			Value: value,
 		},
-		// TODO(brandon): Unhardcode these
-		{
-			Key:   "BENCHMARK_DIR",
-			Value: "/mnt/benchmark-data/65c1a416721b22a98c7925999ae03bc4",
-		},
-		{
-			Key:   "PACTA_DATA_DIR",
-			Value: "/mnt/pacta-data/2023Q4_20240718T150252Z",
-		},
-	})
+	}, env...))
 }
 
-func (tr *TaskRunner) CreateDashboard(ctx context.Context, req *task.CreateDashboardRequest) (task.ID, task.RunnerID, error) {
+func (tr *TaskRunner) CreateDashboard(ctx context.Context, taskID task.ID, req *task.CreateDashboardRequest) (task.RunnerID, error) {
 	value, err := encodeRequest(req)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to encode CreateDashboardRequest: %w", err)
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("failed to encode CreateDashboardRequest: %w", err))
+	}
+	env, err := datasetEnv(req.BenchmarkDataset, req.PACTADataset)
+	if err != nil {
+		return tr.failBeforeDispatch(taskID, err)
+	}
+	image, err := tr.dashboardImage.WithVersion(req.ImageVersion)
+	if err != nil {
+		return tr.failBeforeDispatch(taskID, fmt.Errorf("invalid dashboard image version: %w", err))
 	}
-	return tr.run(ctx, "/dashboard", withTag(tr.dashboardImage, "latest"), []task.EnvVar{
+	return tr.run(ctx, taskID, value, "/dashboard", image, append([]task.EnvVar{
 		{
 			Key:   "TASK_TYPE",
 			Value: string(task.CreateDashboard),
@@ -209,16 +272,30 @@ func (tr *TaskRunner) CreateDashboard(ctx context.Context, req *task.CreateDashb
 			Key:   "CREATE_DASHBOARD_REQUEST",
 			Value: value,
 		},
-		// TODO(brandon): Unhardcode these
-		{
-			Key:   "BENCHMARK_DIR",
-			Value: "/mnt/benchmark-data/65c1a416721b22a98c7925999ae03bc4",
-		},
-		{
-			Key:   "PACTA_DATA_DIR",
-			Value: "/mnt/pacta-data/2023Q4_20240718T150252Z",
-		},
-	})
+	}, env...))
+}
+
+func (tr *TaskRunner) Status(ctx context.Context, runnerID task.RunnerID) (task.Status, error) {
+	return tr.runner.Status(ctx, runnerID)
+}
+
+// TaskRunning reports whether the given task is still pending or running on
+// the underlying runner.
+func (tr *TaskRunner) TaskRunning(ctx context.Context, runnerID task.RunnerID) (bool, error) {
+	status, err := tr.runner.Status(ctx, runnerID)
+	if err != nil {
+		return false, err
+	}
+	return status == task.StatusPending || status == task.StatusRunning, nil
+}
+
+func (tr *TaskRunner) Logs(ctx context.Context, runnerID task.RunnerID) ([]byte, error) {
+	return tr.runner.Logs(ctx, runnerID)
+}
+
+func (tr *TaskRunner) Cancel(ctx context.Context, runnerID task.RunnerID) error {
+	tr.logger.Info("cancelling task", zap.String("runner_id", string(runnerID)))
+	return tr.runner.Cancel(ctx, runnerID)
 }
 
 func withTag(img *task.BaseImage, tag string) *task.Image {
@@ -228,20 +305,67 @@ func withTag(img *task.BaseImage, tag string) *task.Image {
 	}
 }
 
-func (tr *TaskRunner) run(ctx context.Context, binary string, image *task.Image, env []task.EnvVar) (task.ID, task.RunnerID, error) {
-	tr.logger.Info("triggering task run", zap.Any("env", env))
-	taskID := uuid.NewString()
+// failBeforeDispatch marks a task that can't be dispatched (e.g. because its
+// request is invalid) as failed, so that it doesn't sit in the QUEUED state
+// forever, and returns the given error.
+func (tr *TaskRunner) failBeforeDispatch(taskID task.ID, err error) (task.RunnerID, error) {
+	tr.logger.Error("failed to prepare task", zap.String("task_id", string(taskID)), zap.Error(err))
+	tr.markFailed(taskID)
+	return "", err
+}
+
+// markFailed records that the given task failed. Errors are logged rather
+// than returned, as callers are already handling a more relevant error.
+func (tr *TaskRunner) markFailed(taskID task.ID) {
+	// We use a background context here so that the failure is recorded even
+	// if the request context was what caused the task to fail.
+	err := tr.db.UpdateTask(tr.db.NoTxn(context.Background()), pacta.TaskID(taskID),
+		db.SetTaskState(pacta.TaskState_Failed),
+		db.SetTaskCompletedAt(time.Now()),
+	)
+	if err != nil {
+		tr.logger.Error("failed to mark task as failed", zap.String("task_id", string(taskID)), zap.Error(err))
+	}
+}
+
+// run dispatches the task to the underlying runner, recording the request,
+// image, and resulting runner ID on the task.
+func (tr *TaskRunner) run(ctx context.Context, taskID task.ID, request, binary string, image *task.Image, env []task.EnvVar) (task.RunnerID, error) {
+	tr.logger.Info("triggering task run", zap.String("task_id", string(taskID)), zap.Any("env", env))
+	id := pacta.TaskID(taskID)
+	err := tr.db.UpdateTask(tr.db.NoTxn(ctx), id,
+		db.SetTaskRequest(request),
+		db.SetTaskImageTag(image.String()),
+		db.IncrementTaskAttemptCount(),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to record dispatch of task %q: %w", taskID, err)
+	}
+
 	runnerID, err := tr.runner.Run(ctx, &task.Config{
 		Env: append(env, task.EnvVar{
 			Key:   "TASK_ID",
-			Value: taskID,
+			Value: string(taskID),
 		}),
 		Flags:   []string{"--config=" + tr.configPath},
 		Command: []string{binary},
 		Image:   image,
 	})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to run task %q, %q: %w", taskID, runnerID, err)
+		tr.markFailed(taskID)
+		return "", fmt.Errorf("failed to run task %q, %q: %w", taskID, runnerID, err)
+	}
+
+	// The task may have already reported back, or been reaped, by the time
+	// we get here, so this doesn't overwrite a final state.
+	err = tr.db.UpdateTask(tr.db.NoTxn(ctx), id,
+		db.SetTaskRunnerID(string(runnerID)),
+		db.SetTaskStartedIfQueued(time.Now()),
+	)
+	if err != nil {
+		// The task is already running at this point, so we don't want to fail
+		// the whole request just because we couldn't record that fact.
+		tr.logger.Error("failed to record start of task", zap.String("task_id", string(taskID)), zap.String("runner_id", string(runnerID)), zap.Error(err))
 	}
-	return task.ID(taskID), runnerID, nil
+	return runnerID, nil
 }